@@ -0,0 +1,157 @@
+package sqlz
+
+import (
+	"context"
+	"time"
+)
+
+// Audit configures the automatic setting of audit columns by INSERT and
+// UPDATE statements on a set of tables (see Defaults.Audit). INSERT
+// statements set all configured columns, while UPDATE statements only set
+// the UpdatedAt and UpdatedBy columns. Columns explicitly set by the
+// statement are never overridden, and empty column names are skipped.
+// Audit columns are added when statements are executed, so they do not
+// appear in the SQL returned by ToSQL. INSERT statements created with
+// FromSelect and UPDATE statements created with FromValues are not
+// modified.
+type Audit struct {
+	// Tables are the tables whose statements set audit columns
+	Tables []string
+	// CreatedAt is the column holding the time a row was created
+	CreatedAt string
+	// UpdatedAt is the column holding the time a row was last modified
+	UpdatedAt string
+	// CreatedBy is the column holding the actor that created a row
+	CreatedBy string
+	// UpdatedBy is the column holding the actor that last modified a row
+	UpdatedBy string
+	// Actor returns the current actor (e.g. a user ID) from the context
+	// statements are executed with. If nil, or if it returns nil, the
+	// CreatedBy and UpdatedBy columns are not set.
+	Actor func(ctx context.Context) interface{}
+	// Now returns the current time. If nil, time.Now is used.
+	Now func() time.Time
+}
+
+// columns returns the audit columns to set for a statement on the provided
+// table, in the provided context
+func (audit *Audit) columns(ctx context.Context, table string, inserting bool) (cols []string, vals []interface{}) {
+	if audit == nil || !audit.covers(stripAlias(table)) {
+		return nil, nil
+	}
+
+	now := time.Now
+	if audit.Now != nil {
+		now = audit.Now
+	}
+
+	var actor interface{}
+	if audit.Actor != nil {
+		actor = audit.Actor(ctx)
+	}
+
+	ts := now()
+
+	add := func(col string, val interface{}) {
+		if col != "" && val != nil {
+			cols = append(cols, col)
+			vals = append(vals, val)
+		}
+	}
+
+	if inserting {
+		add(audit.CreatedAt, ts)
+	}
+
+	add(audit.UpdatedAt, ts)
+
+	if inserting {
+		add(audit.CreatedBy, actor)
+	}
+
+	add(audit.UpdatedBy, actor)
+
+	return cols, vals
+}
+
+func (audit *Audit) covers(table string) bool {
+	for _, t := range audit.Tables {
+		if t == table {
+			return true
+		}
+	}
+
+	return false
+}
+
+// audited returns a copy of the statement that sets the audit columns
+func (stmt *InsertStmt) audited(ctx context.Context) *InsertStmt {
+	if stmt.SelectStmt != nil {
+		return stmt
+	}
+
+	cols, vals := stmt.audit.columns(ctx, stmt.Table, true)
+	if len(cols) == 0 {
+		return stmt
+	}
+
+	existing := make(map[string]bool, len(stmt.InsCols))
+	for _, col := range stmt.InsCols {
+		existing[col] = true
+	}
+
+	audited := *stmt
+	audited.InsCols = append([]string{}, stmt.InsCols...)
+	audited.InsVals = append([]interface{}{}, stmt.InsVals...)
+	audited.InsMultipleVals = make([][]interface{}, len(stmt.InsMultipleVals))
+
+	for i, row := range stmt.InsMultipleVals {
+		audited.InsMultipleVals[i] = append([]interface{}{}, row...)
+	}
+
+	for i, col := range cols {
+		if existing[col] {
+			continue
+		}
+
+		audited.InsCols = append(audited.InsCols, col)
+
+		if len(stmt.InsVals) > 0 {
+			audited.InsVals = append(audited.InsVals, vals[i])
+		}
+
+		for j := range audited.InsMultipleVals {
+			audited.InsMultipleVals[j] = append(audited.InsMultipleVals[j], vals[i])
+		}
+	}
+
+	return &audited
+}
+
+// audited returns a copy of the statement that sets the audit columns
+func (stmt *UpdateStmt) audited(ctx context.Context) *UpdateStmt {
+	if len(stmt.Updates) == 0 && len(stmt.MultipleValues.Columns) > 0 {
+		// the SET clause is generated from the multiple values
+		return stmt
+	}
+
+	cols, vals := stmt.audit.columns(ctx, stmt.Table, false)
+	if len(cols) == 0 {
+		return stmt
+	}
+
+	audited := *stmt
+	audited.Updates = make(map[string]interface{}, len(stmt.Updates)+len(cols))
+
+	for col, val := range stmt.Updates {
+		audited.Updates[col] = val
+	}
+
+	for i, col := range cols {
+		if _, exists := audited.Updates[col]; !exists {
+			audited.Updates[col] = vals[i]
+		}
+	}
+
+	return &audited
+}
@@ -0,0 +1,61 @@
+package sqlz
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+type actorKey struct{}
+
+func TestAudit(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	dbz := New(db, "postgres")
+	dbz.Defaults.Audit = &Audit{
+		Tables:    []string{"users"},
+		CreatedAt: "created_at",
+		UpdatedAt: "updated_at",
+		CreatedBy: "created_by",
+		UpdatedBy: "updated_by",
+		Actor:     func(ctx context.Context) interface{} { return ctx.Value(actorKey{}) },
+		Now:       func() time.Time { return now },
+	}
+
+	ctx := context.WithValue(context.Background(), actorKey{}, "admin")
+
+	mock.ExpectExec(`INSERT INTO users \(name, created_at, updated_at, created_by, updated_by\) VALUES \(\$1, \$2, \$3, \$4, \$5\)`).
+		WithArgs("Alice", now, now, "admin", "admin").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`UPDATE users SET name = \$1, updated_at = \$2 WHERE id = \$3`).
+		WithArgs("Bob", now, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO logs \(message\) VALUES \(\$1\)`).
+		WithArgs("hello").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	if _, err := dbz.InsertInto("users").Columns("name").Values("Alice").ExecContext(ctx); err != nil {
+		t.Fatalf("Insert failed: %s", err)
+	}
+
+	// no actor in the context, so updated_by is not set
+	if _, err := dbz.Update("users").Set("name", "Bob").Where(Eq("id", 1)).Exec(); err != nil {
+		t.Fatalf("Update failed: %s", err)
+	}
+
+	// tables that are not registered are not modified
+	if _, err := dbz.InsertInto("logs").Columns("message").Values("hello").ExecContext(ctx); err != nil {
+		t.Fatalf("Insert failed: %s", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %s", err)
+	}
+}
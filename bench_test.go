@@ -0,0 +1,108 @@
+package sqlz
+
+import (
+	"testing"
+)
+
+// benchmarkStmts are representative statements covering the main shapes
+// generated by the library, along with the maximum number of allocations
+// their ToSQL method may perform. The budgets guard against performance
+// regressions in the query generation hot path; if a change legitimately
+// requires more allocations, update the budget in the same change.
+var benchmarkStmts = []struct {
+	name   string
+	stmt   func(dbz *DB) SQLStmt
+	budget float64
+}{
+	{
+		"simple select",
+		func(dbz *DB) SQLStmt {
+			return dbz.Select("id", "name").From("users").Where(Eq("id", 1))
+		},
+		10,
+	},
+	{
+		"complex select",
+		func(dbz *DB) SQLStmt {
+			return dbz.Select("u.id", "u.name", "COUNT(o.id) AS orders").
+				From("users u").
+				LeftJoin("orders o", Eq("o.user_id", Indirect("u.id"))).
+				Where(
+					Gte("u.created_at", "2020-01-01"),
+					Or(Eq("u.status", "active"), In("u.role", "admin", "staff")),
+				).
+				GroupBy("u.id", "u.name").
+				Having(Gt("COUNT(o.id)", 5)).
+				OrderBy(Desc("orders"), Asc("u.name")).
+				Limit(50).
+				Offset(100)
+		},
+		42,
+	},
+	{
+		"insert",
+		func(dbz *DB) SQLStmt {
+			return dbz.InsertInto("users").
+				Columns("id", "name", "email", "created_at").
+				Values(1, "Alice", "alice@example.com", "2020-01-01")
+		},
+		13,
+	},
+	{
+		"multiple insert with upsert",
+		func(dbz *DB) SQLStmt {
+			return dbz.InsertInto("users").
+				Columns("id", "name").
+				ValueMultiple([][]interface{}{{1, "Alice"}, {2, "Bob"}, {3, "Carol"}}).
+				OnConflict(OnConflict("id").DoUpdate().Set("name", Indirect("EXCLUDED.name"))).
+				Returning("id")
+		},
+		35,
+	},
+	{
+		"update",
+		func(dbz *DB) SQLStmt {
+			return dbz.Update("users").
+				SetMap(map[string]interface{}{"name": "Bob", "email": "bob@example.com", "age": 30}).
+				Where(Eq("id", 1), IsNull("deleted_at")).
+				Returning("id")
+		},
+		27,
+	},
+}
+
+func BenchmarkToSQL(b *testing.B) {
+	dbz := New(nil, "postgres")
+
+	for _, bench := range benchmarkStmts {
+		stmt := bench.stmt(dbz)
+
+		b.Run(bench.name, func(b *testing.B) {
+			b.ReportAllocs()
+
+			for i := 0; i < b.N; i++ {
+				stmt.ToSQL(true)
+			}
+		})
+	}
+}
+
+func TestToSQLAllocations(t *testing.T) {
+	if testing.Short() || raceEnabled {
+		t.Skip("skipping allocation budgets in short mode or with the race detector")
+	}
+
+	dbz := New(nil, "postgres")
+
+	for _, bench := range benchmarkStmts {
+		stmt := bench.stmt(dbz)
+
+		allocs := testing.AllocsPerRun(100, func() {
+			stmt.ToSQL(true)
+		})
+
+		if allocs > bench.budget {
+			t.Errorf("%s: expected at most %.0f allocations, got %.0f", bench.name, bench.budget, allocs)
+		}
+	}
+}
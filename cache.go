@@ -0,0 +1,302 @@
+package sqlz
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// CacheStore is an interface for stores used to cache the results of
+// SELECT statements (see SelectStmt.Cached). Results are stored as encoded
+// bytes, along with the tables they were read from, so that they can be
+// invalidated when these tables are modified. Implementations must be safe
+// for concurrent use. MemoryCache is an in-memory implementation; stores
+// backed by external services (e.g. Redis) can implement this interface
+// as well.
+type CacheStore interface {
+	// Get returns the value stored under the provided key, and whether
+	// it was found and has not expired
+	Get(key string) (value []byte, ok bool)
+	// Set stores a value under the provided key for the provided
+	// duration, associating it with the provided tables
+	Set(key string, value []byte, ttl time.Duration, tables []string)
+	// Invalidate removes all values associated with any of the
+	// provided tables
+	Invalidate(tables ...string)
+}
+
+// Cached makes GetRow and GetAll (and their context variants) read the
+// results of the statement from the cache store of the DB or Tx the
+// statement was created from (see Defaults.Cache), if they were cached
+// less than ttl ago. Otherwise, the statement is executed and its results
+// are cached. The cache key is a fingerprint of the statement's SQL and
+// bindings. Results are encoded as JSON, so only exported fields of
+// structs are cached. If no cache store is configured, Cached has no
+// effect. Statements executed inside a transaction bypass the cache, as
+// they may see (and would otherwise cache) uncommitted changes.
+func (stmt *SelectStmt) Cached(ttl time.Duration) *SelectStmt {
+	stmt.cacheTTL = ttl
+	return stmt
+}
+
+// cached loads the results of the statement into the provided variable
+// from the cache if possible, and otherwise calls the provided function
+// to load them, caching them if it succeeds
+func (stmt *SelectStmt) cached(asSQL string, bindings []interface{}, into interface{}, load func() error) error {
+	store := stmt.defaults.Cache
+	if store == nil || stmt.cacheTTL <= 0 || inTx(stmt.queryer) {
+		return load()
+	}
+
+	key, ok := cacheKey(asSQL, bindings)
+	if !ok {
+		return load()
+	}
+
+	if value, found := store.Get(key); found && json.Unmarshal(value, into) == nil {
+		return nil
+	}
+
+	err := load()
+	if err != nil {
+		return err
+	}
+
+	if value, err := json.Marshal(into); err == nil {
+		store.Set(key, value, stmt.cacheTTL, stmt.Tables())
+	}
+
+	return nil
+}
+
+// cacheKey returns a fingerprint of the provided SQL and bindings, and
+// false if the bindings cannot be fingerprinted
+func cacheKey(asSQL string, bindings []interface{}) (key string, ok bool) {
+	encoded, err := json.Marshal(bindings)
+	if err != nil {
+		return "", false
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(asSQL)) //nolint: errcheck
+	hash.Write([]byte{0})     //nolint: errcheck
+	hash.Write(encoded)       //nolint: errcheck
+
+	return hex.EncodeToString(hash.Sum(nil)), true
+}
+
+// InvalidateCache removes all cached results read from any of the
+// provided tables from the DB's cache store, if it has one
+func (db *DB) InvalidateCache(tables ...string) {
+	if cache := db.defaults().Cache; cache != nil {
+		cache.Invalidate(tables...)
+	}
+}
+
+// InvalidateCacheFor removes all cached results read from any of the tables
+// referenced by the provided statements (usually INSERT, UPDATE and DELETE
+// statements) from the DB's cache store, if it has one
+func (db *DB) InvalidateCacheFor(stmts ...SQLStmt) {
+	db.InvalidateCache(tablesOfAll(stmts)...)
+}
+
+// InvalidateCache removes all cached results read from any of the
+// provided tables from the Tx's cache store, if it has one, once the
+// transaction is committed. Until then, other connections still see the
+// previous contents of the tables, which may be cached again. If the
+// transaction is rolled back, nothing is invalidated.
+func (tx *Tx) InvalidateCache(tables ...string) {
+	if cache := tx.defaults().Cache; cache != nil {
+		tx.afterCommit(func() {
+			cache.Invalidate(tables...)
+		})
+	}
+}
+
+// InvalidateCacheFor removes all cached results read from any of the tables
+// referenced by the provided statements from the Tx's cache store, if it
+// has one, once the transaction is committed (see InvalidateCache)
+func (tx *Tx) InvalidateCacheFor(stmts ...SQLStmt) {
+	tx.InvalidateCache(tablesOfAll(stmts)...)
+}
+
+// afterCommit registers a function to call once the transaction is
+// committed. If the transaction is rolled back, the function is discarded.
+func (tx *Tx) afterCommit(f func()) {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+
+	tx.onCommit = append(tx.onCommit, f)
+}
+
+// finishCommit calls the functions registered with afterCommit if the
+// transaction was committed, and discards them otherwise
+func (tx *Tx) finishCommit(committed bool) {
+	tx.mu.Lock()
+	funcs := tx.onCommit
+	tx.onCommit = nil
+	tx.mu.Unlock()
+
+	if !committed {
+		return
+	}
+
+	for _, f := range funcs {
+		f()
+	}
+}
+
+// inTx returns whether the provided queryer executes statements inside a
+// transaction
+func inTx(q Queryer) bool {
+	switch unwrapExt(q).(type) {
+	case *txExt, *sqlx.Tx, *Tx:
+		return true
+	default:
+		return false
+	}
+}
+
+// txOf returns the Tx the provided DML statement is executed in, if it
+// was created from (or is executed with) one
+func txOf(stmt SQLStmt) *Tx {
+	var execer Queryer
+
+	switch s := stmt.(type) {
+	case *InsertStmt:
+		execer = s.execer
+	case *UpdateStmt:
+		execer = s.execer
+	case *DeleteStmt:
+		execer = s.execer
+	default:
+		return nil
+	}
+
+	switch e := unwrapExt(execer).(type) {
+	case *txExt:
+		return e.tx
+	case *Tx:
+		return e
+	default:
+		return nil
+	}
+}
+
+// unwrapExt returns the execer wrapped with query hooks and a dialect
+func unwrapExt(q Queryer) Queryer {
+	if hooked, ok := q.(*hookedExt); ok {
+		q = hooked.Ext
+	}
+
+	if dialected, ok := q.(*dialectExt); ok {
+		q = dialected.Ext
+	}
+
+	return q
+}
+
+func tablesOfAll(stmts []SQLStmt) (tables []string) {
+	for _, stmt := range stmts {
+		tables = append(tables, TablesOf(stmt)...)
+	}
+
+	return tables
+}
+
+// MemoryCache is an in-memory implementation of CacheStore. Expired values
+// are removed when they are accessed or invalidated.
+type MemoryCache struct {
+	mu      sync.Mutex
+	entries map[string]memoryCacheEntry
+	tables  map[string]map[string]bool
+}
+
+type memoryCacheEntry struct {
+	value   []byte
+	expires time.Time
+	tables  []string
+}
+
+// NewMemoryCache creates a new, empty in-memory cache store
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{
+		entries: make(map[string]memoryCacheEntry),
+		tables:  make(map[string]map[string]bool),
+	}
+}
+
+// Get returns the value stored under the provided key, if it exists and
+// has not expired
+func (cache *MemoryCache) Get(key string) (value []byte, ok bool) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	entry, ok := cache.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	if time.Now().After(entry.expires) {
+		cache.remove(key)
+		return nil, false
+	}
+
+	return entry.value, true
+}
+
+// Set stores a value under the provided key for the provided duration
+func (cache *MemoryCache) Set(key string, value []byte, ttl time.Duration, tables []string) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	cache.remove(key)
+
+	cache.entries[key] = memoryCacheEntry{
+		value:   value,
+		expires: time.Now().Add(ttl),
+		tables:  tables,
+	}
+
+	for _, table := range tables {
+		if cache.tables[table] == nil {
+			cache.tables[table] = make(map[string]bool)
+		}
+
+		cache.tables[table][key] = true
+	}
+}
+
+// Invalidate removes all values associated with any of the provided tables
+func (cache *MemoryCache) Invalidate(tables ...string) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	for _, table := range tables {
+		for key := range cache.tables[table] {
+			cache.remove(key)
+		}
+	}
+}
+
+// remove removes a key from the cache. The cache must be locked.
+func (cache *MemoryCache) remove(key string) {
+	entry, ok := cache.entries[key]
+	if !ok {
+		return
+	}
+
+	delete(cache.entries, key)
+
+	for _, table := range entry.tables {
+		delete(cache.tables[table], key)
+
+		if len(cache.tables[table]) == 0 {
+			delete(cache.tables, table)
+		}
+	}
+}
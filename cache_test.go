@@ -0,0 +1,170 @@
+package sqlz
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestCached(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	dbz := New(db, "postgres")
+	dbz.Defaults.Cache = NewMemoryCache()
+
+	type user struct {
+		ID   int64  `db:"id"`
+		Name string `db:"name"`
+	}
+
+	// the query is executed once, then read from the cache until the
+	// table is invalidated
+	mock.ExpectQuery(`SELECT id, name FROM users WHERE active = \$1`).
+		WithArgs(true).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "Alice"))
+	mock.ExpectQuery(`SELECT id, name FROM users WHERE active = \$1`).
+		WithArgs(true).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "Alice").AddRow(2, "Bob"))
+
+	for i, expected := range []int{1, 1, 2} {
+		if i == 2 {
+			dbz.InvalidateCacheFor(dbz.InsertInto("users").Columns("name").Values("Bob"))
+		}
+
+		var users []user
+
+		err := dbz.Select("id", "name").From("users").Where(Eq("active", true)).Cached(time.Minute).GetAll(&users)
+		if err != nil {
+			t.Fatalf("GetAll #%d failed: %s", i+1, err)
+		}
+
+		if len(users) != expected {
+			t.Errorf("GetAll #%d: expected %d users, got %d", i+1, expected, len(users))
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %s", err)
+	}
+}
+
+func TestCachedTransaction(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	cache := NewMemoryCache()
+
+	dbz := New(db, "postgres")
+	dbz.Defaults.Cache = cache
+	dbz.Defaults.WriteListeners = []WriteListener{InvalidateOnWrite(cache)}
+
+	count := func(queryer interface {
+		Select(cols ...string) *SelectStmt
+	}) int {
+		var names []string
+
+		err := queryer.Select("name").From("users").Cached(time.Minute).GetAll(&names)
+		if err != nil {
+			t.Fatalf("GetAll failed: %s", err)
+		}
+
+		return len(names)
+	}
+
+	errFailed := errors.New("failed")
+
+	mock.ExpectQuery(`SELECT name FROM users`).
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("Alice"))
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO users \(name\) VALUES \(\$1\)`).
+		WithArgs("Bob").
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectQuery(`SELECT name FROM users`).
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("Alice").AddRow("Bob"))
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO users \(name\) VALUES \(\$1\)`).
+		WithArgs("Bob").
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery(`SELECT name FROM users`).
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("Alice").AddRow("Bob"))
+
+	if n := count(dbz); n != 1 {
+		t.Errorf("Expected 1 user, got %d", n)
+	}
+
+	// statements inside the transaction bypass the cache, and nothing is
+	// invalidated if the transaction is rolled back
+	err = dbz.Transactional(func(tx *Tx) error {
+		if _, err := tx.InsertInto("users").Columns("name").Values("Bob").Exec(); err != nil {
+			return err
+		}
+
+		if n := count(tx); n != 2 {
+			t.Errorf("Expected the transaction to see 2 users, got %d", n)
+		}
+
+		return errFailed
+	})
+	if !errors.Is(err, errFailed) {
+		t.Fatalf("Expected transaction to fail, got %v", err)
+	}
+
+	if n := count(dbz); n != 1 {
+		t.Errorf("Expected cached results to remain after rollback, got %d users", n)
+	}
+
+	// cached results are only invalidated once the transaction is committed
+	err = dbz.Transactional(func(tx *Tx) error {
+		if _, err := tx.InsertInto("users").Columns("name").Values("Bob").Exec(); err != nil {
+			return err
+		}
+
+		if n := count(dbz); n != 1 {
+			t.Errorf("Expected cached results to remain until commit, got %d users", n)
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Transaction failed: %s", err)
+	}
+
+	if n := count(dbz); n != 2 {
+		t.Errorf("Expected cached results to be invalidated after commit, got %d users", n)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %s", err)
+	}
+}
+
+func TestMemoryCache(t *testing.T) {
+	cache := NewMemoryCache()
+
+	cache.Set("a", []byte("1"), time.Minute, []string{"users", "orders"})
+	cache.Set("b", []byte("2"), time.Minute, []string{"orders"})
+	cache.Set("c", []byte("3"), -time.Second, []string{"products"})
+
+	if _, ok := cache.Get("c"); ok {
+		t.Error("Expected expired value to be missing")
+	}
+
+	cache.Invalidate("users")
+
+	if _, ok := cache.Get("a"); ok {
+		t.Error("Expected value of invalidated table to be missing")
+	}
+
+	if value, ok := cache.Get("b"); !ok || string(value) != "2" {
+		t.Errorf("Expected value of other table to remain, got %q", value)
+	}
+}
@@ -0,0 +1,281 @@
+package sqlz
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// ColumnCodec transforms the values of a column: Encode is applied to
+// values bound to the column by INSERT and UPDATE statements, and Decode
+// is applied to values of the column loaded by SELECT statements. This
+// allows transparent field-level encryption, compression, etc. Decode
+// receives the value as scanned by the driver (or into the field of the
+// target struct), and must return a value assignable to the same type.
+type ColumnCodec interface {
+	Encode(value interface{}) (interface{}, error)
+	Decode(value interface{}) (interface{}, error)
+}
+
+// Codecs maps columns to the codecs applied to their values. Columns are
+// identified as "table.column" (see Defaults.Codecs).
+type Codecs map[string]ColumnCodec
+
+// RegisterCodec registers a codec for the values of the provided column
+// of the provided table in the DB's defaults. Values bound to the column
+// by INSERT statements (via Values, ValueMap, ValueMultiple, etc.) and
+// UPDATE statements (via Set and SetMap) are encoded, and values of the
+// column loaded by SELECT statements on the table (via GetRow, GetAll and
+// the map-returning methods) are decoded. Values used in WHERE conditions,
+// values returned by RETURNING clauses, and rows iterated with
+// GetAllAsRows are left untouched. Only statements created after the codec
+// is registered are affected.
+func (db *DB) RegisterCodec(table, column string, codec ColumnCodec) {
+	db.Configure(func(defaults *Defaults) {
+		defaults.Codecs = defaults.Codecs.with(table, column, codec)
+	})
+}
+
+// RegisterCodec registers a codec for the values of the provided column
+// of the provided table in the Tx's defaults, without affecting the DB the
+// transaction was started from (see DB.RegisterCodec)
+func (tx *Tx) RegisterCodec(table, column string, codec ColumnCodec) {
+	tx.Configure(func(defaults *Defaults) {
+		defaults.Codecs = defaults.Codecs.with(table, column, codec)
+	})
+}
+
+// with returns the codecs with the provided codec registered for the
+// provided column, creating them if necessary
+func (codecs Codecs) with(table, column string, codec ColumnCodec) Codecs {
+	if codecs == nil {
+		codecs = make(Codecs)
+	}
+
+	codecs[table+"."+column] = codec
+
+	return codecs
+}
+
+// forTable returns the codecs of the provided table (which may include an
+// alias), keyed by column name
+func (codecs Codecs) forTable(table string) map[string]ColumnCodec {
+	if len(codecs) == 0 {
+		return nil
+	}
+
+	prefix := stripAlias(table) + "."
+
+	var cols map[string]ColumnCodec
+
+	for key, codec := range codecs {
+		if len(key) > len(prefix) && key[:len(prefix)] == prefix {
+			if cols == nil {
+				cols = make(map[string]ColumnCodec)
+			}
+
+			cols[key[len(prefix):]] = codec
+		}
+	}
+
+	return cols
+}
+
+// encodeValue encodes the provided value of the provided column, if it has a
+// codec. Indirect values are never encoded.
+func encodeValue(codecs map[string]ColumnCodec, col string, val interface{}) (interface{}, error) {
+	codec, ok := codecs[col]
+	if !ok {
+		return val, nil
+	}
+
+	switch val.(type) {
+	case IndirectValue, UpdateFunction:
+		return val, nil
+	}
+
+	encoded, err := codec.Encode(val)
+	if err != nil {
+		return nil, fmt.Errorf("failed encoding value of column %s: %w", col, err)
+	}
+
+	return encoded, nil
+}
+
+// encoded returns a copy of the statement whose values are encoded with
+// its codecs
+func (stmt *InsertStmt) encoded() (*InsertStmt, error) {
+	cols := stmt.codecs.forTable(stmt.Table)
+	if len(cols) == 0 {
+		return stmt, nil
+	}
+
+	encoded := *stmt
+
+	encodeRow := func(row []interface{}) ([]interface{}, error) {
+		out := make([]interface{}, len(row))
+
+		for i, val := range row {
+			if i >= len(stmt.InsCols) {
+				out[i] = val
+				continue
+			}
+
+			var err error
+			if out[i], err = encodeValue(cols, stmt.InsCols[i], val); err != nil {
+				return nil, err
+			}
+		}
+
+		return out, nil
+	}
+
+	var err error
+	if encoded.InsVals, err = encodeRow(stmt.InsVals); err != nil {
+		return nil, err
+	}
+
+	encoded.InsMultipleVals = make([][]interface{}, len(stmt.InsMultipleVals))
+	for i, row := range stmt.InsMultipleVals {
+		if encoded.InsMultipleVals[i], err = encodeRow(row); err != nil {
+			return nil, err
+		}
+	}
+
+	return &encoded, nil
+}
+
+// encoded returns a copy of the statement whose values are encoded with
+// its codecs
+func (stmt *UpdateStmt) encoded() (*UpdateStmt, error) {
+	cols := stmt.codecs.forTable(stmt.Table)
+	if len(cols) == 0 {
+		return stmt, nil
+	}
+
+	encoded := *stmt
+	encoded.Updates = make(map[string]interface{}, len(stmt.Updates))
+
+	for col, val := range stmt.Updates {
+		var err error
+		if encoded.Updates[col], err = encodeValue(cols, col, val); err != nil {
+			return nil, err
+		}
+	}
+
+	return &encoded, nil
+}
+
+// decode decodes the values loaded into the provided variable (a struct,
+// a slice of structs, or a slice of pointers to structs) by the statement
+func (stmt *SelectStmt) decode(into interface{}) error {
+	cols := stmt.defaults.Codecs.forTable(stmt.Table)
+	if len(cols) == 0 {
+		return nil
+	}
+
+	v := reflect.ValueOf(into)
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+
+	var items []reflect.Value
+
+	switch v.Kind() {
+	case reflect.Struct:
+		items = []reflect.Value{v}
+	case reflect.Slice:
+		items = make([]reflect.Value, v.Len())
+		for i := range items {
+			items[i] = reflect.Indirect(v.Index(i))
+		}
+	default:
+		return nil
+	}
+
+	if len(items) == 0 || items[0].Kind() != reflect.Struct {
+		return nil
+	}
+
+	for _, field := range structFields(items[0].Type()) {
+		codec, ok := cols[field.Column]
+		if !ok {
+			continue
+		}
+
+		for _, item := range items {
+			fv, ok := fieldByIndex(item, field.Index)
+			if !ok {
+				continue
+			}
+
+			decoded, err := codec.Decode(fv.Interface())
+			if err != nil {
+				return fmt.Errorf("failed decoding value of column %s: %w", field.Column, err)
+			}
+
+			if err := assignValue(fv, decoded); err != nil {
+				return fmt.Errorf("failed decoding value of column %s: %w", field.Column, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// decodeMap decodes the values of a map loaded by the statement
+func (stmt *SelectStmt) decodeMap(results map[string]interface{}) error {
+	for col, codec := range stmt.defaults.Codecs.forTable(stmt.Table) {
+		val, ok := results[col]
+		if !ok {
+			continue
+		}
+
+		decoded, err := codec.Decode(val)
+		if err != nil {
+			return fmt.Errorf("failed decoding value of column %s: %w", col, err)
+		}
+
+		results[col] = decoded
+	}
+
+	return nil
+}
+
+// fieldByIndex returns a settable struct field by its index path, and
+// false if the path goes through a nil embedded pointer
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for _, i := range index {
+		for v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return v, false
+			}
+
+			v = v.Elem()
+		}
+
+		v = v.Field(i)
+	}
+
+	return v, true
+}
+
+// assignValue assigns a value to a field, converting it if necessary
+func assignValue(field reflect.Value, val interface{}) error {
+	if val == nil {
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	}
+
+	rv := reflect.ValueOf(val)
+
+	switch {
+	case rv.Type().AssignableTo(field.Type()):
+		field.Set(rv)
+	case rv.Type().ConvertibleTo(field.Type()):
+		field.Set(rv.Convert(field.Type()))
+	default:
+		return fmt.Errorf("cannot assign %T to field of type %s", val, field.Type())
+	}
+
+	return nil
+}
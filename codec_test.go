@@ -0,0 +1,117 @@
+package sqlz
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+// prefixCodec is a reversible codec that prefixes string values
+type prefixCodec struct{}
+
+func (prefixCodec) Encode(value interface{}) (interface{}, error) {
+	str, ok := value.(string)
+	if !ok {
+		return nil, errors.New("not a string")
+	}
+
+	return "enc:" + str, nil
+}
+
+func (prefixCodec) Decode(value interface{}) (interface{}, error) {
+	var str string
+
+	switch v := value.(type) {
+	case string:
+		str = v
+	case []byte:
+		str = string(v)
+	default:
+		return nil, errors.New("not a string")
+	}
+
+	if !strings.HasPrefix(str, "enc:") {
+		return nil, errors.New("not encoded")
+	}
+
+	return strings.TrimPrefix(str, "enc:"), nil
+}
+
+func TestCodecs(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	dbz := New(db, "postgres")
+	dbz.RegisterCodec("users", "ssn", prefixCodec{})
+
+	mock.ExpectExec(`INSERT INTO users \(name, ssn\) VALUES \(\$1, \$2\)`).
+		WithArgs("Alice", "enc:123").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`UPDATE users u SET name = \$1, ssn = \$2 WHERE id = \$3`).
+		WithArgs("Bob", "enc:456", 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT id, ssn FROM users`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "ssn"}).
+			AddRow(1, "enc:123").
+			AddRow(2, "enc:456"))
+	mock.ExpectQuery(`SELECT \* FROM users WHERE id = \$1`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "ssn"}).AddRow(1, []byte("enc:123")))
+	mock.ExpectQuery(`SELECT ssn FROM logs`).
+		WillReturnRows(sqlmock.NewRows([]string{"ssn"}).AddRow("plain"))
+
+	if _, err := dbz.InsertInto("users").Columns("name", "ssn").Values("Alice", "123").Exec(); err != nil {
+		t.Fatalf("Insert failed: %s", err)
+	}
+
+	if _, err := dbz.Update("users u").Set("name", "Bob").Set("ssn", "456").Where(Eq("id", 1)).Exec(); err != nil {
+		t.Fatalf("Update failed: %s", err)
+	}
+
+	var users []struct {
+		ID  int64  `db:"id"`
+		SSN string `db:"ssn"`
+	}
+
+	if err := dbz.Select("id", "ssn").From("users").GetAll(&users); err != nil {
+		t.Fatalf("Select failed: %s", err)
+	}
+
+	if len(users) != 2 || users[0].SSN != "123" || users[1].SSN != "456" {
+		t.Errorf("Expected decoded values, got %+v", users)
+	}
+
+	row, err := dbz.Select("*").From("users").Where(Eq("id", 1)).GetRowAsMap()
+	if err != nil {
+		t.Fatalf("Select failed: %s", err)
+	}
+
+	if !reflect.DeepEqual(row["ssn"], "123") {
+		t.Errorf("Expected decoded value, got %v", row["ssn"])
+	}
+
+	// tables without codecs are not decoded
+	var ssn string
+	if err := dbz.Select("ssn").From("logs").GetRow(&ssn); err != nil {
+		t.Fatalf("Select failed: %s", err)
+	}
+
+	if ssn != "plain" {
+		t.Errorf("Expected value to be untouched, got %s", ssn)
+	}
+
+	// encoding errors fail the statement before it is executed
+	_, err = dbz.InsertInto("users").Columns("ssn").Values(123).Exec()
+	if err == nil || !strings.Contains(err.Error(), "column ssn") {
+		t.Errorf("Expected encoding error, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %s", err)
+	}
+}
@@ -0,0 +1,105 @@
+package sqlz
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+type contextKey int
+
+const (
+	queryNameKey contextKey = iota
+	tagsKey
+)
+
+// WithQueryName returns a copy of the provided context that carries the
+// provided query name. The name identifies the query for attribution
+// purposes (e.g. in logs, metrics and SQL comments), and can be read back
+// with QueryName.
+func WithQueryName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, queryNameKey, name)
+}
+
+// QueryName returns the query name attached to the context with
+// WithQueryName, or an empty string if there isn't one
+func QueryName(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+
+	name, _ := ctx.Value(queryNameKey).(string)
+
+	return name
+}
+
+// WithTag returns a copy of the provided context that carries the provided
+// key-value tag, in addition to any tags already attached to it. Tags can
+// be read back with Tags.
+func WithTag(ctx context.Context, key, value string) context.Context {
+	existing := Tags(ctx)
+
+	tags := make(map[string]string, len(existing)+1)
+	for k, v := range existing {
+		tags[k] = v
+	}
+
+	tags[key] = value
+
+	return context.WithValue(ctx, tagsKey, tags)
+}
+
+// Tags returns the tags attached to the context with WithTag, or nil if
+// there aren't any. The returned map must not be modified.
+func Tags(ctx context.Context) map[string]string {
+	if ctx == nil {
+		return nil
+	}
+
+	tags, _ := ctx.Value(tagsKey).(map[string]string)
+
+	return tags
+}
+
+// SQLComment formats the query name and tags attached to the context as an
+// SQL comment in the sqlcommenter format (e.g. /*name='get_user',team='x'*/),
+// which can be appended to a query so that the attribution is visible in
+// database logs and monitoring tools. Keys are sorted, and keys and values
+// are URL-encoded. An empty string is returned if the context carries no
+// query name and no tags.
+func SQLComment(ctx context.Context) string {
+	tags := make(map[string]string)
+	for k, v := range Tags(ctx) {
+		tags[k] = v
+	}
+
+	if name := QueryName(ctx); name != "" {
+		tags["name"] = name
+	}
+
+	if len(tags) == 0 {
+		return ""
+	}
+
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+
+	for i, key := range keys {
+		// URL-encoding also escapes single quotes in values
+		pairs[i] = commentEscape(key) + "='" + commentEscape(tags[key]) + "'"
+	}
+
+	return "/*" + strings.Join(pairs, ",") + "*/"
+}
+
+// commentEscape URL-encodes a key or value for an sqlcommenter comment
+func commentEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
@@ -0,0 +1,45 @@
+package sqlz
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSQLComment(t *testing.T) {
+	base := context.Background()
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		comment string
+	}{
+		{"no attribution", base, ""},
+		{"query name only", WithQueryName(base, "get_user"), "/*name='get_user'*/"},
+		{
+			"query name and tags",
+			WithTag(WithTag(WithQueryName(base, "get_user"), "team", "billing"), "route", "/users/{id}"),
+			"/*name='get_user',route='%2Fusers%2F%7Bid%7D',team='billing'*/",
+		},
+		{"tag overridden", WithTag(WithTag(base, "team", "a"), "team", "b c"), "/*team='b%20c'*/"},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.name, func(t *testing.T) {
+			if comment := SQLComment(tst.ctx); comment != tst.comment {
+				t.Errorf("Expected %s, got %s", tst.comment, comment)
+			}
+		})
+	}
+
+	// adding tags to a derived context must not affect the parent
+	parent := WithTag(base, "team", "a")
+	WithTag(parent, "route", "/x")
+
+	if tags := Tags(parent); len(tags) != 1 {
+		t.Errorf("Expected parent context to have 1 tag, got %d", len(tags))
+	}
+
+	if name := QueryName(parent); name != "" {
+		t.Errorf("Expected no query name, got %s", name)
+	}
+}
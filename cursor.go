@@ -0,0 +1,355 @@
+package sqlz
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var (
+	// ErrNoCursorKey is returned when creating or reading cursor tokens
+	// for statements created from a DB or Tx with no cursor key (see
+	// Defaults.CursorKey)
+	ErrNoCursorKey = errors.New("no cursor key configured")
+	// ErrInvalidCursor is returned when reading a cursor token that is
+	// malformed, was not signed with the same key, or does not match
+	// the ordering of the statement
+	ErrInvalidCursor = errors.New("invalid cursor token")
+)
+
+// cursorPayload is the keyset pagination state encoded in cursor tokens
+type cursorPayload struct {
+	Columns []string      `json:"c"`
+	Desc    []bool        `json:"d"`
+	Values  []interface{} `json:"v"`
+}
+
+// CursorToken creates an opaque, signed token encoding the keyset
+// pagination state of the statement: its ordering columns, and the values
+// of these columns in the last row of the current page. The statement must
+// be ordered by columns only (i.e. with Asc and Desc), and the ordering
+// should be unique (e.g. end with the primary key). The token is signed
+// with the cursor key of the DB or Tx the statement was created from (see
+// Defaults.CursorKey). Pass the token to FromCursorToken on the same query
+// to get the next page.
+func (stmt *SelectStmt) CursorToken(lastValues ...interface{}) (string, error) {
+	cols, err := stmt.orderColumns()
+	if err != nil {
+		return "", err
+	}
+
+	if len(lastValues) != len(cols) {
+		return "", fmt.Errorf(
+			"%w: statement has %d order columns but %d values were provided",
+			ErrInvalidCursor, len(cols), len(lastValues),
+		)
+	}
+
+	if len(stmt.defaults.CursorKey) == 0 {
+		return "", ErrNoCursorKey
+	}
+
+	payload := cursorPayload{Values: lastValues}
+	for _, col := range cols {
+		payload.Columns = append(payload.Columns, col.Column)
+		payload.Desc = append(payload.Desc, col.Desc)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed encoding cursor: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data) + "." +
+		base64.RawURLEncoding.EncodeToString(signCursor(stmt.defaults.CursorKey, data)), nil
+}
+
+// FromCursorToken verifies a token created by CursorToken, and adds a
+// condition to the statement selecting only rows that come after the
+// position encoded in it. The statement must have the same ordering as
+// the one the token was created from. Note that values are decoded from
+// JSON, so times are bound as strings and numbers as int64 or float64.
+func (stmt *SelectStmt) FromCursorToken(token string) (*SelectStmt, error) {
+	if len(stmt.defaults.CursorKey) == 0 {
+		return stmt, ErrNoCursorKey
+	}
+
+	cols, err := stmt.orderColumns()
+	if err != nil {
+		return stmt, err
+	}
+
+	dot := strings.IndexByte(token, '.')
+	if dot == -1 {
+		return stmt, ErrInvalidCursor
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(token[:dot])
+	if err != nil {
+		return stmt, ErrInvalidCursor
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(token[dot+1:])
+	if err != nil || !hmac.Equal(sig, signCursor(stmt.defaults.CursorKey, data)) {
+		return stmt, ErrInvalidCursor
+	}
+
+	var payload cursorPayload
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	if err := dec.Decode(&payload); err != nil {
+		return stmt, ErrInvalidCursor
+	}
+
+	if len(payload.Columns) != len(cols) ||
+		len(payload.Desc) != len(cols) ||
+		len(payload.Values) != len(cols) {
+		return stmt, fmt.Errorf("%w: ordering does not match statement", ErrInvalidCursor)
+	}
+
+	for i, col := range cols {
+		if payload.Columns[i] != col.Column || payload.Desc[i] != col.Desc {
+			return stmt, fmt.Errorf("%w: ordering does not match statement", ErrInvalidCursor)
+		}
+
+		if num, ok := payload.Values[i].(json.Number); ok {
+			payload.Values[i] = numberValue(num)
+		}
+	}
+
+	return stmt.Where(keysetCondition(cols, payload.Values)), nil
+}
+
+// orderColumns returns the statement's ordering, which must be made of
+// OrderColumn values only
+func (stmt *SelectStmt) orderColumns() ([]OrderColumn, error) {
+	if len(stmt.Ordering) == 0 {
+		return nil, fmt.Errorf("%w: statement has no ordering", ErrInvalidCursor)
+	}
+
+	cols := make([]OrderColumn, len(stmt.Ordering))
+
+	for i, order := range stmt.Ordering {
+		col, ok := order.(OrderColumn)
+		if !ok {
+			return nil, fmt.Errorf("%w: statement must be ordered by columns only", ErrInvalidCursor)
+		}
+
+		cols[i] = col
+	}
+
+	return cols, nil
+}
+
+// keysetCondition creates a condition selecting the rows that come after
+// the provided values in the provided ordering, e.g. for ordering by
+// (a ASC, b DESC): a > ? OR (a = ? AND b < ?)
+func keysetCondition(cols []OrderColumn, values []interface{}) WhereCondition {
+	ors := make([]WhereCondition, len(cols))
+
+	for i, col := range cols {
+		ands := make([]WhereCondition, 0, i+1)
+		for j := 0; j < i; j++ {
+			ands = append(ands, Eq(cols[j].Column, values[j]))
+		}
+
+		if col.Desc {
+			ands = append(ands, Lt(col.Column, values[i]))
+		} else {
+			ands = append(ands, Gt(col.Column, values[i]))
+		}
+
+		if len(ands) == 1 {
+			ors[i] = ands[0]
+		} else {
+			ors[i] = And(ands...)
+		}
+	}
+
+	if len(ors) == 1 {
+		return ors[0]
+	}
+
+	return Or(ors...)
+}
+
+// KeysetClause describes a page of keyset (or "seek") pagination: the
+// columns results are ordered by, and the values of these columns in the
+// last row of the previous page, if any. Unlike OFFSET, keyset pagination
+// remains fast on deep pages and is not affected by rows being inserted
+// or deleted between pages. The ordering should be unique, e.g. end with
+// the primary key.
+type KeysetClause struct {
+	Columns []OrderColumn
+	Values  []interface{}
+	dialect Dialect
+}
+
+// Keyset creates a KeysetClause for paginating over the provided ordered
+// columns, positioned at the first page. Use After or Next to move it to
+// subsequent pages, and SelectStmt.Keyset to apply it to a query, e.g.:
+//
+//	page := Keyset(Desc("created_at"), Asc("id")).After("2020-01-01", 42)
+//	dbz.Select("*").From("posts").Keyset(page).Limit(20)
+func Keyset(cols ...OrderColumn) *KeysetClause {
+	return &KeysetClause{Columns: cols}
+}
+
+// After returns a copy of the clause positioned after the row with the
+// provided values for its columns (in order)
+func (ks *KeysetClause) After(values ...interface{}) *KeysetClause {
+	return &KeysetClause{Columns: ks.Columns, Values: values}
+}
+
+// Next returns a copy of the clause positioned after the provided row,
+// which is usually the last row fetched for the current page. The row may
+// be a struct (or a pointer to one) whose fields are mapped to columns like
+// sqlx does, or a map[string]interface{} such as the ones returned by
+// GetAllAsMaps. Qualified columns (e.g. p.id) are looked up by their
+// unqualified name.
+func (ks *KeysetClause) Next(lastRow interface{}) (*KeysetClause, error) {
+	values := make([]interface{}, len(ks.Columns))
+
+	if m, ok := lastRow.(map[string]interface{}); ok {
+		for i, col := range ks.Columns {
+			val, ok := m[unqualified(col.Column)]
+			if !ok {
+				return nil, fmt.Errorf("row has no value for keyset column %s", col.Column)
+			}
+
+			values[i] = val
+		}
+
+		return ks.After(values...), nil
+	}
+
+	v := reflect.ValueOf(lastRow)
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%w: Next received %T", ErrNotStruct, lastRow)
+	}
+
+	fields := make(map[string][]int)
+	for _, field := range structFields(v.Type()) {
+		fields[field.Column] = field.Index
+	}
+
+	for i, col := range ks.Columns {
+		index, ok := fields[unqualified(col.Column)]
+		if !ok {
+			return nil, fmt.Errorf("row has no value for keyset column %s", col.Column)
+		}
+
+		values[i] = structValue(v, index)
+	}
+
+	return ks.After(values...), nil
+}
+
+// Parse implements the WhereCondition interface, generating a condition
+// selecting the rows that come after the clause's position. When all
+// columns are ordered in the same direction, a row comparison is used,
+// e.g. (a, b) > (?, ?), which databases can satisfy with a single index
+// range scan. Otherwise, or for databases without row comparisons (Oracle
+// and SQL Server), the condition is expanded, e.g. a > ? OR (a = ? AND
+// b > ?).
+// If the clause has fewer values than columns, only the first columns are
+// compared (Validate reports such clauses).
+func (ks *KeysetClause) Parse() (asSQL string, bindings []interface{}) {
+	if len(ks.Values) < len(ks.Columns) {
+		return (&KeysetClause{ks.Columns[:len(ks.Values)], ks.Values, ks.dialect}).Parse()
+	}
+
+	if len(ks.Columns) == 1 || !ks.dialect.hasRowValues() || !ks.sameDirection() {
+		return keysetCondition(ks.Columns, ks.Values).Parse()
+	}
+
+	cols := make([]string, len(ks.Columns))
+	placeholders := make([]string, len(ks.Columns))
+
+	for i, col := range ks.Columns {
+		cols[i] = col.Column
+		placeholders[i] = "?"
+	}
+
+	op := " > "
+	if ks.Columns[0].Desc {
+		op = " < "
+	}
+
+	return "(" + strings.Join(cols, ", ") + ")" + op + "(" + strings.Join(placeholders, ", ") + ")",
+		ks.Values[:len(ks.Columns)]
+}
+
+func (ks *KeysetClause) conditionForDialect(dialect Dialect) WhereCondition {
+	adapted := *ks
+	adapted.dialect = dialect
+
+	return &adapted
+}
+
+func (ks *KeysetClause) sameDirection() bool {
+	for _, col := range ks.Columns[1:] {
+		if col.Desc != ks.Columns[0].Desc {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Keyset orders the statement by the columns of the provided keyset
+// clause, and if the clause is positioned after a row, adds a condition
+// selecting only the rows that come after it
+func (stmt *SelectStmt) Keyset(ks *KeysetClause) *SelectStmt {
+	for _, col := range ks.Columns {
+		stmt.Ordering = append(stmt.Ordering, col)
+	}
+
+	if len(ks.Values) > 0 {
+		stmt.Conditions = append(stmt.Conditions, ks)
+	}
+
+	return stmt
+}
+
+// unqualified returns the provided column name without its table
+// qualifier, if any
+func unqualified(col string) string {
+	if dot := strings.LastIndexByte(col, '.'); dot > -1 {
+		return col[dot+1:]
+	}
+
+	return col
+}
+
+// numberValue converts a number decoded from JSON into an int64 or float64
+func numberValue(num json.Number) interface{} {
+	if i, err := num.Int64(); err == nil {
+		return i
+	}
+
+	if f, err := num.Float64(); err == nil {
+		return f
+	}
+
+	return num.String()
+}
+
+func signCursor(key, data []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data) //nolint: errcheck
+
+	return mac.Sum(nil)
+}
@@ -0,0 +1,126 @@
+package sqlz
+
+import (
+	"errors"
+	"testing"
+
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestCursorToken(t *testing.T) {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	dbz := New(db, "sqlmock")
+	dbz.Defaults.CursorKey = []byte("secret")
+
+	page := func() *SelectStmt {
+		return dbz.Select("*").From("posts").Where(Eq("published", true)).OrderBy(Desc("created_at"), Asc("id")).Limit(2)
+	}
+
+	token, err := page().CursorToken("2020-01-01T00:00:00Z", 42)
+	if err != nil {
+		t.Fatalf("Failed creating token: %s", err)
+	}
+
+	next, err := page().FromCursorToken(token)
+	if err != nil {
+		t.Fatalf("Failed reading token: %s", err)
+	}
+
+	runTests(t, func(_ *DB) []test {
+		return []test{
+			{
+				"next page from cursor token",
+				next,
+				"SELECT * FROM posts WHERE published = ? AND (created_at < ? OR (created_at = ? AND id > ?)) " +
+					"ORDER BY created_at DESC, id ASC LIMIT 2",
+				[]interface{}{true, "2020-01-01T00:00:00Z", "2020-01-01T00:00:00Z", int64(42)},
+			},
+		}
+	})
+
+	if _, err := page().FromCursorToken(token[:len(token)-2] + "xx"); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("Expected tampered token to be rejected, got %v", err)
+	}
+
+	other := dbz.Select("*").From("posts").OrderBy(Asc("id"))
+	if _, err := other.FromCursorToken(token); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("Expected token with different ordering to be rejected, got %v", err)
+	}
+
+	dbz.Defaults.CursorKey = nil
+	if _, err := page().CursorToken(1, 2); !errors.Is(err, ErrNoCursorKey) {
+		t.Errorf("Expected ErrNoCursorKey, got %v", err)
+	}
+}
+
+func TestKeyset(t *testing.T) {
+	type post struct {
+		ID        int64  `db:"id"`
+		CreatedAt string `db:"created_at"`
+		Title     string
+	}
+
+	first := Keyset(Desc("p.created_at"), Desc("p.id"))
+
+	next, err := first.Next(&post{ID: 42, CreatedAt: "2020-01-01", Title: "Hello"})
+	if err != nil {
+		t.Fatalf("Failed getting next page from struct: %s", err)
+	}
+
+	mixed, err := Keyset(Desc("created_at"), Asc("id")).Next(map[string]interface{}{"id": 7, "created_at": "2020-02-02"})
+	if err != nil {
+		t.Fatalf("Failed getting next page from map: %s", err)
+	}
+
+	if _, err := first.Next(map[string]interface{}{"id": 1}); err == nil {
+		t.Error("Expected Next to fail on a row missing keyset columns")
+	}
+
+	if _, err := first.Next(3); !errors.Is(err, ErrNotStruct) {
+		t.Errorf("Expected ErrNotStruct, got %v", err)
+	}
+
+	runTests(t, func(dbz *DB) []test {
+		return []test{
+			{
+				"first page",
+				dbz.Select("*").From("posts p").Keyset(first).Limit(10),
+				"SELECT * FROM posts p ORDER BY p.created_at DESC, p.id DESC LIMIT 10",
+				nil,
+			},
+			{
+				"next page with row comparison",
+				dbz.Select("*").From("posts p").Where(Eq("p.published", true)).Keyset(next).Limit(10),
+				"SELECT * FROM posts p WHERE p.published = ? AND (p.created_at, p.id) < (?, ?) ORDER BY p.created_at DESC, p.id DESC LIMIT 10",
+				[]interface{}{true, "2020-01-01", int64(42)},
+			},
+			{
+				"next page with mixed directions",
+				dbz.Select("*").From("posts").Keyset(mixed),
+				"SELECT * FROM posts WHERE (created_at < ? OR (created_at = ? AND id > ?)) ORDER BY created_at DESC, id ASC",
+				[]interface{}{"2020-02-02", "2020-02-02", 7},
+			},
+			{
+				"single column",
+				dbz.Select("*").From("posts").Keyset(Keyset(Asc("id")).After(5)),
+				"SELECT * FROM posts WHERE id > ? ORDER BY id ASC",
+				[]interface{}{5},
+			},
+		}
+	})
+
+	runTestsWithDriver(t, "sqlserver", func(dbz *DB) []test {
+		return []test{
+			{
+				"next page without row comparisons",
+				dbz.Select("*").From("posts p").Keyset(next),
+				"SELECT * FROM posts p WHERE (p.created_at < @p1 OR (p.created_at = @p2 AND p.id < @p3)) ORDER BY p.created_at DESC, p.id DESC",
+				[]interface{}{"2020-01-01", "2020-01-01", int64(42)},
+			},
+		}
+	})
+}
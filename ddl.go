@@ -0,0 +1,512 @@
+package sqlz
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// IdentityKind is the kind of an identity column, i.e. whether values
+// may be provided explicitly instead of being generated
+type IdentityKind int8
+
+const (
+	// NoIdentity means the column is not an identity column
+	NoIdentity IdentityKind = iota
+	// IdentityByDefault generates values unless they are provided
+	// explicitly (GENERATED BY DEFAULT AS IDENTITY)
+	IdentityByDefault
+	// IdentityAlways always generates values, rejecting explicit ones
+	// unless overridden (GENERATED ALWAYS AS IDENTITY)
+	IdentityAlways
+)
+
+// ColumnDef represents a column definition in data definition (DDL)
+// statements such as CREATE TABLE and ALTER TABLE ... ADD COLUMN
+type ColumnDef struct {
+	Name string
+	Type string
+	// GeneratedExpr is the expression of a stored generated column
+	GeneratedExpr string
+	// Identity makes the column an identity column
+	Identity IdentityKind
+	// DefaultValue is the column's default value, if HasDefault is true
+	// (see Default)
+	DefaultValue interface{}
+	HasDefault   bool
+	IsNotNull    bool
+	IsUnique     bool
+	IsPrimaryKey bool
+}
+
+// Column creates a definition of a column with the provided name and
+// data type
+func Column(name, dataType string) *ColumnDef {
+	return &ColumnDef{Name: name, Type: dataType}
+}
+
+// GeneratedAs makes the column a stored generated column, whose value is
+// always computed from the provided expression over other columns of the
+// row, i.e. GENERATED ALWAYS AS (expr) STORED
+func (col *ColumnDef) GeneratedAs(expr string) *ColumnDef {
+	col.GeneratedExpr = expr
+	return col
+}
+
+// AsIdentity makes the column an identity column of the provided kind,
+// e.g. GENERATED BY DEFAULT AS IDENTITY for IdentityByDefault. Identity
+// columns are the standard replacement for PostgreSQL's serial types.
+func (col *ColumnDef) AsIdentity(kind IdentityKind) *ColumnDef {
+	col.Identity = kind
+	return col
+}
+
+// Default sets the column's default value. As DDL statements do not
+// support placeholders, the value is inlined as a literal (see Literal);
+// use Indirect for expressions, e.g. Default(Indirect("now()")).
+func (col *ColumnDef) Default(value interface{}) *ColumnDef {
+	col.DefaultValue = value
+	col.HasDefault = true
+
+	return col
+}
+
+// NotNull adds a NOT NULL constraint to the column
+func (col *ColumnDef) NotNull() *ColumnDef {
+	col.IsNotNull = true
+	return col
+}
+
+// Unique adds a UNIQUE constraint to the column
+func (col *ColumnDef) Unique() *ColumnDef {
+	col.IsUnique = true
+	return col
+}
+
+// PrimaryKey makes the column the table's primary key. Use PrimaryKey
+// (the function) for primary keys of multiple columns.
+func (col *ColumnDef) PrimaryKey() *ColumnDef {
+	col.IsPrimaryKey = true
+	return col
+}
+
+// ToSQL generates the column definition's SQL. Column definitions have no
+// bindings, as DDL statements do not support placeholders.
+func (col *ColumnDef) ToSQL(_ bool) (asSQL string, bindings []interface{}) {
+	words := []string{col.Name, col.Type}
+
+	switch col.Identity {
+	case IdentityByDefault:
+		words = append(words, "GENERATED BY DEFAULT AS IDENTITY")
+	case IdentityAlways:
+		words = append(words, "GENERATED ALWAYS AS IDENTITY")
+	}
+
+	if col.GeneratedExpr != "" {
+		words = append(words, "GENERATED ALWAYS AS ("+col.GeneratedExpr+") STORED")
+	}
+
+	if col.HasDefault {
+		// values that cannot be converted are reported by Validate
+		literal, _ := literalOrIndirect(col.DefaultValue)
+		words = append(words, "DEFAULT "+literal)
+	}
+
+	if col.IsNotNull {
+		words = append(words, "NOT NULL")
+	}
+
+	if col.IsUnique {
+		words = append(words, "UNIQUE")
+	}
+
+	if col.IsPrimaryKey {
+		words = append(words, "PRIMARY KEY")
+	}
+
+	return strings.Join(words, " "), nil
+}
+
+// KeyConstraint represents a PRIMARY KEY or UNIQUE constraint on one or
+// more columns in DDL statements
+type KeyConstraint struct {
+	Name    string
+	Primary bool
+	Columns []string
+}
+
+// PrimaryKey creates a PRIMARY KEY constraint on the provided columns
+func PrimaryKey(cols ...string) *KeyConstraint {
+	return &KeyConstraint{Primary: true, Columns: cols}
+}
+
+// Unique creates a UNIQUE constraint on the provided columns
+func Unique(cols ...string) *KeyConstraint {
+	return &KeyConstraint{Columns: cols}
+}
+
+// Named sets the name of the constraint
+func (c *KeyConstraint) Named(name string) *KeyConstraint {
+	c.Name = name
+	return c
+}
+
+// ToSQL generates the constraint's SQL. Constraints have no bindings.
+func (c *KeyConstraint) ToSQL(_ bool) (asSQL string, bindings []interface{}) {
+	keyword := "UNIQUE"
+	if c.Primary {
+		keyword = "PRIMARY KEY"
+	}
+
+	return constraintName(c.Name) + keyword + " (" + strings.Join(c.Columns, ", ") + ")", nil
+}
+
+// CheckConstraint represents a CHECK constraint in DDL statements, whose
+// condition is built from the same condition types used in WHERE clauses
+type CheckConstraint struct {
+	Name       string
+	Conditions []WhereCondition
+}
+
+// Check creates a CHECK constraint requiring rows to satisfy the provided
+// conditions (considered AND conditions if multiple are provided), e.g.
+// Check(Gt("price", 0), Or(IsNull("discount"), Lt("discount", Indirect("price"))))
+func Check(conds ...WhereCondition) *CheckConstraint {
+	return &CheckConstraint{Conditions: conds}
+}
+
+// Named sets the name of the constraint
+func (c *CheckConstraint) Named(name string) *CheckConstraint {
+	c.Name = name
+	return c
+}
+
+// ToSQL generates the constraint's SQL. As DDL statements do not support
+// placeholders, values bound by the conditions are inlined as literals
+// (see Literal), so no bindings are returned.
+func (c *CheckConstraint) ToSQL(_ bool) (asSQL string, bindings []interface{}) {
+	// values that cannot be converted are reported by Validate
+	condSQL, _ := inlineConditions(c.Conditions)
+
+	return constraintName(c.Name) + "CHECK (" + condSQL + ")", nil
+}
+
+// ExcludeElement is an element of an EXCLUDE constraint: an expression
+// (usually a column) and the operator two rows are compared with
+type ExcludeElement struct {
+	Expr     string
+	Operator string
+}
+
+// ExcludeConstraint represents a PostgreSQL EXCLUDE constraint, which
+// guarantees that no two rows satisfy all of its comparisons, e.g. that
+// no two reservations of the same room have overlapping time ranges
+type ExcludeConstraint struct {
+	Name       string
+	Using      string
+	Elements   []ExcludeElement
+	Conditions []WhereCondition
+}
+
+// Exclude creates an EXCLUDE constraint using the provided index method
+// (usually "gist"). Elements are added with With, e.g.:
+//
+//	Exclude("gist").With("room_id", "=").With("during", "&&")
+func Exclude(using string) *ExcludeConstraint {
+	return &ExcludeConstraint{Using: using}
+}
+
+// Named sets the name of the constraint
+func (c *ExcludeConstraint) Named(name string) *ExcludeConstraint {
+	c.Name = name
+	return c
+}
+
+// With adds an element to the constraint, comparing the provided
+// expression of two rows with the provided operator
+func (c *ExcludeConstraint) With(expr, operator string) *ExcludeConstraint {
+	c.Elements = append(c.Elements, ExcludeElement{expr, operator})
+	return c
+}
+
+// Where makes the constraint partial, only applying it to rows that
+// satisfy the provided conditions
+func (c *ExcludeConstraint) Where(conds ...WhereCondition) *ExcludeConstraint {
+	c.Conditions = append(c.Conditions, conds...)
+	return c
+}
+
+// ToSQL generates the constraint's SQL. Values bound by the conditions are
+// inlined as literals, so no bindings are returned.
+func (c *ExcludeConstraint) ToSQL(_ bool) (asSQL string, bindings []interface{}) {
+	elements := make([]string, len(c.Elements))
+	for i, elem := range c.Elements {
+		elements[i] = elem.Expr + " WITH " + elem.Operator
+	}
+
+	asSQL = constraintName(c.Name) + "EXCLUDE"
+	if c.Using != "" {
+		asSQL += " USING " + c.Using
+	}
+
+	asSQL += " (" + strings.Join(elements, ", ") + ")"
+
+	if len(c.Conditions) > 0 {
+		// values that cannot be converted are reported by Validate
+		condSQL, _ := inlineConditions(c.Conditions)
+		asSQL += " WHERE (" + condSQL + ")"
+	}
+
+	return asSQL, nil
+}
+
+// ReferentialAction is the action taken on referencing rows when the row
+// they reference through a foreign key is deleted or updated
+type ReferentialAction string
+
+const (
+	// NoAction fails the deletion or update if referencing rows exist,
+	// possibly at the end of the transaction if the constraint is deferred
+	NoAction ReferentialAction = "NO ACTION"
+	// Restrict fails the deletion or update immediately if referencing
+	// rows exist, even if the constraint is deferred
+	Restrict ReferentialAction = "RESTRICT"
+	// Cascade deletes the referencing rows, or updates their referencing
+	// columns to the new values
+	Cascade ReferentialAction = "CASCADE"
+	// SetNull sets the referencing columns of the referencing rows to NULL
+	SetNull ReferentialAction = "SET NULL"
+	// SetDefault sets the referencing columns of the referencing rows to
+	// their default values
+	SetDefault ReferentialAction = "SET DEFAULT"
+)
+
+// ForeignKeyConstraint represents a FOREIGN KEY constraint in DDL
+// statements
+type ForeignKeyConstraint struct {
+	Name         string
+	Columns      []string
+	RefTable     string
+	RefColumns   []string
+	DeleteAction ReferentialAction
+	UpdateAction ReferentialAction
+	// IsDeferrable allows checking the constraint to be deferred to the
+	// end of the transaction (see Tx.SetConstraints)
+	IsDeferrable bool
+	// IsInitiallyDeferred defers checking the constraint by default
+	IsInitiallyDeferred bool
+}
+
+// ForeignKey creates a FOREIGN KEY constraint on the provided columns. The
+// referenced table is set with References, e.g.:
+//
+//	ForeignKey("user_id").References("users", "id").OnDelete(Cascade)
+func ForeignKey(cols ...string) *ForeignKeyConstraint {
+	return &ForeignKeyConstraint{Columns: cols}
+}
+
+// Named sets the name of the constraint
+func (c *ForeignKeyConstraint) Named(name string) *ForeignKeyConstraint {
+	c.Name = name
+	return c
+}
+
+// References sets the referenced table and columns. If no columns are
+// provided, the primary key of the referenced table is used.
+func (c *ForeignKeyConstraint) References(table string, cols ...string) *ForeignKeyConstraint {
+	c.RefTable = table
+	c.RefColumns = cols
+
+	return c
+}
+
+// OnDelete sets the action taken when a referenced row is deleted
+func (c *ForeignKeyConstraint) OnDelete(action ReferentialAction) *ForeignKeyConstraint {
+	c.DeleteAction = action
+	return c
+}
+
+// OnUpdate sets the action taken when the referenced columns of a
+// referenced row are updated
+func (c *ForeignKeyConstraint) OnUpdate(action ReferentialAction) *ForeignKeyConstraint {
+	c.UpdateAction = action
+	return c
+}
+
+// Deferrable makes the constraint DEFERRABLE, so that checking it can be
+// deferred to the end of the transaction with Tx.SetConstraints
+func (c *ForeignKeyConstraint) Deferrable() *ForeignKeyConstraint {
+	c.IsDeferrable = true
+	return c
+}
+
+// InitiallyDeferred makes the constraint DEFERRABLE INITIALLY DEFERRED, so
+// that it is only checked at the end of transactions unless they use
+// Tx.SetConstraints to check it immediately
+func (c *ForeignKeyConstraint) InitiallyDeferred() *ForeignKeyConstraint {
+	c.IsDeferrable = true
+	c.IsInitiallyDeferred = true
+
+	return c
+}
+
+// ToSQL generates the constraint's SQL. Constraints have no bindings.
+func (c *ForeignKeyConstraint) ToSQL(_ bool) (asSQL string, bindings []interface{}) {
+	asSQL = constraintName(c.Name) + "FOREIGN KEY (" + strings.Join(c.Columns, ", ") + ") REFERENCES " + c.RefTable
+	if len(c.RefColumns) > 0 {
+		asSQL += " (" + strings.Join(c.RefColumns, ", ") + ")"
+	}
+
+	if c.DeleteAction != "" {
+		asSQL += " ON DELETE " + string(c.DeleteAction)
+	}
+
+	if c.UpdateAction != "" {
+		asSQL += " ON UPDATE " + string(c.UpdateAction)
+	}
+
+	if c.IsDeferrable {
+		asSQL += " DEFERRABLE"
+		if c.IsInitiallyDeferred {
+			asSQL += " INITIALLY DEFERRED"
+		}
+	}
+
+	return asSQL, nil
+}
+
+// constraintName returns the CONSTRAINT clause naming a constraint, or an
+// empty string if it has no name
+func constraintName(name string) string {
+	if name == "" {
+		return ""
+	}
+
+	return "CONSTRAINT " + name + " "
+}
+
+// Literal returns the SQL literal representation of the provided value,
+// for the rare cases where values cannot be bound to placeholders, such
+// as in DDL statements. Strings (and byte slices) are quoted with single
+// quotes doubled, times are formatted as quoted timestamps, booleans are
+// rendered as TRUE or FALSE, and nil as NULL. Values implementing
+// driver.Valuer are converted first, and an error is returned if the
+// conversion fails. Other values are formatted with fmt and quoted.
+// Backslashes are not escaped, as in standard SQL; use Dialect.Literal
+// for databases where they are escape characters (e.g. MySQL).
+func Literal(value interface{}) (string, error) {
+	return DialectGeneric.Literal(value)
+}
+
+// Literal is like the Literal function, but quotes strings for the
+// dialect: backslashes are escaped in MySQL, where they are escape
+// characters by default, and strings containing backslashes are quoted
+// as escape strings (E'...') in PostgreSQL, so they are interpreted the
+// same regardless of the standard_conforming_strings setting.
+func (d Dialect) Literal(value interface{}) (string, error) {
+	if valuer, ok := value.(driver.Valuer); ok {
+		converted, err := valuer.Value()
+		if err != nil {
+			return "", fmt.Errorf("failed converting %T to a literal: %w", value, err)
+		}
+
+		value = converted
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return "NULL", nil
+	case bool:
+		if v {
+			return "TRUE", nil
+		}
+
+		return "FALSE", nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprint(v), nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 32), nil
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), nil
+	case string:
+		return d.quoteLiteral(v), nil
+	case []byte:
+		return d.quoteLiteral(string(v)), nil
+	case time.Time:
+		return d.quoteLiteral(v.Format("2006-01-02 15:04:05.999999999Z07:00")), nil
+	default:
+		return d.quoteLiteral(fmt.Sprint(v)), nil
+	}
+}
+
+// quoteLiteral quotes a string as an SQL string literal of the dialect
+func (d Dialect) quoteLiteral(s string) string {
+	if !strings.Contains(s, `\`) {
+		return quoteLiteral(s)
+	}
+
+	switch d {
+	case DialectMySQL:
+		return quoteLiteral(strings.ReplaceAll(s, `\`, `\\`))
+	case DialectPostgres:
+		return "E" + quoteLiteral(strings.ReplaceAll(s, `\`, `\\`))
+	default:
+		return quoteLiteral(s)
+	}
+}
+
+// literalOrIndirect returns the SQL of the provided value as an inline
+// literal, or as-is if it is an IndirectValue
+func literalOrIndirect(value interface{}) (string, error) {
+	if indirect, ok := value.(IndirectValue); ok {
+		return inlineBindings(DialectGeneric, indirect.Reference, indirect.Bindings)
+	}
+
+	return Literal(value)
+}
+
+// inlineConditions parses the provided conditions, inlining their bindings
+// as literals
+func inlineConditions(conds []WhereCondition) (string, error) {
+	condSQL, condBindings := parseConditions(conds)
+	return inlineBindings(DialectGeneric, condSQL, condBindings)
+}
+
+// inlineBindings replaces the placeholders in the provided SQL with the
+// literal representation of the provided bindings in the provided dialect
+// (see Dialect.Literal). Question marks inside quoted strings are left
+// untouched.
+func inlineBindings(dialect Dialect, asSQL string, bindings []interface{}) (string, error) {
+	if len(bindings) == 0 {
+		return asSQL, nil
+	}
+
+	var (
+		b      strings.Builder
+		quoted bool
+		next   int
+	)
+
+	for _, r := range asSQL {
+		switch {
+		case r == '\'':
+			quoted = !quoted
+		case r == '?' && !quoted && next < len(bindings):
+			literal, err := dialect.Literal(bindings[next])
+			if err != nil {
+				return "", err
+			}
+
+			b.WriteString(literal)
+			next++
+
+			continue
+		}
+
+		b.WriteRune(r)
+	}
+
+	return b.String(), nil
+}
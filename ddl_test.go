@@ -0,0 +1,265 @@
+package sqlz
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestColumnDefs(t *testing.T) {
+	runTests(t, func(_ *DB) []test {
+		return []test{
+			{
+				"plain column",
+				Column("name", "text"),
+				"name text",
+				nil,
+			},
+			{
+				"identity column",
+				Column("id", "bigint").AsIdentity(IdentityByDefault),
+				"id bigint GENERATED BY DEFAULT AS IDENTITY",
+				nil,
+			},
+			{
+				"always identity column",
+				Column("id", "integer").AsIdentity(IdentityAlways),
+				"id integer GENERATED ALWAYS AS IDENTITY",
+				nil,
+			},
+			{
+				"generated column",
+				Column("full_name", "text").GeneratedAs("first_name || ' ' || last_name"),
+				"full_name text GENERATED ALWAYS AS (first_name || ' ' || last_name) STORED",
+				nil,
+			},
+			{
+				"column with default and constraints",
+				Column("status", "text").Default("new").NotNull(),
+				"status text DEFAULT 'new' NOT NULL",
+				nil,
+			},
+			{
+				"column with expression default",
+				Column("created_at", "timestamptz").Default(Indirect("NOW()")).NotNull(),
+				"created_at timestamptz DEFAULT NOW() NOT NULL",
+				nil,
+			},
+			{
+				"primary key column",
+				Column("id", "bigint").AsIdentity(IdentityAlways).PrimaryKey(),
+				"id bigint GENERATED ALWAYS AS IDENTITY PRIMARY KEY",
+				nil,
+			},
+			{
+				"unique column",
+				Column("email", "text").NotNull().Unique(),
+				"email text NOT NULL UNIQUE",
+				nil,
+			},
+		}
+	})
+}
+
+func TestConstraints(t *testing.T) {
+	runTests(t, func(_ *DB) []test {
+		return []test{
+			{
+				"check constraint",
+				Check(Gt("price", 0), Or(IsNull("discount"), Lt("discount", Indirect("price")))).Named("valid_price"),
+				"CONSTRAINT valid_price CHECK (price > 0 AND (discount IS NULL OR discount < price))",
+				nil,
+			},
+			{
+				"check constraint with quoted values",
+				Check(In("status", "new", "it's done"), SQLCond("note <> '?' OR note = ?", "x")),
+				"CHECK (status IN ('new', 'it''s done') AND note <> '?' OR note = 'x')",
+				nil,
+			},
+			{
+				"foreign key constraint",
+				ForeignKey("user_id").References("users", "id").OnDelete(Cascade).OnUpdate(Restrict),
+				"FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE ON UPDATE RESTRICT",
+				nil,
+			},
+			{
+				"deferrable foreign key constraint on primary key",
+				ForeignKey("parent_id").Named("parent_fk").References("nodes").OnDelete(SetNull).InitiallyDeferred(),
+				"CONSTRAINT parent_fk FOREIGN KEY (parent_id) REFERENCES nodes ON DELETE SET NULL DEFERRABLE INITIALLY DEFERRED",
+				nil,
+			},
+			{
+				"composite foreign key constraint",
+				ForeignKey("a", "b").References("other", "x", "y").Deferrable(),
+				"FOREIGN KEY (a, b) REFERENCES other (x, y) DEFERRABLE",
+				nil,
+			},
+			{
+				"exclude constraint",
+				Exclude("gist").Named("no_overlaps").With("room_id", "=").With("during", "&&").Where(Eq("cancelled", false)),
+				"CONSTRAINT no_overlaps EXCLUDE USING gist (room_id WITH =, during WITH &&) WHERE (cancelled = FALSE)",
+				nil,
+			},
+		}
+	})
+}
+
+func TestTables(t *testing.T) {
+	runTests(t, func(dbz *DB) []test {
+		return []test{
+			{
+				"create table",
+				dbz.CreateTable("users").
+					IfNotExists().
+					Column(
+						Column("id", "bigint").AsIdentity(IdentityAlways),
+						Column("email", "text").NotNull(),
+						Column("active", "boolean").Default(true),
+					).
+					Constraint(
+						PrimaryKey("id").Named("users_pk"),
+						Unique("email"),
+						Check(Like("email", "%@%")),
+					),
+				"CREATE TABLE IF NOT EXISTS users (id bigint GENERATED ALWAYS AS IDENTITY, email text NOT NULL, active boolean DEFAULT TRUE, CONSTRAINT users_pk PRIMARY KEY (id), UNIQUE (email), CHECK (email LIKE '%@%'))",
+				nil,
+			},
+			{
+				"alter table",
+				dbz.AlterTable("users").
+					AddColumn(Column("name", "text").Default("")).
+					DropColumn("nickname").
+					RenameColumn("mail", "email").
+					SetColumnType("age", "smallint").
+					SetDefault("active", false).
+					DropDefault("role").
+					SetNotNull("email").
+					DropNotNull("name").
+					AddConstraint(ForeignKey("org_id").Named("org_fk").References("orgs", "id")).
+					DropConstraint("old_fk"),
+				"ALTER TABLE users ADD COLUMN name text DEFAULT '', DROP COLUMN nickname, RENAME COLUMN mail TO email, " +
+					"ALTER COLUMN age TYPE smallint, ALTER COLUMN active SET DEFAULT FALSE, ALTER COLUMN role DROP DEFAULT, " +
+					"ALTER COLUMN email SET NOT NULL, ALTER COLUMN name DROP NOT NULL, " +
+					"ADD CONSTRAINT org_fk FOREIGN KEY (org_id) REFERENCES orgs (id), DROP CONSTRAINT old_fk",
+				nil,
+			},
+			{
+				"rename table",
+				dbz.AlterTable("users").RenameTo("accounts"),
+				"ALTER TABLE users RENAME TO accounts",
+				nil,
+			},
+			{
+				"drop table",
+				dbz.DropTable("users"),
+				"DROP TABLE users",
+				nil,
+			},
+			{
+				"drop tables if they exist",
+				dbz.DropTable("users", "orgs").IfExists().Cascade(),
+				"DROP TABLE IF EXISTS users, orgs CASCADE",
+				nil,
+			},
+		}
+	})
+}
+
+func TestTableExec(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	dbz := New(db, "postgres")
+
+	mock.ExpectExec(`CREATE TABLE tags \(name text PRIMARY KEY\)`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`DROP TABLE tags`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	_, err = dbz.CreateTable("tags").Column(Column("name", "text").PrimaryKey()).Exec()
+	if err != nil {
+		t.Errorf("CreateTable failed: %s", err)
+	}
+
+	_, err = dbz.DropTable("tags").Exec()
+	if err != nil {
+		t.Errorf("DropTable failed: %s", err)
+	}
+
+	_, err = dbz.CreateTable("tags").Exec()
+	if err == nil {
+		t.Error("CreateTable without columns should have failed validation")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %s", err)
+	}
+}
+
+func TestLiteral(t *testing.T) {
+	tests := []struct {
+		value    interface{}
+		expected string
+	}{
+		{nil, "NULL"},
+		{true, "TRUE"},
+		{int64(-3), "-3"},
+		{1.5, "1.5"},
+		{"O'Brien", "'O''Brien'"},
+		{[]byte("abc"), "'abc'"},
+		{time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), "'2020-01-02 03:04:05Z'"},
+		{sql.NullString{String: "x", Valid: true}, "'x'"},
+		{sql.NullInt64{}, "NULL"},
+	}
+
+	for _, tst := range tests {
+		if literal, err := Literal(tst.value); err != nil || literal != tst.expected {
+			t.Errorf("Expected %v to be rendered as %s, got %s (%v)", tst.value, tst.expected, literal, err)
+		}
+	}
+
+	dialectTests := []struct {
+		dialect  Dialect
+		value    string
+		expected string
+	}{
+		{DialectGeneric, `a\'b`, `'a\''b'`},
+		{DialectPostgres, "O'Brien", `'O''Brien'`},
+		{DialectPostgres, `a\'b`, `E'a\\''b'`},
+		{DialectMySQL, `a\'b`, `'a\\''b'`},
+		{DialectSQLite, `a\'b`, `'a\''b'`},
+	}
+
+	for _, tst := range dialectTests {
+		if literal, err := tst.dialect.Literal(tst.value); err != nil || literal != tst.expected {
+			t.Errorf("Expected %s to be rendered as %s in %s, got %s (%v)", tst.value, tst.expected, tst.dialect, literal, err)
+		}
+	}
+
+	if _, err := Literal(failingValuer{}); err == nil {
+		t.Error("Expected failing valuer to return an error")
+	}
+
+	dbz := New(nil, "postgres")
+
+	for _, stmt := range []SQLStmt{
+		dbz.CreateTable("t").Column(Column("v", "TEXT").Default(failingValuer{})),
+		dbz.AlterTable("t").SetDefault("v", failingValuer{}),
+		Check(Eq("v", failingValuer{})),
+	} {
+		if err := Validate(stmt); !errors.Is(err, ErrInvalidStatement) {
+			t.Errorf("Expected invalid literal to fail validation, got %v", err)
+		}
+	}
+}
+
+// failingValuer is a driver.Valuer that cannot be converted
+type failingValuer struct{}
+
+func (failingValuer) Value() (driver.Value, error) {
+	return nil, errors.New("cannot convert")
+}
@@ -0,0 +1,159 @@
+package sqlz
+
+import (
+	"errors"
+)
+
+// ErrUnboundedSelect is returned when executing a SELECT statement with
+// neither a LIMIT clause nor conditions, if the DB or Tx it was created
+// from rejects such statements
+var ErrUnboundedSelect = errors.New("unbounded select")
+
+// Defaults are settings applied to statements created from a DB or Tx
+// object, allowing organizational policies to be enforced centrally. They
+// are set via the Defaults field of the DB or Tx object (or its Configure
+// method, once it is used concurrently), and transactions inherit the
+// defaults of the DB they were started from. Statements take a snapshot of
+// the defaults when they are created, so reconfiguring a DB never affects
+// statements that were already created.
+type Defaults struct {
+	// MaxLimit caps the number of rows returned by SELECT statements:
+	// statements with no LIMIT clause, or with a higher limit, are
+	// limited to MaxLimit rows. Sub-queries are not affected (the main
+	// statement of a WITH statement is). Zero means no cap.
+	MaxLimit int64
+	// RejectUnbounded makes GetAll and the other methods returning
+	// multiple rows fail with ErrUnboundedSelect when executing SELECT
+	// statements with neither a LIMIT clause nor conditions, as a
+	// safety net against accidental full table scans. It has no effect
+	// when MaxLimit is set, as all statements are then bounded.
+	// Statements may opt out by calling Unbounded.
+	RejectUnbounded bool
+	// LockWait is the wait policy of lock clauses added to SELECT
+	// statements with no wait policy of their own (e.g. LockNoWait
+	// or LockSkipLocked)
+	LockWait LockWait
+	// Returning is a list of columns added as a RETURNING clause to
+	// INSERT, UPDATE and DELETE statements (e.g. []string{"*"})
+	Returning []string
+	// CursorKey is the secret key used to sign and verify the cursor
+	// tokens of SELECT statements (see SelectStmt.CursorToken)
+	CursorKey []byte
+	// Cache is the store used to cache the results of SELECT statements
+	// marked with Cached. If nil, results are never cached.
+	Cache CacheStore
+	// WriteListeners are called after INSERT, UPDATE and DELETE
+	// statements are executed successfully, with the tables they
+	// modified (see WriteListener)
+	WriteListeners []WriteListener
+	// Scope automatically restricts SELECT, UPDATE and DELETE
+	// statements on a set of tables (see WithScope)
+	Scope *Scope
+	// Audit automatically sets audit columns (e.g. created_at and
+	// updated_by) in INSERT and UPDATE statements on a set of tables
+	Audit *Audit
+	// Codecs are the codecs applied to the values of specific columns,
+	// keyed by "table.column" (see ColumnCodec and DB.RegisterCodec)
+	Codecs Codecs
+	// QueryHooks are called after every query executed by statements
+	// (see QueryHook and DB.OnQuery)
+	QueryHooks []QueryHook
+	// Guards are called before statements are executed, and may reject
+	// them (see Guard)
+	Guards []Guard
+	// Filters are named, reusable conditions referenced by statements
+	// with Filter (see DB.DefineFilter)
+	Filters Filters
+	// Dialect is the SQL dialect statements are generated in, controlling
+	// placeholders, identifier quoting and LIMIT syntax. When
+	// DialectGeneric (the default), the dialect is detected from the name
+	// of the database driver.
+	Dialect Dialect
+	// EmptyIn determines how IN and NOT IN conditions with no values are
+	// handled (see EmptyInPolicy). By default, they are invalid.
+	EmptyIn EmptyInPolicy
+	// LargeIn determines whether and how IN and NOT IN conditions with
+	// many values are rewritten (see LargeIn). By default, they are
+	// never rewritten.
+	LargeIn LargeIn
+}
+
+// returning returns a copy of the default RETURNING columns, so that
+// statements can append to it without modifying the defaults
+func (defaults Defaults) returning() []string {
+	if len(defaults.Returning) == 0 {
+		return nil
+	}
+
+	return append([]string{}, defaults.Returning...)
+}
+
+// clone returns a copy of the defaults that shares no slices or maps with
+// them, so that either can be modified without affecting the other
+func (defaults Defaults) clone() Defaults {
+	cloned := defaults
+	cloned.Returning = defaults.returning()
+	cloned.CursorKey = append([]byte(nil), defaults.CursorKey...)
+	cloned.WriteListeners = append([]WriteListener(nil), defaults.WriteListeners...)
+	cloned.QueryHooks = append([]QueryHook(nil), defaults.QueryHooks...)
+	cloned.Guards = append([]Guard(nil), defaults.Guards...)
+
+	if defaults.Codecs != nil {
+		cloned.Codecs = make(Codecs, len(defaults.Codecs))
+		for key, codec := range defaults.Codecs {
+			cloned.Codecs[key] = codec
+		}
+	}
+
+	if defaults.Filters != nil {
+		cloned.Filters = make(Filters, len(defaults.Filters))
+		for name, fn := range defaults.Filters {
+			cloned.Filters[name] = fn
+		}
+	}
+
+	return cloned
+}
+
+// Configure modifies the DB's defaults with the provided function. Unlike
+// setting the Defaults field directly, it is safe to call while statements
+// are created from the DB concurrently (e.g. to toggle query logging at
+// runtime): the function receives a copy of the current defaults, which
+// replaces them once it returns, so statements that were already created
+// keep the defaults they were created with. Objects referenced by the
+// defaults (e.g. Scope and Audit) should be replaced rather than modified.
+func (db *DB) Configure(fn func(defaults *Defaults)) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	defaults := db.Defaults.clone()
+	fn(&defaults)
+	db.Defaults = defaults
+}
+
+// Configure modifies the Tx's defaults with the provided function, without
+// affecting the DB the transaction was started from (see DB.Configure)
+func (tx *Tx) Configure(fn func(defaults *Defaults)) {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+
+	defaults := tx.Defaults.clone()
+	fn(&defaults)
+	tx.Defaults = defaults
+}
+
+// defaults returns a snapshot of the DB's defaults
+func (db *DB) defaults() Defaults {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	return db.Defaults
+}
+
+// defaults returns a snapshot of the Tx's defaults
+func (tx *Tx) defaults() Defaults {
+	tx.mu.RLock()
+	defer tx.mu.RUnlock()
+
+	return tx.Defaults
+}
@@ -0,0 +1,205 @@
+package sqlz
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestDefaults(t *testing.T) {
+	runTests(t, func(dbz *DB) []test {
+		dbz.Defaults = Defaults{
+			MaxLimit:  100,
+			LockWait:  LockSkipLocked,
+			Returning: []string{"*"},
+		}
+
+		return []test{
+			{
+				"select without a limit is capped",
+				dbz.Select("*").From("users"),
+				"SELECT * FROM users LIMIT 100",
+				[]interface{}{},
+			},
+			{
+				"select with a higher limit is capped",
+				dbz.Select("*").From("users").Limit(1000),
+				"SELECT * FROM users LIMIT 100",
+				[]interface{}{},
+			},
+			{
+				"select with a lower limit is unchanged",
+				dbz.Select("*").From("users").Limit(10),
+				"SELECT * FROM users LIMIT 10",
+				[]interface{}{},
+			},
+			{
+				"sub-queries are not capped",
+				dbz.Select("*").From("users").Where(Exists(dbz.Select("1").From("orders"))),
+				"SELECT * FROM users WHERE EXISTS (SELECT 1 FROM orders) LIMIT 100",
+				[]interface{}{},
+			},
+			{
+				"main statements of with statements are capped",
+				dbz.With(dbz.Select("*").From("orders"), "o").Then(dbz.Select("*").From("o")),
+				"WITH o AS (SELECT * FROM orders) SELECT * FROM o LIMIT 100",
+				[]interface{}{},
+			},
+			{
+				"unions are capped as a whole",
+				dbz.Select("*").From("a").Union(dbz.Select("*").From("b")),
+				"(SELECT * FROM a) UNION (SELECT * FROM b) LIMIT 100",
+				[]interface{}{},
+			},
+			{
+				"union branches keep their own limits",
+				dbz.Select("*").From("a").Limit(5).UnionAll(dbz.Select("*").From("b")),
+				"(SELECT * FROM a LIMIT 5) UNION ALL (SELECT * FROM b) LIMIT 100",
+				[]interface{}{},
+			},
+			{
+				"default lock wait policy",
+				dbz.Select("*").From("jobs").Limit(1).Lock(ForUpdate()),
+				"SELECT * FROM jobs LIMIT 1 FOR UPDATE SKIP LOCKED",
+				[]interface{}{},
+			},
+			{
+				"explicit lock wait policy",
+				dbz.Select("*").From("jobs").Limit(1).Lock(ForUpdate().NoWait()),
+				"SELECT * FROM jobs LIMIT 1 FOR UPDATE NOWAIT",
+				[]interface{}{},
+			},
+			{
+				"default returning on insert",
+				dbz.InsertInto("users").Columns("name").Values("Alice"),
+				"INSERT INTO users (name) VALUES (?) RETURNING *",
+				[]interface{}{"Alice"},
+			},
+			{
+				"default returning on delete",
+				dbz.DeleteFrom("users").Where(Eq("id", 1)),
+				"DELETE FROM users WHERE id = ? RETURNING *",
+				[]interface{}{1},
+			},
+		}
+	})
+}
+
+func TestDefaultsInheritance(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	dbz := New(db, "sqlmock")
+	dbz.Defaults.MaxLimit = 50
+
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	err = dbz.Transactional(func(tx *Tx) error {
+		if asSQL, _ := tx.Select("*").From("users").ToSQL(true); asSQL != "SELECT * FROM users LIMIT 50" {
+			t.Errorf("Expected transaction to inherit defaults, got %s", asSQL)
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Errorf("Transaction failed: %s", err)
+	}
+}
+
+func TestRejectUnbounded(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	dbz := New(db, "sqlmock")
+	dbz.Defaults.RejectUnbounded = true
+
+	var ids []int64
+
+	if err := dbz.Select("id").From("users").GetAll(&ids); !errors.Is(err, ErrUnboundedSelect) {
+		t.Errorf("Expected ErrUnboundedSelect, got %v", err)
+	}
+
+	err = dbz.With(dbz.Select("id").From("users").Where(Eq("active", true)), "active").
+		Then(dbz.Select("id").From("active")).
+		GetAll(&ids)
+	if !errors.Is(err, ErrUnboundedSelect) {
+		t.Errorf("Expected ErrUnboundedSelect for the main statement of a WITH statement, got %v", err)
+	}
+
+	mock.ExpectQuery("SELECT id FROM users LIMIT 10").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(`SELECT id FROM users WHERE active = \?`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery("SELECT id FROM users").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	if err := dbz.Select("id").From("users").Limit(10).GetAll(&ids); err != nil {
+		t.Errorf("Limited select failed: %s", err)
+	}
+
+	if err := dbz.Select("id").From("users").Where(Eq("active", true)).GetAll(&ids); err != nil {
+		t.Errorf("Conditioned select failed: %s", err)
+	}
+
+	if err := dbz.Select("id").From("users").Unbounded().GetAll(&ids); err != nil {
+		t.Errorf("Explicitly unbounded select failed: %s", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %s", err)
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	dbz := New(nil, "postgres")
+	dbz.Defaults.Returning = []string{"id"}
+
+	before := dbz.Update("users").Set("name", "Bob").Where(Eq("id", 1))
+
+	dbz.Configure(func(defaults *Defaults) {
+		defaults.Returning[0] = "*"
+		defaults.MaxLimit = 10
+	})
+
+	after := dbz.Update("users").Set("name", "Bob").Where(Eq("id", 1))
+
+	// statements keep the defaults they were created with, and modifying
+	// the defaults in place does not affect them
+	if asSQL, _ := before.ToSQL(false); asSQL != "UPDATE users SET name = ? WHERE id = ? RETURNING id" {
+		t.Errorf("Unexpected SQL for statement created before Configure: %s", asSQL)
+	}
+
+	if asSQL, _ := after.ToSQL(false); asSQL != "UPDATE users SET name = ? WHERE id = ? RETURNING *" {
+		t.Errorf("Unexpected SQL for statement created after Configure: %s", asSQL)
+	}
+
+	// reconfiguring the DB while statements are created concurrently must
+	// not race (run with -race)
+	var wg sync.WaitGroup
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 100; j++ {
+				dbz.Select("*").From("users").ToSQL(true)
+				dbz.InsertInto("users").Columns("name").Values("Alice").ToSQL(true)
+			}
+		}()
+	}
+
+	for j := 0; j < 100; j++ {
+		dbz.Configure(func(defaults *Defaults) {
+			defaults.MaxLimit = int64(j)
+			defaults.Returning = append(defaults.Returning, "name")
+		})
+	}
+
+	wg.Wait()
+}
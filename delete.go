@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 )
@@ -11,19 +12,39 @@ import (
 // DeleteStmt represents a DELETE statement
 type DeleteStmt struct {
 	*Statement
-	Table       string
-	Conditions  []WhereCondition
-	UsingTables []string
-	Return      []string
-	execer      Ext
+	Table        string
+	Conditions   []WhereCondition
+	UsingTables  []string
+	Joins        []JoinClause
+	TargetTables []string
+	Return       []string
+	CurrentOf    string
+	execer       Ext
+	timeout      time.Duration
+	listeners    []WriteListener
+	guards       []Guard
+	scope        *Scope
+	filters      Filters
+	emptyIn      EmptyInPolicy
+	largeIn      LargeIn
+	returnInto   interface{}
 }
 
 // DeleteFrom creates a new DeleteStmt object for the
 // provided table
 func (db *DB) DeleteFrom(table string) *DeleteStmt {
+	defaults := db.defaults()
+
 	return &DeleteStmt{
 		Table:     table,
-		execer:    db.DB,
+		Return:    defaults.returning(),
+		execer:    db.ext(),
+		listeners: defaults.WriteListeners,
+		guards:    defaults.Guards,
+		scope:     defaults.Scope,
+		filters:   defaults.Filters,
+		emptyIn:   defaults.EmptyIn,
+		largeIn:   defaults.LargeIn,
 		Statement: &Statement{db.ErrHandlers},
 	}
 }
@@ -31,19 +52,82 @@ func (db *DB) DeleteFrom(table string) *DeleteStmt {
 // DeleteFrom creates a new DeleteStmt object for the
 // provided table
 func (tx *Tx) DeleteFrom(table string) *DeleteStmt {
+	defaults := tx.defaults()
+
 	return &DeleteStmt{
 		Table:     table,
-		execer:    tx.Tx,
+		Return:    defaults.returning(),
+		execer:    tx.ext(),
+		listeners: defaults.WriteListeners,
+		guards:    defaults.Guards,
+		scope:     defaults.Scope,
+		filters:   defaults.Filters,
+		emptyIn:   defaults.EmptyIn,
+		largeIn:   defaults.LargeIn,
 		Statement: &Statement{tx.ErrHandlers},
 	}
 }
 
+// WithExecer sets the execer the statement is executed with, overriding the DB
+// or Tx it was created from. This allows a statement built once to be
+// executed against a different transaction or database (e.g. a replica).
+// Both *DB and *Tx objects can be provided.
+func (stmt *DeleteStmt) WithExecer(e Ext) *DeleteStmt {
+	stmt.execer = e
+	return stmt
+}
+
+// WithTimeout sets a timeout for executing the statement. Every execution
+// of the statement derives a context with this timeout (from the provided
+// context, if any), so the query is cancelled if it runs for too long. To
+// also enforce the timeout on the server inside a transaction, see
+// Tx.SetTimeout.
+func (stmt *DeleteStmt) WithTimeout(d time.Duration) *DeleteStmt {
+	stmt.timeout = d
+	return stmt
+}
+
 // Using adds a USING clause for joining in a delete statement
 func (stmt *DeleteStmt) Using(tables ...string) *DeleteStmt {
 	stmt.UsingTables = append(stmt.UsingTables, tables...)
 	return stmt
 }
 
+// Join adds a JOIN clause of the supplied type on the supplied table, with
+// the provided conditions, making the statement a MySQL-style multiple-table
+// DELETE statement, i.e. DELETE t1 FROM t1 JOIN t2 ON ... (PostgreSQL uses
+// USING instead). Unless Targets is used, rows are only deleted from the
+// main table of the statement.
+func (stmt *DeleteStmt) Join(joinType JoinType, table string, conds ...WhereCondition) *DeleteStmt {
+	stmt.Joins = append(stmt.Joins, JoinClause{
+		Type:       joinType,
+		Table:      table,
+		Conditions: append([]WhereCondition{}, conds...),
+	})
+
+	return stmt
+}
+
+// InnerJoin is a wrapper of Join for creating an INNER JOIN on a table
+// with the provided conditions
+func (stmt *DeleteStmt) InnerJoin(table string, conds ...WhereCondition) *DeleteStmt {
+	return stmt.Join(InnerJoin, table, conds...)
+}
+
+// LeftJoin is a wrapper of Join for creating a LEFT JOIN on a table with
+// the provided conditions
+func (stmt *DeleteStmt) LeftJoin(table string, conds ...WhereCondition) *DeleteStmt {
+	return stmt.Join(LeftJoin, table, conds...)
+}
+
+// Targets sets the tables (or aliases) rows are deleted from in a
+// MySQL-style multiple-table DELETE statement, e.g. Targets("t1", "t2")
+// generates DELETE t1, t2 FROM t1 JOIN t2 ON ...
+func (stmt *DeleteStmt) Targets(tables ...string) *DeleteStmt {
+	stmt.TargetTables = append(stmt.TargetTables, tables...)
+	return stmt
+}
+
 // Where creates one or more WHERE conditions for the DELETE statement.
 // If multiple conditions are passed, they are considered AND conditions.
 func (stmt *DeleteStmt) Where(conds ...WhereCondition) *DeleteStmt {
@@ -51,6 +135,14 @@ func (stmt *DeleteStmt) Where(conds ...WhereCondition) *DeleteStmt {
 	return stmt
 }
 
+// WhereCurrentOf makes the statement delete the row the provided cursor
+// is currently positioned on (i.e. WHERE CURRENT OF cursor). It cannot be
+// combined with other WHERE conditions.
+func (stmt *DeleteStmt) WhereCurrentOf(cursor string) *DeleteStmt {
+	stmt.CurrentOf = cursor
+	return stmt
+}
+
 // Returning sets a RETURNING clause to receive values back from the
 // database once executing the DELETE statement. Note that GetRow or
 // GetAll must be used to execute the query rather than Exec to get
@@ -60,18 +152,58 @@ func (stmt *DeleteStmt) Returning(cols ...string) *DeleteStmt {
 	return stmt
 }
 
+// ReturningStruct sets a RETURNING clause with the columns the provided
+// struct maps to (see ColumnsOf), replacing any columns set previously,
+// and makes Exec load the returned values into it. A pointer to a slice
+// of structs can be provided if the DELETE statement affects multiple rows.
+func (stmt *DeleteStmt) ReturningStruct(into interface{}) *DeleteStmt {
+	stmt.Return = ColumnsOf(into, "")
+	stmt.returnInto = into
+
+	return stmt
+}
+
+// conditionEnv returns the environment the statement's conditions are
+// rendered in
+func (stmt *DeleteStmt) conditionEnv() conditionEnv {
+	return conditionEnv{dialectOf(stmt.execer), stmt.filters, stmt.emptyIn, stmt.largeIn}
+}
+
 // ToSQL generates the DELETE statement's SQL and returns a list of
 // bindings. It is used internally by Exec, but is exported if you
 // wish to use it directly.
 func (stmt *DeleteStmt) ToSQL(rebind bool) (asSQL string, bindings []interface{}) {
 	var clauses = []string{"DELETE FROM " + stmt.Table}
 
+	env := stmt.conditionEnv()
+
+	if len(stmt.Joins) > 0 || len(stmt.TargetTables) > 0 {
+		targets := stmt.TargetTables
+		if len(targets) == 0 {
+			// delete from the main table, referenced by its alias if
+			// it has one
+			if fields := strings.Fields(stmt.Table); len(fields) > 0 {
+				targets = fields[len(fields)-1:]
+			}
+		}
+
+		clauses[0] = "DELETE " + strings.Join(targets, ", ") + " FROM " + stmt.Table
+	}
+
 	if len(stmt.UsingTables) > 0 {
 		clauses = append(clauses, "USING "+strings.Join(stmt.UsingTables, ", "))
 	}
 
-	if len(stmt.Conditions) > 0 {
-		whereClause, whereBindings := parseConditions(stmt.Conditions)
+	for _, join := range stmt.Joins {
+		joinSQL, joinBindings := join.toSQL(env)
+		clauses = append(clauses, joinSQL)
+		bindings = append(bindings, joinBindings...)
+	}
+
+	if stmt.CurrentOf != "" {
+		clauses = append(clauses, "WHERE CURRENT OF "+stmt.CurrentOf)
+	} else if len(stmt.Conditions) > 0 {
+		whereClause, whereBindings := parseConditionsFor(env, stmt.Conditions)
 		bindings = append(bindings, whereBindings...)
 		clauses = append(clauses, "WHERE "+whereClause)
 	}
@@ -83,11 +215,7 @@ func (stmt *DeleteStmt) ToSQL(rebind bool) (asSQL string, bindings []interface{}
 	asSQL = strings.Join(clauses, " ")
 
 	if rebind {
-		if db, ok := stmt.execer.(*sqlx.DB); ok {
-			asSQL = db.Rebind(asSQL)
-		} else if tx, ok := stmt.execer.(*sqlx.Tx); ok {
-			asSQL = tx.Rebind(asSQL)
-		}
+		asSQL = rebindFor(stmt.execer, asSQL)
 	}
 
 	return asSQL, bindings
@@ -96,12 +224,7 @@ func (stmt *DeleteStmt) ToSQL(rebind bool) (asSQL string, bindings []interface{}
 // Exec executes the DELETE statement, returning the standard
 // sql.Result struct and an error if the query failed.
 func (stmt *DeleteStmt) Exec() (res sql.Result, err error) {
-	asSQL, bindings := stmt.ToSQL(true)
-
-	res, err = stmt.execer.Exec(asSQL, bindings...)
-	stmt.HandleError(err)
-
-	return res, err
+	return stmt.ExecContext(context.Background())
 }
 
 // ExecContext executes the DELETE statement, returning the standard
@@ -110,10 +233,22 @@ func (stmt *DeleteStmt) ExecContext(ctx context.Context) (
 	res sql.Result,
 	err error,
 ) {
-	asSQL, bindings := stmt.ToSQL(true)
+	if stmt.returnInto != nil {
+		return loadReturning(ctx, stmt, stmt.returnInto)
+	}
+
+	ctx, cancel := contextWithTimeout(ctx, stmt.timeout)
+	defer cancel()
+
+	if err := stmt.guard(ctx); err != nil {
+		return nil, err
+	}
+
+	asSQL, bindings := stmt.scoped(ctx).ToSQL(true)
 
 	res, err = stmt.execer.ExecContext(ctx, asSQL, bindings...)
 	stmt.HandleError(err)
+	notifyWrite(ctx, stmt.listeners, stmt, stmt.writtenTables(), err)
 
 	return res, err
 }
@@ -124,12 +259,7 @@ func (stmt *DeleteStmt) ExecContext(ctx context.Context) (
 // only one column is returned, or a struct if multiple columns
 // are returned)
 func (stmt *DeleteStmt) GetRow(into interface{}) error {
-	asSQL, bindings := stmt.ToSQL(true)
-
-	err := sqlx.Get(stmt.execer, into, asSQL, bindings...)
-	stmt.HandleError(err)
-
-	return err
+	return stmt.GetRowContext(context.Background(), into)
 }
 
 // GetRowContext executes a DELETE statement with a RETURNING clause
@@ -141,10 +271,18 @@ func (stmt *DeleteStmt) GetRowContext(
 	ctx context.Context,
 	into interface{},
 ) error {
-	asSQL, bindings := stmt.ToSQL(true)
+	ctx, cancel := contextWithTimeout(ctx, stmt.timeout)
+	defer cancel()
+
+	if err := stmt.guard(ctx); err != nil {
+		return err
+	}
+
+	asSQL, bindings := stmt.scoped(ctx).ToSQL(true)
 
 	err := sqlx.GetContext(ctx, stmt.execer, into, asSQL, bindings...)
 	stmt.HandleError(err)
+	notifyWrite(ctx, stmt.listeners, stmt, stmt.writtenTables(), err)
 
 	return err
 }
@@ -153,22 +291,68 @@ func (stmt *DeleteStmt) GetRowContext(
 // expected to return multiple rows, and loads the result into
 // the provided slice variable
 func (stmt *DeleteStmt) GetAll(into interface{}) error {
-	asSQL, bindings := stmt.ToSQL(true)
-
-	err := sqlx.Select(stmt.execer, into, asSQL, bindings...)
-	stmt.HandleError(err)
-
-	return err
+	return stmt.GetAllContext(context.Background(), into)
 }
 
 // GetAllContext executes a DELETE statement with a RETURNING clause
 // expected to return multiple rows, and loads the result into
 // the provided slice variable
 func (stmt *DeleteStmt) GetAllContext(ctx context.Context, into interface{}) error {
-	asSQL, bindings := stmt.ToSQL(true)
+	ctx, cancel := contextWithTimeout(ctx, stmt.timeout)
+	defer cancel()
+
+	if err := stmt.guard(ctx); err != nil {
+		return err
+	}
+
+	asSQL, bindings := stmt.scoped(ctx).ToSQL(true)
 
 	err := sqlx.SelectContext(ctx, stmt.execer, into, asSQL, bindings...)
 	stmt.HandleError(err)
+	notifyWrite(ctx, stmt.listeners, stmt, stmt.writtenTables(), err)
 
 	return err
 }
+
+// Rows executes a DELETE statement with a RETURNING clause and returns a
+// Rows object to use for iteration, so that large sets of returned rows
+// can be streamed rather than loaded into a slice. It is the caller's
+// responsibility to close the cursor with Close(), which also releases the
+// statement's timeout (see WithTimeout).
+func (stmt *DeleteStmt) Rows() (rows *Rows, err error) {
+	return stmt.RowsContext(context.Background())
+}
+
+// RowsContext is like Rows, but uses the provided context
+func (stmt *DeleteStmt) RowsContext(ctx context.Context) (rows *Rows, err error) {
+	if err := stmt.guard(ctx); err != nil {
+		return nil, err
+	}
+
+	asSQL, bindings := stmt.scoped(ctx).ToSQL(true)
+
+	rows, err = queryRows(ctx, stmt.timeout, stmt.execer, asSQL, bindings)
+	stmt.HandleError(err)
+	notifyWrite(ctx, stmt.listeners, stmt, stmt.writtenTables(), err)
+
+	return rows, err
+}
+
+// GetAllAsRows executes a DELETE statement with a RETURNING clause and
+// returns an sqlx.Rows object to use for iteration. It is the caller's
+// responsibility to close the cursor with Close(). The context derived for
+// the statement's timeout (see WithTimeout) is only released once the
+// timeout expires; use Rows to release it when the cursor is closed.
+func (stmt *DeleteStmt) GetAllAsRows() (rows *sqlx.Rows, err error) {
+	return stmt.GetAllAsRowsContext(context.Background())
+}
+
+// GetAllAsRowsContext is like GetAllAsRows, but uses the provided context
+func (stmt *DeleteStmt) GetAllAsRowsContext(ctx context.Context) (rows *sqlx.Rows, err error) {
+	cursor, err := stmt.RowsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return cursor.Rows, nil
+}
@@ -1,7 +1,11 @@
 package sqlz
 
 import (
+	"context"
 	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
 )
 
 func TestDelete(t *testing.T) {
@@ -21,6 +25,13 @@ func TestDelete(t *testing.T) {
 				[]interface{}{1, "some-name", 3},
 			},
 
+			{
+				"positioned delete",
+				dbz.DeleteFrom("queue").WhereCurrentOf("queue_cursor"),
+				"DELETE FROM queue WHERE CURRENT OF queue_cursor",
+				[]interface{}{},
+			},
+
 			{
 				"delete with returning clause",
 				dbz.DeleteFrom("table").Where(Eq("id", 2)).Returning("name"),
@@ -34,6 +45,131 @@ func TestDelete(t *testing.T) {
 				"DELETE FROM table USING other, another WHERE other.fk_id = table.id AND another.fk_id = table.id",
 				[]interface{}{},
 			},
+
+			{
+				"multiple-table delete with join",
+				dbz.DeleteFrom("orders o").InnerJoin("customers c", Eq("c.id", Indirect("o.customer_id"))).Where(Eq("c.status", "banned")),
+				"DELETE o FROM orders o INNER JOIN customers c ON c.id = o.customer_id WHERE c.status = ?",
+				[]interface{}{"banned"},
+			},
+
+			{
+				"multiple-table delete with targets",
+				dbz.DeleteFrom("orders").
+					LeftJoin("order_items", Eq("order_items.order_id", Indirect("orders.id")), Gt("order_items.qty", 0)).
+					Targets("orders", "order_items").
+					Where(Eq("orders.id", 3)),
+				"DELETE orders, order_items FROM orders LEFT JOIN order_items ON order_items.order_id = orders.id AND order_items.qty > ? WHERE orders.id = ?",
+				[]interface{}{0, 3},
+			},
 		}
 	})
 }
+
+func TestDeleteContext(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	dbz := New(db, "postgres")
+
+	mock.ExpectExec(`DELETE FROM sessions WHERE expired = \$1`).
+		WithArgs(true).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectQuery(`WITH old AS \(SELECT id FROM sessions WHERE expired = \$1\) DELETE FROM tokens USING old WHERE session_id = old.id RETURNING id`).
+		WithArgs(true).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+
+	ctx := context.Background()
+
+	res, err := dbz.DeleteFrom("sessions").Where(Eq("expired", true)).ExecContext(ctx)
+	if err != nil {
+		t.Fatalf("DeleteStmt.ExecContext failed: %s", err)
+	}
+
+	if affected, _ := res.RowsAffected(); affected != 3 {
+		t.Errorf("Expected 3 affected rows, got %d", affected)
+	}
+
+	var ids []int64
+
+	err = dbz.With(dbz.Select("id").From("sessions").Where(Eq("expired", true)), "old").
+		Then(dbz.DeleteFrom("tokens").Using("old").Where(Eq("session_id", Indirect("old.id"))).Returning("id")).
+		GetAllContext(ctx, &ids)
+	if err != nil {
+		t.Fatalf("WithStmt.GetAllContext failed: %s", err)
+	}
+
+	if len(ids) != 2 {
+		t.Errorf("Expected 2 deleted tokens, got %d", len(ids))
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	if _, err := dbz.DeleteFrom("sessions").ExecContext(cancelled); err == nil {
+		t.Error("Expected ExecContext to fail with a cancelled context")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %s", err)
+	}
+}
+
+// TestReturningRows verifies that rows returned by INSERT, UPDATE and
+// DELETE statements can be iterated over
+func TestReturningRows(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	dbz := New(db, "postgres")
+
+	mock.ExpectQuery(`INSERT INTO tags \(name\) VALUES \(\$1\), \(\$2\) RETURNING id`).
+		WithArgs("a", "b").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+	mock.ExpectQuery(`UPDATE tags SET hidden = \$1 WHERE id IN \(\$2, \$3\) RETURNING id`).
+		WithArgs(true, 1, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+	mock.ExpectQuery(`DELETE FROM tags WHERE hidden = \$1 RETURNING id`).
+		WithArgs(true).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+
+	stmts := []interface {
+		GetAllAsRows() (*sqlx.Rows, error)
+	}{
+		dbz.InsertInto("tags").Columns("name").ValueMultiple([][]interface{}{{"a"}, {"b"}}).Returning("id"),
+		dbz.Update("tags").Set("hidden", true).Where(In("id", 1, 2)).Returning("id"),
+		dbz.DeleteFrom("tags").Where(Eq("hidden", true)).Returning("id"),
+	}
+
+	for _, stmt := range stmts {
+		rows, err := stmt.GetAllAsRows()
+		if err != nil {
+			t.Fatalf("GetAllAsRows failed: %s", err)
+		}
+
+		var ids []int64
+
+		for rows.Next() {
+			var id int64
+			if err := rows.Scan(&id); err != nil {
+				t.Fatalf("Failed scanning row: %s", err)
+			}
+
+			ids = append(ids, id)
+		}
+
+		rows.Close()
+
+		if len(ids) != 2 {
+			t.Errorf("Expected 2 returned rows, got %d", len(ids))
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %s", err)
+	}
+}
@@ -0,0 +1,357 @@
+package sqlz
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Dialect is the SQL dialect of a database system, controlling the parts of
+// the generated SQL whose syntax differs between databases: placeholders,
+// identifier quoting (see QuoteIdent), boolean literals and result limits.
+// This file gathers all such differences, so that portability issues are
+// handled in one place.
+type Dialect uint8
+
+const (
+	// DialectGeneric is standard SQL as supported by PostgreSQL, MySQL and
+	// SQLite. When it is configured (the default), the dialect is detected
+	// from the name of the database driver in use, and placeholders are
+	// rebound by the underlying sqlx object.
+	DialectGeneric Dialect = iota
+	// DialectPostgres is PostgreSQL's dialect ($1 placeholders)
+	DialectPostgres
+	// DialectMySQL is MySQL's and MariaDB's dialect (? placeholders,
+	// `backtick` quoting)
+	DialectMySQL
+	// DialectSQLite is SQLite's dialect (? placeholders)
+	DialectSQLite
+	// DialectMSSQL is Microsoft SQL Server's dialect (@p1 placeholders,
+	// [bracket] quoting, OFFSET ... FETCH instead of LIMIT, no boolean
+	// literals)
+	DialectMSSQL
+	// DialectOracle is Oracle's dialect (:arg1 placeholders, ROWNUM instead
+	// of LIMIT, no boolean literals)
+	DialectOracle
+)
+
+// String returns the name of the dialect
+func (d Dialect) String() string {
+	switch d {
+	case DialectPostgres:
+		return "postgres"
+	case DialectMySQL:
+		return "mysql"
+	case DialectSQLite:
+		return "sqlite"
+	case DialectMSSQL:
+		return "mssql"
+	case DialectOracle:
+		return "oracle"
+	default:
+		return "generic"
+	}
+}
+
+// DialectOf returns the dialect of the database driver with the provided
+// name, or DialectGeneric if the driver is unknown
+func DialectOf(driver string) Dialect {
+	switch driver {
+	case "postgres", "pgx", "pq-timeouts", "cloudsqlpostgres":
+		return DialectPostgres
+	case "mysql":
+		return DialectMySQL
+	case "sqlite3", "sqlite":
+		return DialectSQLite
+	case "sqlserver", "mssql", "azuresql":
+		return DialectMSSQL
+	case "oracle", "godror", "oci8", "ora", "goracle":
+		return DialectOracle
+	default:
+		return DialectGeneric
+	}
+}
+
+// dialectOf returns the dialect used by the provided queryer or execer:
+// the one configured for the DB or Tx it belongs to, or the one detected
+// from its driver
+func dialectOf(q interface{}) Dialect {
+	if dialected, ok := q.(interface{ Dialect() Dialect }); ok {
+		return dialected.Dialect()
+	}
+
+	return DialectOf(driverName(q))
+}
+
+// Rebind replaces the ? placeholders in the provided query with the
+// placeholders used by the dialect
+func (d Dialect) Rebind(query string) string {
+	switch d {
+	case DialectPostgres:
+		return sqlx.Rebind(sqlx.DOLLAR, query)
+	case DialectMSSQL:
+		return sqlx.Rebind(sqlx.AT, query)
+	case DialectOracle:
+		return sqlx.Rebind(sqlx.NAMED, query)
+	default:
+		return query
+	}
+}
+
+// QuoteIdent quotes the provided identifier, which may be qualified (e.g.
+// schema.table or table.column), so that it can be used even if it is a
+// reserved word or contains special characters: "name" in standard SQL,
+// `name` in MySQL and [name] in SQL Server. Quote characters inside the
+// identifier are escaped, and * is never quoted. Statements do not quote
+// the tables and columns provided to them, as these may include aliases
+// and expressions, so QuoteIdent should be used when providing them, e.g.
+// db.Select("*").From(dialect.QuoteIdent("order")).
+func (d Dialect) QuoteIdent(name string) string {
+	open, closing := `"`, `"`
+
+	switch d {
+	case DialectMySQL:
+		open, closing = "`", "`"
+	case DialectMSSQL:
+		open, closing = "[", "]"
+	}
+
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		if part != "*" {
+			parts[i] = open + strings.ReplaceAll(part, closing, closing+closing) + closing
+		}
+	}
+
+	return strings.Join(parts, ".")
+}
+
+// boolPredicate returns a predicate that is always true or always false.
+// Oracle and SQL Server have no boolean literals that can be used as
+// conditions, so 1 = 1 and 1 = 0 are used instead.
+func (d Dialect) boolPredicate(value bool) string {
+	if d == DialectOracle || d == DialectMSSQL {
+		if value {
+			return "1 = 1"
+		}
+
+		return "1 = 0"
+	}
+
+	if value {
+		return "TRUE"
+	}
+
+	return "FALSE"
+}
+
+// hasRowValues returns true if the dialect supports comparing row values,
+// e.g. (a, b) > (?, ?)
+func (d Dialect) hasRowValues() bool {
+	return d != DialectOracle && d != DialectMSSQL
+}
+
+// fetchClauses returns the OFFSET ... FETCH clauses SQL Server uses to
+// limit the results of a SELECT statement to the provided number of rows
+// (unless zero), skipping the provided number of rows. These clauses
+// require an ORDER BY clause, so one is added if the statement is not
+// ordered.
+func fetchClauses(limit, offset int64, ordered bool) (clauses []string) {
+	if !ordered {
+		clauses = append(clauses, "ORDER BY (SELECT NULL)")
+	}
+
+	clauses = append(clauses, "OFFSET "+strconv.FormatInt(offset, 10)+" ROWS")
+	if limit > 0 {
+		clauses = append(clauses, "FETCH NEXT "+strconv.FormatInt(limit, 10)+" ROWS ONLY")
+	}
+
+	return clauses
+}
+
+// Dialect returns the SQL dialect of statements created from the DB: the
+// one configured in its defaults, or the one detected from its driver
+func (db *DB) Dialect() Dialect {
+	return dialectOf(db.ext())
+}
+
+// Dialect returns the SQL dialect of statements created from the Tx
+func (tx *Tx) Dialect() Dialect {
+	return dialectOf(tx.ext())
+}
+
+// withDialect wraps the provided execer so that it uses the provided
+// dialect rather than the one detected from its driver. If the dialect is
+// DialectGeneric, the execer is returned as is.
+func withDialect(execer Ext, dialect Dialect) Ext {
+	if dialect == DialectGeneric {
+		return execer
+	}
+
+	return &dialectExt{Ext: execer, dialect: dialect}
+}
+
+// dialectExt is an execer using an explicitly configured dialect
+type dialectExt struct {
+	Ext
+	dialect Dialect
+}
+
+// Dialect returns the execer's dialect
+func (e *dialectExt) Dialect() Dialect {
+	return e.dialect
+}
+
+// DriverName returns the driver name of the wrapped execer
+func (e *dialectExt) DriverName() string {
+	return driverName(e.Ext)
+}
+
+// Rebind rebinds placeholders for the execer's dialect
+func (e *dialectExt) Rebind(query string) string {
+	return e.dialect.Rebind(query)
+}
+
+// limitWithRownum wraps the provided query so that it returns at most
+// limit rows (unless limit is zero), skipping the first offset rows. When
+// an offset is used, the results include an additional sqlz_rn column with
+// the row number.
+func limitWithRownum(query string, limit, offset int64) string {
+	if offset == 0 {
+		return "SELECT * FROM (" + query + ") WHERE ROWNUM <= " + strconv.FormatInt(limit, 10)
+	}
+
+	inner := "SELECT sqlz_q.*, ROWNUM sqlz_rn FROM (" + query + ") sqlz_q"
+	if limit > 0 {
+		inner += " WHERE ROWNUM <= " + strconv.FormatInt(offset+limit, 10)
+	}
+
+	return "SELECT * FROM (" + inner + ") WHERE sqlz_rn > " + strconv.FormatInt(offset, 10)
+}
+
+// BoolCondition is a condition that is always true or always false,
+// rendered with the syntax supported by the database in use: TRUE and
+// FALSE, or 1 = 1 and 1 = 0 where boolean literals are not supported.
+type BoolCondition struct {
+	Value   bool
+	dialect Dialect
+}
+
+// True creates a condition that is always true
+func True() BoolCondition {
+	return BoolCondition{Value: true}
+}
+
+// False creates a condition that is always false
+func False() BoolCondition {
+	return BoolCondition{Value: false}
+}
+
+// Parse implements the WhereCondition interface, generating SQL from
+// the condition
+func (cond BoolCondition) Parse() (asSQL string, bindings []interface{}) {
+	return cond.dialect.boolPredicate(cond.Value), nil
+}
+
+func (cond BoolCondition) conditionForDialect(dialect Dialect) WhereCondition {
+	cond.dialect = dialect
+	return cond
+}
+
+// dialectAwareCondition is implemented by conditions whose generated SQL
+// depends on the dialect of the database in use
+type dialectAwareCondition interface {
+	conditionForDialect(dialect Dialect) WhereCondition
+}
+
+// conditionEnv is the environment conditions are rendered in: the dialect
+// of the database in use, the filters defined for the statement, and how
+// IN conditions with no values or with many values are handled
+type conditionEnv struct {
+	dialect Dialect
+	filters Filters
+	emptyIn EmptyInPolicy
+	largeIn LargeIn
+}
+
+// parseConditionsFor is like parseConditions, but adapts dialect-aware
+// conditions to the environment's dialect, resolves filters, and applies
+// the empty and large IN policies first
+func parseConditionsFor(env conditionEnv, conds []WhereCondition) (asSQL string, bindings []interface{}) {
+	return parseConditions(conditionsFor(env, conds))
+}
+
+// conditionsFor adapts dialect-aware conditions, including nested ones, to
+// the environment's dialect, and replaces filters with the conditions they
+// resolve to. The provided slice is only copied if it contains such
+// conditions.
+func conditionsFor(env conditionEnv, conds []WhereCondition) []WhereCondition {
+	adapted, _ := adaptConditions(env, conds)
+	return adapted
+}
+
+func adaptConditions(env conditionEnv, conds []WhereCondition) ([]WhereCondition, bool) {
+	var adapted []WhereCondition
+
+	for i, cond := range conds {
+		newCond, changed := adaptCondition(env, cond)
+		if !changed {
+			if adapted != nil {
+				adapted[i] = cond
+			}
+
+			continue
+		}
+
+		if adapted == nil {
+			adapted = make([]WhereCondition, len(conds))
+			copy(adapted, conds[:i])
+		}
+
+		adapted[i] = newCond
+	}
+
+	if adapted == nil {
+		return conds, false
+	}
+
+	return adapted, true
+}
+
+func adaptCondition(env conditionEnv, cond WhereCondition) (WhereCondition, bool) {
+	switch c := cond.(type) {
+	case dialectAwareCondition:
+		return c.conditionForDialect(env.dialect), true
+	case InCondition:
+		if len(c.Right) == 0 && env.emptyIn == EmptyInConstant {
+			return BoolCondition{c.NotIn, env.dialect}, true
+		}
+
+		if rewritten, ok := env.largeIn.rewrite(env.dialect, c); ok {
+			return rewritten, true
+		}
+	case FilterCondition:
+		if resolved, ok := env.filters.resolve(c); ok {
+			adapted, _ := adaptCondition(env, resolved)
+			return adapted, true
+		}
+	case AndOrCondition:
+		if nested, changed := adaptConditions(env, c.Conditions); changed {
+			c.Conditions = nested
+			return c, true
+		}
+	case PreCondition:
+		if nested, changed := adaptCondition(env, c.Condition); changed {
+			c.Condition = nested
+			return c, true
+		}
+	case GroupCondition:
+		if nested, changed := adaptCondition(env, c.Condition); changed {
+			c.Condition = nested
+			return c, true
+		}
+	}
+
+	return cond, false
+}
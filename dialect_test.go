@@ -0,0 +1,331 @@
+package sqlz
+
+import (
+	"testing"
+
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestBoolConditions(t *testing.T) {
+	runTestsWithDriver(t, "mysql", func(dbz *DB) []test {
+		return []test{
+			{
+				"always false condition",
+				dbz.Select("*").From("users").Where(False()),
+				"SELECT * FROM users WHERE FALSE",
+				nil,
+			},
+			{
+				"nested always true condition",
+				dbz.Select("*").From("users").Where(Eq("a", 1), Or(Not(True()), Group(False()))),
+				"SELECT * FROM users WHERE a = ? AND (NOT(TRUE) OR (FALSE))",
+				[]interface{}{1},
+			},
+		}
+	})
+
+	runTestsWithDriver(t, "sqlserver", func(dbz *DB) []test {
+		return []test{
+			{
+				"always false condition",
+				dbz.Select("*").From("users").Where(Eq("a", 1), False()),
+				"SELECT * FROM users WHERE a = @p1 AND 1 = 0",
+				[]interface{}{1},
+			},
+			{
+				"always true condition in a join",
+				dbz.Select("*").From("users u").LeftJoin("roles r", True()),
+				"SELECT * FROM users u LEFT JOIN roles r ON 1 = 1",
+				nil,
+			},
+			{
+				"nested conditions in DELETE",
+				dbz.DeleteFrom("users").Where(Or(Eq("a", 1), Not(False()))),
+				"DELETE FROM users WHERE a = @p1 OR NOT(1 = 0)",
+				[]interface{}{1},
+			},
+			{
+				"always true condition in UPDATE",
+				dbz.Update("users").Set("a", 2).Where(True()),
+				"UPDATE users SET a = @p1 WHERE 1 = 1",
+				[]interface{}{2},
+			},
+		}
+	})
+}
+
+func TestOracleLimits(t *testing.T) {
+	runTestsWithDriver(t, "oci8", func(dbz *DB) []test {
+		return []test{
+			{
+				"limit",
+				dbz.Select("id").From("users").Where(Eq("a", 1)).OrderBy(Asc("id")).Limit(10),
+				"SELECT * FROM (SELECT id FROM users WHERE a = :arg1 ORDER BY id ASC) WHERE ROWNUM <= 10",
+				[]interface{}{1},
+			},
+			{
+				"limit and offset",
+				dbz.Select("id").From("users").Limit(10).Offset(20),
+				"SELECT * FROM (SELECT sqlz_q.*, ROWNUM sqlz_rn FROM (SELECT id FROM users) sqlz_q WHERE ROWNUM <= 30) WHERE sqlz_rn > 20",
+				nil,
+			},
+			{
+				"offset only",
+				dbz.Select("id").From("users").Offset(20),
+				"SELECT * FROM (SELECT sqlz_q.*, ROWNUM sqlz_rn FROM (SELECT id FROM users) sqlz_q) WHERE sqlz_rn > 20",
+				nil,
+			},
+			{
+				"no limit",
+				dbz.Select("id").From("users").Where(False()),
+				"SELECT id FROM users WHERE 1 = 0",
+				nil,
+			},
+		}
+	})
+}
+
+func TestDialects(t *testing.T) {
+	runTests(t, func(dbz *DB) []test {
+		dbz.Configure(func(defaults *Defaults) {
+			defaults.Dialect = DialectPostgres
+		})
+
+		return []test{
+			{
+				"placeholders of configured dialect",
+				dbz.Select("*").From("users").Where(Eq("a", 1), Gt("b", 2)),
+				"SELECT * FROM users WHERE a = $1 AND b > $2",
+				[]interface{}{1, 2},
+			},
+			{
+				"placeholders in UPDATE",
+				dbz.Update("users").Set("a", 1).Where(Eq("id", 2)),
+				"UPDATE users SET a = $1 WHERE id = $2",
+				[]interface{}{1, 2},
+			},
+		}
+	})
+
+	runTests(t, func(dbz *DB) []test {
+		dbz.Configure(func(defaults *Defaults) {
+			defaults.Dialect = DialectMSSQL
+		})
+
+		return []test{
+			{
+				"limit without ordering",
+				dbz.Select("id").From("users").Where(Eq("a", 1)).Limit(10),
+				"SELECT id FROM users WHERE a = @p1 ORDER BY (SELECT NULL) OFFSET 0 ROWS FETCH NEXT 10 ROWS ONLY",
+				[]interface{}{1},
+			},
+			{
+				"limit and offset",
+				dbz.Select("id").From("users").OrderBy(Asc("id")).Limit(10).Offset(20),
+				"SELECT id FROM users ORDER BY id ASC OFFSET 20 ROWS FETCH NEXT 10 ROWS ONLY",
+				nil,
+			},
+			{
+				"offset only",
+				dbz.Select("id").From("users").OrderBy(Desc("id")).Offset(20),
+				"SELECT id FROM users ORDER BY id DESC OFFSET 20 ROWS",
+				nil,
+			},
+			{
+				"no boolean literals",
+				dbz.Select("id").From("users").Where(True()),
+				"SELECT id FROM users WHERE 1 = 1",
+				nil,
+			},
+		}
+	})
+
+	runTestsWithDriver(t, "mysql", func(dbz *DB) []test {
+		return []test{
+			{
+				"dialect detected from driver",
+				dbz.Select("*").From("users").OrderBy(Random()).Limit(5),
+				"SELECT * FROM users ORDER BY RAND() LIMIT 5",
+				nil,
+			},
+		}
+	})
+}
+
+func TestDialectOf(t *testing.T) {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	dbz := New(db, "pgx")
+	if dialect := dbz.Dialect(); dialect != DialectPostgres {
+		t.Errorf("Expected detected dialect to be postgres, got %s", dialect)
+	}
+
+	dbz.Configure(func(defaults *Defaults) {
+		defaults.Dialect = DialectSQLite
+	})
+
+	if dialect := dbz.Dialect(); dialect != DialectSQLite {
+		t.Errorf("Expected configured dialect to be sqlite, got %s", dialect)
+	}
+
+	if dialect := DialectOf("unknown"); dialect != DialectGeneric {
+		t.Errorf("Expected unknown driver to have generic dialect, got %s", dialect)
+	}
+}
+
+func TestQuoteIdent(t *testing.T) {
+	tests := []struct {
+		dialect  Dialect
+		ident    string
+		expected string
+	}{
+		{DialectGeneric, "users", `"users"`},
+		{DialectPostgres, "users", `"users"`},
+		{DialectPostgres, "public.users", `"public"."users"`},
+		{DialectPostgres, `odd"name`, `"odd""name"`},
+		{DialectSQLite, "u.*", `"u".*`},
+		{DialectSQLite, `main."t"`, `"main"."""t"""`},
+		{DialectMySQL, "order", "`order`"},
+		{DialectMySQL, "shop.order", "`shop`.`order`"},
+		{DialectMySQL, "a`b", "`a``b`"},
+		{DialectMySQL, `a"b`, "`a\"b`"},
+		{DialectMSSQL, "dbo.users", "[dbo].[users]"},
+		{DialectMSSQL, "a]b", "[a]]b]"},
+		{DialectMSSQL, "a[b", "[a[b]"},
+		{DialectOracle, "hr.employees", `"hr"."employees"`},
+	}
+
+	for _, tst := range tests {
+		if quoted := tst.dialect.QuoteIdent(tst.ident); quoted != tst.expected {
+			t.Errorf("Expected %s to be quoted as %s in %s, got %s", tst.ident, tst.expected, tst.dialect, quoted)
+		}
+	}
+}
+
+func TestEmptyIn(t *testing.T) {
+	for _, driver := range []string{"postgres", "sqlserver"} {
+		driver := driver
+
+		runTestsWithDriver(t, driver, func(dbz *DB) []test {
+			dbz.Configure(func(defaults *Defaults) {
+				defaults.EmptyIn = EmptyInConstant
+			})
+
+			always, never, placeholder := "TRUE", "FALSE", "$1"
+			if driver == "sqlserver" {
+				always, never, placeholder = "1 = 1", "1 = 0", "@p1"
+			}
+
+			return []test{
+				{
+					driver + ": empty IN",
+					dbz.Select("*").From("users").Where(In("id")),
+					"SELECT * FROM users WHERE " + never,
+					nil,
+				},
+				{
+					driver + ": empty NOT IN in UPDATE",
+					dbz.Update("users").Set("a", 1).Where(NotIn("id")),
+					"UPDATE users SET a = " + placeholder + " WHERE " + always,
+					[]interface{}{1},
+				},
+				{
+					driver + ": nested empty IN in DELETE",
+					dbz.DeleteFrom("users").Where(Or(In("id"), IsNull("id"))),
+					"DELETE FROM users WHERE " + never + " OR id IS NULL",
+					nil,
+				},
+			}
+		})
+	}
+
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	dbz := New(db, "postgres")
+
+	if err := Validate(dbz.Select("*").From("users").Where(In("id"))); err == nil {
+		t.Error("Expected empty IN to be invalid by default")
+	}
+
+	dbz.Configure(func(defaults *Defaults) {
+		defaults.EmptyIn = EmptyInConstant
+	})
+
+	if err := Validate(dbz.Select("*").From("users").Where(In("id"))); err != nil {
+		t.Errorf("Expected empty IN to be valid with EmptyInConstant, got %s", err)
+	}
+}
+
+func TestLargeIn(t *testing.T) {
+	runTestsWithDriver(t, "postgres", func(dbz *DB) []test {
+		dbz.Configure(func(defaults *Defaults) {
+			defaults.LargeIn = LargeIn{Threshold: 2, Rewrite: LargeInArray}
+		})
+
+		return []test{
+			{
+				"postgres: IN below the threshold",
+				dbz.Select("*").From("users").Where(In("id", 1, 2)),
+				"SELECT * FROM users WHERE id IN ($1, $2)",
+				[]interface{}{1, 2},
+			},
+			{
+				"postgres: IN above the threshold as array",
+				dbz.Select("*").From("users").Where(In("id", 1, 2, 3)),
+				"SELECT * FROM users WHERE id = ANY($1)",
+				[]interface{}{pgArray{1, 2, 3}},
+			},
+			{
+				"postgres: nested NOT IN above the threshold as array in DELETE",
+				dbz.DeleteFrom("users").Where(Or(NotIn("name", "a", "b", "c"), IsNull("name"))),
+				"DELETE FROM users WHERE name <> ALL($1) OR name IS NULL",
+				[]interface{}{pgArray{"a", "b", "c"}},
+			},
+		}
+	})
+
+	runTestsWithDriver(t, "sqlite3", func(dbz *DB) []test {
+		dbz.Configure(func(defaults *Defaults) {
+			defaults.LargeIn = LargeIn{Threshold: 2, Rewrite: LargeInValues}
+		})
+
+		return []test{
+			{
+				"sqlite: IN above the threshold as VALUES list",
+				dbz.Update("users").Set("a", 1).Where(In("id", 1, 2, 3)),
+				"UPDATE users SET a = ? WHERE id IN (VALUES (?), (?), (?))",
+				[]interface{}{1, 1, 2, 3},
+			},
+		}
+	})
+
+	runTestsWithDriver(t, "mysql", func(dbz *DB) []test {
+		dbz.Configure(func(defaults *Defaults) {
+			defaults.LargeIn = LargeIn{Threshold: 2, Rewrite: LargeInArray}
+		})
+
+		return []test{
+			{
+				"mysql: IN above the threshold is not rewritten",
+				dbz.Select("*").From("users").Where(In("id", 1, 2, 3)),
+				"SELECT * FROM users WHERE id IN (?, ?, ?)",
+				[]interface{}{1, 2, 3},
+			},
+		}
+	})
+
+	value, err := pgArray{1, 2.5, "a \"b\"", `c\d`, true, nil}.Value()
+	if err != nil {
+		t.Fatalf("Failed encoding array: %s", err)
+	}
+
+	if expected := `{1,2.5,"a \"b\"","c\\d",t,NULL}`; value != expected {
+		t.Errorf("Expected array to be encoded as %s, got %s", expected, value)
+	}
+}
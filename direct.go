@@ -0,0 +1,116 @@
+package sqlz
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// GetDirect executes a raw SQL query and loads the first resulting row into
+// the provided variable, like sqlx's Get. Question marks must be used for
+// placeholders regardless of the database driver, as they are rebound
+// automatically. The query is checked against the DB's guards first, as a
+// *RawStmt (see Defaults.Guards). Errors are passed to the DB's error
+// handlers.
+func (db *DB) GetDirect(into interface{}, query string, bindings ...interface{}) error {
+	return db.GetDirectContext(context.Background(), into, query, bindings...)
+}
+
+// GetDirectContext is like GetDirect, but uses the provided context
+func (db *DB) GetDirectContext(ctx context.Context, into interface{}, query string, bindings ...interface{}) error {
+	return getDirect(ctx, db.ext(), db.defaults().Guards, &Statement{db.ErrHandlers}, into, query, bindings)
+}
+
+// ExecDirect executes a raw SQL statement, rebinding its placeholders for
+// the database driver. The statement is checked against the DB's guards
+// first, as a *RawStmt (see Defaults.Guards). Errors are passed to the
+// DB's error handlers.
+func (db *DB) ExecDirect(query string, bindings ...interface{}) (sql.Result, error) {
+	return db.ExecDirectContext(context.Background(), query, bindings...)
+}
+
+// ExecDirectContext is like ExecDirect, but uses the provided context
+func (db *DB) ExecDirectContext(ctx context.Context, query string, bindings ...interface{}) (sql.Result, error) {
+	return execDirect(ctx, db.ext(), db.defaults().Guards, &Statement{db.ErrHandlers}, query, bindings)
+}
+
+// GetDirect executes a raw SQL query inside the transaction and loads the
+// first resulting row into the provided variable. See DB.GetDirect for
+// more information.
+func (tx *Tx) GetDirect(into interface{}, query string, bindings ...interface{}) error {
+	return tx.GetDirectContext(context.Background(), into, query, bindings...)
+}
+
+// GetDirectContext is like GetDirect, but uses the provided context
+func (tx *Tx) GetDirectContext(ctx context.Context, into interface{}, query string, bindings ...interface{}) error {
+	return getDirect(ctx, tx.ext(), tx.defaults().Guards, &Statement{tx.ErrHandlers}, into, query, bindings)
+}
+
+// ExecDirect executes a raw SQL statement inside the transaction. See
+// DB.ExecDirect for more information.
+func (tx *Tx) ExecDirect(query string, bindings ...interface{}) (sql.Result, error) {
+	return tx.ExecDirectContext(context.Background(), query, bindings...)
+}
+
+// ExecDirectContext is like ExecDirect, but uses the provided context
+func (tx *Tx) ExecDirectContext(ctx context.Context, query string, bindings ...interface{}) (sql.Result, error) {
+	return execDirect(ctx, tx.ext(), tx.defaults().Guards, &Statement{tx.ErrHandlers}, query, bindings)
+}
+
+func getDirect(
+	ctx context.Context,
+	queryer Ext,
+	guards []Guard,
+	stmt *Statement,
+	into interface{},
+	query string,
+	bindings []interface{},
+) error {
+	if err := checkDirect(ctx, queryer, guards, stmt, query, bindings); err != nil {
+		return err
+	}
+
+	err := sqlx.GetContext(ctx, queryer, into, rebindFor(queryer, query), bindings...)
+	stmt.HandleError(err)
+
+	return err
+}
+
+func execDirect(
+	ctx context.Context,
+	execer Ext,
+	guards []Guard,
+	stmt *Statement,
+	query string,
+	bindings []interface{},
+) (sql.Result, error) {
+	if err := checkDirect(ctx, execer, guards, stmt, query, bindings); err != nil {
+		return nil, err
+	}
+
+	res, err := execer.ExecContext(ctx, rebindFor(execer, query), bindings...)
+	stmt.HandleError(err)
+
+	return res, err
+}
+
+// checkDirect checks a raw SQL query against the provided guards, as a
+// RawStmt, passing the error to the error handlers if it is rejected
+func checkDirect(
+	ctx context.Context,
+	execer Ext,
+	guards []Guard,
+	stmt *Statement,
+	query string,
+	bindings []interface{},
+) error {
+	raw := &RawStmt{SQL: query, Bindings: bindings, execer: execer, Statement: stmt}
+
+	err := checkStmt(ctx, guards, raw)
+	if err != nil {
+		stmt.HandleError(err)
+	}
+
+	return err
+}
@@ -0,0 +1,105 @@
+package sqlz
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+// TestTxParity verifies that every method sqlz adds to DB also exists on
+// Tx (and vice versa), so the two handles don't drift apart
+func TestTxParity(t *testing.T) {
+	exceptions := map[string]bool{
+		// connection pool and transaction management
+		"HealthCheck":          true,
+		"PoolStats":            true,
+		"Transactional":        true,
+		"TransactionalContext": true,
+		// only safe inside a transaction, as SET LOCAL is used
+		"SetTimeout":      true,
+		"SetLockTimeout":  true,
+		"WithLockTimeout": true,
+		// constraints can only be deferred inside transactions
+		"SetConstraints": true,
+		// pooled connections may differ in isolation level
+		"CurrentIsolationLevel": true,
+		// transaction tracing
+		"Trace":    true,
+		"Commit":   true,
+		"Rollback": true,
+		// savepoints only exist inside transactions
+		"TryExec":        true,
+		"TryExecContext": true,
+	}
+
+	ownMethods := func(typ, embedded reflect.Type) map[string]bool {
+		methods := make(map[string]bool)
+
+		for i := 0; i < typ.NumMethod(); i++ {
+			name := typ.Method(i).Name
+			if _, promoted := embedded.MethodByName(name); !promoted {
+				methods[name] = true
+			}
+		}
+
+		return methods
+	}
+
+	dbMethods := ownMethods(reflect.TypeOf(&DB{}), reflect.TypeOf(&sqlx.DB{}))
+	txMethods := ownMethods(reflect.TypeOf(&Tx{}), reflect.TypeOf(&sqlx.Tx{}))
+
+	for name := range dbMethods {
+		if !txMethods[name] && !exceptions[name] {
+			t.Errorf("DB.%s has no Tx counterpart", name)
+		}
+	}
+
+	for name := range txMethods {
+		if !dbMethods[name] && !exceptions[name] {
+			t.Errorf("Tx.%s has no DB counterpart", name)
+		}
+	}
+}
+
+func TestDirect(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	dbz := New(db, "postgres")
+
+	mock.ExpectQuery(`SELECT name FROM users WHERE id = \$1`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("Alice"))
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE users SET name = \$1 WHERE id = \$2`).
+		WithArgs("Bob", 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	var name string
+
+	err = dbz.GetDirect(&name, "SELECT name FROM users WHERE id = ?", 1)
+	if err != nil {
+		t.Fatalf("GetDirect failed: %s", err)
+	}
+
+	if name != "Alice" {
+		t.Errorf("Expected Alice, got %s", name)
+	}
+
+	err = dbz.Transactional(func(tx *Tx) error {
+		_, err := tx.ExecDirect("UPDATE users SET name = ? WHERE id = ?", "Bob", 1)
+		return err
+	})
+	if err != nil {
+		t.Errorf("ExecDirect failed: %s", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %s", err)
+	}
+}
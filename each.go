@@ -0,0 +1,99 @@
+package sqlz
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// RowsStmt is implemented by statements that return rows which can be
+// iterated over, such as SelectStmt and WithStmt
+type RowsStmt interface {
+	SQLStmt
+	RowsContext(ctx context.Context) (*Rows, error)
+}
+
+// Each executes the provided statement and calls fn with every result,
+// loaded into a new value of type T, without loading all results into
+// memory first (unlike GetAll). This is useful for exports and batch jobs
+// over large result sets. T may be a struct, a pointer to a struct, or a
+// simple type if only one column is returned, e.g.:
+//
+//	err := sqlz.Each(ctx, dbz.Select("*").From("users"), func(user User) error {
+//		return enc.Encode(user)
+//	})
+//
+// If fn returns an error, iteration stops and the error is returned.
+// Codecs registered for the table of a SELECT statement are applied to
+// every result.
+func Each[T any](ctx context.Context, stmt RowsStmt, fn func(T) error) error {
+	rows, err := stmt.RowsContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	handleError := func(error) {}
+	if handler, ok := stmt.(interface{ HandleError(error) }); ok {
+		handleError = handler.HandleError
+	}
+
+	for rows.Next() {
+		item, err := scanItem[T](rows.Rows)
+		if err == nil {
+			if sel, ok := stmt.(*SelectStmt); ok {
+				err = sel.decode(&item)
+			}
+		}
+
+		if err != nil {
+			handleError(err)
+			return err
+		}
+
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+
+	err = rows.Err()
+	if err != nil {
+		handleError(err)
+	}
+
+	return err
+}
+
+// scanItem scans the current row into a new value of type T, allocating
+// it if T is a pointer type. Structs are scanned like sqlx does, by
+// matching columns to fields, unless they have no exported fields or
+// implement sql.Scanner (e.g. time.Time, sql.NullString).
+func scanItem[T any](rows *sqlx.Rows) (item T, err error) {
+	dest := reflect.ValueOf(&item).Elem()
+	if dest.Kind() == reflect.Ptr {
+		dest.Set(reflect.New(dest.Type().Elem()))
+		dest = dest.Elem()
+	}
+
+	if isStructScannable(dest) {
+		err = rows.StructScan(dest.Addr().Interface())
+	} else {
+		err = rows.Scan(dest.Addr().Interface())
+	}
+
+	return item, err
+}
+
+func isStructScannable(v reflect.Value) bool {
+	if v.Kind() != reflect.Struct {
+		return false
+	}
+
+	if _, isScanner := v.Addr().Interface().(interface{ Scan(interface{}) error }); isScanner {
+		return false
+	}
+
+	return len(structFields(v.Type())) > 0
+}
@@ -0,0 +1,97 @@
+package sqlz
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestEach(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	type user struct {
+		ID   int64  `db:"id"`
+		Name string `db:"name"`
+	}
+
+	mock.ExpectQuery("SELECT id, name FROM users").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "enc:Alice").AddRow(2, "enc:Bob"),
+	)
+	mock.ExpectQuery("SELECT id, name FROM users").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "enc:Alice").AddRow(2, "enc:Bob"),
+	)
+	mock.ExpectQuery("WITH ids AS").WillReturnRows(
+		sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2).AddRow(3),
+	)
+	mock.ExpectQuery("SELECT id FROM users").WillReturnRows(
+		sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2).AddRow(3),
+	)
+
+	dbz := New(db, "sqlmock")
+	dbz.RegisterCodec("users", "name", prefixCodec{})
+	ctx := context.Background()
+
+	var users []user
+
+	err = Each(ctx, dbz.Select("id", "name").From("users"), func(u user) error {
+		users = append(users, u)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed iterating structs: %s", err)
+	}
+
+	if !reflect.DeepEqual(users, []user{{1, "Alice"}, {2, "Bob"}}) {
+		t.Errorf("Unexpected structs: %#v", users)
+	}
+
+	var pointers []*user
+
+	err = Each(ctx, dbz.Select("id", "name").From("users"), func(u *user) error {
+		pointers = append(pointers, u)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed iterating struct pointers: %s", err)
+	}
+
+	if len(pointers) != 2 || pointers[0] == pointers[1] || pointers[1].ID != 2 {
+		t.Errorf("Unexpected struct pointers: %#v", pointers)
+	}
+
+	var ids []int64
+
+	err = Each(ctx, dbz.With(dbz.Select("id").From("users"), "ids").Then(dbz.Select("*").From("ids")), func(id int64) error {
+		ids = append(ids, id)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed iterating scalars: %s", err)
+	}
+
+	if !reflect.DeepEqual(ids, []int64{1, 2, 3}) {
+		t.Errorf("Unexpected scalars: %#v", ids)
+	}
+
+	// errors returned by the function stop the iteration
+	errStop := errors.New("stop")
+	calls := 0
+
+	err = Each(ctx, dbz.Select("id").From("users"), func(int64) error {
+		calls++
+		return errStop
+	})
+	if !errors.Is(err, errStop) || calls != 1 {
+		t.Errorf("Expected iteration to stop after one row, got %d calls and error %v", calls, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %s", err)
+	}
+}
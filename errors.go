@@ -0,0 +1,202 @@
+package sqlz
+
+import (
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+	"reflect"
+	"syscall"
+)
+
+// driverError holds the error codes extracted from a database driver error.
+// Drivers are inspected without importing them: PostgreSQL drivers (pq,
+// pgx) expose an SQLSTATE code, the MySQL driver exposes an error number,
+// and SQLite drivers (mattn/go-sqlite3, modernc.org/sqlite) expose primary
+// and extended result codes.
+type driverError struct {
+	sqlState     string
+	mysqlNumber  int64
+	sqliteCode   int64
+	sqliteExtend int64
+}
+
+// SQLite result codes used for classification
+const (
+	sqliteBusy                 = 5
+	sqliteLocked               = 6
+	sqliteConstraintForeignKey = 787
+	sqliteConstraintPrimaryKey = 1555
+	sqliteConstraintUnique     = 2067
+)
+
+// IsSerializationFailure returns true if the error is a serialization
+// failure or a deadlock, i.e. the transaction was aborted due to concurrent
+// transactions and may succeed if retried
+func IsSerializationFailure(err error) bool {
+	return matchDriverError(err, func(e driverError) bool {
+		switch {
+		case e.sqlState == "40001", e.sqlState == "40P01":
+			return true
+		case e.mysqlNumber == 1213:
+			// ER_LOCK_DEADLOCK
+			return true
+		default:
+			return false
+		}
+	})
+}
+
+// IsUniqueViolation returns true if the error is a violation of a unique
+// (or primary key) constraint
+func IsUniqueViolation(err error) bool {
+	return matchDriverError(err, func(e driverError) bool {
+		switch {
+		case e.sqlState == "23505":
+			return true
+		case e.mysqlNumber == 1062, e.mysqlNumber == 1586:
+			// ER_DUP_ENTRY, ER_DUP_ENTRY_WITH_KEY_NAME
+			return true
+		case e.sqliteExtend == sqliteConstraintUnique, e.sqliteExtend == sqliteConstraintPrimaryKey:
+			return true
+		default:
+			return false
+		}
+	})
+}
+
+// IsForeignKeyViolation returns true if the error is a violation of a
+// foreign key constraint
+func IsForeignKeyViolation(err error) bool {
+	return matchDriverError(err, func(e driverError) bool {
+		switch {
+		case e.sqlState == "23503":
+			return true
+		case e.mysqlNumber == 1216, e.mysqlNumber == 1217, e.mysqlNumber == 1451, e.mysqlNumber == 1452:
+			// ER_NO_REFERENCED_ROW, ER_ROW_IS_REFERENCED and their _2 variants
+			return true
+		case e.sqliteExtend == sqliteConstraintForeignKey:
+			return true
+		default:
+			return false
+		}
+	})
+}
+
+// IsLockTimeout returns true if the error was caused by failing to acquire
+// a lock in time (including NOWAIT locks that could not be acquired)
+func IsLockTimeout(err error) bool {
+	return matchDriverError(err, func(e driverError) bool {
+		switch {
+		case e.sqlState == "55P03":
+			// lock_not_available
+			return true
+		case e.mysqlNumber == 1205, e.mysqlNumber == 3572:
+			// ER_LOCK_WAIT_TIMEOUT, ER_LOCK_NOWAIT
+			return true
+		case e.sqliteCode == sqliteBusy, e.sqliteCode == sqliteLocked:
+			return true
+		default:
+			return false
+		}
+	})
+}
+
+// IsTransientError returns true if the error is a connection-level error
+// that is likely to go away if the query is retried on a new connection,
+// e.g. a bad or reset connection
+func IsTransientError(err error) bool {
+	var opErr *net.OpError
+
+	return errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.As(err, &opErr)
+}
+
+// matchDriverError walks the error's chain, returning true if any of the
+// driver errors in it matches the provided function
+func matchDriverError(err error, match func(driverError) bool) bool {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if e, ok := inspectDriverError(err); ok && match(e) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// inspectDriverError extracts error codes from a driver error
+func inspectDriverError(err error) (e driverError, ok bool) {
+	if withState, isState := err.(interface{ SQLState() string }); isState {
+		e.sqlState = withState.SQLState()
+		return e, true
+	}
+
+	if withCode, isCode := err.(interface{ Code() int }); isCode {
+		// modernc.org/sqlite errors return extended result codes,
+		// whose lower byte is the primary result code
+		e.sqliteExtend = int64(withCode.Code())
+		e.sqliteCode = e.sqliteExtend & 0xff
+
+		return e, true
+	}
+
+	v := reflect.ValueOf(err)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return e, false
+		}
+
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Struct {
+		return e, false
+	}
+
+	if number := v.FieldByName("Number"); number.IsValid() && isUint(number.Kind()) {
+		// github.com/go-sql-driver/mysql
+		e.mysqlNumber = int64(number.Uint())
+		ok = true
+	}
+
+	if code := v.FieldByName("Code"); code.IsValid() {
+		switch {
+		case code.Kind() == reflect.String && code.Len() == 5:
+			// github.com/lib/pq (older versions) and pgconn
+			e.sqlState = code.String()
+			ok = true
+		case isInt(code.Kind()):
+			// github.com/mattn/go-sqlite3
+			e.sqliteCode = code.Int()
+			ok = true
+
+			if ext := v.FieldByName("ExtendedCode"); ext.IsValid() && isInt(ext.Kind()) {
+				e.sqliteExtend = ext.Int()
+			}
+		}
+	}
+
+	return e, ok
+}
+
+func isInt(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	default:
+		return false
+	}
+}
+
+func isUint(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,111 @@
+package sqlz
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+// the following types mimic the error types of common database drivers
+
+type pqError struct {
+	Code    string
+	Message string
+}
+
+func (e *pqError) Error() string { return e.Message }
+
+type pgxError struct {
+	code string
+}
+
+func (e *pgxError) Error() string    { return "pgx error" }
+func (e *pgxError) SQLState() string { return e.code }
+
+type mysqlError struct {
+	Number  uint16
+	Message string
+}
+
+func (e *mysqlError) Error() string { return e.Message }
+
+type sqlite3Error struct {
+	Code         int
+	ExtendedCode int
+}
+
+func (e sqlite3Error) Error() string { return "sqlite3 error" }
+
+type moderncError struct {
+	code int
+}
+
+func (e *moderncError) Error() string { return "sqlite error" }
+func (e *moderncError) Code() int     { return e.code }
+
+func TestErrorClassification(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		serializing bool
+		unique      bool
+		foreignKey  bool
+		lockTimeout bool
+	}{
+		{"pq unique violation", &pqError{Code: "23505"}, false, true, false, false},
+		{"pgx serialization failure", &pgxError{"40001"}, true, false, false, false},
+		{"pgx deadlock", &pgxError{"40P01"}, true, false, false, false},
+		{"wrapped pgx foreign key violation", fmt.Errorf("insert failed: %w", &pgxError{"23503"}), false, false, true, false},
+		{"pq lock not available", &pqError{Code: "55P03"}, false, false, false, true},
+		{"mysql duplicate entry", &mysqlError{Number: 1062}, false, true, false, false},
+		{"mysql deadlock", &mysqlError{Number: 1213}, true, false, false, false},
+		{"mysql foreign key violation", &mysqlError{Number: 1452}, false, false, true, false},
+		{"mysql lock wait timeout", &mysqlError{Number: 1205}, false, false, false, true},
+		{"sqlite3 unique violation", sqlite3Error{Code: 19, ExtendedCode: 2067}, false, true, false, false},
+		{"sqlite3 busy", sqlite3Error{Code: 5, ExtendedCode: 5}, false, false, false, true},
+		{"modernc foreign key violation", &moderncError{787}, false, false, true, false},
+		{"unrelated error", errors.New("oops"), false, false, false, false},
+		{"nil error", nil, false, false, false, false},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.name, func(t *testing.T) {
+			if got := IsSerializationFailure(tst.err); got != tst.serializing {
+				t.Errorf("IsSerializationFailure: expected %t, got %t", tst.serializing, got)
+			}
+
+			if got := IsUniqueViolation(tst.err); got != tst.unique {
+				t.Errorf("IsUniqueViolation: expected %t, got %t", tst.unique, got)
+			}
+
+			if got := IsForeignKeyViolation(tst.err); got != tst.foreignKey {
+				t.Errorf("IsForeignKeyViolation: expected %t, got %t", tst.foreignKey, got)
+			}
+
+			if got := IsLockTimeout(tst.err); got != tst.lockTimeout {
+				t.Errorf("IsLockTimeout: expected %t, got %t", tst.lockTimeout, got)
+			}
+		})
+	}
+}
+
+func TestIsTransientError(t *testing.T) {
+	tests := []struct {
+		err       error
+		transient bool
+	}{
+		{driver.ErrBadConn, true},
+		{fmt.Errorf("query failed: %w", io.ErrUnexpectedEOF), true},
+		{&pgxError{"23505"}, false},
+		{errors.New("oops"), false},
+		{nil, false},
+	}
+
+	for _, tst := range tests {
+		if got := IsTransientError(tst.err); got != tst.transient {
+			t.Errorf("IsTransientError(%v): expected %t, got %t", tst.err, tst.transient, got)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package sqlz
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrNoEstimate is returned by EstimateCount when the database's query plan
+// does not include a row estimate
+var ErrNoEstimate = errors.New("query plan has no row estimate")
+
+// EstimateCount returns the PostgreSQL query planner's estimate of the
+// number of rows the statement would return, disregarding limits and
+// offsets (like GetCount). The estimate is obtained by running EXPLAIN
+// (FORMAT JSON) on the statement, which does not execute it, making this
+// a cheap alternative to GetCount when paginating huge tables. Note that
+// estimates depend on table statistics, and may be far from accurate.
+func (stmt *SelectStmt) EstimateCount(ctx context.Context) (count int64, err error) {
+	countStmt := *stmt
+	countStmt.LimitTo = 0
+	countStmt.OffsetFrom = 0
+	countStmt.OffsetRows = 0
+	countStmt.defaults.MaxLimit = 0
+	countStmt.Ordering = []SQLStmt{}
+
+	asSQL, bindings := countStmt.scoped(ctx).ToSQL(true)
+
+	var plan []byte
+
+	err = stmt.queryer.QueryRowxContext(ctx, "EXPLAIN (FORMAT JSON) "+asSQL, bindings...).Scan(&plan)
+	if err != nil {
+		stmt.HandleError(err)
+		return count, err
+	}
+
+	count, err = planRows(plan)
+	if err != nil {
+		stmt.HandleError(err)
+	}
+
+	return count, err
+}
+
+// planRows extracts the estimated number of rows from a PostgreSQL query
+// plan in JSON format
+func planRows(plan []byte) (int64, error) {
+	var explained []struct {
+		Plan struct {
+			Rows *float64 `json:"Plan Rows"`
+		} `json:"Plan"`
+	}
+
+	err := json.Unmarshal(plan, &explained)
+	if err != nil {
+		return 0, fmt.Errorf("failed parsing query plan: %w", err)
+	}
+
+	if len(explained) == 0 || explained[0].Plan.Rows == nil {
+		return 0, ErrNoEstimate
+	}
+
+	return int64(math.Round(*explained[0].Plan.Rows)), nil
+}
@@ -0,0 +1,46 @@
+package sqlz
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestEstimateCount(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	dbz := New(db, "postgres")
+
+	mock.ExpectQuery(`EXPLAIN \(FORMAT JSON\) SELECT \* FROM events WHERE type = \$1`).
+		WithArgs("click").
+		WillReturnRows(sqlmock.NewRows([]string{"QUERY PLAN"}).
+			AddRow(`[{"Plan": {"Node Type": "Seq Scan", "Plan Rows": 120345, "Plan Width": 40}}]`))
+
+	count, err := dbz.Select("*").
+		From("events").
+		Where(Eq("type", "click")).
+		OrderBy(Desc("created_at")).
+		Limit(20).
+		Offset(40).
+		EstimateCount(context.Background())
+	if err != nil {
+		t.Fatalf("EstimateCount failed: %s", err)
+	}
+
+	if count != 120345 {
+		t.Errorf("Expected 120345, got %d", count)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %s", err)
+	}
+
+	if _, err := planRows([]byte(`[{"Plan": {}}]`)); !errors.Is(err, ErrNoEstimate) {
+		t.Errorf("Expected ErrNoEstimate, got %v", err)
+	}
+}
@@ -0,0 +1,161 @@
+package sqlz
+
+import (
+	"context"
+	"strings"
+)
+
+// WriteEvent describes a successfully executed INSERT, UPDATE or DELETE
+// statement
+type WriteEvent struct {
+	// Kind is the kind of the statement
+	Kind StmtKind
+	// Tables are the tables modified by the statement, without aliases
+	Tables []string
+	// Stmt is the statement itself
+	Stmt SQLStmt
+}
+
+// WriteListener is a function called after INSERT, UPDATE and DELETE
+// statements are executed successfully (see Defaults.WriteListeners).
+// Listeners allow cache layers, materialized view refreshers and similar
+// components to react to modified tables without scattering invalidation
+// calls across the code. The context is the one the statement was executed
+// with. Note that inside transactions, listeners are called when the
+// statement is executed, not when the transaction is committed.
+type WriteListener func(ctx context.Context, event WriteEvent)
+
+// InvalidateOnWrite returns a WriteListener that invalidates the cached
+// results of all modified tables in the provided cache store. For
+// statements executed inside a transaction, the results are invalidated
+// once the transaction is committed (see Tx.InvalidateCache).
+func InvalidateOnWrite(store CacheStore) WriteListener {
+	return func(_ context.Context, event WriteEvent) {
+		if tx := txOf(event.Stmt); tx != nil {
+			tx.afterCommit(func() {
+				store.Invalidate(event.Tables...)
+			})
+
+			return
+		}
+
+		store.Invalidate(event.Tables...)
+	}
+}
+
+// notifyWrite calls the provided listeners with an event describing the
+// provided statement, unless it failed
+func notifyWrite(
+	ctx context.Context,
+	listeners []WriteListener,
+	stmt MetadataStmt,
+	tables []string,
+	err error,
+) {
+	if err != nil || len(listeners) == 0 {
+		return
+	}
+
+	event := WriteEvent{
+		Kind:   stmt.Kind(),
+		Tables: tables,
+		Stmt:   stmt,
+	}
+
+	for _, listener := range listeners {
+		listener(ctx, event)
+	}
+}
+
+// stripAlias removes the alias from a table name (e.g. "users u")
+func stripAlias(table string) string {
+	fields := strings.Fields(table)
+	if len(fields) == 0 {
+		return table
+	}
+
+	return fields[0]
+}
+
+// writtenTables returns the table modified by the statement
+func (stmt *InsertStmt) writtenTables() []string {
+	return []string{stripAlias(stmt.Table)}
+}
+
+// writtenTables returns the tables modified by the statement: the main
+// table, or in MySQL-style multiple-table UPDATE statements, the tables
+// whose columns are set
+func (stmt *UpdateStmt) writtenTables() []string {
+	if len(stmt.Joins) == 0 || len(stmt.FromTables) > 0 {
+		return []string{stripAlias(stmt.Table)}
+	}
+
+	sources := []string{stmt.Table}
+	for _, join := range stmt.Joins {
+		if join.ResultSet == nil {
+			sources = append(sources, join.Table)
+		}
+	}
+
+	var tables []string
+	for _, col := range sortKeys(stmt.Updates) {
+		table := stmt.Table
+		if i := strings.LastIndex(col, "."); i > 0 {
+			table = resolveTable(col[:i], sources)
+		}
+
+		tables = appendTable(tables, stripAlias(table))
+	}
+
+	if len(tables) == 0 {
+		return []string{stripAlias(stmt.Table)}
+	}
+
+	return tables
+}
+
+// writtenTables returns the tables modified by the statement: the main
+// table, or in MySQL-style multiple-table DELETE statements, the targets
+func (stmt *DeleteStmt) writtenTables() []string {
+	if len(stmt.TargetTables) == 0 {
+		return []string{stripAlias(stmt.Table)}
+	}
+
+	sources := append([]string{stmt.Table}, stmt.UsingTables...)
+	for _, join := range stmt.Joins {
+		if join.ResultSet == nil {
+			sources = append(sources, join.Table)
+		}
+	}
+
+	var tables []string
+	for _, target := range stmt.TargetTables {
+		tables = appendTable(tables, stripAlias(resolveTable(target, sources)))
+	}
+
+	return tables
+}
+
+// resolveTable returns the table, among the provided tables, that the
+// provided name (a table name or alias) references. Names that reference
+// none of them are returned as is.
+func resolveTable(name string, tables []string) string {
+	for _, table := range tables {
+		if tableAlias(table) == name || stripAlias(table) == name {
+			return table
+		}
+	}
+
+	return name
+}
+
+// appendTable appends a table to a list of tables, unless already included
+func appendTable(tables []string, table string) []string {
+	for _, t := range tables {
+		if t == table {
+			return tables
+		}
+	}
+
+	return append(tables, table)
+}
@@ -0,0 +1,152 @@
+package sqlz
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestWriteListeners(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	var events []WriteEvent
+
+	dbz := New(db, "postgres")
+	dbz.Defaults.WriteListeners = []WriteListener{
+		func(_ context.Context, event WriteEvent) {
+			events = append(events, event)
+		},
+	}
+
+	mock.ExpectExec(`INSERT INTO users \(name\) VALUES \(\$1\)`).
+		WithArgs("Alice").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`UPDATE users u SET name = \$1`).
+		WithArgs("Bob").
+		WillReturnError(errors.New("failed"))
+	mock.ExpectQuery(`DELETE FROM sessions USING users WHERE sessions.user_id = users.id RETURNING sessions.id`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	if _, err := dbz.InsertInto("users").Columns("name").Values("Alice").Exec(); err != nil {
+		t.Fatalf("Insert failed: %s", err)
+	}
+
+	if _, err := dbz.Update("users u").Set("name", "Bob").Exec(); err == nil {
+		t.Fatal("Expected update to fail")
+	}
+
+	var ids []int64
+
+	err = dbz.DeleteFrom("sessions").
+		Using("users").
+		Where(Eq("sessions.user_id", Indirect("users.id"))).
+		Returning("sessions.id").
+		GetAll(&ids)
+	if err != nil {
+		t.Fatalf("Delete failed: %s", err)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %d", len(events))
+	}
+
+	if events[0].Kind != KindInsert || !reflect.DeepEqual(events[0].Tables, []string{"users"}) {
+		t.Errorf("Unexpected insert event: %+v", events[0])
+	}
+
+	if events[1].Kind != KindDelete || !reflect.DeepEqual(events[1].Tables, []string{"sessions"}) {
+		t.Errorf("Unexpected delete event: %+v", events[1])
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %s", err)
+	}
+}
+
+func TestWriteListenersMultipleTables(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	var events []WriteEvent
+
+	dbz := New(db, "mysql")
+	dbz.Defaults.WriteListeners = []WriteListener{
+		func(_ context.Context, event WriteEvent) {
+			events = append(events, event)
+		},
+	}
+
+	mock.ExpectExec(`DELETE o, i FROM orders o INNER JOIN items i ON i.order_id = o.id WHERE o.id = \?`).
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec(`DELETE sessions FROM sessions INNER JOIN users ON users.id = sessions.user_id`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE orders o INNER JOIN items i ON i.order_id = o.id SET i.price = \?, o.total = \?`).
+		WithArgs(0, 0).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec(`UPDATE orders o INNER JOIN items i ON i.order_id = o.id SET i.price = \?`).
+		WithArgs(0).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	_, err = dbz.DeleteFrom("orders o").
+		InnerJoin("items i", Eq("i.order_id", Indirect("o.id"))).
+		Targets("o", "i").
+		Where(Eq("o.id", 1)).
+		Exec()
+	if err != nil {
+		t.Fatalf("Delete failed: %s", err)
+	}
+
+	_, err = dbz.DeleteFrom("sessions").
+		InnerJoin("users", Eq("users.id", Indirect("sessions.user_id"))).
+		Exec()
+	if err != nil {
+		t.Fatalf("Delete failed: %s", err)
+	}
+
+	_, err = dbz.Update("orders o").
+		InnerJoin("items i", Eq("i.order_id", Indirect("o.id"))).
+		Set("o.total", 0).
+		Set("i.price", 0).
+		Exec()
+	if err != nil {
+		t.Fatalf("Update failed: %s", err)
+	}
+
+	_, err = dbz.Update("orders o").
+		InnerJoin("items i", Eq("i.order_id", Indirect("o.id"))).
+		Set("i.price", 0).
+		Exec()
+	if err != nil {
+		t.Fatalf("Update failed: %s", err)
+	}
+
+	expected := [][]string{
+		{"orders", "items"},
+		{"sessions"},
+		{"items", "orders"},
+		{"items"},
+	}
+
+	if len(events) != len(expected) {
+		t.Fatalf("Expected %d events, got %d", len(expected), len(events))
+	}
+
+	for i, event := range events {
+		if !reflect.DeepEqual(event.Tables, expected[i]) {
+			t.Errorf("Event %d: expected tables %v, got %v", i, expected[i], event.Tables)
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %s", err)
+	}
+}
@@ -0,0 +1,220 @@
+package sqlz
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// CSVOptions controls how WriteCSV formats result sets
+type CSVOptions struct {
+	// NoHeader disables writing a header line with column names
+	NoHeader bool
+	// Comma is the field delimiter (defaults to ',')
+	Comma rune
+	// TimeFormat is the layout used to format time.Time values (defaults
+	// to time.RFC3339)
+	TimeFormat string
+	// NullValue is the string written for NULL values (defaults to an
+	// empty string)
+	NullValue string
+}
+
+// WriteCSV executes the SELECT statement and streams the results as CSV
+// into the provided writer, one row at a time, so that large result sets
+// are never loaded into memory in their entirety. Values are converted
+// as described in TypedMaps before they are formatted.
+func (stmt *SelectStmt) WriteCSV(w io.Writer, opts CSVOptions) (err error) {
+	if opts.TimeFormat == "" {
+		opts.TimeFormat = time.RFC3339
+	}
+
+	cw := csv.NewWriter(w)
+	if opts.Comma != 0 {
+		cw.Comma = opts.Comma
+	}
+
+	err = stmt.exportRows(func(cols []*sql.ColumnType) error {
+		if opts.NoHeader {
+			return nil
+		}
+
+		header := make([]string, len(cols))
+		for i, col := range cols {
+			header[i] = col.Name()
+		}
+
+		return cw.Write(header)
+	}, func(values []interface{}) error {
+		record := make([]string, len(values))
+		for i, val := range values {
+			record[i] = formatCSVValue(val, opts)
+		}
+
+		return cw.Write(record)
+	})
+	if err != nil {
+		return err
+	}
+
+	cw.Flush()
+
+	if err = cw.Error(); err != nil {
+		stmt.HandleError(err)
+	}
+
+	return err
+}
+
+// WriteJSON executes the SELECT statement and streams the results into the
+// provided writer as a JSON array of objects (one object per row, with keys
+// in the order the columns were selected), one row at a time. Values are
+// converted as described in TypedMaps before they are encoded.
+func (stmt *SelectStmt) WriteJSON(w io.Writer) (err error) {
+	bw := bufio.NewWriter(w)
+
+	var keys [][]byte
+
+	first := true
+
+	err = stmt.exportRows(func(cols []*sql.ColumnType) error {
+		keys = make([][]byte, len(cols))
+
+		for i, col := range cols {
+			key, err := json.Marshal(col.Name())
+			if err != nil {
+				return err
+			}
+
+			keys[i] = key
+		}
+
+		_, err := bw.WriteString("[")
+
+		return err
+	}, func(values []interface{}) error {
+		if !first {
+			if err := bw.WriteByte(','); err != nil {
+				return err
+			}
+		}
+
+		first = false
+
+		if err := bw.WriteByte('{'); err != nil {
+			return err
+		}
+
+		for i, val := range values {
+			encoded, err := json.Marshal(val)
+			if err != nil {
+				return fmt.Errorf("failed encoding column %s: %w", keys[i], err)
+			}
+
+			if i > 0 {
+				if err := bw.WriteByte(','); err != nil {
+					return err
+				}
+			}
+
+			if _, err := bw.Write(keys[i]); err != nil {
+				return err
+			}
+
+			if err := bw.WriteByte(':'); err != nil {
+				return err
+			}
+
+			if _, err := bw.Write(encoded); err != nil {
+				return err
+			}
+		}
+
+		return bw.WriteByte('}')
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err = bw.WriteString("]"); err == nil {
+		err = bw.Flush()
+	}
+
+	if err != nil {
+		stmt.HandleError(err)
+	}
+
+	return err
+}
+
+// exportRows executes the statement and calls the header function with the
+// column types of the result set, and then the row function for every row
+// with its (converted) values
+func (stmt *SelectStmt) exportRows(
+	header func(cols []*sql.ColumnType) error,
+	row func(values []interface{}) error,
+) (err error) {
+	var rows *Rows
+
+	rows, err = stmt.Rows()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if closeErr := rows.Close(); err == nil {
+			err = closeErr
+		}
+
+		if err != nil {
+			stmt.HandleError(err)
+		}
+	}()
+
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return err
+	}
+
+	err = header(types)
+	if err != nil {
+		return err
+	}
+
+	for rows.Next() {
+		values, err := rows.SliceScan()
+		if err != nil {
+			return err
+		}
+
+		for i := range values {
+			values[i] = typedValue(types[i].DatabaseTypeName(), values[i])
+		}
+
+		err = row(values)
+		if err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func formatCSVValue(val interface{}, opts CSVOptions) string {
+	switch v := val.(type) {
+	case nil:
+		return opts.NullValue
+	case []byte:
+		return string(v)
+	case string:
+		return v
+	case time.Time:
+		return v.Format(opts.TimeFormat)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,65 @@
+package sqlz
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestExport(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	dbz := New(db, "sqlmock")
+	created := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	newRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "name", "created"}).
+			AddRow(1, []byte("Alice, Jr."), created).
+			AddRow(2, nil, created)
+	}
+
+	mock.ExpectQuery("SELECT").WillReturnRows(newRows())
+	mock.ExpectQuery("SELECT").WillReturnRows(newRows())
+	mock.ExpectQuery("SELECT").WillReturnRows(newRows())
+
+	var csvOut bytes.Buffer
+
+	err = dbz.Select("id", "name", "created").From("users").WriteCSV(&csvOut, CSVOptions{NullValue: "NULL"})
+	if err != nil {
+		t.Fatalf("Failed writing CSV: %s", err)
+	}
+
+	expectedCSV := "id,name,created\n1,\"Alice, Jr.\",2020-01-02T03:04:05Z\n2,NULL,2020-01-02T03:04:05Z\n"
+	if csvOut.String() != expectedCSV {
+		t.Errorf("Expected CSV:\n%s\nGot:\n%s", expectedCSV, csvOut.String())
+	}
+
+	csvOut.Reset()
+
+	err = dbz.Select("id", "name", "created").From("users").WriteCSV(&csvOut, CSVOptions{NoHeader: true, Comma: ';', TimeFormat: "2006-01-02"})
+	if err != nil {
+		t.Fatalf("Failed writing CSV: %s", err)
+	}
+
+	expectedCSV = "1;Alice, Jr.;2020-01-02\n2;;2020-01-02\n"
+	if csvOut.String() != expectedCSV {
+		t.Errorf("Expected CSV:\n%s\nGot:\n%s", expectedCSV, csvOut.String())
+	}
+
+	var jsonOut bytes.Buffer
+
+	err = dbz.Select("id", "name", "created").From("users").WriteJSON(&jsonOut)
+	if err != nil {
+		t.Fatalf("Failed writing JSON: %s", err)
+	}
+
+	expectedJSON := `[{"id":1,"name":"Alice, Jr.","created":"2020-01-02T03:04:05Z"},{"id":2,"name":null,"created":"2020-01-02T03:04:05Z"}]`
+	if jsonOut.String() != expectedJSON {
+		t.Errorf("Expected JSON:\n%s\nGot:\n%s", expectedJSON, jsonOut.String())
+	}
+}
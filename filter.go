@@ -0,0 +1,81 @@
+package sqlz
+
+// FilterFunc creates a condition from the arguments provided to Filter
+type FilterFunc func(args ...interface{}) WhereCondition
+
+// Filters are named, reusable condition fragments, keyed by name (see
+// DB.DefineFilter)
+type Filters map[string]FilterFunc
+
+// FilterCondition references a filter by name, along with the arguments
+// to create its condition with. It is resolved when the statement it is
+// used in generates SQL, using the filters defined in the DB or Tx the
+// statement was created from.
+type FilterCondition struct {
+	Name string
+	Args []interface{}
+}
+
+// Filter references the filter with the provided name, to be used as a
+// condition in SELECT, UPDATE and DELETE statements, e.g.:
+//
+//	dbz.DefineFilter("visibleTo", func(args ...interface{}) WhereCondition {
+//		return Or(Eq("public", true), Eq("owner_id", args[0]))
+//	})
+//
+//	dbz.Select("*").From("posts").Where(Filter("visibleTo", userID))
+//
+// Filters may reference other filters. Referencing a filter that is not
+// defined generates invalid SQL, which Validate reports.
+func Filter(name string, args ...interface{}) FilterCondition {
+	return FilterCondition{name, args}
+}
+
+// Parse implements the WhereCondition interface. Filters are resolved
+// before conditions are parsed, so this is only called for filters that
+// are not defined, and generates SQL that the database rejects.
+func (cond FilterCondition) Parse() (asSQL string, bindings []interface{}) {
+	return "<undefined filter " + cond.Name + ">", nil
+}
+
+// DefineFilter defines a named, reusable condition in the DB's defaults,
+// which statements subsequently created from the DB (and from transactions
+// started from it) can reference with Filter. Defining a filter with an
+// existing name replaces it.
+func (db *DB) DefineFilter(name string, fn FilterFunc) {
+	db.Configure(func(defaults *Defaults) {
+		defaults.Filters = defaults.Filters.with(name, fn)
+	})
+}
+
+// DefineFilter defines a named, reusable condition in the Tx's defaults,
+// without affecting the DB the transaction was started from (see
+// DB.DefineFilter)
+func (tx *Tx) DefineFilter(name string, fn FilterFunc) {
+	tx.Configure(func(defaults *Defaults) {
+		defaults.Filters = defaults.Filters.with(name, fn)
+	})
+}
+
+// with returns the filters with the provided filter defined, allocating
+// them if necessary
+func (filters Filters) with(name string, fn FilterFunc) Filters {
+	if filters == nil {
+		filters = make(Filters)
+	}
+
+	filters[name] = fn
+
+	return filters
+}
+
+// resolve returns the condition the provided filter resolves to, and false
+// if it is not defined
+func (filters Filters) resolve(cond FilterCondition) (WhereCondition, bool) {
+	fn, ok := filters[cond.Name]
+	if !ok {
+		return nil, false
+	}
+
+	return fn(cond.Args...), true
+}
@@ -0,0 +1,83 @@
+package sqlz
+
+import (
+	"errors"
+	"testing"
+
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestFilters(t *testing.T) {
+	runTests(t, func(dbz *DB) []test {
+		dbz.DefineFilter("active", func(_ ...interface{}) WhereCondition {
+			return IsNull("deleted_at")
+		})
+		dbz.DefineFilter("visibleTo", func(args ...interface{}) WhereCondition {
+			return Or(Eq("public", true), Eq("owner_id", args[0]))
+		})
+		dbz.DefineFilter("activeVisibleTo", func(args ...interface{}) WhereCondition {
+			return And(Filter("active"), Filter("visibleTo", args...))
+		})
+
+		return []test{
+			{
+				"select with filters",
+				dbz.Select("*").From("posts").Where(Eq("category", 3), Filter("visibleTo", 42)),
+				"SELECT * FROM posts WHERE category = ? AND (public = ? OR owner_id = ?)",
+				[]interface{}{3, true, 42},
+			},
+			{
+				"nested filter references",
+				dbz.Select("*").From("posts").Where(Not(Filter("activeVisibleTo", 7))),
+				"SELECT * FROM posts WHERE NOT((deleted_at IS NULL AND (public = ? OR owner_id = ?)))",
+				[]interface{}{true, 7},
+			},
+			{
+				"filter in a join",
+				dbz.Select("*").From("users u").InnerJoin("posts p", Eq("p.user_id", Indirect("u.id")), Filter("active")),
+				"SELECT * FROM users u INNER JOIN posts p ON p.user_id = u.id AND deleted_at IS NULL",
+				nil,
+			},
+			{
+				"update with filter",
+				dbz.Update("posts").Set("title", "x").Where(Filter("visibleTo", 1)),
+				"UPDATE posts SET title = ? WHERE public = ? OR owner_id = ?",
+				[]interface{}{"x", true, 1},
+			},
+			{
+				"delete with filter",
+				dbz.DeleteFrom("posts").Where(Filter("active"), Eq("id", 5)),
+				"DELETE FROM posts WHERE deleted_at IS NULL AND id = ?",
+				[]interface{}{5},
+			},
+		}
+	})
+}
+
+func TestUndefinedFilter(t *testing.T) {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	dbz := New(db, "postgres")
+	stmt := dbz.Select("*").From("posts").Where(Filter("active"))
+
+	dbz.DefineFilter("active", func(_ ...interface{}) WhereCondition {
+		return IsNull("deleted_at")
+	})
+
+	// statements keep the filters defined when they were created
+	err = Validate(stmt)
+	if !errors.Is(err, ErrInvalidStatement) {
+		t.Fatalf("Expected undefined filter to be reported, got %v", err)
+	}
+
+	if err.Error() != "invalid statement: WHERE: filter active is not defined" {
+		t.Errorf("Unexpected validation error %q", err)
+	}
+
+	if err := Validate(dbz.Select("*").From("posts").Where(Filter("active"))); err != nil {
+		t.Errorf("Expected defined filter to be valid, got %v", err)
+	}
+}
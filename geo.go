@@ -0,0 +1,56 @@
+package sqlz
+
+// Point is a geographical point, in WGS 84 coordinates
+type Point struct {
+	Lng float64
+	Lat float64
+}
+
+// GeoPoint creates a PostGIS expression for the provided point (as a
+// geometry with SRID 4326), with its coordinates bound as parameters
+func GeoPoint(p Point) IndirectValue {
+	return Indirect("ST_SetSRID(ST_MakePoint(?, ?), 4326)", p.Lng, p.Lat)
+}
+
+// GeoDistance creates a PostGIS expression calculating the distance, in
+// meters, between a geometry or geography column and the provided point
+func GeoDistance(geoCol string, p Point) IndirectValue {
+	return Indirect(
+		"ST_Distance("+geoCol+"::geography, ST_SetSRID(ST_MakePoint(?, ?), 4326)::geography)",
+		p.Lng, p.Lat,
+	)
+}
+
+// EarthDistance creates an expression calculating the distance, in meters,
+// between the provided point and the point stored in a pair of latitude
+// and longitude columns, using PostgreSQL's earthdistance extension
+func EarthDistance(latCol, lngCol string, p Point) IndirectValue {
+	return Indirect(
+		"earth_distance(ll_to_earth("+latCol+", "+lngCol+"), ll_to_earth(?, ?))",
+		p.Lat, p.Lng,
+	)
+}
+
+// WithinDistance creates a condition checking that a geometry or geography
+// column is within the provided distance, in meters, of the provided point,
+// using PostGIS's ST_DWithin (which can use spatial indexes)
+func WithinDistance(geoCol string, p Point, meters float64) SQLCondition {
+	return SQLCond(
+		"ST_DWithin("+geoCol+"::geography, ST_SetSRID(ST_MakePoint(?, ?), 4326)::geography, ?)",
+		p.Lng, p.Lat, meters,
+	)
+}
+
+// OrderByNearest orders the results by the distance of a PostGIS geometry
+// column from the provided point (nearest first), using the index-assisted
+// "<->" operator. Combine with Limit for "nearest N" queries.
+func (stmt *SelectStmt) OrderByNearest(geoCol string, p Point) *SelectStmt {
+	return stmt.OrderBy(Indirect(geoCol+" <-> ST_SetSRID(ST_MakePoint(?, ?), 4326)", p.Lng, p.Lat))
+}
+
+// SelectDistance adds the distance, in meters, of a PostGIS geometry or
+// geography column from the provided point to the selected columns, under
+// the provided alias
+func (stmt *SelectStmt) SelectDistance(geoCol string, p Point, as string) *SelectStmt {
+	return stmt.SelectExpr(GeoDistance(geoCol, p), as)
+}
@@ -0,0 +1,42 @@
+package sqlz
+
+import (
+	"testing"
+)
+
+func TestGeo(t *testing.T) {
+	runTests(t, func(dbz *DB) []test {
+		p := Point{Lng: 34.78, Lat: 32.08}
+
+		return []test{
+			{
+				"nearest N with distance column",
+				dbz.Select("id", "name").
+					SelectDistance("location", p, "distance").
+					From("stores").
+					Where(Eq("open", true)).
+					OrderByNearest("location", p).
+					Limit(10),
+				"SELECT id, name, ST_Distance(location::geography, ST_SetSRID(ST_MakePoint(?, ?), 4326)::geography) AS distance " +
+					"FROM stores WHERE open = ? ORDER BY location <-> ST_SetSRID(ST_MakePoint(?, ?), 4326) LIMIT 10",
+				[]interface{}{34.78, 32.08, true, 34.78, 32.08},
+			},
+			{
+				"within distance",
+				dbz.Select("id").From("stores").Where(WithinDistance("location", p, 500)),
+				"SELECT id FROM stores WHERE ST_DWithin(location::geography, ST_SetSRID(ST_MakePoint(?, ?), 4326)::geography, ?)",
+				[]interface{}{34.78, 32.08, float64(500)},
+			},
+			{
+				"earthdistance ordering",
+				dbz.Select("id").
+					SelectExpr(EarthDistance("lat", "lng", p), "distance").
+					From("stores").
+					OrderBy(EarthDistance("lat", "lng", p)),
+				"SELECT id, earth_distance(ll_to_earth(lat, lng), ll_to_earth(?, ?)) AS distance FROM stores " +
+					"ORDER BY earth_distance(ll_to_earth(lat, lng), ll_to_earth(?, ?))",
+				[]interface{}{32.08, 34.78, 32.08, 34.78},
+			},
+		}
+	})
+}
@@ -0,0 +1,179 @@
+package sqlz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Guard is a function that can veto the execution of statements based on
+// their metadata (see Defaults.Guards), e.g. to forbid DELETE statements
+// without a WHERE clause, or raw SQL outside of migrations. It is called
+// before a statement is executed, with the context it is executed with,
+// and returns a non-nil error to reject it. Statements of all kinds are
+// provided, so guards should use KindOf and TablesOf rather than assume
+// statements implement MetadataStmt; raw statements are provided as
+// *RawStmt objects, whose SQL can be inspected.
+type Guard func(ctx context.Context, stmt SQLStmt) error
+
+// PolicyError is the error returned when a statement is rejected by a
+// guard, before it reaches the database
+type PolicyError struct {
+	// Stmt is the rejected statement
+	Stmt SQLStmt
+	// Err is the error returned by the guard
+	Err error
+}
+
+// Error returns the error message
+func (e *PolicyError) Error() string {
+	return fmt.Sprintf("statement rejected by policy: %s", e.Err)
+}
+
+// Unwrap returns the error returned by the guard
+func (e *PolicyError) Unwrap() error {
+	return e.Err
+}
+
+// IsPolicyError returns true if the provided error (or any error it wraps)
+// is a PolicyError
+func IsPolicyError(err error) bool {
+	var policyErr *PolicyError
+	return errors.As(err, &policyErr)
+}
+
+// ErrUnconditionalWrite is returned by the guard created with
+// ForbidUnconditionalWrites
+var ErrUnconditionalWrite = errors.New("UPDATE and DELETE statements must have conditions")
+
+// ForbidUnconditionalWrites returns a guard rejecting UPDATE and DELETE
+// statements with no WHERE conditions, as a safety net against accidental
+// modification of entire tables. Statements using WHERE CURRENT OF, and
+// UPDATE statements created with FromValues, are allowed. Conditions added
+// by scopes are not taken into account (see Defaults.Scope).
+func ForbidUnconditionalWrites() Guard {
+	return func(_ context.Context, stmt SQLStmt) error {
+		if isUnconditionalWrite(stmt) {
+			return ErrUnconditionalWrite
+		}
+
+		return nil
+	}
+}
+
+// isUnconditionalWrite returns true if the provided statement is an UPDATE
+// or DELETE statement with no conditions, or a WITH statement including one
+func isUnconditionalWrite(stmt SQLStmt) bool {
+	switch s := stmt.(type) {
+	case *UpdateStmt:
+		return len(s.Conditions) == 0 && s.CurrentOf == "" && len(s.MultipleValues.Columns) == 0
+	case *DeleteStmt:
+		return len(s.Conditions) == 0 && s.CurrentOf == ""
+	case *WithStmt:
+		for _, aux := range s.AuxStmts {
+			if isUnconditionalWrite(aux.Stmt) {
+				return true
+			}
+		}
+
+		return isUnconditionalWrite(s.MainStmt)
+	}
+
+	return false
+}
+
+// ForbidKinds returns a guard rejecting statements of the provided kinds.
+// KindUnknown can be used to reject raw SQL statements.
+func ForbidKinds(kinds ...StmtKind) Guard {
+	return func(_ context.Context, stmt SQLStmt) error {
+		kind := KindOf(stmt)
+
+		for _, forbidden := range kinds {
+			if kind == forbidden {
+				if kind == KindUnknown {
+					return errors.New("raw statements are forbidden")
+				}
+
+				return fmt.Errorf("%s statements are forbidden", kind)
+			}
+		}
+
+		return nil
+	}
+}
+
+// checkStmt validates the provided statement (see Validate) and calls the
+// provided guards with it, returning a *ValidationError if it is invalid,
+// or a PolicyError for the first guard that rejects it
+func checkStmt(ctx context.Context, guards []Guard, stmt SQLStmt) error {
+	if err := Validate(stmt); err != nil {
+		return err
+	}
+
+	for _, guard := range guards {
+		if err := guard(ctx, stmt); err != nil {
+			return &PolicyError{Stmt: stmt, Err: err}
+		}
+	}
+
+	return nil
+}
+
+// guard validates the statement and checks it against its guards, passing
+// the error to the statement's error handlers if it is rejected
+func (stmt *SelectStmt) guard(ctx context.Context) error {
+	err := checkStmt(ctx, stmt.defaults.Guards, stmt)
+	if err != nil {
+		stmt.HandleError(err)
+	}
+
+	return err
+}
+
+// guard validates the statement and checks it against its guards, passing
+// the error to the statement's error handlers if it is rejected
+func (stmt *InsertStmt) guard(ctx context.Context) error {
+	err := stmt.err
+	if err == nil {
+		err = checkStmt(ctx, stmt.guards, stmt)
+	}
+
+	if err != nil {
+		stmt.HandleError(err)
+	}
+
+	return err
+}
+
+// guard validates the statement and checks it against its guards, passing
+// the error to the statement's error handlers if it is rejected
+func (stmt *UpdateStmt) guard(ctx context.Context) error {
+	err := checkStmt(ctx, stmt.guards, stmt)
+	if err != nil {
+		stmt.HandleError(err)
+	}
+
+	return err
+}
+
+// guard validates the statement and checks it against its guards, passing
+// the error to the statement's error handlers if it is rejected
+func (stmt *DeleteStmt) guard(ctx context.Context) error {
+	err := checkStmt(ctx, stmt.guards, stmt)
+	if err != nil {
+		stmt.HandleError(err)
+	}
+
+	return err
+}
+
+// guard validates the statement and checks it against its guards, passing
+// the error to the statement's error handlers if it is rejected
+func (stmt *RawStmt) guard(ctx context.Context) error {
+	err := checkStmt(ctx, stmt.guards, stmt)
+	if err != nil {
+		stmt.HandleError(err)
+	}
+
+	return err
+}
@@ -0,0 +1,145 @@
+package sqlz
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestGuards(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	var handled []error
+
+	dbz := New(db, "postgres", func(err error) {
+		if err != nil {
+			handled = append(handled, err)
+		}
+	})
+	dbz.Defaults.Guards = []Guard{
+		ForbidUnconditionalWrites(),
+		ForbidKinds(KindUnknown, KindSet),
+		func(_ context.Context, stmt SQLStmt) error {
+			for _, table := range TablesOf(stmt) {
+				if table == "secrets" {
+					return errors.New("access to secrets is forbidden")
+				}
+			}
+
+			return nil
+		},
+	}
+
+	mock.ExpectExec(`DELETE FROM users WHERE id = \$1`).
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT \* FROM users`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	if _, err := dbz.DeleteFrom("users").Where(Eq("id", 1)).Exec(); err != nil {
+		t.Fatalf("Delete failed: %s", err)
+	}
+
+	if _, err := dbz.Select("*").From("users").GetAllAsMaps(); err != nil {
+		t.Fatalf("Select failed: %s", err)
+	}
+
+	tests := []struct {
+		name string
+		exec func() error
+		msg  string
+	}{
+		{
+			"delete without conditions",
+			func() error {
+				_, err := dbz.DeleteFrom("users").Exec()
+				return err
+			},
+			"must have conditions",
+		},
+		{
+			"update without conditions",
+			func() error {
+				_, err := dbz.Update("users").Set("name", "Bob").Exec()
+				return err
+			},
+			"must have conditions",
+		},
+		{
+			"update without conditions in WITH",
+			func() error {
+				_, err := dbz.With(dbz.Update("users").Set("name", "Bob").Returning("id"), "updated").
+					Then(dbz.Select("*").From("updated")).
+					Exec()
+				return err
+			},
+			"must have conditions",
+		},
+		{
+			"raw SQL",
+			func() error {
+				_, err := dbz.Raw("DROP TABLE users").Exec()
+				return err
+			},
+			"raw statements are forbidden",
+		},
+		{
+			"direct SQL",
+			func() error {
+				_, err := dbz.ExecDirect("DROP TABLE users")
+				return err
+			},
+			"raw statements are forbidden",
+		},
+		{
+			"direct query",
+			func() error {
+				var count int64
+				return dbz.GetDirect(&count, "SELECT COUNT(*) FROM users")
+			},
+			"raw statements are forbidden",
+		},
+		{
+			"SET command",
+			func() error {
+				_, err := dbz.Set("search_path", "evil").Exec()
+				return err
+			},
+			"SET statements are forbidden",
+		},
+		{
+			"forbidden table in join",
+			func() error {
+				var ids []int64
+				return dbz.Select("id").From("users u").InnerJoin("secrets s", Eq("s.id", Indirect("u.id"))).GetAll(&ids)
+			},
+			"access to secrets",
+		},
+	}
+
+	for _, test := range tests {
+		err := test.exec()
+		if err == nil {
+			t.Errorf("%s: expected statement to be rejected", test.name)
+			continue
+		}
+
+		if !IsPolicyError(err) || !strings.Contains(err.Error(), test.msg) {
+			t.Errorf("%s: unexpected error %q", test.name, err)
+		}
+	}
+
+	if len(handled) != len(tests) {
+		t.Errorf("Expected %d errors to be handled, got %d", len(tests), len(handled))
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %s", err)
+	}
+}
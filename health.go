@@ -0,0 +1,66 @@
+package sqlz
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// DefaultHealthCheckTimeout is the timeout used by HealthCheck when the
+// provided context has no deadline of its own
+var DefaultHealthCheckTimeout = 5 * time.Second
+
+// HealthCheck verifies the database is reachable and able to execute
+// queries, by pinging it and issuing a trivial query appropriate for its
+// dialect (e.g. "SELECT 1", or "SELECT 1 FROM DUAL" for Oracle). If the
+// context has no deadline, DefaultHealthCheckTimeout is used. This is
+// useful for implementing readiness probes.
+func (db *DB) HealthCheck(ctx context.Context) (err error) {
+	if _, ok := ctx.Deadline(); !ok && DefaultHealthCheckTimeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, DefaultHealthCheckTimeout)
+		defer cancel()
+	}
+
+	err = db.PingContext(ctx)
+	if err != nil {
+		err = fmt.Errorf("database ping failed: %w", err)
+		db.handleError(err)
+
+		return err
+	}
+
+	var one int
+
+	err = db.QueryRowxContext(ctx, healthQuery(dialectOf(db.ext()))).Scan(&one)
+	if err != nil {
+		err = fmt.Errorf("database health query failed: %w", err)
+		db.handleError(err)
+
+		return err
+	}
+
+	return nil
+}
+
+// PoolStats returns statistics about the database's connection pool
+func (db *DB) PoolStats() sql.DBStats {
+	return db.Stats()
+}
+
+// handleError calls the DB's error handlers with the provided error
+func (db *DB) handleError(err error) {
+	(&Statement{db.ErrHandlers}).HandleError(err)
+}
+
+// healthQuery returns the query used by HealthCheck for the provided
+// dialect
+func healthQuery(dialect Dialect) string {
+	if dialect == DialectOracle {
+		return "SELECT 1 FROM DUAL"
+	}
+
+	return "SELECT 1"
+}
@@ -0,0 +1,54 @@
+package sqlz
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestHealthCheck(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	var handled error
+
+	dbz := New(db, "sqlmock", func(err error) { handled = err })
+	errDown := errors.New("down")
+
+	mock.ExpectQuery("SELECT 1").WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectQuery("SELECT 1").WillReturnError(errDown)
+
+	if err := dbz.HealthCheck(context.Background()); err != nil {
+		t.Errorf("Expected health check to succeed, got %s", err)
+	}
+
+	if err := dbz.HealthCheck(context.Background()); !errors.Is(err, errDown) {
+		t.Errorf("Expected health check to fail, got %v", err)
+	}
+
+	if !errors.Is(handled, errDown) {
+		t.Errorf("Expected error handlers to be called, got %v", handled)
+	}
+
+	if stats := dbz.PoolStats(); stats.OpenConnections != 1 {
+		t.Errorf("Expected 1 open connection, got %d", stats.OpenConnections)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %s", err)
+	}
+}
+
+func TestHealthQuery(t *testing.T) {
+	if q := healthQuery(DialectOf("godror")); q != "SELECT 1 FROM DUAL" {
+		t.Errorf("Unexpected Oracle health query %q", q)
+	}
+
+	if q := healthQuery(DialectPostgres); q != "SELECT 1" {
+		t.Errorf("Unexpected PostgreSQL health query %q", q)
+	}
+}
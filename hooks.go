@@ -0,0 +1,170 @@
+package sqlz
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// QueryEvent describes an executed query
+type QueryEvent struct {
+	// SQL is the query, with placeholders rebound for the database driver
+	SQL string
+	// Bindings are the values bound to the query's placeholders
+	Bindings []interface{}
+	// Duration is the time it took to execute the query. For queries
+	// returning rows, it does not include the time spent iterating them.
+	Duration time.Duration
+	// Err is the error returned by the execution, if it failed
+	Err error
+}
+
+// QueryHook is a function called after every query executed by statements
+// created from a DB or Tx (see DB.OnQuery), whether it succeeded or not.
+// Hooks allow plugging in structured logging, metrics and slow query
+// detection without wrapping every call site. The context is the one the
+// query was executed with, so it can be used to read the query's name and
+// tags (see QueryName and Tags).
+type QueryHook func(ctx context.Context, event QueryEvent)
+
+// OnQuery registers a hook called after every query executed by statements
+// subsequently created from the DB, and from transactions started from it.
+// It is safe to call while the DB is used concurrently.
+func (db *DB) OnQuery(hook QueryHook) {
+	db.Configure(func(defaults *Defaults) {
+		defaults.QueryHooks = append(defaults.QueryHooks, hook)
+	})
+}
+
+// OnQuery registers a hook called after every query executed by statements
+// subsequently created from the Tx, without affecting the DB the
+// transaction was started from
+func (tx *Tx) OnQuery(hook QueryHook) {
+	tx.Configure(func(defaults *Defaults) {
+		defaults.QueryHooks = append(defaults.QueryHooks, hook)
+	})
+}
+
+// ext returns the execer used by statements created from the DB
+func (db *DB) ext() Ext {
+	defaults := db.defaults()
+	return withHooks(withDialect(&dbExt{db.DB, db}, defaults.Dialect), defaults.QueryHooks)
+}
+
+// ext returns the execer used by statements created from the Tx
+func (tx *Tx) ext() Ext {
+	defaults := tx.defaults()
+	return withHooks(withDialect(&txExt{tx.Tx, tx}, defaults.Dialect), defaults.QueryHooks)
+}
+
+// dbExt is the execer of statements created from a DB. It remembers the
+// DB, so that transactions started on behalf of statements (see
+// inTransaction) get its defaults and error handlers.
+type dbExt struct {
+	*sqlx.DB
+	db *DB
+}
+
+// txExt is the execer of statements created from a Tx. It remembers the
+// Tx, so that savepoints created on behalf of statements (see
+// inTransaction) get its defaults and error handlers.
+type txExt struct {
+	*sqlx.Tx
+	tx *Tx
+}
+
+// withHooks wraps the provided execer so that the provided hooks are called
+// after every query it executes. If there are no hooks, the execer is
+// returned as is.
+func withHooks(execer Ext, hooks []QueryHook) Ext {
+	if len(hooks) == 0 {
+		return execer
+	}
+
+	return &hookedExt{Ext: execer, hooks: hooks}
+}
+
+// hookedExt is an execer that calls query hooks after every query. It also
+// exposes the driver name and placeholder rebinding of the wrapped execer.
+type hookedExt struct {
+	Ext
+	hooks []QueryHook
+}
+
+func (h *hookedExt) notify(ctx context.Context, query string, args []interface{}, start time.Time, err error) {
+	event := QueryEvent{
+		SQL:      query,
+		Bindings: args,
+		Duration: time.Since(start),
+		Err:      err,
+	}
+
+	for _, hook := range h.hooks {
+		hook(ctx, event)
+	}
+}
+
+// DriverName returns the driver name of the wrapped execer
+func (h *hookedExt) DriverName() string {
+	return driverName(h.Ext)
+}
+
+// Dialect returns the dialect of the wrapped execer
+func (h *hookedExt) Dialect() Dialect {
+	return dialectOf(h.Ext)
+}
+
+// Rebind rebinds placeholders for the driver of the wrapped execer
+func (h *hookedExt) Rebind(query string) string {
+	return rebindFor(h.Ext, query)
+}
+
+func (h *hookedExt) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return h.QueryContext(context.Background(), query, args...)
+}
+
+func (h *hookedExt) Queryx(query string, args ...interface{}) (*sqlx.Rows, error) {
+	return h.QueryxContext(context.Background(), query, args...)
+}
+
+func (h *hookedExt) QueryRowx(query string, args ...interface{}) *sqlx.Row {
+	return h.QueryRowxContext(context.Background(), query, args...)
+}
+
+func (h *hookedExt) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return h.ExecContext(context.Background(), query, args...)
+}
+
+func (h *hookedExt) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	start := time.Now()
+	rows, err := h.Ext.QueryContext(ctx, query, args...)
+	h.notify(ctx, query, args, start, err)
+
+	return rows, err
+}
+
+func (h *hookedExt) QueryxContext(ctx context.Context, query string, args ...interface{}) (*sqlx.Rows, error) {
+	start := time.Now()
+	rows, err := h.Ext.QueryxContext(ctx, query, args...)
+	h.notify(ctx, query, args, start, err)
+
+	return rows, err
+}
+
+func (h *hookedExt) QueryRowxContext(ctx context.Context, query string, args ...interface{}) *sqlx.Row {
+	start := time.Now()
+	row := h.Ext.QueryRowxContext(ctx, query, args...)
+	h.notify(ctx, query, args, start, row.Err())
+
+	return row
+}
+
+func (h *hookedExt) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	start := time.Now()
+	res, err := h.Ext.ExecContext(ctx, query, args...)
+	h.notify(ctx, query, args, start, err)
+
+	return res, err
+}
@@ -0,0 +1,91 @@
+package sqlz
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestQueryHooks(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	var (
+		events []QueryEvent
+		names  []string
+	)
+
+	dbz := New(db, "postgres")
+	dbz.OnQuery(func(ctx context.Context, event QueryEvent) {
+		events = append(events, event)
+		names = append(names, QueryName(ctx))
+	})
+
+	mock.ExpectExec(`INSERT INTO users \(name\) VALUES \(\$1\)`).
+		WithArgs("Alice").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(`SELECT name FROM users WHERE id = \$1`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("Alice"))
+	mock.ExpectQuery(`SELECT \* FROM users`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM users WHERE id = \$1`).
+		WithArgs(2).
+		WillReturnError(errors.New("failed"))
+	mock.ExpectRollback()
+
+	if _, err := dbz.InsertInto("users").Columns("name").Values("Alice").Exec(); err != nil {
+		t.Fatalf("Insert failed: %s", err)
+	}
+
+	var name string
+
+	ctx := WithQueryName(context.Background(), "get-user")
+	if err := dbz.Select("name").From("users").Where(Eq("id", 1)).GetRowContext(ctx, &name); err != nil {
+		t.Fatalf("Select failed: %s", err)
+	}
+
+	if _, err := dbz.Select("*").From("users").GetAllAsMaps(); err != nil {
+		t.Fatalf("Select failed: %s", err)
+	}
+
+	err = dbz.Transactional(func(tx *Tx) error {
+		_, err := tx.DeleteFrom("users").Where(Eq("id", 2)).Exec()
+		return err
+	})
+	if err == nil {
+		t.Fatal("Expected delete to fail")
+	}
+
+	if len(events) != 4 {
+		t.Fatalf("Expected 4 events, got %d", len(events))
+	}
+
+	if events[0].SQL != "INSERT INTO users (name) VALUES ($1)" ||
+		!reflect.DeepEqual(events[0].Bindings, []interface{}{"Alice"}) ||
+		events[0].Err != nil {
+		t.Errorf("Unexpected insert event: %+v", events[0])
+	}
+
+	if names[1] != "get-user" {
+		t.Errorf("Expected hook to receive the query's context, got name %q", names[1])
+	}
+
+	if events[2].SQL != "SELECT * FROM users" {
+		t.Errorf("Unexpected select event: %+v", events[2])
+	}
+
+	if events[3].Err == nil {
+		t.Errorf("Expected delete event to have an error")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %s", err)
+	}
+}
@@ -3,8 +3,10 @@ package sqlz
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 )
@@ -21,14 +23,34 @@ type InsertStmt struct {
 	Conflicts       []*ConflictClause
 	execer          Ext
 	sqliteConflict  string
+	timeout         time.Duration
+	listeners       []WriteListener
+	guards          []Guard
+	audit           *Audit
+	codecs          Codecs
+	filters         Filters
+	emptyIn         EmptyInPolicy
+	largeIn         LargeIn
+	returnInto      interface{}
+	err             error
 }
 
 // InsertInto creates a new InsertStmt object for the
 // provided table
 func (db *DB) InsertInto(table string) *InsertStmt {
+	defaults := db.defaults()
+
 	return &InsertStmt{
 		Table:     table,
-		execer:    db.DB,
+		Return:    defaults.returning(),
+		execer:    db.ext(),
+		listeners: defaults.WriteListeners,
+		guards:    defaults.Guards,
+		audit:     defaults.Audit,
+		codecs:    defaults.Codecs,
+		filters:   defaults.Filters,
+		emptyIn:   defaults.EmptyIn,
+		largeIn:   defaults.LargeIn,
 		Statement: &Statement{db.ErrHandlers},
 	}
 }
@@ -36,13 +58,48 @@ func (db *DB) InsertInto(table string) *InsertStmt {
 // InsertInto creates a new InsertStmt object for the
 // provided table
 func (tx *Tx) InsertInto(table string) *InsertStmt {
+	defaults := tx.defaults()
+
 	return &InsertStmt{
 		Table:     table,
-		execer:    tx.Tx,
+		Return:    defaults.returning(),
+		execer:    tx.ext(),
+		listeners: defaults.WriteListeners,
+		guards:    defaults.Guards,
+		audit:     defaults.Audit,
+		codecs:    defaults.Codecs,
+		filters:   defaults.Filters,
+		emptyIn:   defaults.EmptyIn,
+		largeIn:   defaults.LargeIn,
 		Statement: &Statement{tx.ErrHandlers},
 	}
 }
 
+// WithExecer sets the execer the statement is executed with, overriding the DB
+// or Tx it was created from. This allows a statement built once to be
+// executed against a different transaction or database (e.g. a replica).
+// Both *DB and *Tx objects can be provided.
+func (stmt *InsertStmt) WithExecer(e Ext) *InsertStmt {
+	stmt.execer = e
+	return stmt
+}
+
+// WithTimeout sets a timeout for executing the statement. Every execution
+// of the statement derives a context with this timeout (from the provided
+// context, if any), so the query is cancelled if it runs for too long. To
+// also enforce the timeout on the server inside a transaction, see
+// Tx.SetTimeout.
+func (stmt *InsertStmt) WithTimeout(d time.Duration) *InsertStmt {
+	stmt.timeout = d
+	return stmt
+}
+
+// conditionEnv returns the environment the conditions of the statement's
+// conflict clauses are rendered in
+func (stmt *InsertStmt) conditionEnv() conditionEnv {
+	return conditionEnv{dialectOf(stmt.execer), stmt.filters, stmt.emptyIn, stmt.largeIn}
+}
+
 // Columns defines the columns to insert. It can be safely
 // used alongside ValueMap in the same query, provided Values
 // is used immediately after Columns
@@ -74,6 +131,51 @@ func (stmt *InsertStmt) ValueMultiple(vals [][]interface{}) *InsertStmt {
 	return stmt
 }
 
+// ValueStructs receives a slice of structs (or pointers to structs), and
+// sets the columns mapped from their fields (via `db` tags, like sqlx does)
+// as the statement's columns, and the values of their fields as the rows
+// to insert, replacing any columns set previously. Column filters (e.g.
+// OnlyColumns and ExceptColumns) can be provided to restrict the columns
+// that are inserted, e.g. to leave out identity columns. Large slices can
+// be inserted with ExecBatched. If the value is not a slice of structs,
+// the error is returned when the statement is executed.
+func (stmt *InsertStmt) ValueStructs(slice interface{}, filters ...ColumnFilter) *InsertStmt {
+	cols, rows, err := structRows(slice)
+	if err != nil {
+		stmt.err = fmt.Errorf("%w: ValueStructs received %T", err, slice)
+		return stmt
+	}
+
+	var include []int
+
+COLUMNS:
+	for i, col := range cols {
+		for _, filter := range filters {
+			if !filter(col) {
+				continue COLUMNS
+			}
+		}
+
+		include = append(include, i)
+	}
+
+	stmt.InsCols = make([]string, len(include))
+	for i, index := range include {
+		stmt.InsCols[i] = cols[index]
+	}
+
+	for _, row := range rows {
+		vals := make([]interface{}, len(include))
+		for i, index := range include {
+			vals[i] = row[index]
+		}
+
+		stmt.InsMultipleVals = append(stmt.InsMultipleVals, vals)
+	}
+
+	return stmt
+}
+
 // FromSelect sets a SELECT statements that will supply the rows to be inserted.
 func (stmt *InsertStmt) FromSelect(selStmt *SelectStmt) *InsertStmt {
 	stmt.SelectStmt = selStmt
@@ -89,6 +191,32 @@ func (stmt *InsertStmt) Returning(cols ...string) *InsertStmt {
 	return stmt
 }
 
+// ReturningStruct sets a RETURNING clause with the columns the provided
+// struct maps to (see ColumnsOf), replacing any columns set previously,
+// and makes Exec load the returned values into it. A pointer to a slice
+// of structs can be provided if the INSERT statement affects multiple rows.
+func (stmt *InsertStmt) ReturningStruct(into interface{}) *InsertStmt {
+	stmt.Return = ColumnsOf(into, "")
+	stmt.returnInto = into
+
+	return stmt
+}
+
+// ReturningInserted adds a boolean column to the RETURNING clause that is
+// true for rows that were inserted by the statement, and false for rows
+// that were updated by an ON CONFLICT DO UPDATE clause. The column is
+// named with the provided alias, or "inserted" if the alias is empty.
+// This relies on PostgreSQL's xmax system column being 0 for newly
+// inserted rows, i.e. "(xmax = 0) AS inserted", and is only supported by
+// PostgreSQL.
+func (stmt *InsertStmt) ReturningInserted(as string) *InsertStmt {
+	if as == "" {
+		as = "inserted"
+	}
+
+	return stmt.Returning("(xmax = 0) AS " + as)
+}
+
 // OnConflictDoNothing sets an ON CONFLICT clause on the statement. This method
 // is deprecated in favor of OnConflict.
 func (stmt *InsertStmt) OnConflictDoNothing() *InsertStmt {
@@ -167,7 +295,7 @@ func (stmt *InsertStmt) ToSQL(rebind bool) (asSQL string, bindings []interface{}
 	}
 
 	for _, conflict := range stmt.Conflicts {
-		conflictSQL, conflictBindings := conflict.ToSQL()
+		conflictSQL, conflictBindings := conflict.toSQL(stmt.conditionEnv())
 		clauses = append(clauses, conflictSQL)
 		bindings = append(bindings, conflictBindings...)
 	}
@@ -179,11 +307,7 @@ func (stmt *InsertStmt) ToSQL(rebind bool) (asSQL string, bindings []interface{}
 	asSQL = strings.Join(clauses, " ")
 
 	if rebind {
-		if db, ok := stmt.execer.(*sqlx.DB); ok {
-			asSQL = db.Rebind(asSQL)
-		} else if tx, ok := stmt.execer.(*sqlx.Tx); ok {
-			asSQL = tx.Rebind(asSQL)
-		}
+		asSQL = rebindFor(stmt.execer, asSQL)
 	}
 
 	return asSQL, bindings
@@ -192,20 +316,34 @@ func (stmt *InsertStmt) ToSQL(rebind bool) (asSQL string, bindings []interface{}
 // Exec executes the INSERT statement, returning the standard
 // sql.Result struct and an error if the query failed.
 func (stmt *InsertStmt) Exec() (res sql.Result, err error) {
-	asSQL, bindings := stmt.ToSQL(true)
-	res, err = stmt.execer.Exec(asSQL, bindings...)
-	stmt.Statement.HandleError(err)
-
-	return res, err
+	return stmt.ExecContext(context.Background())
 }
 
 // ExecContext executes the INSERT statement, returning the standard
 // sql.Result struct and an error if the query failed.
 func (stmt *InsertStmt) ExecContext(ctx context.Context) (res sql.Result, err error) {
-	asSQL, bindings := stmt.ToSQL(true)
+	if stmt.returnInto != nil {
+		return loadReturning(ctx, stmt, stmt.returnInto)
+	}
+
+	ctx, cancel := contextWithTimeout(ctx, stmt.timeout)
+	defer cancel()
+
+	if err := stmt.guard(ctx); err != nil {
+		return nil, err
+	}
+
+	prepared, err := stmt.prepared(ctx)
+	if err != nil {
+		stmt.Statement.HandleError(err)
+		return nil, err
+	}
+
+	asSQL, bindings := prepared.ToSQL(true)
 
 	res, err = stmt.execer.ExecContext(ctx, asSQL, bindings...)
 	stmt.Statement.HandleError(err)
+	notifyWrite(ctx, stmt.listeners, stmt, stmt.writtenTables(), err)
 
 	return res, err
 }
@@ -216,9 +354,7 @@ func (stmt *InsertStmt) ExecContext(ctx context.Context) (res sql.Result, err er
 // only one column is returned, or a struct if multiple columns
 // are returned)
 func (stmt *InsertStmt) GetRow(into interface{}) error {
-	asSQL, bindings := stmt.ToSQL(true)
-
-	return sqlx.Get(stmt.execer, into, asSQL, bindings...)
+	return stmt.GetRowContext(context.Background(), into)
 }
 
 // GetRowContext executes an INSERT statement with a RETURNING clause
@@ -227,25 +363,225 @@ func (stmt *InsertStmt) GetRow(into interface{}) error {
 // only one column is returned, or a struct if multiple columns
 // are returned)
 func (stmt *InsertStmt) GetRowContext(ctx context.Context, into interface{}) error {
-	asSQL, bindings := stmt.ToSQL(true)
+	ctx, cancel := contextWithTimeout(ctx, stmt.timeout)
+	defer cancel()
+
+	if err := stmt.guard(ctx); err != nil {
+		return err
+	}
 
-	return sqlx.GetContext(ctx, stmt.execer, into, asSQL, bindings...)
+	prepared, err := stmt.prepared(ctx)
+	if err != nil {
+		stmt.Statement.HandleError(err)
+		return err
+	}
+
+	asSQL, bindings := prepared.ToSQL(true)
+
+	err = sqlx.GetContext(ctx, stmt.execer, into, asSQL, bindings...)
+	notifyWrite(ctx, stmt.listeners, stmt, stmt.writtenTables(), err)
+
+	return err
 }
 
 // GetAll executes an INSERT statement with a RETURNING clause
 // expected to return multiple rows, and loads the result into
 // the provided slice variable
 func (stmt *InsertStmt) GetAll(into interface{}) error {
-	asSQL, bindings := stmt.ToSQL(true)
-	return sqlx.Select(stmt.execer, into, asSQL, bindings...)
+	return stmt.GetAllContext(context.Background(), into)
 }
 
 // GetAllContext executes an INSERT statement with a RETURNING clause
 // expected to return multiple rows, and loads the result into
 // the provided slice variable
 func (stmt *InsertStmt) GetAllContext(ctx context.Context, into interface{}) error {
-	asSQL, bindings := stmt.ToSQL(true)
-	return sqlx.SelectContext(ctx, stmt.execer, into, asSQL, bindings...)
+	ctx, cancel := contextWithTimeout(ctx, stmt.timeout)
+	defer cancel()
+
+	if err := stmt.guard(ctx); err != nil {
+		return err
+	}
+
+	prepared, err := stmt.prepared(ctx)
+	if err != nil {
+		stmt.Statement.HandleError(err)
+		return err
+	}
+
+	asSQL, bindings := prepared.ToSQL(true)
+
+	err = sqlx.SelectContext(ctx, stmt.execer, into, asSQL, bindings...)
+	notifyWrite(ctx, stmt.listeners, stmt, stmt.writtenTables(), err)
+
+	return err
+}
+
+// Rows executes an INSERT statement with a RETURNING clause and returns a
+// Rows object to use for iteration, so that large sets of returned rows
+// can be streamed rather than loaded into a slice. It is the caller's
+// responsibility to close the cursor with Close(), which also releases the
+// statement's timeout (see WithTimeout).
+func (stmt *InsertStmt) Rows() (rows *Rows, err error) {
+	return stmt.RowsContext(context.Background())
+}
+
+// RowsContext is like Rows, but uses the provided context
+func (stmt *InsertStmt) RowsContext(ctx context.Context) (rows *Rows, err error) {
+	if err := stmt.guard(ctx); err != nil {
+		return nil, err
+	}
+
+	prepared, err := stmt.prepared(ctx)
+	if err != nil {
+		stmt.HandleError(err)
+		return nil, err
+	}
+
+	asSQL, bindings := prepared.ToSQL(true)
+
+	rows, err = queryRows(ctx, stmt.timeout, stmt.execer, asSQL, bindings)
+	stmt.HandleError(err)
+	notifyWrite(ctx, stmt.listeners, stmt, stmt.writtenTables(), err)
+
+	return rows, err
+}
+
+// GetAllAsRows executes an INSERT statement with a RETURNING clause and
+// returns an sqlx.Rows object to use for iteration. It is the caller's
+// responsibility to close the cursor with Close(). The context derived for
+// the statement's timeout (see WithTimeout) is only released once the
+// timeout expires; use Rows to release it when the cursor is closed.
+func (stmt *InsertStmt) GetAllAsRows() (rows *sqlx.Rows, err error) {
+	return stmt.GetAllAsRowsContext(context.Background())
+}
+
+// GetAllAsRowsContext is like GetAllAsRows, but uses the provided context
+func (stmt *InsertStmt) GetAllAsRowsContext(ctx context.Context) (rows *sqlx.Rows, err error) {
+	cursor, err := stmt.RowsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return cursor.Rows, nil
+}
+
+// ExecBatched executes an INSERT statement with multiple rows (see
+// ValueMultiple) in batches of at most batchSize rows, so that the number
+// of bindings in every statement does not exceed the limit of the database
+// (65535 in PostgreSQL, 999 in older versions of SQLite), which fails
+// statements with an unhelpful error. The batch size is lowered if needed
+// to fit the limit; a batch size of zero uses the largest size that fits.
+//
+// If the statement was created from (or set to execute with) a DB, all
+// batches are executed inside one transaction; if it was created from a
+// Tx, they are executed inside it. The returned result aggregates the
+// results of all batches: RowsAffected is their sum, and LastInsertId is
+// that of the last batch. Statements with ReturningStruct cannot be
+// executed in batches.
+func (stmt *InsertStmt) ExecBatched(batchSize int) (res sql.Result, err error) {
+	return stmt.ExecBatchedContext(context.Background(), batchSize)
+}
+
+// ExecBatchedContext is like ExecBatched, but uses the provided context
+func (stmt *InsertStmt) ExecBatchedContext(ctx context.Context, batchSize int) (res sql.Result, err error) {
+	if stmt.returnInto != nil {
+		err = fmt.Errorf("%w: ExecBatched cannot load returned rows into a struct", ErrInvalidStatement)
+		stmt.HandleError(err)
+
+		return nil, err
+	}
+
+	rows := stmt.InsMultipleVals
+	if len(rows) == 0 {
+		return stmt.ExecContext(ctx)
+	}
+
+	// the column count is taken from the statement as it is executed, as
+	// audit columns add bindings to every row
+	prepared, err := stmt.prepared(ctx)
+	if err != nil {
+		stmt.HandleError(err)
+		return nil, err
+	}
+
+	numCols := len(prepared.InsCols)
+	if numCols == 0 {
+		numCols = len(prepared.InsMultipleVals[0])
+	}
+
+	// conflict clauses add the same bindings to every batch
+	limit := maxBindingsFor(dialectOf(stmt.execer))
+	for _, conflict := range prepared.Conflicts {
+		_, bindings := conflict.toSQL(prepared.conditionEnv())
+		limit -= len(bindings)
+	}
+
+	size := batchSizeWithin(batchSize, numCols, limit)
+
+	var results batchResult
+
+	run := func(tx *Tx) error {
+		for start := 0; start < len(rows); start += size {
+			end := start + size
+			if end > len(rows) {
+				end = len(rows)
+			}
+
+			batch := *stmt
+			batch.InsMultipleVals = rows[start:end]
+
+			if tx != nil {
+				batch.execer = tx.ext()
+			}
+
+			res, err := batch.ExecContext(ctx)
+			if err != nil {
+				return err
+			}
+
+			results = append(results, res)
+		}
+
+		return nil
+	}
+
+	err = inTransaction(ctx, stmt.execer, run)
+	if errors.Is(err, errNoTransaction) {
+		err = run(nil)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// batchResult aggregates the results of the statements executed by
+// ExecBatched
+type batchResult []sql.Result
+
+// LastInsertId returns the ID of the last row inserted by the last batch
+func (results batchResult) LastInsertId() (int64, error) {
+	if len(results) == 0 {
+		return 0, nil
+	}
+
+	return results[len(results)-1].LastInsertId()
+}
+
+// RowsAffected returns the total number of rows affected by all batches
+func (results batchResult) RowsAffected() (affected int64, err error) {
+	for _, res := range results {
+		n, err := res.RowsAffected()
+		if err != nil {
+			return affected, err
+		}
+
+		affected += n
+	}
+
+	return affected, nil
 }
 
 // ConflictAction represents an action to perform on an INSERT conflict
@@ -261,11 +597,13 @@ const (
 
 // ConflictClause represents an ON CONFLICT clause in an INSERT statement
 type ConflictClause struct {
-	Targets []string
-	Action  ConflictAction
-	SetCols []string
-	SetVals []interface{}
-	Updates map[string]interface{}
+	Targets    []string
+	Constraint string
+	Action     ConflictAction
+	SetCols    []string
+	SetVals    []interface{}
+	Updates    map[string]interface{}
+	Conditions []WhereCondition
 }
 
 // OnConflict gets a list of targets and creates a new ConflictClause object
@@ -275,6 +613,15 @@ func OnConflict(targets ...string) *ConflictClause {
 	}
 }
 
+// OnConflictConstraint creates a new ConflictClause object whose conflict
+// target is the provided named constraint (i.e. ON CONFLICT ON CONSTRAINT
+// name), rather than a list of columns
+func OnConflictConstraint(name string) *ConflictClause {
+	return &ConflictClause{
+		Constraint: name,
+	}
+}
+
 // DoNothing sets the conflict clause's action as DO NOTHING
 func (conflict *ConflictClause) DoNothing() *ConflictClause {
 	conflict.Action = DoNothing
@@ -325,10 +672,37 @@ func (conflict *ConflictClause) SetIf(col string, val interface{}, b bool) *Conf
 	return conflict
 }
 
+// SetExcluded adds columns to update with the values proposed for
+// insertion, i.e. col = EXCLUDED.col for every column
+func (conflict *ConflictClause) SetExcluded(cols ...string) *ConflictClause {
+	for _, col := range cols {
+		conflict.SetIf(col, Indirect("EXCLUDED."+col), true)
+	}
+
+	return conflict
+}
+
+// Where adds conditions to the DO UPDATE action, so that conflicting rows
+// are only updated if they match the conditions (i.e. DO UPDATE SET ...
+// WHERE ...). If multiple conditions are passed, they are considered AND
+// conditions.
+func (conflict *ConflictClause) Where(conds ...WhereCondition) *ConflictClause {
+	conflict.Conditions = append(conflict.Conditions, conds...)
+	return conflict
+}
+
 // ToSQL generates the SQL code for the conflict clause
 func (conflict *ConflictClause) ToSQL() (asSQL string, bindings []interface{}) {
+	return conflict.toSQL(conditionEnv{})
+}
+
+// toSQL generates the SQL code for the conflict clause, rendering its
+// conditions in the provided environment
+func (conflict *ConflictClause) toSQL(env conditionEnv) (asSQL string, bindings []interface{}) {
 	words := []string{"ON CONFLICT"}
-	if len(conflict.Targets) > 0 {
+	if conflict.Constraint != "" {
+		words = append(words, "ON CONSTRAINT "+conflict.Constraint)
+	} else if len(conflict.Targets) > 0 {
 		words = append(words, "("+strings.Join(conflict.Targets, ", ")+")")
 	}
 
@@ -366,6 +740,12 @@ func (conflict *ConflictClause) ToSQL() (asSQL string, bindings []interface{}) {
 		}
 
 		words = append(words, strings.Join(updates, ", "))
+
+		if len(conflict.Conditions) > 0 {
+			whereClause, whereBindings := parseConditionsFor(env, conflict.Conditions)
+			words = append(words, "WHERE "+whereClause)
+			bindings = append(bindings, whereBindings...)
+		}
 	}
 
 	return strings.Join(words, " "), bindings
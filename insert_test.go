@@ -1,6 +1,13 @@
 package sqlz
 
-import "testing"
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
 
 func TestInsert(t *testing.T) {
 	runTests(t, func(dbz *DB) []test {
@@ -71,6 +78,46 @@ func TestInsert(t *testing.T) {
 				[]interface{}{"My Name", 55151515, 1},
 			},
 
+			{
+				"upsert returning inserted indicator",
+				dbz.InsertInto("table").Columns("id", "name").Values(1, "My Name").
+					OnConflict(OnConflict("id").DoUpdate().Set("name", Indirect("EXCLUDED.name"))).
+					Returning("id").
+					ReturningInserted(""),
+				"INSERT INTO table (id, name) VALUES (?, ?) ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name RETURNING id, (xmax = 0) AS inserted",
+				[]interface{}{1, "My Name"},
+			},
+
+			{
+				"upsert returning inserted indicator with alias",
+				dbz.InsertInto("table").Columns("id").Values(1).
+					OnConflict(OnConflict("id").DoUpdate().Set("id", Indirect("EXCLUDED.id"))).
+					ReturningInserted("was_inserted"),
+				"INSERT INTO table (id) VALUES (?) ON CONFLICT (id) DO UPDATE SET id = EXCLUDED.id RETURNING (xmax = 0) AS was_inserted",
+				[]interface{}{1},
+			},
+
+			{
+				"upsert with excluded columns and conditional update",
+				dbz.InsertInto("prices").Columns("sku", "price", "updated_at").Values("A1", 10, 1000).
+					OnConflict(
+						OnConflict("sku").
+							DoUpdate().
+							SetExcluded("price", "updated_at").
+							Where(Lt("prices.updated_at", Indirect("EXCLUDED.updated_at")), Ne("prices.locked", true)),
+					),
+				"INSERT INTO prices (sku, price, updated_at) VALUES (?, ?, ?) ON CONFLICT (sku) DO UPDATE SET price = EXCLUDED.price, updated_at = EXCLUDED.updated_at WHERE prices.updated_at < EXCLUDED.updated_at AND prices.locked <> ?",
+				[]interface{}{"A1", 10, 1000, true},
+			},
+
+			{
+				"upsert on a named constraint",
+				dbz.InsertInto("users").Columns("email", "name").Values("a@b.c", "Alice").
+					OnConflict(OnConflictConstraint("users_email_key").DoUpdate().SetExcluded("name")),
+				"INSERT INTO users (email, name) VALUES (?, ?) ON CONFLICT ON CONSTRAINT users_email_key DO UPDATE SET name = EXCLUDED.name",
+				[]interface{}{"a@b.c", "Alice"},
+			},
+
 			{
 				"insert or ignore",
 				dbz.InsertInto("table").OrIgnore().Columns("id", "name", "date").Values(1, "My Name", 96969696),
@@ -91,6 +138,153 @@ func TestInsert(t *testing.T) {
 				"INSERT INTO table (id, name) VALUES (?, ?), (?, ?), (?, ?)",
 				[]interface{}{1, "My Name", 2, "John", 3, "Golang"},
 			},
+			{
+				"insert from a slice of structs",
+				dbz.InsertInto("users").ValueStructs([]upsertRow{
+					{upsertBase{1}, "Alice", "alice@example.com", "x", ""},
+					{upsertBase{2}, "Bob", "bob@example.com", "y", ""},
+				}),
+				"INSERT INTO users (id, name, email) VALUES (?, ?, ?), (?, ?, ?)",
+				[]interface{}{int64(1), "Alice", "alice@example.com", int64(2), "Bob", "bob@example.com"},
+			},
+			{
+				"insert from a slice of struct pointers with filtered columns",
+				dbz.InsertInto("users").ValueStructs([]*upsertRow{{Name: "Alice"}, {Name: "Bob"}}, ExceptColumns("id")),
+				"INSERT INTO users (name, email) VALUES (?, ?), (?, ?)",
+				[]interface{}{"Alice", "", "Bob", ""},
+			},
+		}
+	})
+}
+
+func TestExecBatched(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	dbz := New(db, "postgres")
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO logs \(id, msg\) VALUES \(\$1, \$2\), \(\$3, \$4\) ON CONFLICT DO NOTHING`).
+		WithArgs(1, "a", 2, "b").
+		WillReturnResult(sqlmock.NewResult(2, 2))
+	mock.ExpectExec(`INSERT INTO logs \(id, msg\) VALUES \(\$1, \$2\) ON CONFLICT DO NOTHING`).
+		WithArgs(3, "c").
+		WillReturnResult(sqlmock.NewResult(3, 1))
+	mock.ExpectCommit()
+
+	res, err := dbz.InsertInto("logs").
+		Columns("id", "msg").
+		ValueMultiple([][]interface{}{{1, "a"}, {2, "b"}, {3, "c"}}).
+		OnConflictDoNothing().
+		ExecBatched(2)
+	if err != nil {
+		t.Fatalf("ExecBatched failed: %s", err)
+	}
+
+	if affected, _ := res.RowsAffected(); affected != 3 {
+		t.Errorf("Expected 3 affected rows, got %d", affected)
+	}
+
+	if id, _ := res.LastInsertId(); id != 3 {
+		t.Errorf("Expected last insert ID 3, got %d", id)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO logs \(id, msg\) VALUES \(\$1, \$2\)`).
+		WithArgs(1, "a").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO logs \(id, msg\) VALUES \(\$1, \$2\)`).
+		WithArgs(2, "b").
+		WillReturnError(errors.New("failed"))
+	mock.ExpectRollback()
+
+	_, err = dbz.InsertInto("logs").
+		Columns("id", "msg").
+		ValueMultiple([][]interface{}{{1, "a"}, {2, "b"}}).
+		ExecBatched(1)
+	if err == nil {
+		t.Error("Expected ExecBatched to fail")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %s", err)
+	}
+}
+
+func TestExecBatchedAuditColumns(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	dbz := New(db, "sqlite3")
+	dbz.Defaults.Audit = &Audit{
+		Tables:    []string{"logs"},
+		CreatedAt: "created_at",
+		UpdatedAt: "updated_at",
+		Now:       func() time.Time { return now },
+	}
+
+	var bindings []int
+	dbz.OnQuery(func(ctx context.Context, event QueryEvent) {
+		bindings = append(bindings, len(event.Bindings))
+	})
+
+	// with the two audit columns, every row has four bindings, so at most
+	// 249 rows fit in SQLite's limit of 999 bindings
+	rows := make([][]interface{}, 300)
+	for i := range rows {
+		rows[i] = []interface{}{i, "msg"}
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO logs \(id, msg, created_at, updated_at\) VALUES`).
+		WillReturnResult(sqlmock.NewResult(249, 249))
+	mock.ExpectExec(`INSERT INTO logs \(id, msg, created_at, updated_at\) VALUES`).
+		WillReturnResult(sqlmock.NewResult(300, 51))
+	mock.ExpectCommit()
+
+	_, err = dbz.InsertInto("logs").
+		Columns("id", "msg").
+		ValueMultiple(rows).
+		ExecBatched(0)
+	if err != nil {
+		t.Fatalf("ExecBatched failed: %s", err)
+	}
+
+	if len(bindings) != 2 || bindings[0] != 996 || bindings[1] != 204 {
+		t.Errorf("Expected batches of 996 and 204 bindings, got %v", bindings)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %s", err)
+	}
+}
+
+func TestConflictConditionEnv(t *testing.T) {
+	runTestsWithDriver(t, "postgres", func(dbz *DB) []test {
+		dbz.Configure(func(defaults *Defaults) {
+			defaults.EmptyIn = EmptyInConstant
+			defaults.LargeIn = LargeIn{Threshold: 2, Rewrite: LargeInArray}
+		})
+
+		return []test{
+			{
+				"conflict conditions use the statement's defaults",
+				dbz.InsertInto("prices").Columns("sku", "price").Values("A1", 10).
+					OnConflict(
+						OnConflict("sku").
+							DoUpdate().
+							SetExcluded("price").
+							Where(NotIn("prices.state"), In("prices.region", "eu", "us", "ap")),
+					),
+				"INSERT INTO prices (sku, price) VALUES ($1, $2) ON CONFLICT (sku) DO UPDATE SET price = EXCLUDED.price WHERE TRUE AND prices.region = ANY($3)",
+				[]interface{}{"A1", 10, pgArray{"eu", "us", "ap"}},
+			},
 		}
 	})
 }
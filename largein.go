@@ -0,0 +1,128 @@
+package sqlz
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// LargeIn configures the rewriting of IN and NOT IN conditions with many
+// values (see Defaults.LargeIn). Lists of thousands of placeholders are
+// slow to parse and plan, and may exceed the binding limits of the
+// database, so conditions with more values than the threshold are
+// rewritten into a form that performs better:
+//
+//	dbz.Configure(func(defaults *sqlz.Defaults) {
+//	    defaults.LargeIn = sqlz.LargeIn{Threshold: 100, Rewrite: sqlz.LargeInArray}
+//	})
+type LargeIn struct {
+	// Threshold is the number of values above which conditions are
+	// rewritten. Zero means conditions are never rewritten.
+	Threshold int
+	// Rewrite is how conditions are rewritten
+	Rewrite LargeInRewrite
+}
+
+// LargeInRewrite determines how IN and NOT IN conditions with more values
+// than the threshold of LargeIn are rewritten
+type LargeInRewrite uint8
+
+const (
+	// LargeInValues rewrites conditions to match against a VALUES list,
+	// e.g. "id IN (VALUES (?), (?), ...)", which PostgreSQL executes as a
+	// join rather than a long list of comparisons. It is only applied
+	// in PostgreSQL and SQLite, which support such lists.
+	LargeInValues LargeInRewrite = iota
+	// LargeInArray rewrites conditions to compare against a single array
+	// binding, e.g. "id = ANY(?)" (or "id <> ALL(?)" for NOT IN), so the
+	// query has one placeholder regardless of the number of values. The
+	// array is bound in PostgreSQL's text format. It is only applied in
+	// PostgreSQL.
+	LargeInArray
+)
+
+// rewrite returns the condition to render instead of the provided IN
+// condition in the provided dialect, if it must be rewritten
+func (large LargeIn) rewrite(dialect Dialect, in InCondition) (WhereCondition, bool) {
+	if large.Threshold <= 0 || len(in.Right) <= large.Threshold {
+		return nil, false
+	}
+
+	op := "IN"
+	if in.NotIn {
+		op = "NOT IN"
+	}
+
+	switch {
+	case large.Rewrite == LargeInArray && dialect == DialectPostgres:
+		if in.NotIn {
+			return SQLCond(in.Left+" <> ALL(?)", pgArray(in.Right)), true
+		}
+
+		return SQLCond(in.Left+" = ANY(?)", pgArray(in.Right)), true
+	case large.Rewrite == LargeInValues && (dialect == DialectPostgres || dialect == DialectSQLite):
+		rows := strings.TrimSuffix(strings.Repeat("(?), ", len(in.Right)), ", ")
+		return SQLCond(in.Left+" "+op+" (VALUES "+rows+")", in.Right...), true
+	}
+
+	return nil, false
+}
+
+// pgArray is a list of values bound as a PostgreSQL array, encoded in the
+// array's text format (e.g. {1,2,"a b",NULL}), which PostgreSQL casts to
+// the type of the array expected by the query
+type pgArray []interface{}
+
+// Value implements the driver.Valuer interface
+func (arr pgArray) Value() (driver.Value, error) {
+	elems := make([]string, len(arr))
+
+	for i, val := range arr {
+		elem, err := pgArrayElem(val)
+		if err != nil {
+			return nil, err
+		}
+
+		elems[i] = elem
+	}
+
+	return "{" + strings.Join(elems, ",") + "}", nil
+}
+
+func pgArrayElem(val interface{}) (string, error) {
+	if valuer, ok := val.(driver.Valuer); ok {
+		value, err := valuer.Value()
+		if err != nil {
+			return "", err
+		}
+
+		val = value
+	}
+
+	switch v := val.(type) {
+	case nil:
+		return "NULL", nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprint(v), nil
+	case bool:
+		if v {
+			return "t", nil
+		}
+
+		return "f", nil
+	case time.Time:
+		return quoteArrayElem(v.Format(time.RFC3339Nano)), nil
+	case []byte:
+		return quoteArrayElem(string(v)), nil
+	default:
+		return quoteArrayElem(fmt.Sprint(v)), nil
+	}
+}
+
+func quoteArrayElem(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+
+	return `"` + s + `"`
+}
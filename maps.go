@@ -0,0 +1,114 @@
+package sqlz
+
+import (
+	"database/sql"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timeLayouts is the list of layouts attempted when converting textual
+// date and time values into time.Time values
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999-07",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02",
+	"15:04:05.999999999",
+}
+
+// ColumnInfo describes a column in the result set of a query
+type ColumnInfo struct {
+	// Name is the name of the column
+	Name string
+	// DatabaseType is the name of the column's database type, as reported
+	// by the driver (e.g. "VARCHAR", "INT8"), or an empty string if the
+	// driver does not report it
+	DatabaseType string
+	// Nullable is true if the column may contain NULL values. It is only
+	// meaningful if NullableKnown is true.
+	Nullable bool
+	// NullableKnown is true if the driver reports the nullability of
+	// the column
+	NullableKnown bool
+	// ScanType is the Go type suitable for scanning values of the column,
+	// as reported by the driver
+	ScanType reflect.Type
+}
+
+// columnInfo converts a list of column types into a list of ColumnInfo
+func columnInfo(types []*sql.ColumnType) []ColumnInfo {
+	cols := make([]ColumnInfo, len(types))
+
+	for i, colType := range types {
+		nullable, ok := colType.Nullable()
+		cols[i] = ColumnInfo{
+			Name:          colType.Name(),
+			DatabaseType:  colType.DatabaseTypeName(),
+			Nullable:      nullable,
+			NullableKnown: ok,
+			ScanType:      colType.ScanType(),
+		}
+	}
+
+	return cols
+}
+
+// convertMapTypes converts the values of a map loaded with MapScan into
+// friendly Go types, based on the database types of the columns.
+func convertMapTypes(results map[string]interface{}, types []*sql.ColumnType) {
+	dbTypes := make(map[string]string, len(types))
+	for _, colType := range types {
+		dbTypes[colType.Name()] = colType.DatabaseTypeName()
+	}
+
+	for col, val := range results {
+		results[col] = typedValue(dbTypes[col], val)
+	}
+}
+
+// typedValue converts a value loaded from the database into a friendly Go
+// type based on the database type of its column. Values returned by drivers
+// as byte slices are converted into int64, float64, bool or time.Time values
+// if the database type is numeric, boolean or temporal, respectively, and
+// into strings otherwise (unless the database type is binary). Values of
+// other types, and values that cannot be parsed, are returned as-is.
+func typedValue(dbType string, val interface{}) interface{} {
+	b, isBytes := val.([]byte)
+	if !isBytes {
+		return val
+	}
+
+	str := string(b)
+
+	switch dbType = strings.ToUpper(dbType); dbType {
+	case "INT", "INTEGER", "BIGINT", "SMALLINT", "TINYINT", "MEDIUMINT",
+		"INT2", "INT4", "INT8", "SERIAL", "BIGSERIAL", "UNSIGNED INT", "UNSIGNED BIGINT":
+		if n, err := strconv.ParseInt(str, 10, 64); err == nil {
+			return n
+		}
+	case "FLOAT", "DOUBLE", "REAL", "FLOAT4", "FLOAT8", "NUMERIC", "DECIMAL", "MONEY":
+		if n, err := strconv.ParseFloat(str, 64); err == nil {
+			return n
+		}
+	case "BOOL", "BOOLEAN", "BIT":
+		if v, err := strconv.ParseBool(str); err == nil {
+			return v
+		}
+	case "DATE", "TIME", "DATETIME", "TIMESTAMP", "TIMESTAMPTZ", "TIMETZ":
+		for _, layout := range timeLayouts {
+			if t, err := time.Parse(layout, str); err == nil {
+				return t
+			}
+		}
+	case "BYTEA", "BLOB", "BINARY", "VARBINARY", "TINYBLOB", "MEDIUMBLOB", "LONGBLOB":
+		return b
+	default:
+		return str
+	}
+
+	return val
+}
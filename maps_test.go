@@ -0,0 +1,139 @@
+package sqlz
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestTypedValue(t *testing.T) {
+	tests := []struct {
+		dbType   string
+		val      interface{}
+		expected interface{}
+	}{
+		{"VARCHAR", []byte("text"), "text"},
+		{"", []byte("text"), "text"},
+		{"int8", []byte("42"), int64(42)},
+		{"NUMERIC", []byte("3.5"), 3.5},
+		{"BOOL", []byte("t"), true},
+		{"DATE", []byte("2020-01-02"), time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{"INT", []byte("not a number"), []byte("not a number")},
+		{"INT", int64(3), int64(3)},
+		{"TEXT", nil, nil},
+	}
+
+	for _, tst := range tests {
+		got := typedValue(tst.dbType, tst.val)
+
+		switch expected := tst.expected.(type) {
+		case []byte:
+			if b, ok := got.([]byte); !ok || string(b) != string(expected) {
+				t.Errorf("%s %v: expected %v, got %#v", tst.dbType, tst.val, expected, got)
+			}
+		case time.Time:
+			if tm, ok := got.(time.Time); !ok || !tm.Equal(expected) {
+				t.Errorf("%s %v: expected %v, got %#v", tst.dbType, tst.val, expected, got)
+			}
+		default:
+			if got != tst.expected {
+				t.Errorf("%s %v: expected %#v, got %#v", tst.dbType, tst.val, tst.expected, got)
+			}
+		}
+	}
+}
+
+func TestGetAllAsTypedMaps(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	mock.ExpectQuery("SELECT").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "name"}).AddRow(1, []byte("Alice")),
+	)
+
+	maps, err := New(db, "sqlmock").Select("id", "name").From("users").TypedMaps().GetAllAsMaps()
+	if err != nil {
+		t.Fatalf("Failed loading rows: %s", err)
+	}
+
+	if len(maps) != 1 || maps[0]["name"] != "Alice" {
+		t.Errorf("Unexpected results: %#v", maps)
+	}
+}
+
+func TestGetAllWithColumns(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	mock.ExpectQuery("SELECT").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "Alice").AddRow(2, "Bob"),
+	)
+
+	var rows []struct {
+		ID   int64  `db:"id"`
+		Name string `db:"name"`
+	}
+
+	cols, err := New(db, "sqlmock").Select("id", "name").From("users").GetAllWithColumns(&rows)
+	if err != nil {
+		t.Fatalf("Failed loading rows: %s", err)
+	}
+
+	if len(rows) != 2 || rows[1].Name != "Bob" {
+		t.Errorf("Unexpected results: %+v", rows)
+	}
+
+	if len(cols) != 2 || cols[0].Name != "id" || cols[1].Name != "name" {
+		t.Errorf("Unexpected columns: %+v", cols)
+	}
+}
+
+func TestEachRowAsMap(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	mock.ExpectQuery("SELECT").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "name"}).AddRow(1, []byte("Alice")).AddRow(2, []byte("Bob")),
+	)
+	mock.ExpectQuery("SELECT").WillReturnRows(
+		sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2).AddRow(3),
+	)
+
+	dbz := New(db, "sqlmock")
+
+	var names []interface{}
+
+	err = dbz.Select("id", "name").From("users").TypedMaps().EachRowAsMap(context.Background(), func(row map[string]interface{}) error {
+		names = append(names, row["name"])
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed iterating rows: %s", err)
+	}
+
+	if !reflect.DeepEqual(names, []interface{}{"Alice", "Bob"}) {
+		t.Errorf("Unexpected results: %#v", names)
+	}
+
+	// errors returned by the function stop the iteration
+	errStop := errors.New("stop")
+	calls := 0
+
+	err = dbz.Select("id").From("users").EachRowAsMap(context.Background(), func(map[string]interface{}) error {
+		calls++
+		return errStop
+	})
+	if !errors.Is(err, errStop) || calls != 1 {
+		t.Errorf("Expected iteration to stop after one row, got %d calls and error %v", calls, err)
+	}
+}
@@ -0,0 +1,318 @@
+package sqlz
+
+import (
+	"strings"
+)
+
+// StmtKind is an enumerated type representing the kind of
+// an SQL statement
+type StmtKind string
+
+const (
+	// KindUnknown represents a statement of unknown kind, e.g.
+	// raw SQL
+	KindUnknown StmtKind = ""
+	// KindSelect represents a SELECT statement
+	KindSelect StmtKind = "SELECT"
+	// KindInsert represents an INSERT statement
+	KindInsert StmtKind = "INSERT"
+	// KindUpdate represents an UPDATE statement
+	KindUpdate StmtKind = "UPDATE"
+	// KindDelete represents a DELETE statement
+	KindDelete StmtKind = "DELETE"
+	// KindSet represents a SET command
+	KindSet StmtKind = "SET"
+	// KindDDL represents a data definition statement, e.g. CREATE TABLE
+	KindDDL StmtKind = "DDL"
+)
+
+// String returns the string representation of the statement kind
+func (k StmtKind) String() string {
+	return string(k)
+}
+
+// IsWrite returns true if statements of this kind modify data
+func (k StmtKind) IsWrite() bool {
+	return k == KindInsert || k == KindUpdate || k == KindDelete
+}
+
+// MetadataStmt is an interface implemented by all statement types in the
+// library, exposing metadata about the statement without having to parse
+// its SQL. This is useful for middleware, routing of queries between
+// primary and replica databases, auditing, etc.
+type MetadataStmt interface {
+	SQLStmt
+	// Kind returns the kind of the statement
+	Kind() StmtKind
+	// Tables returns the names of all tables referenced by the
+	// statement, including in joins, sub-queries and unions, without
+	// aliases and without duplicates
+	Tables() []string
+}
+
+// KindOf returns the kind of the provided statement, or KindUnknown if
+// it does not implement MetadataStmt
+func KindOf(stmt SQLStmt) StmtKind {
+	if meta, ok := stmt.(MetadataStmt); ok {
+		return meta.Kind()
+	}
+
+	return KindUnknown
+}
+
+// TablesOf returns the tables referenced by the provided statement, or nil
+// if it does not implement MetadataStmt
+func TablesOf(stmt SQLStmt) []string {
+	if meta, ok := stmt.(MetadataStmt); ok {
+		return meta.Tables()
+	}
+
+	return nil
+}
+
+// IsReadOnly returns true if the provided statement does not modify data.
+// WITH statements are only read-only if both their main statement and all
+// of their auxiliary statements are read-only. Statements of unknown kind
+// are never considered read-only.
+func IsReadOnly(stmt SQLStmt) bool {
+	if with, ok := stmt.(*WithStmt); ok {
+		for _, aux := range with.AuxStmts {
+			if !IsReadOnly(aux.Stmt) {
+				return false
+			}
+		}
+
+		return IsReadOnly(with.MainStmt)
+	}
+
+	return KindOf(stmt) == KindSelect
+}
+
+// Kind returns KindSelect
+func (stmt *SelectStmt) Kind() StmtKind {
+	return KindSelect
+}
+
+// Tables returns the tables referenced by the statement
+func (stmt *SelectStmt) Tables() []string {
+	return collectTables(stmt)
+}
+
+// Kind returns KindInsert
+func (stmt *InsertStmt) Kind() StmtKind {
+	return KindInsert
+}
+
+// Tables returns the tables referenced by the statement
+func (stmt *InsertStmt) Tables() []string {
+	return collectTables(stmt)
+}
+
+// Kind returns KindUpdate
+func (stmt *UpdateStmt) Kind() StmtKind {
+	return KindUpdate
+}
+
+// Tables returns the tables referenced by the statement
+func (stmt *UpdateStmt) Tables() []string {
+	return collectTables(stmt)
+}
+
+// Kind returns KindDelete
+func (stmt *DeleteStmt) Kind() StmtKind {
+	return KindDelete
+}
+
+// Tables returns the tables referenced by the statement
+func (stmt *DeleteStmt) Tables() []string {
+	return collectTables(stmt)
+}
+
+// Kind returns the kind of the WITH statement's main statement
+func (stmt *WithStmt) Kind() StmtKind {
+	return KindOf(stmt.MainStmt)
+}
+
+// Tables returns the tables referenced by the statement, both in its
+// auxiliary statements and its main statement. The names of the auxiliary
+// statements are not included.
+func (stmt *WithStmt) Tables() []string {
+	return collectTables(stmt)
+}
+
+// Kind returns KindSelect
+func (stmt *ValuesStmt) Kind() StmtKind {
+	return KindSelect
+}
+
+// Tables returns nil, as VALUES lists do not reference tables
+func (stmt *ValuesStmt) Tables() []string {
+	return nil
+}
+
+// Kind returns KindSet
+func (cmd *SetCmd) Kind() StmtKind {
+	return KindSet
+}
+
+// Tables returns nil, as SET commands do not reference tables
+func (cmd *SetCmd) Tables() []string {
+	return nil
+}
+
+// Kind returns KindSet
+func (cmd *SetConstraintsCmd) Kind() StmtKind {
+	return KindSet
+}
+
+// Tables returns nil, as SET CONSTRAINTS commands do not reference tables
+func (cmd *SetConstraintsCmd) Tables() []string {
+	return nil
+}
+
+// Kind returns KindDDL
+func (stmt *CreateTableStmt) Kind() StmtKind {
+	return KindDDL
+}
+
+// Tables returns the created table
+func (stmt *CreateTableStmt) Tables() []string {
+	return []string{stmt.Table}
+}
+
+// Kind returns KindDDL
+func (stmt *AlterTableStmt) Kind() StmtKind {
+	return KindDDL
+}
+
+// Tables returns the altered table
+func (stmt *AlterTableStmt) Tables() []string {
+	return []string{stmt.Table}
+}
+
+// Kind returns KindDDL
+func (stmt *DropTableStmt) Kind() StmtKind {
+	return KindDDL
+}
+
+// Tables returns the dropped tables
+func (stmt *DropTableStmt) Tables() []string {
+	return append([]string(nil), stmt.TableNames...)
+}
+
+type tableCollector struct {
+	seen   map[string]bool
+	tables []string
+}
+
+func collectTables(stmt SQLStmt) []string {
+	c := &tableCollector{seen: make(map[string]bool)}
+	c.stmt(stmt)
+
+	return c.tables
+}
+
+func (c *tableCollector) add(table string) {
+	fields := strings.Fields(table)
+	if len(fields) == 0 || c.seen[fields[0]] {
+		return
+	}
+
+	c.seen[fields[0]] = true
+	c.tables = append(c.tables, fields[0])
+}
+
+func (c *tableCollector) stmt(stmt SQLStmt) {
+	switch s := stmt.(type) {
+	case *SelectStmt:
+		c.add(s.Table)
+
+		for _, join := range s.Joins {
+			if join.ResultSet != nil {
+				c.stmt(join.ResultSet)
+			} else {
+				c.add(join.Table)
+			}
+
+			c.conditions(join.Conditions)
+		}
+
+		c.conditions(s.Conditions)
+		c.conditions(s.GroupConditions)
+
+		for _, union := range s.Unions {
+			c.stmt(union)
+		}
+	case *InsertStmt:
+		c.add(s.Table)
+
+		if s.SelectStmt != nil {
+			c.stmt(s.SelectStmt)
+		}
+	case *UpdateStmt:
+		c.add(s.Table)
+
+		if s.SelectStmt != nil {
+			c.stmt(s.SelectStmt)
+		}
+
+		for _, table := range s.FromTables {
+			c.add(table)
+		}
+
+		for _, join := range s.Joins {
+			c.add(join.Table)
+			c.conditions(join.Conditions)
+		}
+
+		c.conditions(s.Conditions)
+	case *DeleteStmt:
+		c.add(s.Table)
+
+		for _, table := range s.UsingTables {
+			c.add(table)
+		}
+
+		for _, join := range s.Joins {
+			c.add(join.Table)
+			c.conditions(join.Conditions)
+		}
+
+		c.conditions(s.Conditions)
+	case *WithStmt:
+		inner := &tableCollector{seen: make(map[string]bool)}
+		for _, aux := range s.AuxStmts {
+			inner.stmt(aux.Stmt)
+		}
+
+		if s.MainStmt != nil {
+			inner.stmt(s.MainStmt)
+		}
+
+		auxNames := make(map[string]bool, len(s.AuxStmts))
+		for _, aux := range s.AuxStmts {
+			auxNames[aux.As] = true
+		}
+
+		for _, table := range inner.tables {
+			if !auxNames[table] {
+				c.add(table)
+			}
+		}
+	}
+}
+
+func (c *tableCollector) conditions(conds []WhereCondition) {
+	for _, cond := range conds {
+		switch cnd := cond.(type) {
+		case AndOrCondition:
+			c.conditions(cnd.Conditions)
+		case PreCondition:
+			c.conditions([]WhereCondition{cnd.Condition})
+		case GroupCondition:
+			c.conditions([]WhereCondition{cnd.Condition})
+		case SubqueryCondition:
+			c.stmt(cnd.Stmt)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package sqlz
+
+import (
+	"reflect"
+	"testing"
+
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestMetadata(t *testing.T) {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	dbz := New(db, "sqlmock")
+
+	tests := []struct {
+		name     string
+		stmt     SQLStmt
+		kind     StmtKind
+		readOnly bool
+		tables   []string
+	}{
+		{
+			"select with joins and sub-queries",
+			dbz.Select("*").From("users u").
+				LeftJoin("profiles p", Eq("p.user_id", Indirect("u.id"))).
+				InnerJoinRS(dbz.Select("user_id").From("orders"), "o", Eq("o.user_id", Indirect("u.id"))).
+				Where(Or(Eq("u.id", 1), Exists(dbz.Select("1").From("bans b")))).
+				Union(dbz.Select("*").From("users")),
+			KindSelect,
+			true,
+			[]string{"users", "profiles", "orders", "bans"},
+		},
+		{
+			"select with sub-query inside a grouped condition",
+			dbz.Select("*").From("users").
+				Where(Group(Or(Eq("id", 1), Exists(dbz.Select("1").From("admins"))))),
+			KindSelect,
+			true,
+			[]string{"users", "admins"},
+		},
+		{
+			"insert from select",
+			dbz.InsertInto("archive").FromSelect(dbz.Select("*").From("events")),
+			KindInsert,
+			false,
+			[]string{"archive", "events"},
+		},
+		{
+			"delete using",
+			dbz.DeleteFrom("table").Using("other"),
+			KindDelete,
+			false,
+			[]string{"table", "other"},
+		},
+		{
+			"with statement with data-modifying auxiliary statement",
+			dbz.With(dbz.DeleteFrom("queue").Returning("*"), "deleted").
+				Then(dbz.Select("*").From("deleted").LeftJoin("jobs j", Eq("j.id", Indirect("deleted.job_id")))),
+			KindSelect,
+			false,
+			[]string{"queue", "jobs"},
+		},
+		{
+			"raw SQL",
+			Indirect("SELECT 1"),
+			KindUnknown,
+			false,
+			nil,
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.name, func(t *testing.T) {
+			if kind := KindOf(tst.stmt); kind != tst.kind {
+				t.Errorf("Expected kind %q, got %q", tst.kind, kind)
+			}
+
+			if readOnly := IsReadOnly(tst.stmt); readOnly != tst.readOnly {
+				t.Errorf("Expected read-only to be %t, got %t", tst.readOnly, readOnly)
+			}
+
+			if tables := TablesOf(tst.stmt); !reflect.DeepEqual(tables, tst.tables) {
+				t.Errorf("Expected tables %v, got %v", tst.tables, tables)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package sqlz
+
+import (
+	"sync"
+)
+
+// Operator is an SQL operator used by simple conditions to compare a
+// left-value (usually a column) with a right-value
+type Operator string
+
+const (
+	// OpEq is the equality operator
+	OpEq Operator = "="
+	// OpNe is the non-equality operator
+	OpNe Operator = "<>"
+	// OpGt is the "greater than" operator
+	OpGt Operator = ">"
+	// OpGte is the "greater than or equals" operator
+	OpGte Operator = ">="
+	// OpLt is the "less than" operator
+	OpLt Operator = "<"
+	// OpLte is the "less than or equals" operator
+	OpLte Operator = "<="
+	// OpLike is the LIKE operator
+	OpLike Operator = "LIKE"
+	// OpNotLike is the NOT LIKE operator
+	OpNotLike Operator = "NOT LIKE"
+	// OpILike is the ILIKE operator (PostgreSQL)
+	OpILike Operator = "ILIKE"
+	// OpIsNull is the IS NULL operator
+	OpIsNull Operator = "IS NULL"
+	// OpIsNotNull is the IS NOT NULL operator
+	OpIsNotNull Operator = "IS NOT NULL"
+)
+
+var (
+	operatorsMtx sync.RWMutex
+	operators    = map[Operator]bool{
+		OpEq: true, OpNe: true, OpGt: true, OpGte: true, OpLt: true, OpLte: true,
+		OpLike: true, OpNotLike: true, OpILike: true, OpIsNull: true, OpIsNotNull: true,
+		// JSONB operators, see JSONBOp
+		"@>": true, "<@": true, "?": true, "?!": true, "?&": true, "||": true, "-": true, "#-": true,
+		// pgvector distance operators, see DistanceMetric
+		"<->": true, "<#>": true, "<=>": true,
+	}
+)
+
+// RegisterOperator registers custom operators (e.g. dialect-specific
+// operators such as "&&" or "~*"), so that conditions using them
+// pass validation by Validate. The library's own operators are registered
+// by default.
+func RegisterOperator(ops ...Operator) {
+	operatorsMtx.Lock()
+	defer operatorsMtx.Unlock()
+
+	for _, op := range ops {
+		operators[op] = true
+	}
+}
+
+// IsRegisteredOperator returns true if the provided operator is one of the
+// library's operators, or was registered with RegisterOperator
+func IsRegisteredOperator(op Operator) bool {
+	operatorsMtx.RLock()
+	defer operatorsMtx.RUnlock()
+
+	return operators[op]
+}
+
+// Op creates a simple condition comparing a left-value (usually a column)
+// with a right-value using the provided operator, which may be any of the
+// operator constants, or a custom operator registered with
+// RegisterOperator. If the right-value is nil, it is omitted from the
+// condition (for unary operators such as "IS NULL").
+func Op(left string, op Operator, right interface{}) SimpleCondition {
+	return SimpleCondition{left, right, string(op)}
+}
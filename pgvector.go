@@ -0,0 +1,110 @@
+package sqlz
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidVector is returned when scanning a value that is not a valid
+// pgvector vector
+var ErrInvalidVector = errors.New("invalid vector")
+
+// Vector represents a value of the "vector" type of the pgvector extension
+// for PostgreSQL. It can be used both as a binding and as a scan target.
+type Vector []float32
+
+// Value implements the driver.Valuer interface, encoding the vector in
+// pgvector's text format (e.g. "[1,2,3]")
+func (vec Vector) Value() (driver.Value, error) {
+	if vec == nil {
+		return nil, nil
+	}
+
+	elems := make([]string, len(vec))
+	for i, f := range vec {
+		elems[i] = strconv.FormatFloat(float64(f), 'f', -1, 32)
+	}
+
+	return "[" + strings.Join(elems, ",") + "]", nil
+}
+
+// Scan implements the sql.Scanner interface, decoding vectors from
+// pgvector's text format
+func (vec *Vector) Scan(src interface{}) error {
+	var text string
+
+	switch v := src.(type) {
+	case nil:
+		*vec = nil
+		return nil
+	case []byte:
+		text = string(v)
+	case string:
+		text = v
+	default:
+		return fmt.Errorf("%w: cannot scan %T into a vector", ErrInvalidVector, src)
+	}
+
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "[") || !strings.HasSuffix(text, "]") {
+		return fmt.Errorf("%w: %q", ErrInvalidVector, text)
+	}
+
+	text = strings.TrimSpace(text[1 : len(text)-1])
+	if text == "" {
+		*vec = Vector{}
+		return nil
+	}
+
+	elems := strings.Split(text, ",")
+	out := make(Vector, len(elems))
+
+	for i, elem := range elems {
+		f, err := strconv.ParseFloat(strings.TrimSpace(elem), 32)
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidVector, err)
+		}
+
+		out[i] = float32(f)
+	}
+
+	*vec = out
+
+	return nil
+}
+
+// DistanceMetric is a pgvector distance operator
+type DistanceMetric Operator
+
+const (
+	// L2Distance is the Euclidean distance operator
+	L2Distance DistanceMetric = "<->"
+	// InnerProduct is the negative inner product operator
+	InnerProduct DistanceMetric = "<#>"
+	// CosineDistance is the cosine distance operator
+	CosineDistance DistanceMetric = "<=>"
+)
+
+// VectorDistance creates an expression calculating the distance between a
+// vector column and the provided vector using the provided metric. It can
+// be used in ORDER BY clauses, conditions and updates.
+func VectorDistance(col string, vec Vector, metric DistanceMetric) IndirectValue {
+	return Indirect(col+" "+string(metric)+" ?", vec)
+}
+
+// DistanceLt creates a condition checking that the distance between a
+// vector column and the provided vector is less than the provided
+// threshold
+func DistanceLt(col string, vec Vector, metric DistanceMetric, threshold float64) SQLCondition {
+	return SQLCond(col+" "+string(metric)+" ? < ?", vec, threshold)
+}
+
+// OrderByDistance orders the results by their distance from the provided
+// vector (nearest first), which is how similarity searches are performed
+// with pgvector. Combine with Limit for "nearest N" queries.
+func (stmt *SelectStmt) OrderByDistance(col string, vec Vector, metric DistanceMetric) *SelectStmt {
+	return stmt.OrderBy(VectorDistance(col, vec, metric))
+}
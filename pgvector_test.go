@@ -0,0 +1,54 @@
+package sqlz
+
+import (
+	"testing"
+)
+
+func TestVector(t *testing.T) {
+	runTests(t, func(dbz *DB) []test {
+		vec := Vector{0.5, 1, -2}
+
+		return []test{
+			{
+				"nearest neighbours by L2 distance",
+				dbz.Select("id").From("items").OrderByDistance("embedding", vec, L2Distance).Limit(5),
+				"SELECT id FROM items ORDER BY embedding <-> ? LIMIT 5",
+				[]interface{}{vec},
+			},
+			{
+				"cosine distance threshold",
+				dbz.Select("id").
+					From("items").
+					Where(Eq("category", "books"), DistanceLt("embedding", vec, CosineDistance, 0.3)).
+					OrderByDistance("embedding", vec, CosineDistance),
+				"SELECT id FROM items WHERE category = ? AND embedding <=> ? < ? ORDER BY embedding <=> ?",
+				[]interface{}{"books", vec, 0.3, vec},
+			},
+		}
+	})
+}
+
+func TestVectorValue(t *testing.T) {
+	val, err := Vector{0.5, 1, -2}.Value()
+	if err != nil {
+		t.Fatalf("Failed encoding vector: %s", err)
+	}
+
+	if val != "[0.5,1,-2]" {
+		t.Errorf("Expected [0.5,1,-2], got %v", val)
+	}
+
+	var vec Vector
+
+	if err := vec.Scan([]byte("[0.5, 1,-2]")); err != nil {
+		t.Fatalf("Failed scanning vector: %s", err)
+	}
+
+	if len(vec) != 3 || vec[0] != 0.5 || vec[1] != 1 || vec[2] != -2 {
+		t.Errorf("Unexpected vector %v", vec)
+	}
+
+	if err := vec.Scan("1,2"); err == nil {
+		t.Errorf("Expected an error scanning an invalid vector")
+	}
+}
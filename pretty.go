@@ -0,0 +1,226 @@
+package sqlz
+
+import (
+	"strings"
+)
+
+// prettyIndent is the string used for indenting sub-queries in
+// pretty-printed SQL
+const prettyIndent = "  "
+
+// prettyKeyword is an SQL keyword recognized by the pretty-printer
+type prettyKeyword struct {
+	word string
+	// brk is true if a line break should be added before the keyword
+	brk bool
+	// stmt is true if the keyword starts a statement, in which case a
+	// line break is added before it unless it starts a sub-query
+	stmt bool
+}
+
+// prettyKeywords is the list of keywords recognized by the pretty-printer.
+// Longer keywords must come before shorter keywords they begin with.
+var prettyKeywords = []prettyKeyword{
+	{word: "DELETE FROM", stmt: true},
+	{word: "INSERT OR", stmt: true},
+	{word: "INSERT INTO", stmt: true},
+	{word: "DO UPDATE SET"},
+	{word: "IS DISTINCT FROM"},
+	{word: "IS NOT DISTINCT FROM"},
+	{word: "SELECT", stmt: true},
+	{word: "UPDATE", stmt: true},
+	{word: "FROM", brk: true},
+	{word: "WHERE", brk: true},
+	{word: "GROUP BY", brk: true},
+	{word: "HAVING", brk: true},
+	{word: "WINDOW", brk: true},
+	{word: "ORDER BY", brk: true},
+	{word: "LIMIT", brk: true},
+	{word: "OFFSET", brk: true},
+	{word: "UNION ALL", brk: true},
+	{word: "UNION", brk: true},
+	{word: "INNER JOIN", brk: true},
+	{word: "LEFT JOIN", brk: true},
+	{word: "RIGHT JOIN", brk: true},
+	{word: "FULL JOIN", brk: true},
+	{word: "CROSS JOIN", brk: true},
+	{word: "NATURAL JOIN", brk: true},
+	{word: "JOIN", brk: true},
+	{word: "SET", brk: true},
+	{word: "VALUES", brk: true},
+	{word: "ON CONFLICT", brk: true},
+	{word: "RETURNING", brk: true},
+	{word: "USING", brk: true},
+	{word: "FOR NO KEY UPDATE", brk: true},
+	{word: "FOR KEY SHARE", brk: true},
+	{word: "FOR UPDATE", brk: true},
+	{word: "FOR SHARE", brk: true},
+}
+
+// PrettySQL generates SQL for the provided statement (with placeholders
+// rebound for the database driver), formatted over multiple lines: every
+// clause starts on a new line, and sub-queries are indented. This is
+// meant for logging and debugging large queries; the formatted SQL is
+// equivalent to the compact SQL returned by ToSQL, which is the one used
+// when executing statements.
+func PrettySQL(stmt SQLStmt) (asSQL string, bindings []interface{}) {
+	asSQL, bindings = stmt.ToSQL(true)
+	return prettify(asSQL), bindings
+}
+
+// ToPrettySQL generates the statement's SQL formatted over multiple lines.
+// See PrettySQL for more information.
+func (stmt *SelectStmt) ToPrettySQL() (asSQL string, bindings []interface{}) {
+	return PrettySQL(stmt)
+}
+
+// ToPrettySQL generates the statement's SQL formatted over multiple lines.
+// See PrettySQL for more information.
+func (stmt *InsertStmt) ToPrettySQL() (asSQL string, bindings []interface{}) {
+	return PrettySQL(stmt)
+}
+
+// ToPrettySQL generates the statement's SQL formatted over multiple lines.
+// See PrettySQL for more information.
+func (stmt *UpdateStmt) ToPrettySQL() (asSQL string, bindings []interface{}) {
+	return PrettySQL(stmt)
+}
+
+// ToPrettySQL generates the statement's SQL formatted over multiple lines.
+// See PrettySQL for more information.
+func (stmt *DeleteStmt) ToPrettySQL() (asSQL string, bindings []interface{}) {
+	return PrettySQL(stmt)
+}
+
+// ToPrettySQL generates the statement's SQL formatted over multiple lines.
+// See PrettySQL for more information.
+func (stmt *WithStmt) ToPrettySQL() (asSQL string, bindings []interface{}) {
+	return PrettySQL(stmt)
+}
+
+// prettify formats compact SQL over multiple lines. Parentheses that open
+// a sub-statement increase the indentation level, while other parentheses
+// (function calls, column lists, etc.) are left untouched, and keywords
+// inside them never cause line breaks. Quoted strings and identifiers are
+// copied as-is.
+func prettify(asSQL string) string {
+	out := make([]byte, 0, len(asSQL)+len(asSQL)/4)
+	// stack holds, for every level of nesting, whether the parentheses
+	// opened a sub-statement. The first item is the top-level statement.
+	stack := []bool{true}
+	depth := 0
+
+	var quote byte
+
+	newline := func() {
+		out = []byte(strings.TrimRight(string(out), " "))
+		if len(out) == 0 {
+			return
+		}
+
+		out = append(out, '\n')
+		out = append(out, strings.Repeat(prettyIndent, depth)...)
+	}
+
+	for i := 0; i < len(asSQL); i++ {
+		c := asSQL[i]
+
+		if quote != 0 {
+			out = append(out, c)
+			if c == quote {
+				quote = 0
+			}
+
+			continue
+		}
+
+		switch c {
+		case '\'', '"', '`':
+			quote = c
+			out = append(out, c)
+
+			continue
+		case '(':
+			isStmt := startsStatement(asSQL[i+1:])
+			stack = append(stack, isStmt)
+			out = append(out, c)
+
+			if isStmt {
+				depth++
+
+				newline()
+			}
+
+			continue
+		case ')':
+			if len(stack) > 1 {
+				if stack[len(stack)-1] {
+					depth--
+
+					newline()
+				}
+
+				stack = stack[:len(stack)-1]
+			}
+
+			out = append(out, c)
+
+			continue
+		}
+
+		if stack[len(stack)-1] && (i == 0 || !isIdentChar(asSQL[i-1])) {
+			if kw, ok := matchKeyword(asSQL[i:]); ok {
+				if kw.brk || (kw.stmt && lastNonSpace(out) != '(') {
+					newline()
+				}
+
+				out = append(out, kw.word...)
+				i += len(kw.word) - 1
+
+				continue
+			}
+		}
+
+		out = append(out, c)
+	}
+
+	return string(out)
+}
+
+func matchKeyword(s string) (kw prettyKeyword, ok bool) {
+	for _, kw := range prettyKeywords {
+		if strings.HasPrefix(s, kw.word) &&
+			(len(s) == len(kw.word) || !isIdentChar(s[len(kw.word)])) {
+			return kw, true
+		}
+	}
+
+	return kw, false
+}
+
+func startsStatement(s string) bool {
+	s = strings.TrimLeft(s, " ")
+
+	for _, word := range []string{"SELECT", "WITH", "VALUES", "INSERT", "UPDATE", "DELETE"} {
+		if strings.HasPrefix(s, word) && (len(s) == len(word) || !isIdentChar(s[len(word)])) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func lastNonSpace(out []byte) byte {
+	for i := len(out) - 1; i >= 0; i-- {
+		if out[i] != ' ' && out[i] != '\n' {
+			return out[i]
+		}
+	}
+
+	return 0
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c == '.' || c == '$' ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
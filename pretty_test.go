@@ -0,0 +1,76 @@
+package sqlz
+
+import (
+	"testing"
+
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestPrettySQL(t *testing.T) {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	dbz := New(db, "postgres")
+
+	tests := []struct {
+		name     string
+		stmt     SQLStmt
+		expected string
+	}{
+		{
+			"select with joins and a sub-query",
+			dbz.Select("a.id", "COUNT(b.id) c").
+				From("table a").
+				LeftJoin("other b", Eq("b.a_id", Indirect("a.id"))).
+				Where(Eq("a.name", "it's FROM"), Exists(dbz.Select("1").From("x").Where(Eq("x.id", Indirect("a.id"))))).
+				GroupBy("a.id").
+				OrderBy(Desc("c")).
+				Limit(5),
+			"SELECT a.id, COUNT(b.id) c\n" +
+				"FROM table a\n" +
+				"LEFT JOIN other b ON b.a_id = a.id\n" +
+				"WHERE a.name = $1 AND EXISTS (\n" +
+				"  SELECT 1\n" +
+				"  FROM x\n" +
+				"  WHERE x.id = a.id\n" +
+				")\n" +
+				"GROUP BY a.id\n" +
+				"ORDER BY c DESC\n" +
+				"LIMIT 5",
+		},
+		{
+			"with statement",
+			dbz.With(dbz.DeleteFrom("t").Where(Eq("a", 1)).Returning("*"), "d").
+				Then(dbz.InsertInto("t2").FromSelect(dbz.Select("*").From("d"))),
+			"WITH d AS (\n" +
+				"  DELETE FROM t\n" +
+				"  WHERE a = $1\n" +
+				"  RETURNING *\n" +
+				")\n" +
+				"INSERT INTO t2\n" +
+				"SELECT *\n" +
+				"FROM d",
+		},
+		{
+			"upsert",
+			dbz.InsertInto("t").Columns("a", "b").Values(1, 2).
+				OnConflict(OnConflict("a").DoUpdate().Set("b", 3)).
+				Returning("id"),
+			"INSERT INTO t (a, b)\n" +
+				"VALUES ($1, $2)\n" +
+				"ON CONFLICT (a) DO UPDATE SET b = $3\n" +
+				"RETURNING id",
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.name, func(t *testing.T) {
+			pretty, _ := PrettySQL(tst.stmt)
+			if pretty != tst.expected {
+				t.Errorf("Expected:\n%s\nGot:\n%s", tst.expected, pretty)
+			}
+		})
+	}
+}
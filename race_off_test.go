@@ -0,0 +1,8 @@
+//go:build !race
+// +build !race
+
+package sqlz
+
+// raceEnabled reports whether the race detector is enabled, as it affects
+// allocation counts
+const raceEnabled = false
@@ -0,0 +1,299 @@
+package sqlz
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// RawStmt represents a statement written in raw SQL. It serves as an escape
+// hatch for queries the builder cannot express, while still providing the
+// library's execution conveniences (placeholder rebinding, result loading
+// and error handlers).
+type RawStmt struct {
+	*Statement
+	// SQL is the statement's SQL, with question marks for placeholders
+	SQL string
+	// Bindings are the values bound to the statement's placeholders
+	Bindings []interface{}
+
+	execer  Ext
+	err     error
+	guards  []Guard
+	timeout time.Duration
+}
+
+// Raw creates a new RawStmt object from the provided SQL and bindings.
+// Question marks must be used for placeholders regardless of the database
+// driver, as they are rebound automatically. Slice bindings are expanded
+// like sqlx.In does, so "WHERE id IN (?)" can be used with a slice of IDs.
+// This is not named Query so as not to shadow the Query method of the
+// underlying sql.DB object.
+func (db *DB) Raw(query string, bindings ...interface{}) *RawStmt {
+	stmt := &RawStmt{
+		SQL:       query,
+		Bindings:  bindings,
+		execer:    db.ext(),
+		guards:    db.defaults().Guards,
+		Statement: &Statement{db.ErrHandlers},
+	}
+
+	return stmt.expandIn()
+}
+
+// Raw creates a new RawStmt object from the provided SQL and bindings.
+// See DB.Raw for more information.
+func (tx *Tx) Raw(query string, bindings ...interface{}) *RawStmt {
+	stmt := &RawStmt{
+		SQL:       query,
+		Bindings:  bindings,
+		execer:    tx.ext(),
+		guards:    tx.defaults().Guards,
+		Statement: &Statement{tx.ErrHandlers},
+	}
+
+	return stmt.expandIn()
+}
+
+// Named creates a new RawStmt object from SQL with named parameters
+// (e.g. "WHERE id = :id"), whose values are taken from the provided struct
+// or map, using sqlx's named parameter expansion. Like all statements, it
+// can be executed directly, used as an auxiliary statement of a WITH
+// statement, or used as a sub-query. Struct fields are matched
+// using `db` tags, like sqlx does, and slice values are expanded like in
+// Raw. If expansion fails, the error is returned when the statement is
+// executed.
+func (db *DB) Named(query string, arg interface{}) *RawStmt {
+	return db.Raw(query).named(arg)
+}
+
+// Named creates a new RawStmt object from SQL with named parameters.
+// See DB.Named for more information.
+func (tx *Tx) Named(query string, arg interface{}) *RawStmt {
+	return tx.Raw(query).named(arg)
+}
+
+// named expands the named parameters in the statement's SQL into question
+// mark placeholders, binding the values from the provided argument
+func (stmt *RawStmt) named(arg interface{}) *RawStmt {
+	stmt.SQL, stmt.Bindings, stmt.err = sqlx.Named(stmt.SQL, arg)
+	return stmt.expandIn()
+}
+
+// expandIn expands slice bindings into one placeholder per element, using
+// sqlx.In. Statements without slice bindings are left as-is.
+func (stmt *RawStmt) expandIn() *RawStmt {
+	if stmt.err != nil {
+		return stmt
+	}
+
+	stmt.SQL, stmt.Bindings, stmt.err = sqlx.In(stmt.SQL, stmt.Bindings...)
+
+	return stmt
+}
+
+// WithExecer sets the execer the statement is executed with, overriding the
+// DB or Tx it was created from
+func (stmt *RawStmt) WithExecer(e Ext) *RawStmt {
+	stmt.execer = e
+	return stmt
+}
+
+// WithTimeout sets a timeout for executing the statement. Every execution
+// of the statement derives a context with this timeout (from the provided
+// context, if any), so the query is cancelled if it runs for too long. To
+// also enforce the timeout on the server inside a transaction, see
+// Tx.SetTimeout.
+func (stmt *RawStmt) WithTimeout(d time.Duration) *RawStmt {
+	stmt.timeout = d
+	return stmt
+}
+
+// ToSQL generates the statement's SQL and returns a list of
+// bindings. It is used internally, but is exposed for testing purposes.
+func (stmt *RawStmt) ToSQL(rebind bool) (asSQL string, bindings []interface{}) {
+	asSQL = stmt.SQL
+
+	if rebind {
+		asSQL = rebindFor(stmt.execer, asSQL)
+	}
+
+	return asSQL, stmt.Bindings
+}
+
+// Exec executes the statement, returning the standard
+// sql.Result struct and an error if the query failed.
+func (stmt *RawStmt) Exec() (res sql.Result, err error) {
+	return stmt.ExecContext(context.Background())
+}
+
+// ExecContext executes the statement, returning the standard
+// sql.Result struct and an error if the query failed.
+func (stmt *RawStmt) ExecContext(ctx context.Context) (res sql.Result, err error) {
+	ctx, cancel := contextWithTimeout(ctx, stmt.timeout)
+	defer cancel()
+
+	if stmt.err != nil {
+		stmt.HandleError(stmt.err)
+		return nil, stmt.err
+	}
+
+	if err := stmt.guard(ctx); err != nil {
+		return nil, err
+	}
+
+	asSQL, bindings := stmt.ToSQL(true)
+
+	res, err = stmt.execer.ExecContext(ctx, asSQL, bindings...)
+	stmt.HandleError(err)
+
+	return res, err
+}
+
+// GetRow executes the statement and loads the first
+// result into the provided variable (which may be a
+// simple variable if only one column was selected,
+// or a struct if multiple columns were selected).
+func (stmt *RawStmt) GetRow(into interface{}) error {
+	return stmt.GetRowContext(context.Background(), into)
+}
+
+// GetRowContext executes the statement and loads the first
+// result into the provided variable (which may be a
+// simple variable if only one column was selected,
+// or a struct if multiple columns were selected).
+func (stmt *RawStmt) GetRowContext(ctx context.Context, into interface{}) error {
+	ctx, cancel := contextWithTimeout(ctx, stmt.timeout)
+	defer cancel()
+
+	if stmt.err != nil {
+		stmt.HandleError(stmt.err)
+		return stmt.err
+	}
+
+	if err := stmt.guard(ctx); err != nil {
+		return err
+	}
+
+	asSQL, bindings := stmt.ToSQL(true)
+
+	err := sqlx.GetContext(ctx, stmt.execer, into, asSQL, bindings...)
+	stmt.HandleError(err)
+
+	return err
+}
+
+// GetAll executes the statement and loads all the
+// results into the provided slice variable.
+func (stmt *RawStmt) GetAll(into interface{}) error {
+	return stmt.GetAllContext(context.Background(), into)
+}
+
+// GetAllContext executes the statement and loads all the
+// results into the provided slice variable.
+func (stmt *RawStmt) GetAllContext(ctx context.Context, into interface{}) error {
+	ctx, cancel := contextWithTimeout(ctx, stmt.timeout)
+	defer cancel()
+
+	if stmt.err != nil {
+		stmt.HandleError(stmt.err)
+		return stmt.err
+	}
+
+	if err := stmt.guard(ctx); err != nil {
+		return err
+	}
+
+	asSQL, bindings := stmt.ToSQL(true)
+
+	err := sqlx.SelectContext(ctx, stmt.execer, into, asSQL, bindings...)
+	stmt.HandleError(err)
+
+	return err
+}
+
+// GetAllAsMaps executes the statement and returns all results as a slice of
+// maps from column names to their values
+func (stmt *RawStmt) GetAllAsMaps() (maps []map[string]interface{}, err error) {
+	return stmt.GetAllAsMapsContext(context.Background())
+}
+
+// GetAllAsMapsContext executes the statement and returns all results as a
+// slice of maps from column names to their values
+func (stmt *RawStmt) GetAllAsMapsContext(ctx context.Context) (maps []map[string]interface{}, err error) {
+	rows, err := stmt.RowsContext(ctx)
+	if err != nil {
+		return maps, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		results := make(map[string]interface{})
+
+		err = rows.MapScan(results)
+		if err != nil {
+			stmt.HandleError(err)
+			return maps, err
+		}
+
+		maps = append(maps, results)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		stmt.HandleError(err)
+		return maps, err
+	}
+
+	return maps, nil
+}
+
+// Rows executes the statement and returns a Rows object to use for
+// iteration. It is the caller's responsibility to close the cursor with
+// Close(), which also releases the statement's timeout (see WithTimeout).
+func (stmt *RawStmt) Rows() (rows *Rows, err error) {
+	return stmt.RowsContext(context.Background())
+}
+
+// RowsContext is like Rows, but uses the provided context
+func (stmt *RawStmt) RowsContext(ctx context.Context) (rows *Rows, err error) {
+	if stmt.err != nil {
+		stmt.HandleError(stmt.err)
+		return nil, stmt.err
+	}
+
+	if err := stmt.guard(ctx); err != nil {
+		return nil, err
+	}
+
+	asSQL, bindings := stmt.ToSQL(true)
+
+	rows, err = queryRows(ctx, stmt.timeout, stmt.execer, asSQL, bindings)
+	if err != nil {
+		stmt.HandleError(err)
+	}
+
+	return rows, err
+}
+
+// GetAllAsRows executes the statement and returns an sqlx.Rows object to
+// use for iteration. It is the caller's responsibility to close the cursor
+// with Close(). The context derived for the statement's timeout (see
+// WithTimeout) is only released once the timeout expires; use Rows to
+// release it when the cursor is closed.
+func (stmt *RawStmt) GetAllAsRows() (rows *sqlx.Rows, err error) {
+	return stmt.GetAllAsRowsContext(context.Background())
+}
+
+// GetAllAsRowsContext is like GetAllAsRows, but uses the provided context
+func (stmt *RawStmt) GetAllAsRowsContext(ctx context.Context) (rows *sqlx.Rows, err error) {
+	cursor, err := stmt.RowsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return cursor.Rows, nil
+}
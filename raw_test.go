@@ -0,0 +1,119 @@
+package sqlz
+
+import (
+	"testing"
+
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestRaw(t *testing.T) {
+	runTestsWithDriver(t, "postgres", func(dbz *DB) []test {
+		return []test{
+			{
+				"raw query rebinding",
+				dbz.Raw("SELECT * FROM users WHERE id = ? AND name = ?", 1, "Alice"),
+				"SELECT * FROM users WHERE id = $1 AND name = $2",
+				[]interface{}{1, "Alice"},
+			},
+			{
+				"raw query with named parameters from a map",
+				dbz.Named("SELECT * FROM users WHERE id = :id AND name = :name", map[string]interface{}{
+					"id":   1,
+					"name": "Alice",
+				}),
+				"SELECT * FROM users WHERE id = $1 AND name = $2",
+				[]interface{}{1, "Alice"},
+			},
+			{
+				"raw query with named parameters from a struct",
+				dbz.Named("UPDATE users SET name = :name WHERE id = :id", struct {
+					ID   int64  `db:"id"`
+					Name string `db:"name"`
+				}{2, "Bob"}),
+				"UPDATE users SET name = $1 WHERE id = $2",
+				[]interface{}{"Bob", int64(2)},
+			},
+			{
+				"named query as an auxiliary statement",
+				dbz.With(
+					dbz.Named("SELECT id FROM users WHERE name = :name", map[string]interface{}{"name": "Alice"}),
+					"alice",
+				).Then(dbz.Select("*").From("orders").Where(InSubquery("user_id", Indirect("SELECT id FROM alice")))),
+				"WITH alice AS (SELECT id FROM users WHERE name = $1) SELECT * FROM orders WHERE user_id IN (SELECT id FROM alice)",
+				[]interface{}{"Alice"},
+			},
+			{
+				"raw query with slice expansion",
+				dbz.Raw("SELECT * FROM users WHERE id IN (?) AND name <> ?", []int{1, 2, 3}, "Alice"),
+				"SELECT * FROM users WHERE id IN ($1, $2, $3) AND name <> $4",
+				[]interface{}{1, 2, 3, "Alice"},
+			},
+			{
+				"named raw query with slice expansion",
+				dbz.Named("SELECT * FROM users WHERE id IN (:ids)", map[string]interface{}{
+					"ids": []int64{4, 5},
+				}),
+				"SELECT * FROM users WHERE id IN ($1, $2)",
+				[]interface{}{int64(4), int64(5)},
+			},
+		}
+	})
+}
+
+func TestRawBytesBinding(t *testing.T) {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	asSQL, bindings := New(db, "sqlmock").Raw("SELECT * FROM files WHERE hash = ?", []byte("abc")).ToSQL(true)
+	if asSQL != "SELECT * FROM files WHERE hash = ?" {
+		t.Errorf("Byte slices must not be expanded, got %s", asSQL)
+	}
+
+	if len(bindings) != 1 || string(bindings[0].([]byte)) != "abc" {
+		t.Errorf("Unexpected bindings %v", bindings)
+	}
+}
+
+func TestRawExecution(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	dbz := New(db, "postgres")
+
+	mock.ExpectQuery(`SELECT id, name FROM users WHERE id > \$1`).
+		WithArgs(0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "Alice").AddRow(2, "Bob"))
+	mock.ExpectExec(`DELETE FROM users WHERE id = \$1`).
+		WithArgs(2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	maps, err := dbz.Raw("SELECT id, name FROM users WHERE id > ?", 0).GetAllAsMaps()
+	if err != nil {
+		t.Fatalf("GetAllAsMaps failed: %s", err)
+	}
+
+	if len(maps) != 2 || maps[1]["name"] != "Bob" {
+		t.Errorf("Unexpected results %v", maps)
+	}
+
+	res, err := dbz.Raw("DELETE FROM users WHERE id = ?", 2).Exec()
+	if err != nil {
+		t.Fatalf("Exec failed: %s", err)
+	}
+
+	if n, _ := res.RowsAffected(); n != 1 {
+		t.Errorf("Expected 1 affected row, got %d", n)
+	}
+
+	if _, err = dbz.Named("SELECT * FROM users WHERE id = :id", map[string]interface{}{}).Exec(); err == nil {
+		t.Errorf("Expected an error for a missing named parameter")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %s", err)
+	}
+}
@@ -0,0 +1,96 @@
+package sqlz
+
+import (
+	"context"
+	"reflect"
+	"time"
+)
+
+// RetryPolicy configures how idempotent SELECT statements are retried when
+// they fail due to transient errors (see SelectStmt.WithRetry)
+type RetryPolicy struct {
+	// Attempts is the maximum number of times the statement is retried
+	// after the first failure
+	Attempts int
+	// Backoff is the delay before the first retry, doubled after every
+	// retry. A zero Backoff retries immediately.
+	Backoff time.Duration
+	// MaxBackoff caps the delay between retries, if positive
+	MaxBackoff time.Duration
+	// Classifier decides whether an error is transient and the statement
+	// should be retried. If nil, IsTransientError is used.
+	Classifier func(err error) bool
+}
+
+// DefaultRetryPolicy retries up to 3 times on transient errors, with an
+// exponential backoff starting at 50 milliseconds
+var DefaultRetryPolicy = RetryPolicy{
+	Attempts:   3,
+	Backoff:    50 * time.Millisecond,
+	MaxBackoff: time.Second,
+}
+
+// WithRetry makes the statement retry on transient errors according to the
+// provided policy, rather than surfacing them to the caller. Only the
+// loading methods are retried: GetRow, GetAll, GetAllAsMaps, GetRowAsMap
+// and their variants. Retries are only safe because SELECT statements are
+// idempotent, so do not use this for statements that call functions with
+// side effects. Errors are passed to the error handlers only once all
+// attempts have failed.
+func (stmt *SelectStmt) WithRetry(policy RetryPolicy) *SelectStmt {
+	stmt.retry = &policy
+	return stmt
+}
+
+// withRetry runs the provided function, retrying it according to the
+// statement's retry policy, if it has one
+func (stmt *SelectStmt) withRetry(ctx context.Context, f func() error) error {
+	if stmt.retry == nil {
+		return f()
+	}
+
+	return stmt.retry.run(ctx, f)
+}
+
+func (policy *RetryPolicy) run(ctx context.Context, f func() error) error {
+	classify := policy.Classifier
+	if classify == nil {
+		classify = IsTransientError
+	}
+
+	backoff := policy.Backoff
+
+	for attempt := 0; ; attempt++ {
+		err := f()
+		if err == nil || attempt >= policy.Attempts || !classify(err) {
+			return err
+		}
+
+		if backoff <= 0 {
+			continue
+		}
+
+		timer := time.NewTimer(backoff)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+
+		backoff *= 2
+		if policy.MaxBackoff > 0 && backoff > policy.MaxBackoff {
+			backoff = policy.MaxBackoff
+		}
+	}
+}
+
+// resetSlice empties the slice pointed to by into, so that results of a
+// failed attempt are not kept when retrying
+func resetSlice(into interface{}) {
+	v := reflect.ValueOf(into)
+	if v.Kind() == reflect.Ptr && !v.IsNil() && v.Elem().Kind() == reflect.Slice {
+		v.Elem().Set(v.Elem().Slice(0, 0))
+	}
+}
@@ -0,0 +1,68 @@
+package sqlz
+
+import (
+	"errors"
+	"testing"
+
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestWithRetry(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	dbz := New(db, "postgres")
+
+	errFlaky := errors.New("connection reset")
+	policy := RetryPolicy{
+		Attempts:   2,
+		Classifier: func(err error) bool { return errors.Is(err, errFlaky) },
+	}
+
+	mock.ExpectQuery(`SELECT id FROM users`).WillReturnError(errFlaky)
+	mock.ExpectQuery(`SELECT id FROM users`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2).RowError(1, errFlaky))
+	mock.ExpectQuery(`SELECT id FROM users`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+
+	var ids []int64
+
+	err = dbz.Select("id").From("users").WithRetry(policy).GetAll(&ids)
+	if err != nil {
+		t.Fatalf("GetAll failed: %s", err)
+	}
+
+	if len(ids) != 2 {
+		t.Errorf("Expected 2 results, got %v", ids)
+	}
+
+	// permanent errors are not retried
+	errPermanent := errors.New("syntax error")
+
+	mock.ExpectQuery(`SELECT name FROM users WHERE id = \$1`).WithArgs(1).WillReturnError(errPermanent)
+
+	var handled int
+
+	dbz.ErrHandlers = append(dbz.ErrHandlers, func(err error) {
+		if err != nil {
+			handled++
+		}
+	})
+
+	var name string
+
+	err = dbz.Select("name").From("users").Where(Eq("id", 1)).WithRetry(policy).GetRow(&name)
+	if !errors.Is(err, errPermanent) {
+		t.Errorf("Expected permanent error, got %v", err)
+	}
+
+	if handled != 1 {
+		t.Errorf("Expected error handlers to be called once, got %d", handled)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %s", err)
+	}
+}
@@ -0,0 +1,175 @@
+package sqlz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// DefaultInsertBatchSize is the maximum number of rows inserted by every
+// statement generated by InsertRows. As with UpsertAll, the actual number
+// may be lower if the rows have too many columns.
+var DefaultInsertBatchSize = 1000
+
+// RowsProvider is a source of rows to insert, which is consumed lazily by
+// InsertRows. Next returns the values of the next row (in the order of the
+// columns being inserted), or io.EOF when there are no more rows.
+type RowsProvider interface {
+	Next() ([]interface{}, error)
+}
+
+// RowsFunc is an adapter allowing the use of ordinary functions as
+// RowsProviders
+type RowsFunc func() ([]interface{}, error)
+
+// Next calls the function
+func (f RowsFunc) Next() ([]interface{}, error) {
+	return f()
+}
+
+// RowsFromSlice creates a RowsProvider from a slice of rows
+func RowsFromSlice(rows [][]interface{}) RowsProvider {
+	var i int
+
+	return RowsFunc(func() ([]interface{}, error) {
+		if i >= len(rows) {
+			return nil, io.EOF
+		}
+
+		i++
+
+		return rows[i-1], nil
+	})
+}
+
+// InsertRows inserts all rows from the provided RowsProvider into the
+// provided table, in batches of DefaultInsertBatchSize rows, so that rows
+// can be streamed from their source (e.g. a file or another database)
+// without materializing all of them in memory first. All batches are
+// inserted inside one transaction, and the total number of affected rows
+// is returned.
+func (db *DB) InsertRows(table string, cols []string, rows RowsProvider) (affected int64, err error) {
+	return db.InsertRowsContext(context.Background(), table, cols, rows)
+}
+
+// InsertRowsContext is like InsertRows, but uses the provided context
+func (db *DB) InsertRowsContext(
+	ctx context.Context,
+	table string,
+	cols []string,
+	rows RowsProvider,
+) (affected int64, err error) {
+	err = db.TransactionalContext(ctx, nil, func(tx *Tx) error {
+		affected, err = tx.InsertRowsContext(ctx, table, cols, rows)
+		return err
+	})
+
+	return affected, err
+}
+
+// InsertRows inserts all rows from the provided RowsProvider into the
+// provided table, in batches. See DB.InsertRows for more information.
+func (tx *Tx) InsertRows(table string, cols []string, rows RowsProvider) (affected int64, err error) {
+	return tx.InsertRowsContext(context.Background(), table, cols, rows)
+}
+
+// InsertRowsContext is like InsertRows, but uses the provided context
+func (tx *Tx) InsertRowsContext(
+	ctx context.Context,
+	table string,
+	cols []string,
+	rows RowsProvider,
+) (affected int64, err error) {
+	if len(cols) == 0 {
+		return 0, fmt.Errorf("%w: insert into %s requires columns", ErrInvalidStatement, table)
+	}
+
+	batchSize := batchSizeFor(DefaultInsertBatchSize, len(cols))
+	batch := make([][]interface{}, 0, batchSize)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		res, err := tx.InsertInto(table).Columns(cols...).ValueMultiple(batch).ExecContext(ctx)
+		if err != nil {
+			return err
+		}
+
+		n, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		affected += n
+		batch = batch[:0]
+
+		return nil
+	}
+
+	for {
+		row, err := rows.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return affected, fmt.Errorf("failed reading row: %w", err)
+		}
+
+		if len(row) != len(cols) {
+			return affected, fmt.Errorf(
+				"%w: insert into %s has %d columns but row has %d values",
+				ErrInvalidStatement, table, len(cols), len(row),
+			)
+		}
+
+		batch = append(batch, row)
+
+		if len(batch) == batchSize {
+			if err := flush(); err != nil {
+				return affected, err
+			}
+		}
+	}
+
+	return affected, flush()
+}
+
+// batchSizeFor returns the number of rows to insert in every statement of a
+// batched operation, capping the provided size so that the number of
+// bindings in a statement does not exceed maxBindings
+func batchSizeFor(size, numCols int) int {
+	return batchSizeWithin(size, numCols, maxBindings)
+}
+
+// batchSizeWithin is like batchSizeFor, but with a custom limit on the
+// number of bindings in a statement
+func batchSizeWithin(size, numCols, limit int) int {
+	max := limit / numCols
+	if max < 1 {
+		max = 1
+	}
+
+	if size <= 0 || size > max {
+		return max
+	}
+
+	return size
+}
+
+// maxBindingsFor returns the maximum number of bindings in a single
+// statement supported by the provided dialect
+func maxBindingsFor(dialect Dialect) int {
+	switch dialect {
+	case DialectSQLite:
+		// the default limit of SQLite versions prior to 3.32.0
+		return 999
+	case DialectMSSQL:
+		return 2100
+	case DialectOracle:
+		return 1000
+	default:
+		return maxBindings
+	}
+}
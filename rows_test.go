@@ -0,0 +1,78 @@
+package sqlz
+
+import (
+	"errors"
+	"testing"
+
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestInsertRows(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	defer func(size int) { DefaultInsertBatchSize = size }(DefaultInsertBatchSize)
+	DefaultInsertBatchSize = 2
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO logs \(id, msg\) VALUES \(\?, \?\), \(\?, \?\)`).
+		WithArgs(1, "a", 2, "b").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`INSERT INTO logs \(id, msg\) VALUES \(\?, \?\)`).
+		WithArgs(3, "c").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	affected, err := New(db, "sqlmock").InsertRows("logs", []string{"id", "msg"}, RowsFromSlice([][]interface{}{
+		{1, "a"},
+		{2, "b"},
+		{3, "c"},
+	}))
+	if err != nil {
+		t.Fatalf("Failed inserting rows: %s", err)
+	}
+
+	if affected != 3 {
+		t.Errorf("Expected 3 affected rows, got %d", affected)
+	}
+
+	errSource := errors.New("source failed")
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	_, err = New(db, "sqlmock").InsertRows("logs", []string{"id", "msg"}, RowsFunc(func() ([]interface{}, error) {
+		return nil, errSource
+	}))
+	if !errors.Is(err, errSource) {
+		t.Errorf("Expected source error, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %s", err)
+	}
+}
+
+func TestBatchSizeWithin(t *testing.T) {
+	tests := []struct {
+		dialect  Dialect
+		size     int
+		numCols  int
+		expected int
+	}{
+		{DialectPostgres, 0, 10, 6553},
+		{DialectPostgres, 100, 10, 100},
+		{DialectSQLite, 1000, 2, 499},
+		{DialectSQLite, 0, 2000, 1},
+		{DialectMSSQL, 0, 3, 700},
+	}
+
+	for _, tst := range tests {
+		size := batchSizeWithin(tst.size, tst.numCols, maxBindingsFor(tst.dialect))
+		if size != tst.expected {
+			t.Errorf("Expected batch size %d for %d columns in %s, got %d", tst.expected, tst.numCols, tst.dialect, size)
+		}
+	}
+}
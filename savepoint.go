@@ -0,0 +1,98 @@
+package sqlz
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync/atomic"
+)
+
+// TryExec executes the provided statement inside a savepoint. If the
+// statement fails, the transaction is rolled back to the savepoint, so
+// that only the effects of the failed statement are discarded and the
+// transaction can continue (in PostgreSQL, a failed statement otherwise
+// aborts the entire transaction). This allows batch jobs to skip bad rows
+// without aborting the whole transaction. The statement's error is
+// returned.
+func (tx *Tx) TryExec(stmt SQLStmt) (res sql.Result, err error) {
+	return tx.TryExecContext(context.Background(), stmt)
+}
+
+// TryExecContext is like TryExec, but uses the provided context
+func (tx *Tx) TryExecContext(ctx context.Context, stmt SQLStmt) (res sql.Result, err error) {
+	err = tx.withSavepoint(ctx, func() error {
+		res, err = execOn(ctx, tx.ext(), tx.defaults().Guards, &Statement{tx.ErrHandlers}, stmt)
+		return err
+	})
+
+	return res, err
+}
+
+// Transactional runs the provided function inside a savepoint of the
+// transaction, so that functions using transactions can be composed: if
+// the function returns an error, the transaction is rolled back to the
+// savepoint, discarding only the function's changes, and the error is
+// returned. Otherwise, the savepoint is released. Calls may be nested to
+// any depth:
+//
+//	err := db.Transactional(func(tx *sqlz.Tx) error {
+//	    if err := createOrder(tx, order); err != nil {
+//	        return err
+//	    }
+//
+//	    // a failure to notify the customer does not abort the order
+//	    tx.Transactional(func(tx *sqlz.Tx) error {
+//	        return notifyCustomer(tx, order)
+//	    })
+//
+//	    return nil
+//	})
+func (tx *Tx) Transactional(f func(tx *Tx) error) error {
+	return tx.TransactionalContext(context.Background(), f)
+}
+
+// TransactionalContext is like Transactional, but uses the provided context
+// for creating, rolling back to and releasing the savepoint
+func (tx *Tx) TransactionalContext(ctx context.Context, f func(tx *Tx) error) error {
+	return tx.withSavepoint(ctx, func() error {
+		return f(tx)
+	})
+}
+
+// savepoints is the sequence savepoint names are taken from. It is shared
+// by all transactions, so names are unique on every connection, even when
+// several Tx values (e.g. temporary ones created for raw sqlx execers)
+// wrap the same transaction.
+var savepoints uint64
+
+// withSavepoint runs the provided function inside a savepoint, rolling back
+// to the savepoint if it returns an error, and releasing it otherwise
+func (tx *Tx) withSavepoint(ctx context.Context, f func() error) error {
+	name := fmt.Sprintf("sqlz_savepoint_%d", atomic.AddUint64(&savepoints, 1))
+
+	// savepoint commands are executed like statements, so that query
+	// hooks and traces see them
+	execer := tx.ext()
+
+	_, err := execer.ExecContext(ctx, "SAVEPOINT "+name)
+	if err != nil {
+		return fmt.Errorf("failed creating savepoint: %w", err)
+	}
+
+	err = f()
+	if err != nil {
+		_, rbErr := execer.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name)
+		if rbErr != nil {
+			return fmt.Errorf("failed rolling back to savepoint: %v (after %w)", rbErr, err)
+		}
+
+		return err
+	}
+
+	_, err = execer.ExecContext(ctx, "RELEASE SAVEPOINT "+name)
+	if err != nil {
+		return fmt.Errorf("failed releasing savepoint: %w", err)
+	}
+
+	return nil
+}
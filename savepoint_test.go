@@ -0,0 +1,166 @@
+package sqlz
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestTryExec(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	errDuplicate := errors.New("duplicate key")
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`SAVEPOINT sqlz_savepoint_\d+`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO users \(id\) VALUES \(\$1\)`).WithArgs(1).WillReturnError(errDuplicate)
+	mock.ExpectExec(`ROLLBACK TO SAVEPOINT sqlz_savepoint_\d+`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`SAVEPOINT sqlz_savepoint_\d+`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO users \(id\) VALUES \(\$1\)`).WithArgs(2).WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectExec(`RELEASE SAVEPOINT sqlz_savepoint_\d+`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	var failed int
+
+	err = New(db, "postgres").Transactional(func(tx *Tx) error {
+		for _, id := range []int{1, 2} {
+			if _, err := tx.TryExec(tx.InsertInto("users").Columns("id").Values(id)); err != nil {
+				if !errors.Is(err, errDuplicate) {
+					return err
+				}
+
+				failed++
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Transaction failed: %s", err)
+	}
+
+	if failed != 1 {
+		t.Errorf("Expected 1 failed statement, got %d", failed)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %s", err)
+	}
+}
+
+func TestNestedTransactional(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	errFailed := errors.New("failed")
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO orders \(id\) VALUES \(\$1\)`).WithArgs(1).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`SAVEPOINT sqlz_savepoint_\d+`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO items \(order_id\) VALUES \(\$1\)`).WithArgs(1).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`SAVEPOINT sqlz_savepoint_\d+`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO notifications \(order_id\) VALUES \(\$1\)`).WithArgs(1).WillReturnError(errFailed)
+	mock.ExpectExec(`ROLLBACK TO SAVEPOINT sqlz_savepoint_\d+`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`RELEASE SAVEPOINT sqlz_savepoint_\d+`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	var nestedErr error
+
+	err = New(db, "postgres").Transactional(func(tx *Tx) error {
+		if _, err := tx.InsertInto("orders").Columns("id").Values(1).Exec(); err != nil {
+			return err
+		}
+
+		return tx.Transactional(func(tx *Tx) error {
+			if _, err := tx.InsertInto("items").Columns("order_id").Values(1).Exec(); err != nil {
+				return err
+			}
+
+			nestedErr = tx.Transactional(func(tx *Tx) error {
+				_, err := tx.InsertInto("notifications").Columns("order_id").Values(1).Exec()
+				return err
+			})
+
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatalf("Transaction failed: %s", err)
+	}
+
+	if !errors.Is(nestedErr, errFailed) {
+		t.Errorf("Expected nested transaction to fail, got %v", nestedErr)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %s", err)
+	}
+}
+
+func TestTryExecStatementPath(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	var (
+		queries  []string
+		modified []string
+	)
+
+	dbz := New(db, "postgres")
+	dbz.OnQuery(func(_ context.Context, event QueryEvent) {
+		queries = append(queries, event.SQL)
+	})
+	dbz.Defaults.WriteListeners = []WriteListener{func(_ context.Context, event WriteEvent) {
+		modified = append(modified, event.Tables...)
+	}}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`SAVEPOINT sqlz_savepoint_\d+`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`DELETE FROM sessions WHERE id = \$1`).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`RELEASE SAVEPOINT sqlz_savepoint_\d+`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	// statements created from the DB are executed inside the transaction
+	stmt := dbz.DeleteFrom("sessions").Where(Eq("id", 1))
+
+	err = dbz.Transactional(func(tx *Tx) error {
+		_, err := tx.TryExec(stmt)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Transaction failed: %s", err)
+	}
+
+	expected := []string{
+		`^SAVEPOINT sqlz_savepoint_\d+$`,
+		`^DELETE FROM sessions WHERE id = \$1$`,
+		`^RELEASE SAVEPOINT sqlz_savepoint_\d+$`,
+	}
+	if len(queries) != len(expected) {
+		t.Fatalf("Expected hooks to see %d queries, got %v", len(expected), queries)
+	}
+	for i, pattern := range expected {
+		if !regexp.MustCompile(pattern).MatchString(queries[i]) {
+			t.Errorf("Expected query %d to match %s, got %q", i, pattern, queries[i])
+		}
+	}
+
+	if !reflect.DeepEqual(modified, []string{"sessions"}) {
+		t.Errorf("Expected write listeners to be notified, got %v", modified)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %s", err)
+	}
+}
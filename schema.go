@@ -0,0 +1,424 @@
+package sqlz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrSchemaMismatch is returned (wrapped in a PolicyError) when executing a
+// statement referencing a table or column that does not exist in the
+// schema snapshot used by the DB or Tx it was created from (see
+// DB.StrictSchema)
+var ErrSchemaMismatch = errors.New("statement does not match schema")
+
+// Schema is a snapshot of the tables of a database and their columns,
+// keyed by table name. Tables outside the default schema should also be
+// keyed by their qualified name (e.g. "audit.events"). A Schema can be
+// loaded from the database with LoadSchema, or built manually in tests.
+type Schema map[string][]string
+
+// LoadSchema loads a snapshot of the tables and columns of the database
+// from its information schema. Tables are keyed both by name and by
+// qualified name, so that statements may reference them either way.
+// System schemas are excluded.
+func (db *DB) LoadSchema(ctx context.Context) (Schema, error) {
+	return loadSchema(ctx, db.Select("table_schema", "table_name", "column_name"))
+}
+
+// LoadSchema loads a snapshot of the tables and columns of the database
+// from its information schema (see DB.LoadSchema)
+func (tx *Tx) LoadSchema(ctx context.Context) (Schema, error) {
+	return loadSchema(ctx, tx.Select("table_schema", "table_name", "column_name"))
+}
+
+func loadSchema(ctx context.Context, stmt *SelectStmt) (Schema, error) {
+	var cols []struct {
+		Schema string `db:"table_schema"`
+		Table  string `db:"table_name"`
+		Column string `db:"column_name"`
+	}
+
+	err := stmt.From("information_schema.columns").
+		Where(NotIn("table_schema", "information_schema", "pg_catalog", "mysql", "performance_schema", "sys")).
+		Unbounded().
+		GetAllContext(ctx, &cols)
+	if err != nil {
+		return nil, err
+	}
+
+	schema := make(Schema)
+	for _, col := range cols {
+		schema[col.Table] = append(schema[col.Table], col.Column)
+		if col.Schema != "" {
+			qualified := col.Schema + "." + col.Table
+			schema[qualified] = append(schema[qualified], col.Column)
+		}
+	}
+
+	return schema, nil
+}
+
+// StrictSchema makes statements subsequently created from the DB (and from
+// transactions started from it) verify, before they are executed, that the
+// tables and columns they reference exist in the provided schema snapshot,
+// failing with ErrSchemaMismatch otherwise. This is mostly useful in tests
+// and CI, to catch typos in table and column names early. Only plain
+// identifiers are verified; expressions and raw SQL are ignored.
+func (db *DB) StrictSchema(schema Schema) {
+	db.Configure(func(defaults *Defaults) {
+		defaults.Guards = append(defaults.Guards, CheckSchema(schema))
+	})
+}
+
+// StrictSchema makes statements subsequently created from the Tx verify
+// the tables and columns they reference against the provided schema
+// snapshot (see DB.StrictSchema)
+func (tx *Tx) StrictSchema(schema Schema) {
+	tx.Configure(func(defaults *Defaults) {
+		defaults.Guards = append(defaults.Guards, CheckSchema(schema))
+	})
+}
+
+// CheckSchema returns a guard rejecting statements referencing tables or
+// columns that do not exist in the provided schema snapshot (see
+// DB.StrictSchema)
+func CheckSchema(schema Schema) Guard {
+	tables := make(map[string]map[string]bool, len(schema))
+	for table, cols := range schema {
+		colSet := make(map[string]bool, len(cols))
+		for _, col := range cols {
+			colSet[strings.ToLower(col)] = true
+		}
+
+		tables[strings.ToLower(table)] = colSet
+	}
+
+	return func(_ context.Context, stmt SQLStmt) error {
+		return (&schemaChecker{tables}).stmt(stmt, nil)
+	}
+}
+
+type schemaChecker struct {
+	tables map[string]map[string]bool
+}
+
+// schemaScope holds the tables a statement selects from, keyed by alias
+// (or name), along with the scopes of enclosing statements, which
+// correlated sub-queries may reference. Tables with nil columns are
+// auxiliary statements or sub-queries, whose columns are not known.
+type schemaScope struct {
+	tables  map[string]map[string]bool
+	aliases map[string]bool
+	parent  *schemaScope
+	// ctes are the names of the auxiliary statements of enclosing WITH
+	// statements
+	ctes map[string]bool
+}
+
+func (c *schemaChecker) newScope(parent *schemaScope) *schemaScope {
+	scope := &schemaScope{
+		tables:  make(map[string]map[string]bool),
+		aliases: make(map[string]bool),
+		parent:  parent,
+		ctes:    make(map[string]bool),
+	}
+
+	if parent != nil {
+		for name := range parent.ctes {
+			scope.ctes[name] = true
+		}
+	}
+
+	return scope
+}
+
+// addTable adds a table (possibly followed by an alias) to the scope,
+// returning an error if it does not exist
+func (c *schemaChecker) addTable(scope *schemaScope, table string) error {
+	fields := strings.Fields(table)
+	if len(fields) == 0 {
+		return nil
+	}
+
+	name := strings.ToLower(fields[0])
+	alias := strings.ToLower(fields[len(fields)-1])
+
+	if scope.ctes[name] || !safeIdentifier.MatchString(name) {
+		scope.tables[alias] = nil
+		return nil
+	}
+
+	cols, ok := c.tables[name]
+	if !ok {
+		return fmt.Errorf("%w: unknown table %s", ErrSchemaMismatch, fields[0])
+	}
+
+	scope.tables[alias] = cols
+
+	return nil
+}
+
+// addSubquery adds a sub-query with the provided alias to the scope
+func (c *schemaChecker) addSubquery(scope *schemaScope, alias string) {
+	if alias != "" {
+		scope.tables[strings.ToLower(alias)] = nil
+	}
+}
+
+// column returns an error if the provided column, which may be qualified
+// by a table name or alias, does not exist in the scope. Expressions and
+// columns of sub-queries are ignored.
+func (c *schemaChecker) column(scope *schemaScope, col string) error {
+	col = strings.ToLower(strings.TrimSpace(col))
+	if !safeIdentifier.MatchString(col) {
+		return nil
+	}
+
+	var table string
+	if i := strings.LastIndexByte(col, '.'); i >= 0 {
+		table, col = col[:i], col[i+1:]
+	}
+
+	for s := scope; s != nil; s = s.parent {
+		if table == "" && s.aliases[col] {
+			return nil
+		}
+
+		for name, cols := range s.tables {
+			if table != "" && name != table {
+				continue
+			}
+
+			if cols == nil || cols[col] {
+				return nil
+			}
+		}
+
+		if table != "" {
+			if _, ok := s.tables[table]; ok {
+				return fmt.Errorf("%w: unknown column %s.%s", ErrSchemaMismatch, table, col)
+			}
+		}
+	}
+
+	if table != "" {
+		return fmt.Errorf("%w: unknown column %s.%s", ErrSchemaMismatch, table, col)
+	}
+
+	return fmt.Errorf("%w: unknown column %s", ErrSchemaMismatch, col)
+}
+
+func (c *schemaChecker) columns(scope *schemaScope, cols []string) error {
+	for _, col := range cols {
+		if err := c.column(scope, col); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *schemaChecker) stmt(stmt SQLStmt, parent *schemaScope) error { //nolint: gocognit, gocyclo
+	scope := c.newScope(parent)
+
+	switch s := stmt.(type) {
+	case *SelectStmt:
+		if err := c.addTable(scope, s.Table); err != nil {
+			return err
+		}
+
+		for _, join := range s.Joins {
+			if join.ResultSet != nil {
+				if err := c.stmt(join.ResultSet, scope); err != nil {
+					return err
+				}
+
+				c.addSubquery(scope, join.Table)
+			} else if err := c.addTable(scope, join.Table); err != nil {
+				return err
+			}
+		}
+
+		for _, col := range s.Columns {
+			if i := strings.LastIndex(strings.ToUpper(col), " AS "); i >= 0 {
+				scope.aliases[strings.ToLower(strings.TrimSpace(col[i+4:]))] = true
+				continue
+			}
+
+			if err := c.column(scope, col); err != nil {
+				return err
+			}
+		}
+
+		for _, join := range s.Joins {
+			if err := c.columns(scope, join.UsingColumns); err != nil {
+				return err
+			}
+
+			if err := c.conditions(scope, join.Conditions); err != nil {
+				return err
+			}
+		}
+
+		if err := c.conditions(scope, s.Conditions); err != nil {
+			return err
+		}
+
+		if err := c.columns(scope, s.Grouping); err != nil {
+			return err
+		}
+
+		if err := c.conditions(scope, s.GroupConditions); err != nil {
+			return err
+		}
+
+		for _, order := range s.Ordering {
+			if col, ok := order.(OrderColumn); ok {
+				if err := c.column(scope, col.Column); err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, union := range s.Unions {
+			if err := c.stmt(union, parent); err != nil {
+				return err
+			}
+		}
+	case *InsertStmt:
+		if err := c.addTable(scope, s.Table); err != nil {
+			return err
+		}
+
+		if err := c.columns(scope, s.InsCols); err != nil {
+			return err
+		}
+
+		if s.SelectStmt != nil {
+			return c.stmt(s.SelectStmt, parent)
+		}
+	case *UpdateStmt:
+		if err := c.addTable(scope, s.Table); err != nil {
+			return err
+		}
+
+		for col := range s.Updates {
+			if err := c.column(scope, col); err != nil {
+				return err
+			}
+		}
+
+		for _, table := range s.FromTables {
+			if err := c.addTable(scope, table); err != nil {
+				return err
+			}
+		}
+
+		if s.SelectStmt != nil {
+			if err := c.stmt(s.SelectStmt, parent); err != nil {
+				return err
+			}
+
+			c.addSubquery(scope, s.SelectStmtAlias)
+		}
+
+		c.addSubquery(scope, s.MultipleValues.As)
+
+		for _, join := range s.Joins {
+			if err := c.addTable(scope, join.Table); err != nil {
+				return err
+			}
+		}
+
+		for _, join := range s.Joins {
+			if err := c.conditions(scope, join.Conditions); err != nil {
+				return err
+			}
+		}
+
+		return c.conditions(scope, s.Conditions)
+	case *DeleteStmt:
+		if err := c.addTable(scope, s.Table); err != nil {
+			return err
+		}
+
+		for _, table := range s.UsingTables {
+			if err := c.addTable(scope, table); err != nil {
+				return err
+			}
+		}
+
+		for _, join := range s.Joins {
+			if err := c.addTable(scope, join.Table); err != nil {
+				return err
+			}
+		}
+
+		for _, join := range s.Joins {
+			if err := c.conditions(scope, join.Conditions); err != nil {
+				return err
+			}
+		}
+
+		return c.conditions(scope, s.Conditions)
+	case *WithStmt:
+		for _, aux := range s.AuxStmts {
+			if err := c.stmt(aux.Stmt, scope); err != nil {
+				return err
+			}
+
+			scope.ctes[strings.ToLower(aux.As)] = true
+		}
+
+		if s.MainStmt != nil {
+			return c.stmt(s.MainStmt, scope)
+		}
+	}
+
+	return nil
+}
+
+func (c *schemaChecker) conditions(scope *schemaScope, conds []WhereCondition) error {
+	for _, cond := range conds {
+		if err := c.condition(scope, cond); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *schemaChecker) condition(scope *schemaScope, cond WhereCondition) error {
+	switch cnd := cond.(type) {
+	case SimpleCondition:
+		if err := c.column(scope, cnd.Left); err != nil {
+			return err
+		}
+
+		if indirect, ok := cnd.Right.(IndirectValue); ok {
+			return c.column(scope, indirect.Reference)
+		}
+	case InCondition:
+		return c.column(scope, cnd.Left)
+	case BetweenCondition:
+		return c.column(scope, cnd.Left)
+	case AndOrCondition:
+		return c.conditions(scope, cnd.Conditions)
+	case PreCondition:
+		return c.condition(scope, cnd.Condition)
+	case GroupCondition:
+		return c.condition(scope, cnd.Condition)
+	case SubqueryCondition:
+		if cnd.Left != "" {
+			if err := c.column(scope, cnd.Left); err != nil {
+				return err
+			}
+		}
+
+		return c.stmt(cnd.Stmt, scope)
+	}
+
+	return nil
+}
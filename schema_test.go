@@ -0,0 +1,142 @@
+package sqlz
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestCheckSchema(t *testing.T) {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	dbz := New(db, "postgres")
+
+	guard := CheckSchema(Schema{
+		"users":        {"id", "name", "role_id", "created_at"},
+		"roles":        {"id", "name"},
+		"orders":       {"id", "user_id", "total"},
+		"audit.events": {"id", "user_id"},
+	})
+
+	tests := []struct {
+		name string
+		stmt SQLStmt
+		err  string
+	}{
+		{
+			"valid select with joins and aliases",
+			dbz.Select("u.id", "r.name", "COUNT(o.id) AS num_orders").
+				From("users u").
+				InnerJoin("roles r", Eq("r.id", Indirect("u.role_id"))).
+				LeftJoin("orders AS o", Eq("o.user_id", Indirect("u.id"))).
+				Where(Gte("created_at", "2020-01-01"), Or(In("u.name", "a", "b"), IsNull("r.name"))).
+				GroupBy("u.id", "r.name").
+				OrderBy(Desc("num_orders")),
+			"",
+		},
+		{
+			"valid correlated sub-query",
+			dbz.Select("*").From("users u").Where(
+				Exists(dbz.Select("1").From("orders o").Where(Eq("o.user_id", Indirect("u.id")))),
+			),
+			"",
+		},
+		{
+			"valid WITH statement",
+			dbz.With(dbz.Select("user_id").From("audit.events"), "active").
+				Then(dbz.Select("a.user_id", "name").From("active a").InnerJoin("users u", Eq("u.id", Indirect("a.user_id")))),
+			"",
+		},
+		{
+			"unknown table",
+			dbz.Select("*").From("userz"),
+			"unknown table userz",
+		},
+		{
+			"unknown column",
+			dbz.Select("id").From("users").Where(Eq("nmae", "Alice")),
+			"unknown column nmae",
+		},
+		{
+			"unknown qualified column",
+			dbz.Select("u.id").From("users u").InnerJoin("roles r", Eq("r.id", Indirect("u.role"))),
+			"unknown column u.role",
+		},
+		{
+			"unknown column in INSERT",
+			dbz.InsertInto("orders").Columns("id", "userid").Values(1, 2),
+			"unknown column userid",
+		},
+		{
+			"unknown column in UPDATE",
+			dbz.Update("users").Set("nme", "Bob").Where(Eq("id", 1)),
+			"unknown column nme",
+		},
+		{
+			"unknown table in DELETE",
+			dbz.DeleteFrom("order").Where(Eq("id", 1)),
+			"unknown table order",
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.name, func(t *testing.T) {
+			err := guard(context.Background(), tst.stmt)
+			if tst.err == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got %s", err)
+				}
+
+				return
+			}
+
+			if !errors.Is(err, ErrSchemaMismatch) || err.Error() != ErrSchemaMismatch.Error()+": "+tst.err {
+				t.Errorf("Expected %q, got %v", tst.err, err)
+			}
+		})
+	}
+}
+
+func TestLoadSchema(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	dbz := New(db, "postgres")
+
+	mock.ExpectQuery(`SELECT table_schema, table_name, column_name FROM information_schema.columns WHERE table_schema NOT IN`).
+		WillReturnRows(sqlmock.NewRows([]string{"table_schema", "table_name", "column_name"}).
+			AddRow("public", "users", "id").
+			AddRow("public", "users", "name"))
+
+	schema, err := dbz.LoadSchema(context.Background())
+	if err != nil {
+		t.Fatalf("LoadSchema failed: %s", err)
+	}
+
+	expected := Schema{
+		"users":        {"id", "name"},
+		"public.users": {"id", "name"},
+	}
+
+	if !reflect.DeepEqual(schema, expected) {
+		t.Errorf("Expected %v, got %v", expected, schema)
+	}
+
+	dbz.StrictSchema(schema)
+
+	if _, err := dbz.DeleteFrom("users").Where(Eq("nam", "x")).Exec(); !errors.Is(err, ErrSchemaMismatch) || !IsPolicyError(err) {
+		t.Errorf("Expected a schema mismatch policy error, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %s", err)
+	}
+}
@@ -0,0 +1,186 @@
+package sqlz
+
+import (
+	"context"
+	"strings"
+)
+
+// ScopeFunc returns the condition restricting a statement's access to a
+// scoped table, usually based on values from the context the statement is
+// executed with (e.g. the current tenant). The table is provided along
+// with the name it is referenced by in the statement (its alias, if it
+// has one, otherwise its name), which should be used to qualify columns,
+// e.g.:
+//
+//	func(ctx context.Context, table, ref string) WhereCondition {
+//		return Eq(ref+".tenant_id", TenantFrom(ctx))
+//	}
+//
+// Returning nil leaves the statement unrestricted.
+type ScopeFunc func(ctx context.Context, table, ref string) WhereCondition
+
+// Scope automatically restricts SELECT, UPDATE and DELETE statements on a
+// set of tables, e.g. to implement multi-tenancy (see Defaults.Scope).
+// Conditions are added when statements are executed: to the WHERE clause
+// for the main table of the statement, and to the ON clause for joined
+// tables. UNION branches, joined sub-queries and the SELECT statements of
+// INSERT ... SELECT and UPDATE ... FROM are restricted by their own scopes,
+// which they get from the DB or Tx they were created from. Tables
+// referenced only in sub-queries of conditions are not restricted.
+// Statements may opt out by calling Unscoped.
+type Scope struct {
+	fn     ScopeFunc
+	tables map[string]bool
+}
+
+// WithScope creates a new Scope restricting statements on the provided
+// tables with the conditions returned by the provided function
+func WithScope(fn ScopeFunc, tables ...string) *Scope {
+	scope := &Scope{
+		fn:     fn,
+		tables: make(map[string]bool, len(tables)),
+	}
+
+	for _, table := range tables {
+		scope.tables[table] = true
+	}
+
+	return scope
+}
+
+// condition returns the scope's condition for the provided table
+// reference (e.g. "users u"), or nil if the table is not scoped
+func (scope *Scope) condition(ctx context.Context, table string) WhereCondition {
+	if scope == nil {
+		return nil
+	}
+
+	fields := strings.Fields(table)
+	if len(fields) == 0 || !scope.tables[fields[0]] {
+		return nil
+	}
+
+	return scope.fn(ctx, fields[0], fields[len(fields)-1])
+}
+
+// scoped returns a copy of the statement restricted by its scope, with its
+// union branches and joined sub-queries restricted by theirs
+func (stmt *SelectStmt) scoped(ctx context.Context) *SelectStmt {
+	scope := stmt.defaults.Scope
+	if scope == nil && len(stmt.Joins) == 0 && len(stmt.Unions) == 0 {
+		return stmt
+	}
+
+	scoped := *stmt
+
+	if cond := scope.condition(ctx, stmt.Table); cond != nil {
+		scoped.Conditions = append(append([]WhereCondition{}, stmt.Conditions...), cond)
+	}
+
+	scoped.Joins = scope.joins(ctx, stmt.Joins)
+
+	if len(stmt.Unions) > 0 {
+		scoped.Unions = make([]*SelectStmt, len(stmt.Unions))
+		for i, union := range stmt.Unions {
+			scoped.Unions[i] = union.scoped(ctx)
+		}
+	}
+
+	return &scoped
+}
+
+// joins returns a copy of the provided joins, with the scope's conditions
+// added to the ON clauses of joined tables, and joined sub-queries
+// restricted by their own scopes. The scope may be nil.
+func (scope *Scope) joins(ctx context.Context, joins []JoinClause) []JoinClause {
+	if len(joins) == 0 {
+		return joins
+	}
+
+	scoped := make([]JoinClause, len(joins))
+
+	for i, join := range joins {
+		switch {
+		case join.ResultSet != nil:
+			join.ResultSet = join.ResultSet.scoped(ctx)
+		case join.Type.hasOnClause() && len(join.UsingColumns) == 0:
+			if cond := scope.condition(ctx, join.Table); cond != nil {
+				join.Conditions = append(append([]WhereCondition{}, join.Conditions...), cond)
+			}
+		}
+
+		scoped[i] = join
+	}
+
+	return scoped
+}
+
+// Unscoped disables the restrictions of the scope of the DB or Tx the
+// statement was created from (see Defaults.Scope)
+func (stmt *SelectStmt) Unscoped() *SelectStmt {
+	stmt.defaults.Scope = nil
+	return stmt
+}
+
+// scoped returns a copy of the statement restricted by its scope
+func (stmt *UpdateStmt) scoped(ctx context.Context) *UpdateStmt {
+	if stmt.CurrentOf != "" || (stmt.scope == nil && len(stmt.Joins) == 0 && stmt.SelectStmt == nil) {
+		return stmt
+	}
+
+	scoped := *stmt
+	scoped.Joins = stmt.scope.joins(ctx, stmt.Joins)
+
+	if stmt.SelectStmt != nil {
+		scoped.SelectStmt = stmt.SelectStmt.scoped(ctx)
+	}
+
+	if cond := stmt.scope.condition(ctx, stmt.Table); cond != nil {
+		scoped.Conditions = append(append([]WhereCondition{}, stmt.Conditions...), cond)
+	}
+
+	return &scoped
+}
+
+// scoped returns a copy of the statement whose SELECT statement (see
+// FromSelect) is restricted by its scope
+func (stmt *InsertStmt) scoped(ctx context.Context) *InsertStmt {
+	if stmt.SelectStmt == nil {
+		return stmt
+	}
+
+	scoped := *stmt
+	scoped.SelectStmt = stmt.SelectStmt.scoped(ctx)
+
+	return &scoped
+}
+
+// Unscoped disables the restrictions of the scope of the DB or Tx the
+// statement was created from (see Defaults.Scope)
+func (stmt *UpdateStmt) Unscoped() *UpdateStmt {
+	stmt.scope = nil
+	return stmt
+}
+
+// scoped returns a copy of the statement restricted by its scope
+func (stmt *DeleteStmt) scoped(ctx context.Context) *DeleteStmt {
+	if stmt.CurrentOf != "" || (stmt.scope == nil && len(stmt.Joins) == 0) {
+		return stmt
+	}
+
+	scoped := *stmt
+	scoped.Joins = stmt.scope.joins(ctx, stmt.Joins)
+
+	if cond := stmt.scope.condition(ctx, stmt.Table); cond != nil {
+		scoped.Conditions = append(append([]WhereCondition{}, stmt.Conditions...), cond)
+	}
+
+	return &scoped
+}
+
+// Unscoped disables the restrictions of the scope of the DB or Tx the
+// statement was created from (see Defaults.Scope)
+func (stmt *DeleteStmt) Unscoped() *DeleteStmt {
+	stmt.scope = nil
+	return stmt
+}
@@ -0,0 +1,147 @@
+package sqlz
+
+import (
+	"context"
+	"testing"
+
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+type tenantKey struct{}
+
+func TestScope(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	dbz := New(db, "postgres")
+	dbz.Defaults.Scope = WithScope(func(ctx context.Context, table, ref string) WhereCondition {
+		return Eq(ref+".tenant_id", ctx.Value(tenantKey{}))
+	}, "users", "orders")
+
+	ctx := context.WithValue(context.Background(), tenantKey{}, 7)
+
+	mock.ExpectQuery(`SELECT o.id FROM orders o LEFT JOIN users u ON u.id = o.user_id AND u.tenant_id = \$1 LEFT JOIN products p ON p.id = o.product_id WHERE o.total > \$2 AND o.tenant_id = \$3`).
+		WithArgs(7, 100, 7).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectExec(`UPDATE users SET name = \$1 WHERE id = \$2 AND users.tenant_id = \$3`).
+		WithArgs("Alice", 1, 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM orders WHERE id = \$1$`).
+		WithArgs(2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	stmt := dbz.Select("o.id").
+		From("orders o").
+		LeftJoin("users u", Eq("u.id", Indirect("o.user_id"))).
+		LeftJoin("products p", Eq("p.id", Indirect("o.product_id"))).
+		Where(Gt("o.total", 100))
+
+	var ids []int64
+
+	if err := stmt.GetAllContext(ctx, &ids); err != nil {
+		t.Fatalf("Select failed: %s", err)
+	}
+
+	// the statement itself must not be modified
+	if asSQL, _ := stmt.ToSQL(false); asSQL != "SELECT o.id FROM orders o LEFT JOIN users u ON u.id = o.user_id LEFT JOIN products p ON p.id = o.product_id WHERE o.total > ?" {
+		t.Errorf("Statement was modified by scope: %s", asSQL)
+	}
+
+	if _, err := dbz.Update("users").Set("name", "Alice").Where(Eq("id", 1)).ExecContext(ctx); err != nil {
+		t.Fatalf("Update failed: %s", err)
+	}
+
+	if _, err := dbz.DeleteFrom("orders").Where(Eq("id", 2)).Unscoped().ExecContext(ctx); err != nil {
+		t.Fatalf("Delete failed: %s", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %s", err)
+	}
+}
+
+func TestScopeUnionsAndJoins(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	dbz := New(db, "postgres")
+	dbz.Defaults.Scope = WithScope(func(ctx context.Context, table, ref string) WhereCondition {
+		return Eq(ref+".tenant_id", ctx.Value(tenantKey{}))
+	}, "orders")
+
+	ctx := context.WithValue(context.Background(), tenantKey{}, 7)
+
+	mock.ExpectQuery(`SELECT \* FROM orders WHERE orders.tenant_id = \$1 `+
+		`UNION SELECT \* FROM orders WHERE orders.tenant_id = \$2`).
+		WithArgs(7, 7).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(`SELECT u.id FROM users u ` +
+		`INNER JOIN \(SELECT user_id FROM orders WHERE orders.tenant_id = \$1\) o ON o.user_id = u.id`).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	var ids []int64
+
+	err = dbz.Select("*").From("orders").Union(dbz.Select("*").From("orders")).GetAllContext(ctx, &ids)
+	if err != nil {
+		t.Fatalf("Union failed: %s", err)
+	}
+
+	err = dbz.Select("u.id").
+		From("users u").
+		InnerJoinRS(dbz.Select("user_id").From("orders"), "o", Eq("o.user_id", Indirect("u.id"))).
+		GetAllContext(ctx, &ids)
+	if err != nil {
+		t.Fatalf("Join failed: %s", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %s", err)
+	}
+}
+
+func TestScopeTryExecAndScript(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	dbz := New(db, "postgres")
+	dbz.Defaults.Scope = WithScope(func(ctx context.Context, table, ref string) WhereCondition {
+		return Eq(ref+".tenant_id", ctx.Value(tenantKey{}))
+	}, "users")
+
+	ctx := context.WithValue(context.Background(), tenantKey{}, 7)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`SAVEPOINT sqlz_savepoint_\d+`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`UPDATE users SET name = \$1 WHERE id = \$2 AND users.tenant_id = \$3`).
+		WithArgs("Alice", 1, 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`RELEASE SAVEPOINT sqlz_savepoint_\d+`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	mock.ExpectExec(`UPDATE users SET name = \$1 WHERE id = \$2 AND users.tenant_id = \$3`).
+		WithArgs("Bob", 2, 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = dbz.TransactionalContext(ctx, nil, func(tx *Tx) error {
+		_, err := tx.TryExecContext(ctx, tx.Update("users").Set("name", "Alice").Where(Eq("id", 1)))
+		return err
+	})
+	if err != nil {
+		t.Fatalf("TryExec failed: %s", err)
+	}
+
+	_, err = dbz.Script().Add(dbz.Update("users").Set("name", "Bob").Where(Eq("id", 2))).ExecContext(ctx)
+	if err != nil {
+		t.Fatalf("Script failed: %s", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %s", err)
+	}
+}
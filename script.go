@@ -0,0 +1,120 @@
+package sqlz
+
+import (
+	"context"
+	"database/sql"
+)
+
+// ErrorPolicy determines what a Script does when one of its statements
+// fails
+type ErrorPolicy int8
+
+const (
+	// StopOnError stops the script when the statement fails
+	StopOnError ErrorPolicy = iota
+	// ContinueOnError continues to the next statement when the
+	// statement fails
+	ContinueOnError
+)
+
+// ScriptStep is a statement in a Script
+type ScriptStep struct {
+	Stmt    SQLStmt
+	OnError ErrorPolicy
+}
+
+// ScriptResult is the result of executing one statement of a Script
+type ScriptResult struct {
+	// Stmt is the statement that was executed
+	Stmt SQLStmt
+	// Result is the result of the execution, if it succeeded
+	Result sql.Result
+	// Err is the error returned by the execution, if it failed
+	Err error
+}
+
+// Script represents a sequence of statements (sqlz statements or raw SQL)
+// that are executed one after the other, useful for seeding databases and
+// maintenance tasks. Note that when created from a DB object, statements
+// are not executed inside a transaction; create the script from a Tx
+// object if that is required.
+type Script struct {
+	*Statement
+	Steps  []ScriptStep
+	execer Ext
+	guards []Guard
+}
+
+// Script creates a new, empty Script
+func (db *DB) Script() *Script {
+	return &Script{
+		execer:    db.ext(),
+		guards:    db.defaults().Guards,
+		Statement: &Statement{db.ErrHandlers},
+	}
+}
+
+// Script creates a new, empty Script
+func (tx *Tx) Script() *Script {
+	return &Script{
+		execer:    tx.ext(),
+		guards:    tx.defaults().Guards,
+		Statement: &Statement{tx.ErrHandlers},
+	}
+}
+
+// Add adds statements to the script. By default, the script stops when a
+// statement fails; use ContinueOnError after Add to change that.
+func (script *Script) Add(stmts ...SQLStmt) *Script {
+	for _, stmt := range stmts {
+		script.Steps = append(script.Steps, ScriptStep{Stmt: stmt})
+	}
+
+	return script
+}
+
+// Raw adds a statement written in raw SQL to the script. Question marks
+// must be used for placeholders regardless of the database driver.
+func (script *Script) Raw(asSQL string, bindings ...interface{}) *Script {
+	return script.Add(Indirect(asSQL, bindings...))
+}
+
+// ContinueOnError sets the error policy of the last statement added to the
+// script, so that the script continues to the next statement if it fails
+func (script *Script) ContinueOnError() *Script {
+	if len(script.Steps) > 0 {
+		script.Steps[len(script.Steps)-1].OnError = ContinueOnError
+	}
+
+	return script
+}
+
+// Exec executes the script's statements in order, and returns a result for
+// every statement executed. If a statement whose error policy is
+// StopOnError fails, execution stops and its error is returned.
+func (script *Script) Exec() (results []ScriptResult, err error) {
+	return script.ExecContext(context.Background())
+}
+
+// ExecContext executes the script's statements in order, and returns a
+// result for every statement executed. If a statement whose error policy
+// is StopOnError fails, execution stops and its error is returned. SQLz
+// statements are executed with the script's execer through their own
+// ExecContext method, so they are scoped, audited, timed out and reported
+// to write listeners as usual, and their errors are handled by their own
+// error handlers.
+func (script *Script) ExecContext(ctx context.Context) (results []ScriptResult, err error) {
+	results = make([]ScriptResult, 0, len(script.Steps))
+
+	for _, step := range script.Steps {
+		res, err := execOn(ctx, script.execer, script.guards, script.Statement, step.Stmt)
+
+		results = append(results, ScriptResult{Stmt: step.Stmt, Result: res, Err: err})
+
+		if err != nil && step.OnError == StopOnError {
+			return results, err
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,99 @@
+package sqlz
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestScript(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	dbz := New(db, "sqlmock")
+	errFailed := errors.New("failed")
+
+	mock.ExpectExec("DELETE FROM logs").WillReturnResult(sqlmock.NewResult(0, 5))
+	mock.ExpectExec("CREATE INDEX").WillReturnError(errFailed)
+	mock.ExpectExec("INSERT INTO users").WithArgs(1, "admin").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("UPDATE users").WillReturnError(errFailed)
+
+	results, err := dbz.Script().
+		Add(dbz.DeleteFrom("logs")).
+		Raw("CREATE INDEX idx ON users (name)").ContinueOnError().
+		Add(dbz.InsertInto("users").Columns("id", "name").Values(1, "admin")).
+		Add(dbz.Update("users").Set("name", "root")).
+		Raw("SELECT 1").
+		Exec()
+	if !errors.Is(err, errFailed) {
+		t.Fatalf("Expected script to fail, got %v", err)
+	}
+
+	if len(results) != 4 {
+		t.Fatalf("Expected 4 results, got %d", len(results))
+	}
+
+	if results[0].Err != nil || results[2].Err != nil {
+		t.Errorf("Expected first and third statements to succeed")
+	}
+
+	if results[1].Err == nil || results[3].Err == nil {
+		t.Errorf("Expected second and fourth statements to fail")
+	}
+
+	if n, _ := results[0].Result.RowsAffected(); n != 5 {
+		t.Errorf("Expected first statement to affect 5 rows, got %d", n)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %s", err)
+	}
+}
+
+func TestScriptStatementPath(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	var modified []string
+
+	dbz := New(db, "postgres")
+	dbz.Defaults.Audit = &Audit{
+		Tables:    []string{"users"},
+		CreatedAt: "created_at",
+		Now:       func() time.Time { return now },
+	}
+	dbz.Defaults.WriteListeners = []WriteListener{func(_ context.Context, event WriteEvent) {
+		modified = append(modified, event.Tables...)
+	}}
+
+	mock.ExpectExec(`INSERT INTO users \(name, created_at\) VALUES \(\$1, \$2\)`).
+		WithArgs("admin", now).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`VACUUM users`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	_, err = dbz.Script().
+		Add(dbz.InsertInto("users").Columns("name").Values("admin")).
+		Raw("VACUUM users").
+		Exec()
+	if err != nil {
+		t.Fatalf("Script failed: %s", err)
+	}
+
+	if !reflect.DeepEqual(modified, []string{"users"}) {
+		t.Errorf("Expected write listeners to be notified, got %v", modified)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %s", err)
+	}
+}
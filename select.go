@@ -2,14 +2,17 @@ package sqlz
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 )
 
 // JoinType is an enumerated type representing the
-// type of a JOIN clause (INNER, LEFT, RIGHT or FULL)
+// type of a JOIN clause (INNER, LEFT, RIGHT, FULL, CROSS or NATURAL)
 type JoinType string
 
 // InnerJoin represents an inner join
@@ -19,6 +22,8 @@ type JoinType string
 // InnerLateralJoin represents an inner lateral join
 // LeftLateralJoin represents a left lateral join
 // RightLateralJoin represents a right lateral join
+// CrossJoin represents a cross join
+// NaturalJoin represents a natural (inner) join
 const (
 	InnerJoin        JoinType = "INNER JOIN"
 	LeftJoin         JoinType = "LEFT JOIN"
@@ -27,6 +32,8 @@ const (
 	InnerLateralJoin          = "INNER JOIN LATERAL"
 	LeftLateralJoin           = "LEFT JOIN LATERAL"
 	RightLateralJoin          = "RIGHT JOIN LATERAL"
+	CrossJoin        JoinType = "CROSS JOIN"
+	NaturalJoin      JoinType = "NATURAL JOIN"
 )
 
 // String returns the string representation of the
@@ -40,6 +47,25 @@ func (j JoinType) IsLateral() bool {
 	return j == InnerLateralJoin || j == LeftLateralJoin || j == RightLateralJoin
 }
 
+// hasOnClause returns false for join types that do not accept an ON clause
+func (j JoinType) hasOnClause() bool {
+	return j != CrossJoin && j != NaturalJoin
+}
+
+// lateral returns the lateral version of the join type, if there is one
+func (j JoinType) lateral() JoinType {
+	switch j {
+	case InnerJoin:
+		return InnerLateralJoin
+	case LeftJoin:
+		return LeftLateralJoin
+	case RightJoin:
+		return RightLateralJoin
+	default:
+		return j
+	}
+}
+
 // SelectStmt represents a SELECT statement
 type SelectStmt struct {
 	Table           string
@@ -48,27 +74,37 @@ type SelectStmt struct {
 	OffsetRows      int64
 	IsDistinct      bool
 	IsUnionAll      bool
+	ParenUnions     bool
+	typedMaps       bool
+	defaults        Defaults
 	orderWithNulls  orderWithNulls
 	queryer         Queryer
+	retry           *RetryPolicy
+	timeout         time.Duration
+	cacheTTL        time.Duration
 	DistinctColumns []string
 	Columns         []string
+	ColumnBindings  []interface{}
 	Joins           []JoinClause
 	Conditions      []WhereCondition
 	Ordering        []SQLStmt
 	Grouping        []string
 	GroupConditions []WhereCondition
+	Windows         []NamedWindow
 	Unions          []*SelectStmt
 	Locks           []*LockClause
+	OuterAliases    []string
 	*Statement
 }
 
 // JoinClause represents a JOIN clause in a
 // SELECT statement
 type JoinClause struct {
-	Type       JoinType
-	Table      string
-	ResultSet  *SelectStmt
-	Conditions []WhereCondition
+	Type         JoinType
+	Table        string
+	ResultSet    *SelectStmt
+	Conditions   []WhereCondition
+	UsingColumns []string
 }
 
 // LockClause represents a row or table level locking for a SELECT statement
@@ -147,6 +183,46 @@ func (o OrderColumn) ToSQL(_ bool) (string, []interface{}) {
 	return str, nil
 }
 
+// RandomOrder represents random ordering of results in an ORDER BY
+// clause. The function used depends on the database's dialect: RAND() for
+// MySQL, RANDOM() for everything else.
+type RandomOrder struct {
+	Seed    int64
+	Seeded  bool
+	dialect Dialect
+}
+
+// ToSQL generates SQL for a RandomOrder
+func (o RandomOrder) ToSQL(_ bool) (string, []interface{}) {
+	if o.dialect == DialectMySQL {
+		if o.Seeded {
+			return fmt.Sprintf("RAND(%d)", o.Seed), nil
+		}
+
+		return "RAND()", nil
+	}
+
+	return "RANDOM()", nil
+}
+
+func (o RandomOrder) forDialect(dialect Dialect) SQLStmt {
+	o.dialect = dialect
+	return o
+}
+
+// Random creates a RandomOrder for sampling rows in random order
+func Random() RandomOrder {
+	return RandomOrder{}
+}
+
+// RandomSeed creates a RandomOrder with a seed, so that the same order is
+// returned across executions. Only MySQL accepts a seed in its random
+// function; other databases ignore the seed (in PostgreSQL, use setseed
+// in the same session instead).
+func RandomSeed(seed int64) RandomOrder {
+	return RandomOrder{Seed: seed, Seeded: true}
+}
+
 // Asc creates an OrderColumn for the provided
 // column in ascending order
 func Asc(col string) OrderColumn {
@@ -159,6 +235,42 @@ func Desc(col string) OrderColumn {
 	return OrderColumn{col, true}
 }
 
+// OrderExpression represents an arbitrary expression in an ORDER BY clause,
+// which may include placeholders, e.g. similarity(name, ?)
+type OrderExpression struct {
+	Expr      string
+	Bindings  []interface{}
+	Direction string
+}
+
+// OrderExpr creates an OrderExpression from the provided SQL and bindings,
+// e.g. OrderExpr("similarity(name, ?)", query).Desc(). Question marks must
+// be used for placeholders regardless of the database driver.
+func OrderExpr(expr string, bindings ...interface{}) OrderExpression {
+	return OrderExpression{Expr: expr, Bindings: bindings}
+}
+
+// Asc returns a copy of the expression in ascending order
+func (o OrderExpression) Asc() OrderExpression {
+	o.Direction = "ASC"
+	return o
+}
+
+// Desc returns a copy of the expression in descending order
+func (o OrderExpression) Desc() OrderExpression {
+	o.Direction = "DESC"
+	return o
+}
+
+// ToSQL generates SQL for an OrderExpression
+func (o OrderExpression) ToSQL(_ bool) (string, []interface{}) {
+	if o.Direction == "" {
+		return o.Expr, o.Bindings
+	}
+
+	return o.Expr + " " + o.Direction, o.Bindings
+}
+
 // Select creates a new SelectStmt object, selecting
 // the provided columns. You can use any SQL syntax
 // supported by your database system, e.g. Select("*"),
@@ -166,7 +278,8 @@ func Desc(col string) OrderColumn {
 func (db *DB) Select(cols ...string) *SelectStmt {
 	return &SelectStmt{
 		Columns:   append([]string{}, cols...),
-		queryer:   db.DB,
+		queryer:   db.ext(),
+		defaults:  db.defaults(),
 		Statement: &Statement{db.ErrHandlers},
 	}
 }
@@ -178,11 +291,31 @@ func (db *DB) Select(cols ...string) *SelectStmt {
 func (tx *Tx) Select(cols ...string) *SelectStmt {
 	return &SelectStmt{
 		Columns:   append([]string{}, cols...),
-		queryer:   tx.Tx,
+		queryer:   tx.ext(),
+		defaults:  tx.defaults(),
 		Statement: &Statement{tx.ErrHandlers},
 	}
 }
 
+// WithQueryer sets the queryer the statement is executed with, overriding
+// the DB or Tx it was created from. This allows a statement built once to
+// be executed against a different transaction or database (e.g. a
+// replica). Both *DB and *Tx objects can be provided.
+func (stmt *SelectStmt) WithQueryer(q Queryer) *SelectStmt {
+	stmt.queryer = q
+	return stmt
+}
+
+// WithTimeout sets a timeout for executing the statement. Every execution
+// of the statement derives a context with this timeout (from the provided
+// context, if any), so the query is cancelled if it runs for too long. To
+// also enforce the timeout on the server inside a transaction, see
+// Tx.SetTimeout.
+func (stmt *SelectStmt) WithTimeout(d time.Duration) *SelectStmt {
+	stmt.timeout = d
+	return stmt
+}
+
 // Distinct marks the statements as a SELECT DISTINCT
 // statement
 func (stmt *SelectStmt) Distinct(cols ...string) *SelectStmt {
@@ -192,6 +325,57 @@ func (stmt *SelectStmt) Distinct(cols ...string) *SelectStmt {
 	return stmt
 }
 
+// SelectPrefixed adds columns to the statement, aliased with the provided
+// prefix, so that rows of joined tables can be loaded into nested structs.
+// For example, SelectPrefixed("customer", "c.id", "c.name") selects
+// `c.id AS "customer.id", c.name AS "customer.name"`, which sqlx loads
+// into the ID and Name fields of a struct field tagged `db:"customer"`:
+//
+//	type Order struct {
+//		ID       int64    `db:"id"`
+//		Customer Customer `db:"customer"`
+//	}
+//
+// Columns must be provided individually (i.e. "c.*" is not supported).
+func (stmt *SelectStmt) SelectPrefixed(prefix string, cols ...string) *SelectStmt {
+	stmt.Columns = append(stmt.Columns, Prefixed(prefix, cols...)...)
+	return stmt
+}
+
+// SelectExpr adds an expression to the selected columns, optionally aliased
+// with the provided name. As opposed to columns provided to Select, the
+// expression may have bindings, e.g. SelectExpr(Indirect("price * ?", 1.17),
+// "gross_price").
+func (stmt *SelectStmt) SelectExpr(expr SQLStmt, as string) *SelectStmt {
+	asSQL, bindings := expr.ToSQL(false)
+	if as != "" {
+		asSQL += " AS " + as
+	}
+
+	stmt.Columns = append(stmt.Columns, asSQL)
+	stmt.ColumnBindings = append(stmt.ColumnBindings, bindings...)
+
+	return stmt
+}
+
+// Prefixed receives a list of columns and returns them aliased with the
+// provided prefix, for usage with Select. See SelectPrefixed for more
+// information.
+func Prefixed(prefix string, cols ...string) []string {
+	prefixed := make([]string, len(cols))
+
+	for i, col := range cols {
+		name := col
+		if dot := strings.LastIndex(col, "."); dot > -1 {
+			name = col[dot+1:]
+		}
+
+		prefixed[i] = col + ` AS "` + prefix + "." + name + `"`
+	}
+
+	return prefixed
+}
+
 // From sets the table to select from
 func (stmt *SelectStmt) From(table string) *SelectStmt {
 	stmt.Table = table
@@ -243,6 +427,83 @@ func (stmt *SelectStmt) FullJoin(table string, conds ...WhereCondition) *SelectS
 	return stmt.Join(FullJoin, table, nil, conds...)
 }
 
+// JoinUsing creates a new join with the supplied type on the supplied
+// table, using a USING clause with the provided columns rather than ON
+// conditions, e.g. JOIN table USING (col1, col2)
+func (stmt *SelectStmt) JoinUsing(joinType JoinType, table string, cols ...string) *SelectStmt {
+	stmt.Joins = append(stmt.Joins, JoinClause{
+		Type:         joinType,
+		Table:        table,
+		UsingColumns: append([]string{}, cols...),
+	})
+
+	return stmt
+}
+
+// InnerJoinUsing is a wrapper of JoinUsing for creating an INNER JOIN on a
+// table with a USING clause
+func (stmt *SelectStmt) InnerJoinUsing(table string, cols ...string) *SelectStmt {
+	return stmt.JoinUsing(InnerJoin, table, cols...)
+}
+
+// LeftJoinUsing is a wrapper of JoinUsing for creating a LEFT JOIN on a
+// table with a USING clause
+func (stmt *SelectStmt) LeftJoinUsing(table string, cols ...string) *SelectStmt {
+	return stmt.JoinUsing(LeftJoin, table, cols...)
+}
+
+// RightJoinUsing is a wrapper of JoinUsing for creating a RIGHT JOIN on a
+// table with a USING clause
+func (stmt *SelectStmt) RightJoinUsing(table string, cols ...string) *SelectStmt {
+	return stmt.JoinUsing(RightJoin, table, cols...)
+}
+
+// FullJoinUsing is a wrapper of JoinUsing for creating a FULL JOIN on a
+// table with a USING clause
+func (stmt *SelectStmt) FullJoinUsing(table string, cols ...string) *SelectStmt {
+	return stmt.JoinUsing(FullJoin, table, cols...)
+}
+
+// CrossJoin is a wrapper of Join for creating a CROSS JOIN on a table,
+// i.e. the cartesian product of the statement's table and the joined
+// table
+func (stmt *SelectStmt) CrossJoin(table string) *SelectStmt {
+	return stmt.Join(CrossJoin, table, nil)
+}
+
+// NaturalJoin is a wrapper of Join for creating a NATURAL JOIN on a table,
+// i.e. an inner join on all columns with the same name in both tables
+func (stmt *SelectStmt) NaturalJoin(table string) *SelectStmt {
+	return stmt.Join(NaturalJoin, table, nil)
+}
+
+// SelfJoin is a wrapper of Join for creating an INNER JOIN of the
+// statement's table on itself, under the provided alias. If the table
+// the statement selects from was provided with an alias (e.g. "table t"),
+// the alias is removed. This is useful for hierarchy and deduplication
+// queries, e.g.:
+//
+//	Select("c.*").From("nodes c").SelfJoin("p", Eq("p.id", Indirect("c.parent_id")))
+func (stmt *SelectStmt) SelfJoin(alias string, conds ...WhereCondition) *SelectStmt {
+	return stmt.Join(InnerJoin, stmt.baseTable()+" "+alias, nil, conds...)
+}
+
+// LeftSelfJoin is the same as SelfJoin, but creates a LEFT JOIN
+func (stmt *SelectStmt) LeftSelfJoin(alias string, conds ...WhereCondition) *SelectStmt {
+	return stmt.Join(LeftJoin, stmt.baseTable()+" "+alias, nil, conds...)
+}
+
+// baseTable returns the name of the table the statement selects from,
+// without an alias if one was provided
+func (stmt *SelectStmt) baseTable() string {
+	fields := strings.Fields(stmt.Table)
+	if len(fields) == 0 {
+		return ""
+	}
+
+	return fields[0]
+}
+
 // LeftJoinRS is a wrapper of Join for creating a LEFT JOIN on the
 // results of a sub-query
 func (stmt *SelectStmt) LeftJoinRS(rs *SelectStmt, as string, conds ...WhereCondition) *SelectStmt {
@@ -285,6 +546,59 @@ func (stmt *SelectStmt) InnerLateralJoin(rs *SelectStmt, as string, conds ...Whe
 	return stmt.Join(InnerLateralJoin, as, rs, conds...)
 }
 
+// On adds conditions to the ON clause of the last join added to the
+// statement. It is an alternative to passing the conditions to the join
+// method directly, and is mostly useful for providing the entire ON clause
+// as raw SQL, e.g.:
+//
+//	LeftJoinRS(rs, "b").On(SQLCond("b.a_id = a.id AND b.value > ?", 3))
+//
+// The bindings of the ON clause are always placed after the bindings of
+// the joined result set (if any). Calling On before any join was added is
+// a no-op.
+func (stmt *SelectStmt) On(conds ...WhereCondition) *SelectStmt {
+	if len(stmt.Joins) == 0 {
+		return stmt
+	}
+
+	join := &stmt.Joins[len(stmt.Joins)-1]
+	join.Conditions = append(join.Conditions, conds...)
+
+	return stmt
+}
+
+// CorrelatedWith marks the statement as a correlated sub-query, i.e. a
+// sub-query that references columns of an outer query by the provided
+// aliases. References to the outer query should be created with Outer.
+// When a correlated statement is joined as a result set, the join is
+// automatically made LATERAL. Validate reports correlated sub-queries
+// referencing aliases that no outer query defines.
+//
+// Outer references never carry bindings, so the bindings of a correlated
+// sub-query are always placed exactly where the sub-query appears in the
+// outer query (e.g. after the bindings of conditions preceding an EXISTS
+// condition, and before the bindings of conditions following it).
+func (stmt *SelectStmt) CorrelatedWith(aliases ...string) *SelectStmt {
+	stmt.OuterAliases = append(stmt.OuterAliases, aliases...)
+	return stmt
+}
+
+// Outer creates a reference to a column of the outer query in a correlated
+// sub-query, for use as a value in conditions, e.g.:
+//
+//	sub := db.Select("1").From("orders o2").CorrelatedWith("o")
+//	sub.Where(Eq("o2.customer_id", sub.Outer("customer_id")))
+//
+// If the column is not qualified with an alias, it is qualified with the
+// first alias provided to CorrelatedWith.
+func (stmt *SelectStmt) Outer(col string) IndirectValue {
+	if !strings.Contains(col, ".") && len(stmt.OuterAliases) > 0 {
+		col = stmt.OuterAliases[0] + "." + col
+	}
+
+	return Indirect(col)
+}
+
 // Where creates one or more WHERE conditions for the SELECT statement.
 // If multiple conditions are passed, they are considered AND conditions.
 func (stmt *SelectStmt) Where(conditions ...WhereCondition) *SelectStmt {
@@ -309,7 +623,8 @@ func (stmt *SelectStmt) WithNullsLast() *SelectStmt {
 }
 
 // OrderBy sets an ORDER BY clause for the query. Pass OrderColumn objects
-// using the Asc and Desc functions.
+// using the Asc and Desc functions, or OrderExpression objects using the
+// OrderExpr function for ordering by expressions with bindings.
 func (stmt *SelectStmt) OrderBy(cols ...SQLStmt) *SelectStmt {
 	stmt.Ordering = append(stmt.Ordering, cols...)
 	return stmt
@@ -330,7 +645,9 @@ func (stmt *SelectStmt) Having(conditions ...WhereCondition) *SelectStmt {
 
 // Limit limits the amount of results returned to the provided value
 // (this is a LIMIT clause). In some database systems, Offset with two
-// values should be used instead.
+// values should be used instead. SQL Server uses OFFSET ... FETCH NEXT
+// instead, and Oracle has no such clauses, so the query is wrapped with a
+// ROWNUM condition instead.
 func (stmt *SelectStmt) Limit(limit int64) *SelectStmt {
 	stmt.LimitTo = limit
 	return stmt
@@ -350,6 +667,10 @@ func (stmt *SelectStmt) Offset(start int64, rows ...int64) *SelectStmt {
 
 // Lock sets a LOCK clause on the SELECT statement.
 func (stmt *SelectStmt) Lock(lock *LockClause) *SelectStmt {
+	if lock.Wait == LockDefault {
+		lock.Wait = stmt.defaults.LockWait
+	}
+
 	stmt.Locks = append(stmt.Locks, lock)
 	return stmt
 }
@@ -374,11 +695,68 @@ func ForKeyShare() *LockClause {
 	return &LockClause{Strength: LockForKeyShare}
 }
 
+// toSQL generates the SQL of the join and returns its bindings
+func (join JoinClause) toSQL(env conditionEnv) (asSQL string, bindings []interface{}) {
+	var (
+		onClause     string
+		joinBindings []interface{}
+	)
+
+	// CROSS and NATURAL joins never have an ON clause, and joins
+	// with a USING clause use it instead
+	if len(join.UsingColumns) > 0 {
+		onClause = " USING (" + strings.Join(join.UsingColumns, ", ") + ")"
+	} else if join.Type.hasOnClause() {
+		onClause, joinBindings = parseConditionsFor(env, join.Conditions)
+		if onClause != "" {
+			onClause = " ON " + onClause
+		}
+	}
+
+	if join.ResultSet != nil {
+		joinType := join.Type
+		if len(join.ResultSet.OuterAliases) > 0 {
+			// correlated result sets must be joined laterally
+			joinType = joinType.lateral()
+		}
+
+		rsSQL, rsBindings := join.ResultSet.ToSQL(false)
+		asSQL = joinType.String() + " (" + rsSQL + ") " + join.Table + onClause
+		bindings = append(bindings, rsBindings...)
+	} else {
+		asSQL = join.Type.String() + " " + join.Table + onClause
+	}
+
+	// add the join condition bindings (this MUST happen after adding the clause
+	// itself, because if the join is on a result set then the result set's bindings
+	// need to come first
+	bindings = append(bindings, joinBindings...)
+
+	return asSQL, bindings
+}
+
+// conditionEnv returns the environment the statement's conditions are
+// rendered in
+func (stmt *SelectStmt) conditionEnv() conditionEnv {
+	return conditionEnv{dialectOf(stmt.queryer), stmt.defaults.Filters, stmt.defaults.EmptyIn, stmt.defaults.LargeIn}
+}
+
 // ToSQL generates the SELECT statement's SQL and returns a list of
 // bindings. It is used internally by GetRow and GetAll, but is
 // exported if you wish to use it directly.
-func (stmt *SelectStmt) ToSQL(rebind bool) (asSQL string, bindings []interface{}) { //nolint: gocognit, gocyclo
-	var clauses = []string{"SELECT"}
+func (stmt *SelectStmt) ToSQL(rebind bool) (asSQL string, bindings []interface{}) {
+	return stmt.toSQL(rebind, rebind)
+}
+
+// toSQL generates the statement's SQL and bindings. Top-level statements,
+// i.e. those executed on their own or as the main statement of a WITH
+// statement, are capped by the MaxLimit default; sub-queries are not.
+func (stmt *SelectStmt) toSQL(rebind, topLevel bool) (asSQL string, bindings []interface{}) { //nolint: gocognit, gocyclo
+	clauses := make([]string, 1, 16)
+	clauses[0] = "SELECT"
+
+	env := stmt.conditionEnv()
+	dialect := env.dialect
 
 	if stmt.IsDistinct {
 		clauses = append(clauses, "DISTINCT")
@@ -391,54 +769,61 @@ func (stmt *SelectStmt) ToSQL(rebind bool) (asSQL string, bindings []interface{}
 		clauses = append(clauses, "*")
 	} else {
 		clauses = append(clauses, strings.Join(stmt.Columns, ", "))
+		bindings = append(bindings, stmt.ColumnBindings...)
 	}
 
 	if len(stmt.Table) > 0 {
-		clauses = append(clauses, fmt.Sprintf("FROM %s", stmt.Table))
+		clauses = append(clauses, "FROM "+stmt.Table)
 	}
 
 	for _, join := range stmt.Joins {
-		onClause, joinBindings := parseConditions(join.Conditions)
-
-		if join.ResultSet != nil {
-			rsSQL, rsBindings := join.ResultSet.ToSQL(false)
-			clauses = append(clauses, join.Type.String()+" ("+rsSQL+") "+join.Table+" ON "+onClause)
-			bindings = append(bindings, rsBindings...)
-		} else {
-			clauses = append(clauses, join.Type.String()+" "+join.Table+" ON "+onClause)
-		}
-
-		// add the join condition bindings (this MUST happen after adding the clause
-		// itself, because if the join is on a result set then the result set's bindings
-		// need to come first
+		joinSQL, joinBindings := join.toSQL(env)
+		clauses = append(clauses, joinSQL)
 		bindings = append(bindings, joinBindings...)
 	}
 
 	if len(stmt.Conditions) > 0 {
-		whereClause, whereBindings := parseConditions(stmt.Conditions)
+		whereClause, whereBindings := parseConditionsFor(env, stmt.Conditions)
 		bindings = append(bindings, whereBindings...)
-		clauses = append(clauses, fmt.Sprintf("WHERE %s", whereClause))
+		clauses = append(clauses, "WHERE "+whereClause)
 	}
 
 	if len(stmt.Grouping) > 0 {
-		clauses = append(clauses, fmt.Sprintf("GROUP BY %s", strings.Join(stmt.Grouping, ", ")))
+		clauses = append(clauses, "GROUP BY "+strings.Join(stmt.Grouping, ", "))
 	}
 
 	if len(stmt.GroupConditions) > 0 {
-		groupByClause, groupBindings := parseConditions(stmt.GroupConditions)
+		groupByClause, groupBindings := parseConditionsFor(env, stmt.GroupConditions)
 		bindings = append(bindings, groupBindings...)
-		clauses = append(clauses, fmt.Sprintf("HAVING %s", groupByClause))
+		clauses = append(clauses, "HAVING "+groupByClause)
+	}
+
+	if len(stmt.Windows) > 0 {
+		windows := make([]string, len(stmt.Windows))
+
+		for i, window := range stmt.Windows {
+			specSQL, specBindings := window.Spec.ToSQL(false)
+			windows[i] = window.Name + " AS (" + specSQL + ")"
+			bindings = append(bindings, specBindings...)
+		}
+
+		clauses = append(clauses, "WINDOW "+strings.Join(windows, ", "))
 	}
 
 	if len(stmt.Ordering) > 0 {
 		var ordering []string
 
 		for _, order := range stmt.Ordering {
-			o, _ := order.ToSQL(false)
+			if aware, ok := order.(dialectAware); ok {
+				order = aware.forDialect(dialect)
+			}
+
+			o, orderBindings := order.ToSQL(false)
 			ordering = append(ordering, o)
+			bindings = append(bindings, orderBindings...)
 		}
 
-		clauses = append(clauses, fmt.Sprintf("ORDER BY %s", strings.Join(ordering, ", ")))
+		clauses = append(clauses, "ORDER BY "+strings.Join(ordering, ", "))
 
 		if stmt.orderWithNulls.Enabled {
 			if stmt.orderWithNulls.First {
@@ -449,18 +834,16 @@ func (stmt *SelectStmt) ToSQL(rebind bool) (asSQL string, bindings []interface{}
 		}
 	}
 
-	if stmt.LimitTo > 0 {
-		clauses = append(clauses, fmt.Sprintf("LIMIT %d", stmt.LimitTo))
+	// the limit cap is only applied to top-level statements. In statements
+	// with unions, it applies to their combined result, so it is added
+	// after the unions, with all branches parenthesized.
+	limit := stmt.LimitTo
+	capUnions := topLevel && stmt.defaults.MaxLimit > 0 && len(stmt.Unions) > 0
+	if topLevel && !capUnions && stmt.defaults.MaxLimit > 0 && (limit == 0 || limit > stmt.defaults.MaxLimit) {
+		limit = stmt.defaults.MaxLimit
 	}
 
-	if stmt.OffsetFrom > 0 {
-		offset := fmt.Sprintf("%d", stmt.OffsetFrom)
-		if stmt.OffsetRows > 0 {
-			offset += fmt.Sprintf(" %d", stmt.OffsetRows)
-		}
-
-		clauses = append(clauses, "OFFSET "+offset)
-	}
+	clauses = appendLimit(dialect, clauses, limit, stmt.OffsetFrom, stmt.OffsetRows, len(stmt.Ordering) > 0)
 
 	for _, lock := range stmt.Locks {
 		var lockStrength string
@@ -494,6 +877,11 @@ func (stmt *SelectStmt) ToSQL(rebind bool) (asSQL string, bindings []interface{}
 	}
 
 	if len(stmt.Unions) > 0 {
+		paren := stmt.ParenUnions || capUnions
+		if paren {
+			clauses = []string{"(" + strings.Join(clauses, " ") + ")"}
+		}
+
 		cmd := "UNION"
 		if stmt.IsUnionAll {
 			cmd += " ALL"
@@ -501,35 +889,92 @@ func (stmt *SelectStmt) ToSQL(rebind bool) (asSQL string, bindings []interface{}
 
 		for _, union := range stmt.Unions {
 			u, b := union.ToSQL(false)
+			if paren || union.hasOwnLimits() {
+				u = "(" + u + ")"
+			}
+
 			bindings = append(bindings, b...)
-			clauses = append(clauses, fmt.Sprintf("%s %s", cmd, u))
+			clauses = append(clauses, cmd+" "+u)
+		}
+
+		if capUnions {
+			clauses = appendLimit(dialect, clauses, stmt.defaults.MaxLimit, 0, 0, false)
 		}
 	}
 
 	asSQL = strings.Join(clauses, " ")
 
 	if rebind {
-		if db, ok := stmt.queryer.(*sqlx.DB); ok {
-			asSQL = db.Rebind(asSQL)
-		} else if tx, ok := stmt.queryer.(*sqlx.Tx); ok {
-			asSQL = tx.Rebind(asSQL)
-		}
+		asSQL = rebindFor(stmt.queryer, asSQL)
 	}
 
 	return asSQL, bindings
 }
 
+// Unbounded explicitly allows the statement to be executed without a LIMIT
+// clause or conditions, when the DB or Tx it was created from rejects such
+// statements (see Defaults.RejectUnbounded)
+func (stmt *SelectStmt) Unbounded() *SelectStmt {
+	stmt.defaults.RejectUnbounded = false
+	return stmt
+}
+
+// checkBounds returns an error if the statement is rejected for having
+// neither a LIMIT clause nor conditions
+func (stmt *SelectStmt) checkBounds() error {
+	if !stmt.defaults.RejectUnbounded || stmt.LimitTo > 0 || stmt.defaults.MaxLimit > 0 || len(stmt.Conditions) > 0 {
+		return nil
+	}
+
+	err := fmt.Errorf(
+		"%w: SELECT from %q has no LIMIT clause or conditions (use Limit, or Unbounded to allow it)",
+		ErrUnboundedSelect, stmt.Table,
+	)
+	stmt.HandleError(err)
+
+	return err
+}
+
+// appendLimit adds the clauses limiting the number of rows returned by a
+// query (LIMIT and OFFSET, or their equivalents in the dialect) to the
+// provided clauses
+func appendLimit(dialect Dialect, clauses []string, limit, offset, offsetRows int64, ordered bool) []string {
+	switch {
+	case limit == 0 && offset == 0:
+	case dialect == DialectOracle:
+		clauses = []string{limitWithRownum(strings.Join(clauses, " "), limit, offset)}
+	case dialect == DialectMSSQL:
+		clauses = append(clauses, fetchClauses(limit, offset, ordered)...)
+	default:
+		if limit > 0 {
+			clauses = append(clauses, "LIMIT "+strconv.FormatInt(limit, 10))
+		}
+
+		if offset > 0 {
+			offsetClause := strconv.FormatInt(offset, 10)
+			if offsetRows > 0 {
+				offsetClause += " " + strconv.FormatInt(offsetRows, 10)
+			}
+
+			clauses = append(clauses, "OFFSET "+offsetClause)
+		}
+	}
+
+	return clauses
+}
+
+// hasOwnLimits returns true if the statement has an ORDER BY, LIMIT or OFFSET
+// clause, in which case it must be parenthesized when used as a UNION branch
+func (stmt *SelectStmt) hasOwnLimits() bool {
+	return len(stmt.Ordering) > 0 || stmt.LimitTo > 0 || stmt.OffsetFrom > 0
+}
+
 // GetRow executes the SELECT statement and loads the first
 // result into the provided variable (which may be a simple
 // variable if only one column was selected, or a struct if
 // multiple columns were selected).
 func (stmt *SelectStmt) GetRow(into interface{}) error {
-	asSQL, bindings := stmt.ToSQL(true)
-
-	err := sqlx.Get(stmt.queryer, into, asSQL, bindings...)
-	stmt.HandleError(err)
-
-	return err
+	return stmt.GetRowContext(context.Background(), into)
 }
 
 // GetRowContext executes the SELECT statement and loads the first
@@ -537,9 +982,23 @@ func (stmt *SelectStmt) GetRow(into interface{}) error {
 // variable if only one column was selected, or a struct if
 // multiple columns were selected).
 func (stmt *SelectStmt) GetRowContext(ctx context.Context, into interface{}) error {
-	asSQL, bindings := stmt.ToSQL(true)
+	ctx, cancel := contextWithTimeout(ctx, stmt.timeout)
+	defer cancel()
+
+	if err := stmt.guard(ctx); err != nil {
+		return err
+	}
 
-	err := sqlx.GetContext(ctx, stmt.queryer, into, asSQL, bindings...)
+	asSQL, bindings := stmt.scoped(ctx).ToSQL(true)
+
+	err := stmt.cached(asSQL, bindings, into, func() error {
+		return stmt.withRetry(ctx, func() error {
+			return sqlx.GetContext(ctx, stmt.queryer, into, asSQL, bindings...)
+		})
+	})
+	if err == nil {
+		err = stmt.decode(into)
+	}
 	stmt.HandleError(err)
 
 	return err
@@ -548,20 +1007,34 @@ func (stmt *SelectStmt) GetRowContext(ctx context.Context, into interface{}) err
 // GetAll executes the SELECT statement and loads all the
 // results into the provided slice variable.
 func (stmt *SelectStmt) GetAll(into interface{}) error {
-	asSQL, bindings := stmt.ToSQL(true)
-
-	err := sqlx.Select(stmt.queryer, into, asSQL, bindings...)
-	stmt.HandleError(err)
-
-	return err
+	return stmt.GetAllContext(context.Background(), into)
 }
 
 // GetAllContext executes the SELECT statement and loads all the
 // results into the provided slice variable.
 func (stmt *SelectStmt) GetAllContext(ctx context.Context, into interface{}) error {
-	asSQL, bindings := stmt.ToSQL(true)
+	ctx, cancel := contextWithTimeout(ctx, stmt.timeout)
+	defer cancel()
+
+	if err := stmt.checkBounds(); err != nil {
+		return err
+	}
 
-	err := sqlx.SelectContext(ctx, stmt.queryer, into, asSQL, bindings...)
+	if err := stmt.guard(ctx); err != nil {
+		return err
+	}
+
+	asSQL, bindings := stmt.scoped(ctx).ToSQL(true)
+
+	err := stmt.cached(asSQL, bindings, into, func() error {
+		return stmt.withRetry(ctx, func() error {
+			resetSlice(into)
+			return sqlx.SelectContext(ctx, stmt.queryer, into, asSQL, bindings...)
+		})
+	})
+	if err == nil {
+		err = stmt.decode(into)
+	}
 	stmt.HandleError(err)
 
 	return err
@@ -576,6 +1049,8 @@ func (stmt *SelectStmt) GetCount() (count int64, err error) {
 
 	countStmt := *stmt
 	countStmt.Columns = []string{"COUNT(*)"}
+	countStmt.ColumnBindings = nil
+	countStmt.defaults.RejectUnbounded = false
 	countStmt.LimitTo = 0
 	countStmt.OffsetFrom = 0
 	countStmt.OffsetRows = 0
@@ -583,13 +1058,14 @@ func (stmt *SelectStmt) GetCount() (count int64, err error) {
 
 	for _, st := range countStmt.Unions {
 		st.Columns = []string{"COUNT(*)"}
+		st.ColumnBindings = nil
 		st.LimitTo = 0
 		st.OffsetFrom = 0
 		st.OffsetRows = 0
 		st.Ordering = []SQLStmt{}
 	}
 
-	rows, err := countStmt.GetAllAsRows()
+	rows, err := countStmt.Rows()
 	if err != nil {
 		return count, err
 	}
@@ -618,6 +1094,8 @@ func (stmt *SelectStmt) GetCount() (count int64, err error) {
 func (stmt *SelectStmt) GetCountContext(ctx context.Context) (count int64, err error) {
 	countStmt := *stmt
 	countStmt.Columns = []string{"COUNT(*)"}
+	countStmt.ColumnBindings = nil
+	countStmt.defaults.RejectUnbounded = false
 	countStmt.LimitTo = 0
 	countStmt.OffsetFrom = 0
 	countStmt.OffsetRows = 0
@@ -628,27 +1106,76 @@ func (stmt *SelectStmt) GetCountContext(ctx context.Context) (count int64, err e
 	return count, err
 }
 
+// TypedMaps makes GetAllAsMaps and GetRowAsMap convert values into friendly
+// Go types (string, int64, float64, bool and time.Time) based on the
+// database types of the columns, rather than the types returned by the
+// driver (many drivers return textual values as byte slices).
+func (stmt *SelectStmt) TypedMaps() *SelectStmt {
+	stmt.typedMaps = true
+	return stmt
+}
+
 // GetAllAsMaps executes the SELECT statement and returns all results as a slice
 // of maps from string to empty interfaces. This is useful for intermediary
 // query where creating a struct type would be redundant
 func (stmt *SelectStmt) GetAllAsMaps() (maps []map[string]interface{}, err error) {
-	defer stmt.HandleError(err)
+	maps, _, err = stmt.GetAllAsMapsWithColumns()
+	return maps, err
+}
+
+// GetAllAsMapsWithColumns is the same as GetAllAsMaps, but also returns
+// metadata about the columns of the result set (names, database types and
+// nullability), in the order in which they were selected. This is useful
+// for dynamic reports and exports.
+func (stmt *SelectStmt) GetAllAsMapsWithColumns() (
+	maps []map[string]interface{},
+	cols []ColumnInfo,
+	err error,
+) {
+	if err := stmt.checkBounds(); err != nil {
+		return nil, nil, err
+	}
+
+	ctx, cancel := contextWithTimeout(context.Background(), stmt.timeout)
+	defer cancel()
+
+	if err := stmt.guard(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	asSQL, bindings := stmt.scoped(ctx).ToSQL(true)
+
+	err = stmt.withRetry(ctx, func() error {
+		maps, cols, err = stmt.queryMaps(ctx, asSQL, bindings)
+		return err
+	})
+	stmt.HandleError(err)
 
-	asSQL, bindings := stmt.ToSQL(true)
+	return maps, cols, err
+}
 
-	rows, err := stmt.queryer.Queryx(asSQL, bindings...)
+// queryMaps executes the provided query and loads the results as maps
+func (stmt *SelectStmt) queryMaps(ctx context.Context, asSQL string, bindings []interface{}) (
+	maps []map[string]interface{},
+	cols []ColumnInfo,
+	err error,
+) {
+	rows, err := stmt.queryer.QueryxContext(ctx, asSQL, bindings...)
 	if err != nil {
-		return maps, err
+		return maps, cols, err
 	}
 
 	defer rows.Close()
 
-	for rows.Next() {
-		results := make(map[string]interface{})
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return maps, cols, err
+	}
 
-		err = rows.MapScan(results)
+	for rows.Next() {
+		results, err := stmt.scanMap(rows, types)
 		if err != nil {
-			return maps, err
+			return maps, cols, err
 		}
 
 		maps = append(maps, results)
@@ -656,49 +1183,208 @@ func (stmt *SelectStmt) GetAllAsMaps() (maps []map[string]interface{}, err error
 
 	err = rows.Err()
 	if err != nil {
-		return maps, err
+		return maps, cols, err
 	}
 
-	return maps, nil
+	return maps, columnInfo(types), nil
+}
+
+// scanMap loads the current row as a map, converting and decoding its
+// values as necessary
+func (stmt *SelectStmt) scanMap(rows *sqlx.Rows, types []*sql.ColumnType) (map[string]interface{}, error) {
+	results := make(map[string]interface{})
+
+	err := rows.MapScan(results)
+	if err != nil {
+		return nil, err
+	}
+
+	if stmt.typedMaps {
+		convertMapTypes(results, types)
+	}
+
+	return results, stmt.decodeMap(results)
+}
+
+// EachRowAsMap executes the SELECT statement and calls the provided function
+// with every result as a map from column names to their values (like
+// GetAllAsMaps), without loading all results into memory first. This is
+// useful for exports and dynamic reports over large result sets. A new map
+// is provided for every row, so the function may retain it. If the function
+// returns an error, iteration stops and the error is returned.
+func (stmt *SelectStmt) EachRowAsMap(ctx context.Context, fn func(map[string]interface{}) error) error {
+	rows, err := stmt.RowsContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		stmt.HandleError(err)
+		return err
+	}
+
+	for rows.Next() {
+		results, err := stmt.scanMap(rows.Rows, types)
+		if err != nil {
+			stmt.HandleError(err)
+			return err
+		}
+
+		if err := fn(results); err != nil {
+			return err
+		}
+	}
+
+	err = rows.Err()
+	if err != nil {
+		stmt.HandleError(err)
+	}
+
+	return err
+}
+
+// GetAllWithColumns executes the SELECT statement and loads all the results
+// into the provided slice variable (like GetAll), and also returns metadata
+// about the columns of the result set.
+func (stmt *SelectStmt) GetAllWithColumns(into interface{}) (cols []ColumnInfo, err error) {
+	if err := stmt.checkBounds(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := contextWithTimeout(context.Background(), stmt.timeout)
+	defer cancel()
+
+	if err := stmt.guard(ctx); err != nil {
+		return nil, err
+	}
+
+	asSQL, bindings := stmt.scoped(ctx).ToSQL(true)
+
+	rows, err := stmt.queryer.QueryxContext(ctx, asSQL, bindings...)
+	if err != nil {
+		stmt.HandleError(err)
+		return cols, err
+	}
+
+	defer rows.Close()
+
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		stmt.HandleError(err)
+		return cols, err
+	}
+
+	err = sqlx.StructScan(rows, into)
+	if err == nil {
+		err = stmt.decode(into)
+	}
+	if err != nil {
+		stmt.HandleError(err)
+		return cols, err
+	}
+
+	return columnInfo(types), nil
 }
 
 // GetRowAsMap executes the SELECT statement and returns the first result as a
 // map from string to empty interfaces. This is useful for intermediary query
 // where creating a struct type would be redundant
 func (stmt *SelectStmt) GetRowAsMap() (results map[string]interface{}, err error) {
-	asSQL, bindings := stmt.ToSQL(true)
-	results = make(map[string]interface{})
+	ctx, cancel := contextWithTimeout(context.Background(), stmt.timeout)
+	defer cancel()
+
+	if err := stmt.guard(ctx); err != nil {
+		return nil, err
+	}
+
+	asSQL, bindings := stmt.scoped(ctx).ToSQL(true)
 
-	err = stmt.queryer.QueryRowx(asSQL, bindings...).MapScan(results)
+	err = stmt.withRetry(ctx, func() error {
+		results, err = stmt.queryRowMap(ctx, asSQL, bindings)
+		return err
+	})
 	stmt.HandleError(err)
 
 	return results, err
 }
 
-// GetAllAsRows executes the SELECT statement and returns an sqlx.Rows object
-// to use for iteration. It is the caller's responsibility to close the cursor
-// with Close().
-func (stmt *SelectStmt) GetAllAsRows() (rows *sqlx.Rows, err error) {
-	asSQL, bindings := stmt.ToSQL(true)
+// queryRowMap executes the provided query and loads the first result as a map
+func (stmt *SelectStmt) queryRowMap(ctx context.Context, asSQL string, bindings []interface{}) (
+	results map[string]interface{},
+	err error,
+) {
+	results = make(map[string]interface{})
 
-	rows, err = stmt.queryer.Queryx(asSQL, bindings...)
-	stmt.HandleError(err)
+	row := stmt.queryer.QueryRowxContext(ctx, asSQL, bindings...)
 
-	return rows, err
+	var types []*sql.ColumnType
+
+	if stmt.typedMaps {
+		types, err = row.ColumnTypes()
+		if err != nil {
+			return results, err
+		}
+	}
+
+	err = row.MapScan(results)
+	if err != nil {
+		return results, err
+	}
+
+	if stmt.typedMaps {
+		convertMapTypes(results, types)
+	}
+
+	return results, stmt.decodeMap(results)
 }
 
-// GetAllAsRowsContext executes the SELECT statement and returns an sqlx.Rows object
-// to use for iteration. It is the caller's responsibility to close the cursor
-// with Close().
-func (stmt *SelectStmt) GetAllAsRowsContext(ctx context.Context) (rows *sqlx.Rows, err error) {
-	asSQL, bindings := stmt.ToSQL(true)
+// Rows executes the SELECT statement and returns a Rows object to use for
+// iteration. It is the caller's responsibility to close the cursor with
+// Close(), which also releases the statement's timeout (see WithTimeout).
+func (stmt *SelectStmt) Rows() (rows *Rows, err error) {
+	return stmt.RowsContext(context.Background())
+}
 
-	rows, err = stmt.queryer.QueryxContext(ctx, asSQL, bindings...)
+// RowsContext is like Rows, but uses the provided context
+func (stmt *SelectStmt) RowsContext(ctx context.Context) (rows *Rows, err error) {
+	if err := stmt.checkBounds(); err != nil {
+		return nil, err
+	}
+
+	if err := stmt.guard(ctx); err != nil {
+		return nil, err
+	}
+
+	asSQL, bindings := stmt.scoped(ctx).ToSQL(true)
+
+	rows, err = queryRows(ctx, stmt.timeout, stmt.queryer, asSQL, bindings)
 	stmt.HandleError(err)
 
 	return rows, err
 }
 
+// GetAllAsRows executes the SELECT statement and returns an sqlx.Rows
+// object to use for iteration. It is the caller's responsibility to close
+// the cursor with Close(). The context derived for the statement's timeout
+// (see WithTimeout) is only released once the timeout expires; use Rows to
+// release it when the cursor is closed.
+func (stmt *SelectStmt) GetAllAsRows() (rows *sqlx.Rows, err error) {
+	return stmt.GetAllAsRowsContext(context.Background())
+}
+
+// GetAllAsRowsContext is like GetAllAsRows, but uses the provided context
+func (stmt *SelectStmt) GetAllAsRowsContext(ctx context.Context) (rows *sqlx.Rows, err error) {
+	cursor, err := stmt.RowsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return cursor.Rows, nil
+}
+
 // Union adds the 'UNION' command between two or more SELECT statements.
 func (stmt *SelectStmt) Union(statements ...*SelectStmt) *SelectStmt {
 	stmt.Unions = append(stmt.Unions, statements...)
@@ -712,3 +1398,13 @@ func (stmt *SelectStmt) UnionAll(statements ...*SelectStmt) *SelectStmt {
 
 	return stmt
 }
+
+// ParenthesizeUnions wraps the statement and all of its UNION branches in
+// parentheses, e.g. (SELECT ... LIMIT 5) UNION ALL (SELECT ... LIMIT 5), so
+// that each branch can have its own ORDER BY, LIMIT and OFFSET clauses.
+// Union branches that have such clauses are always parenthesized, but the
+// main statement is only parenthesized when this method is used.
+func (stmt *SelectStmt) ParenthesizeUnions() *SelectStmt {
+	stmt.ParenUnions = true
+	return stmt
+}
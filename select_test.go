@@ -1,6 +1,10 @@
 package sqlz
 
-import "testing"
+import (
+	"testing"
+
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
 
 func TestSelect(t *testing.T) {
 	runTests(t, func(dbz *DB) []test {
@@ -73,6 +77,13 @@ func TestSelect(t *testing.T) {
 				[]interface{}{3, 4, "a", "b"},
 			},
 
+			{
+				"select with IN conditions on slices",
+				dbz.Select("*").From("table").Where(In("one", []int64{3, 4}), NotIn("two", []string{"a"}), In("three", []byte("x"))),
+				"SELECT * FROM table WHERE one IN (?, ?) AND two NOT IN (?) AND three IN (?)",
+				[]interface{}{int64(3), int64(4), "a", []byte("x")},
+			},
+
 			{
 				"select with both IN and simple conditions",
 				dbz.Select("*").From("table").Where(In("one", 3, 4), Eq("id", "a")),
@@ -87,6 +98,63 @@ func TestSelect(t *testing.T) {
 				[]interface{}{2, "bla"},
 			},
 
+			{
+				"select with IN sub-queries",
+				dbz.Select("*").From("users").Where(
+					Eq("active", true),
+					InSubquery("id", dbz.Select("user_id").From("orders").Where(Gt("total", 100))),
+					NotInSubquery("id", dbz.Select("user_id").From("bans").Where(Eq("reason", "fraud"))),
+				),
+				"SELECT * FROM users WHERE active = ? AND id IN (SELECT user_id FROM orders WHERE total > ?) " +
+					"AND id NOT IN (SELECT user_id FROM bans WHERE reason = ?)",
+				[]interface{}{true, 100, "fraud"},
+			},
+
+			{
+				"select with BETWEEN conditions",
+				dbz.Select("*").From("events").Where(
+					Between("created_at", "2020-01-01", "2020-12-31"),
+					NotBetween("priority", 3, Indirect("max_priority - ?", 1)),
+				),
+				"SELECT * FROM events WHERE created_at BETWEEN ? AND ? AND priority NOT BETWEEN ? AND max_priority - ?",
+				[]interface{}{"2020-01-01", "2020-12-31", 3, 1},
+			},
+
+			{
+				"select with custom operators",
+				dbz.Select("*").From("table").Where(Op("a", OpGte, 1), Op("b", "~*", "^x"), Op("c", OpIsNull, nil)),
+				"SELECT * FROM table WHERE a >= ? AND b ~* ? AND c IS NULL",
+				[]interface{}{1, "^x"},
+			},
+
+			{
+				"select with a single parenthesized SQL condition",
+				dbz.Select("*").From("table").Where(SQLCond("(a = ?) OR (b = ?)", 1, 2)),
+				"SELECT * FROM table WHERE (a = ?) OR (b = ?)",
+				[]interface{}{1, 2},
+			},
+
+			{
+				"select with a single OR condition",
+				dbz.Select("*").From("table").Where(Or(Eq("a", 1), Eq("b", 2))),
+				"SELECT * FROM table WHERE a = ? OR b = ?",
+				[]interface{}{1, 2},
+			},
+
+			{
+				"select with a grouped OR condition",
+				dbz.Select("*").From("table").Where(Group(Or(Eq("a", 1), Eq("b", 2)))),
+				"SELECT * FROM table WHERE (a = ? OR b = ?)",
+				[]interface{}{1, 2},
+			},
+
+			{
+				"select with a grouped SQL condition",
+				dbz.Select("*").From("table").Where(Group(SQLCond("a = ? OR b = ?", 1, 2)), Eq("c", 3)),
+				"SELECT * FROM table WHERE (a = ? OR b = ?) AND c = ?",
+				[]interface{}{1, 2, 3},
+			},
+
 			{
 				"select for update",
 				dbz.Select("*").From("table").Where(Eq("id", 1)).Lock(ForUpdate()),
@@ -131,6 +199,99 @@ func TestSelect(t *testing.T) {
 				"SELECT a.id, a.value FROM table a RIGHT JOIN LATERAL (SELECT count FROM table WHERE a.value > ?) counts ON a.id = b.id WHERE a.id = ?",
 				[]interface{}{0, 1},
 			},
+			{
+				"select with a raw ON clause",
+				dbz.Select("*").From("table a").LeftJoin("other b").On(SQLCond("b.a_id = a.id AND b.value > ?", 3)).Where(Eq("a.id", 1)),
+				"SELECT * FROM table a LEFT JOIN other b ON b.a_id = a.id AND b.value > ? WHERE a.id = ?",
+				[]interface{}{3, 1},
+			},
+			{
+				"select with a raw ON clause on a result set",
+				dbz.Select("*").From("table a").
+					InnerJoinRS(dbz.Select("id, value").From("other").Where(Eq("kind", "x")), "b").
+					On(SQLCond("b.id = a.id AND b.value > ?", 3)).
+					Where(Eq("a.id", 1)),
+				"SELECT * FROM table a INNER JOIN (SELECT id, value FROM other WHERE kind = ?) b ON b.id = a.id AND b.value > ? WHERE a.id = ?",
+				[]interface{}{"x", 3, 1},
+			},
+			{
+				"select with conditions mixed between join and On",
+				dbz.Select("*").From("table a").
+					LeftJoin("other b", Eq("b.a_id", Indirect("a.id"))).
+					On(SQLCond("b.value > ?", 3)),
+				"SELECT * FROM table a LEFT JOIN other b ON b.a_id = a.id AND b.value > ?",
+				[]interface{}{3},
+			},
+			{
+				"select with a self join",
+				dbz.Select("c.id", "p.name").From("nodes c").SelfJoin("p", Eq("p.id", Indirect("c.parent_id"))).Where(Eq("c.id", 3)),
+				"SELECT c.id, p.name FROM nodes c INNER JOIN nodes p ON p.id = c.parent_id WHERE c.id = ?",
+				[]interface{}{3},
+			},
+			{
+				"select with a left self join on an unaliased table",
+				dbz.Select("*").From("nodes").LeftSelfJoin("dup", Eq("dup.email", Indirect("nodes.email")), Ne("dup.id", Indirect("nodes.id"))),
+				"SELECT * FROM nodes LEFT JOIN nodes dup ON dup.email = nodes.email AND dup.id <> nodes.id",
+				[]interface{}{},
+			},
+			{
+				"select with a window function",
+				dbz.Select("id").
+					SelectExpr(WindowFunc("ROW_NUMBER()").PartitionBy("user_id").OrderBy(Desc("created_at")), "rn").
+					From("orders"),
+				"SELECT id, ROW_NUMBER() OVER (PARTITION BY user_id ORDER BY created_at DESC) AS rn FROM orders",
+				[]interface{}{},
+			},
+			{
+				"select with window functions over a named window",
+				dbz.Select("id").
+					SelectExpr(WindowFunc("LAG(price, ?)", 1).OverWindow("w"), "prev_price").
+					SelectExpr(WindowFunc("AVG(price)").OverWindow("w").Frame("ROWS BETWEEN 6 PRECEDING AND CURRENT ROW"), "moving_avg").
+					From("prices").
+					Where(Eq("symbol", "ABC")).
+					Window("w", Window().PartitionBy("symbol").OrderBy(Asc("day"))),
+				"SELECT id, LAG(price, ?) OVER w AS prev_price, AVG(price) OVER (w ROWS BETWEEN 6 PRECEDING AND CURRENT ROW) AS moving_avg FROM prices WHERE symbol = ? WINDOW w AS (PARTITION BY symbol ORDER BY day ASC)",
+				[]interface{}{1, "ABC"},
+			},
+			{
+				"select with a cross join",
+				dbz.Select("s.size", "c.color").From("sizes s").CrossJoin("colors c").Where(Eq("c.active", true)),
+				"SELECT s.size, c.color FROM sizes s CROSS JOIN colors c WHERE c.active = ?",
+				[]interface{}{true},
+			},
+			{
+				"select with join using columns",
+				dbz.Select("*").From("orders").InnerJoinUsing("customers", "customer_id").LeftJoinUsing("regions", "region_id", "country").Where(Eq("total", 5)),
+				"SELECT * FROM orders INNER JOIN customers USING (customer_id) LEFT JOIN regions USING (region_id, country) WHERE total = ?",
+				[]interface{}{5},
+			},
+			{
+				"select with a natural join",
+				dbz.Select("*").From("orders").NaturalJoin("customers"),
+				"SELECT * FROM orders NATURAL JOIN customers",
+				[]interface{}{},
+			},
+			{
+				"select with exists on a select statement",
+				dbz.Select("*").From("users u").Where(Exists(dbz.Select("1").From("orders o").Where(Eq("o.user_id", Indirect("u.id")), Gt("o.total", 100)))),
+				"SELECT * FROM users u WHERE EXISTS (SELECT 1 FROM orders o WHERE o.user_id = u.id AND o.total > ?)",
+				[]interface{}{100},
+			},
+			{
+				"select with not exists on a with statement",
+				dbz.Select("*").From("users u").Where(Eq("u.active", true), NotExists(
+					dbz.With(dbz.Select("user_id").From("bans").Where(Eq("permanent", true)), "b").
+						Then(dbz.Select("1").From("b").Where(Eq("b.user_id", Indirect("u.id")))),
+				)),
+				"SELECT * FROM users u WHERE u.active = ? AND NOT EXISTS (WITH b AS (SELECT user_id FROM bans WHERE permanent = ?) SELECT 1 FROM b WHERE b.user_id = u.id)",
+				[]interface{}{true, true},
+			},
+			{
+				"select with exists on raw SQL",
+				dbz.Select("*").From("users u").Where(Exists(Indirect("SELECT 1 FROM orders o WHERE o.user_id = u.id AND o.total > ?", 100)), Eq("u.id", 2)),
+				"SELECT * FROM users u WHERE EXISTS (SELECT 1 FROM orders o WHERE o.user_id = u.id AND o.total > ?) AND u.id = ?",
+				[]interface{}{100, 2},
+			},
 			{
 				"select with a single union",
 				dbz.Select("a.name").From("table a").Where(Eq("a.name", "a")).Union(
@@ -155,6 +316,178 @@ func TestSelect(t *testing.T) {
 					"UNION SELECT c.name FROM table c WHERE c.name = ?",
 				[]interface{}{"a", "b", "c"},
 			},
+			{
+				"select with union branch having its own ordering and limit",
+				dbz.Select("a.name").From("table a").UnionAll(
+					dbz.Select("b.name").From("table b").OrderBy(Desc("b.name")).Limit(5)),
+				"SELECT a.name FROM table a UNION ALL (SELECT b.name FROM table b ORDER BY b.name DESC LIMIT 5)",
+				[]interface{}{},
+			},
+			{
+				"select with parenthesized unions",
+				dbz.Select("a.name").From("table a").Where(Eq("a.name", "a")).Limit(5).UnionAll(
+					dbz.Select("b.name").From("table b").Where(Eq("b.name", "b")).Limit(5)).
+					ParenthesizeUnions(),
+				"(SELECT a.name FROM table a WHERE a.name = ? LIMIT 5) UNION ALL (SELECT b.name FROM table b WHERE b.name = ? LIMIT 5)",
+				[]interface{}{"a", "b"},
+			},
 		}
 	})
 }
+
+func TestSelectOrderExpr(t *testing.T) {
+	runTestsWithDriver(t, "postgres", func(dbz *DB) []test {
+		return []test{
+			{
+				"order by expression with bindings",
+				dbz.Select("*").From("users").Where(Gt("score", 0.3)).OrderBy(OrderExpr("similarity(name, ?)", "bob").Desc(), Asc("id")).Limit(10),
+				"SELECT * FROM users WHERE score > $1 ORDER BY similarity(name, $2) DESC, id ASC LIMIT 10",
+				[]interface{}{0.3, "bob"},
+			},
+			{
+				"order by expression without direction",
+				dbz.Select("*").From("items").OrderBy(OrderExpr("CASE WHEN status = ? THEN 0 ELSE 1 END", "urgent")),
+				"SELECT * FROM items ORDER BY CASE WHEN status = $1 THEN 0 ELSE 1 END",
+				[]interface{}{"urgent"},
+			},
+		}
+	})
+}
+
+func TestSelectRandomOrder(t *testing.T) {
+	runTestsWithDriver(t, "mysql", func(dbz *DB) []test {
+		return []test{
+			{
+				"mysql random order",
+				dbz.Select("*").From("table").OrderBy(Random()).Limit(5),
+				"SELECT * FROM table ORDER BY RAND() LIMIT 5",
+				[]interface{}{},
+			},
+			{
+				"mysql seeded random order",
+				dbz.Select("*").From("table").OrderBy(RandomSeed(42)),
+				"SELECT * FROM table ORDER BY RAND(42)",
+				[]interface{}{},
+			},
+		}
+	})
+
+	runTestsWithDriver(t, "postgres", func(dbz *DB) []test {
+		return []test{
+			{
+				"postgres random order",
+				dbz.Select("*").From("table").Where(Eq("a", 1)).OrderBy(Random()),
+				"SELECT * FROM table WHERE a = $1 ORDER BY RANDOM()",
+				[]interface{}{1},
+			},
+		}
+	})
+}
+
+func TestSelectSubqueryRebind(t *testing.T) {
+	runTestsWithDriver(t, "postgres", func(dbz *DB) []test {
+		return []test{
+			{
+				"exists on a with statement is rebound once",
+				dbz.Select("*").From("users u").Where(Eq("u.active", true), Exists(
+					dbz.With(dbz.Select("user_id").From("bans").Where(Eq("permanent", true)), "b").
+						Then(dbz.Select("1").From("b").Where(Eq("b.user_id", Indirect("u.id")), Gt("b.level", 2))),
+				)),
+				"SELECT * FROM users u WHERE u.active = $1 AND EXISTS (WITH b AS (SELECT user_id FROM bans WHERE permanent = $2) SELECT 1 FROM b WHERE b.user_id = u.id AND b.level > $3)",
+				[]interface{}{true, true, 2},
+			},
+		}
+	})
+}
+
+func TestSelectCorrelated(t *testing.T) {
+	runTestsWithDriver(t, "postgres", func(dbz *DB) []test {
+		sub := dbz.Select("1").From("orders o2").CorrelatedWith("o")
+		sub.Where(Eq("o2.customer_id", sub.Outer("customer_id")), Gt("o2.total", 100))
+
+		latest := dbz.Select("MAX(created_at) latest").From("orders o3").CorrelatedWith("c")
+		latest.Where(Eq("o3.customer_id", latest.Outer("c.id")), Eq("o3.status", "paid"))
+
+		larger := dbz.Select("o4.id").From("orders o4").CorrelatedWith("o")
+		larger.Where(
+			Eq("o4.status", "paid"),
+			Gt("o4.total", Indirect(larger.Outer("total").Reference+" * ?", 2)),
+			Eq("o4.customer_id", larger.Outer("customer_id")),
+		)
+
+		return []test{
+			{
+				"correlated exists keeps binding order",
+				dbz.Select("*").From("orders o").Where(Eq("o.status", "open"), Exists(sub), Lt("o.total", 50)),
+				"SELECT * FROM orders o WHERE o.status = $1 AND EXISTS (SELECT 1 FROM orders o2 WHERE o2.customer_id = o.customer_id AND o2.total > $2) AND o.total < $3",
+				[]interface{}{"open", 100, 50},
+			},
+			{
+				"outer references in a where sub-query keep binding order",
+				dbz.Select("o.id").From("orders o").Where(Eq("o.status", "open"), NotInSubquery("o.id", larger), Lt("o.total", 50)),
+				"SELECT o.id FROM orders o WHERE o.status = $1 AND o.id NOT IN (SELECT o4.id FROM orders o4 WHERE o4.status = $2 AND o4.total > o.total * $3 AND o4.customer_id = o.customer_id) AND o.total < $4",
+				[]interface{}{"open", "paid", 2, 50},
+			},
+			{
+				"correlated result set is joined laterally",
+				dbz.Select("c.id", "l.latest").From("customers c").LeftJoinRS(latest, "l", SQLCond("TRUE")).Where(Eq("c.active", true)),
+				"SELECT c.id, l.latest FROM customers c LEFT JOIN LATERAL (SELECT MAX(created_at) latest FROM orders o3 WHERE o3.customer_id = c.id AND o3.status = $1) l ON TRUE WHERE c.active = $2",
+				[]interface{}{"paid", true},
+			},
+		}
+	})
+}
+
+func TestSelectPrefixed(t *testing.T) {
+	runTests(t, func(dbz *DB) []test {
+		return []test{
+			{
+				"select with prefixed columns",
+				dbz.Select("o.id", "o.total").From("orders o").
+					InnerJoin("customers c", Eq("c.id", Indirect("o.customer_id"))).
+					SelectPrefixed("customer", "c.id", "c.name"),
+				`SELECT o.id, o.total, c.id AS "customer.id", c.name AS "customer.name" FROM orders o INNER JOIN customers c ON c.id = o.customer_id`,
+				[]interface{}{},
+			},
+		}
+	})
+}
+
+func TestSelectPrefixedScan(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	type customer struct {
+		ID   int64  `db:"id"`
+		Name string `db:"name"`
+	}
+
+	type order struct {
+		ID       int64    `db:"id"`
+		Customer customer `db:"customer"`
+	}
+
+	mock.ExpectQuery("SELECT").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "customer.id", "customer.name"}).
+			AddRow(1, 10, "Alice").
+			AddRow(2, 20, "Bob"),
+	)
+
+	var orders []order
+
+	err = New(db, "sqlmock").
+		Select("o.id").
+		From("orders o").
+		InnerJoin("customers c", Eq("c.id", Indirect("o.customer_id"))).
+		SelectPrefixed("customer", "c.id", "c.name").
+		GetAll(&orders)
+	if err != nil {
+		t.Fatalf("Failed loading rows: %s", err)
+	}
+
+	if len(orders) != 2 || orders[1].ID != 2 || orders[1].Customer.ID != 20 || orders[1].Customer.Name != "Bob" {
+		t.Errorf("Unexpected results: %+v", orders)
+	}
+}
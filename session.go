@@ -0,0 +1,165 @@
+package sqlz
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// WithSessionInit wraps the provided connector so that every new
+// connection it opens executes the provided statements before it is used,
+// e.g. to set the search path, the time zone or custom configuration
+// parameters, rather than sprinkling SET commands across transactions.
+// Since connections are pooled, this is the only reliable way to configure
+// every session. Bindings of the statements are inlined as literals in the
+// dialect of the connector's driver (see Dialect.Literal), as commands
+// such as SET do not support placeholders. If the dialect of the driver is
+// unknown, strings containing backslashes cannot be inlined safely, and
+// are rejected. Statements can be built before the database is opened:
+//
+//	connector := sqlz.WithSessionInit(pq.NewConnector(dsn),
+//	    sqlz.Indirect("SET search_path TO app, public"),
+//	    sqlz.Indirect("SET TIME ZONE ?", "UTC"),
+//	)
+//	db := sqlz.New(sql.OpenDB(connector), "postgres")
+//
+// Statements are prepared like any other statement (e.g. restricted by
+// their scope) with a background context. If a statement cannot be
+// prepared, or fails, the connection is closed and the error is returned
+// by the operation that required a connection.
+func WithSessionInit(connector driver.Connector, stmts ...SQLStmt) driver.Connector {
+	dialect := driverDialect(connector.Driver())
+
+	queries := make([]string, len(stmts))
+	for i, stmt := range stmts {
+		prepared, err := prepare(context.Background(), stmt)
+		if err != nil {
+			return &sessionConnector{Connector: connector, err: err}
+		}
+
+		asSQL, bindings := prepared.ToSQL(false)
+
+		queries[i], err = inlineSession(dialect, asSQL, bindings)
+		if err != nil {
+			return &sessionConnector{Connector: connector, err: err}
+		}
+	}
+
+	return &sessionConnector{Connector: connector, queries: queries}
+}
+
+// inlineSession inlines the bindings of a session initialization query
+// as literals of the provided dialect. Strings containing backslashes are
+// rejected if the dialect is unknown, as backslashes may be escape
+// characters (e.g. in MySQL), allowing values to break out of literals.
+func inlineSession(dialect Dialect, asSQL string, bindings []interface{}) (string, error) {
+	if dialect == DialectGeneric {
+		for _, binding := range bindings {
+			literal, err := Literal(binding)
+			if err == nil && strings.Contains(literal, `\`) {
+				return "", fmt.Errorf("cannot inline %s, as the dialect of the driver is unknown", literal)
+			}
+		}
+	}
+
+	return inlineBindings(dialect, asSQL, bindings)
+}
+
+// driverDialect returns the dialect of the provided driver, detected from
+// the name it is registered with (see DialectOf)
+func driverDialect(drv driver.Driver) Dialect {
+	typ := reflect.TypeOf(drv)
+
+	for _, name := range sql.Drivers() {
+		dialect := DialectOf(name)
+		if dialect == DialectGeneric {
+			continue
+		}
+
+		// opening a database does not connect to it
+		db, err := sql.Open(name, "")
+		if err != nil {
+			continue
+		}
+
+		matches := reflect.TypeOf(db.Driver()) == typ
+		db.Close() //nolint: errcheck
+
+		if matches {
+			return dialect
+		}
+	}
+
+	return DialectGeneric
+}
+
+// sessionConnector is a connector executing session initialization
+// queries on every connection it opens
+type sessionConnector struct {
+	driver.Connector
+	queries []string
+	// err is the error preparing the statements, if any
+	err error
+}
+
+// Connect opens a connection with the wrapped connector, and executes the
+// session initialization queries on it
+func (c *sessionConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	if c.err != nil {
+		return nil, fmt.Errorf("failed initializing session: %w", c.err)
+	}
+
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, query := range c.queries {
+		if err := execConn(ctx, conn, query); err != nil {
+			conn.Close() //nolint: errcheck
+			return nil, fmt.Errorf("failed initializing session: %w", err)
+		}
+	}
+
+	return conn, nil
+}
+
+// execConn executes a query with no arguments directly on a driver
+// connection, preparing it if the driver cannot execute it directly
+func execConn(ctx context.Context, conn driver.Conn, query string) error {
+	if execer, ok := conn.(driver.ExecerContext); ok {
+		_, err := execer.ExecContext(ctx, query, nil)
+		if !errors.Is(err, driver.ErrSkip) {
+			return err
+		}
+	}
+
+	var (
+		stmt driver.Stmt
+		err  error
+	)
+
+	if preparer, ok := conn.(driver.ConnPrepareContext); ok {
+		stmt, err = preparer.PrepareContext(ctx, query)
+	} else {
+		stmt, err = conn.Prepare(query)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	defer stmt.Close()
+
+	if execer, ok := stmt.(driver.StmtExecContext); ok {
+		_, err = execer.ExecContext(ctx, nil)
+	} else {
+		_, err = stmt.Exec(nil) //nolint: staticcheck
+	}
+
+	return err
+}
@@ -0,0 +1,108 @@
+package sqlz
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"regexp"
+	"testing"
+
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+// dsnConnector is a connector opening connections of a driver by DSN
+type dsnConnector struct {
+	drv driver.Driver
+	dsn string
+}
+
+func (c dsnConnector) Connect(_ context.Context) (driver.Conn, error) {
+	return c.drv.Open(c.dsn)
+}
+
+func (c dsnConnector) Driver() driver.Driver {
+	return c.drv
+}
+
+func TestWithSessionInit(t *testing.T) {
+	mockDB, mock, err := sqlmock.NewWithDSN("session_init")
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	connector := WithSessionInit(dsnConnector{mockDB.Driver(), "session_init"},
+		Indirect("SET search_path TO app, public"),
+		Indirect("SET TIME ZONE ?", "UTC"),
+	)
+
+	mock.ExpectExec(`SET search_path TO app, public`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`SET TIME ZONE 'UTC'`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`DELETE FROM sessions WHERE expired = \$1`).
+		WithArgs(true).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	dbz := New(sql.OpenDB(connector), "postgres")
+
+	if _, err := dbz.DeleteFrom("sessions").Where(Eq("expired", true)).Exec(); err != nil {
+		t.Fatalf("Delete failed: %s", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %s", err)
+	}
+
+	mock.ExpectExec(`SET search_path TO app, public`).WillReturnError(errors.New("invalid value"))
+
+	_, err = connector.Connect(context.Background())
+	if err == nil || err.Error() != "failed initializing session: invalid value" {
+		t.Errorf("Expected session initialization to fail, got %v", err)
+	}
+}
+
+// mysqlDriver is a driver registered as "mysql" for testing dialect
+// detection, opening sqlmock connections
+type mysqlDriver struct {
+	driver.Driver
+}
+
+func init() {
+	sql.Register("mysql", mysqlDriver{})
+}
+
+func TestWithSessionInitEscaping(t *testing.T) {
+	mockDB, mock, err := sqlmock.NewWithDSN("session_init_mysql")
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	value := `x\' OR 1=1 -- `
+
+	// backslashes are escaped in MySQL, so the value cannot break out of
+	// the literal
+	connector := WithSessionInit(dsnConnector{mysqlDriver{mockDB.Driver()}, "session_init_mysql"},
+		Indirect("SET @tenant = ?", value),
+	)
+
+	mock.ExpectExec(regexp.QuoteMeta(`SET @tenant = 'x\\'' OR 1=1 -- '`)).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	conn, err := connector.Connect(context.Background())
+	if err != nil {
+		t.Fatalf("Connect failed: %s", err)
+	}
+
+	conn.Close()
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %s", err)
+	}
+
+	// the dialect of sqlmock's driver is unknown, so the value is rejected
+	connector = WithSessionInit(dsnConnector{mockDB.Driver(), "session_init_mysql"},
+		Indirect("SET @tenant = ?", value),
+	)
+
+	if _, err := connector.Connect(context.Background()); err == nil {
+		t.Error("Expected value with backslashes to be rejected for an unknown dialect")
+	}
+}
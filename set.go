@@ -1,21 +1,24 @@
 package sqlz
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"strings"
 	"time"
-
-	"github.com/jmoiron/sqlx"
 )
 
-// SetCmd represents a PostgreSQL SET command
+// SetCmd represents a SET command, setting a configuration parameter (or,
+// in MySQL, a user-defined variable). The syntax depends on the dialect:
+// SET param TO value in PostgreSQL, SET param = value in MySQL.
 type SetCmd struct {
 	*Statement
 	level       string
 	configParam string
 	value       string
+	bindings    []interface{}
 	execer      Ext
+	guards      []Guard
 }
 
 // Set creates a new SetCmd object, with configuration parameter
@@ -24,7 +27,8 @@ func (db *DB) Set(configParam, value string) *SetCmd {
 	return &SetCmd{
 		configParam: configParam,
 		value:       value,
-		execer:      db.DB,
+		execer:      db.ext(),
+		guards:      db.defaults().Guards,
 		Statement:   &Statement{db.ErrHandlers},
 	}
 }
@@ -35,7 +39,34 @@ func (tx *Tx) Set(configParam, value string) *SetCmd {
 	return &SetCmd{
 		configParam: configParam,
 		value:       value,
-		execer:      tx.Tx,
+		execer:      tx.ext(),
+		guards:      tx.defaults().Guards,
+		Statement:   &Statement{tx.ErrHandlers},
+	}
+}
+
+// SetVar creates a new SetCmd object setting a MySQL user-defined variable
+// (SET @name = ?) to the provided value, which is bound to a placeholder.
+// The variable can then be referenced in subsequent statements in the
+// same session, e.g. with Indirect("@name").
+func (db *DB) SetVar(name string, value interface{}) *SetCmd {
+	return &SetCmd{
+		configParam: "@" + name,
+		bindings:    []interface{}{value},
+		execer:      db.ext(),
+		guards:      db.defaults().Guards,
+		Statement:   &Statement{db.ErrHandlers},
+	}
+}
+
+// SetVar creates a new SetCmd object setting a MySQL user-defined variable
+// (see DB.SetVar)
+func (tx *Tx) SetVar(name string, value interface{}) *SetCmd {
+	return &SetCmd{
+		configParam: "@" + name,
+		bindings:    []interface{}{value},
+		execer:      tx.ext(),
+		guards:      tx.defaults().Guards,
 		Statement:   &Statement{tx.ErrHandlers},
 	}
 }
@@ -48,13 +79,58 @@ func (tx *Tx) SetTimeout(d time.Duration) (res sql.Result, err error) {
 	stmt := &SetCmd{
 		configParam: "statement_timeout",
 		value:       fmt.Sprintf("\"%dms\"", d.Milliseconds()),
-		execer:      tx.Tx,
+		execer:      tx.ext(),
+		guards:      tx.defaults().Guards,
+		Statement:   &Statement{tx.ErrHandlers},
+	}
+
+	return stmt.Local().Exec()
+}
+
+// SetLockTimeout sets a lock timeout (SET LOCAL lock_timeout). When set,
+// any statement (in the transaction) that waits longer than the specified
+// duration to acquire a lock on a table, row or other object will be
+// aborted. A value of zero turns this off.
+func (tx *Tx) SetLockTimeout(d time.Duration) (res sql.Result, err error) {
+	stmt := &SetCmd{
+		configParam: "lock_timeout",
+		value:       fmt.Sprintf("\"%dms\"", d.Milliseconds()),
+		execer:      tx.ext(),
+		guards:      tx.defaults().Guards,
 		Statement:   &Statement{tx.ErrHandlers},
 	}
 
 	return stmt.Local().Exec()
 }
 
+// WithLockTimeout executes the provided function with a lock timeout (see
+// SetLockTimeout), restoring the transaction's previous lock timeout after
+// it returns successfully. If the function returns an error, the lock
+// timeout is not restored, as the transaction is expected to be rolled
+// back. This is useful for migrations and hot-path updates that should
+// fail fast rather than queue behind long-running transactions.
+func (tx *Tx) WithLockTimeout(d time.Duration, f func(tx *Tx) error) error {
+	var prev string
+
+	err := tx.ext().QueryRowx("SHOW lock_timeout").Scan(&prev)
+	if err != nil {
+		(&Statement{tx.ErrHandlers}).HandleError(err)
+		return err
+	}
+
+	if _, err := tx.SetLockTimeout(d); err != nil {
+		return err
+	}
+
+	if err := f(tx); err != nil {
+		return err
+	}
+
+	_, err = tx.Set("lock_timeout", quoteLiteral(prev)).Local().Exec()
+
+	return err
+}
+
 // Local sets the configuration parameter locally in a transaction.
 //
 //	The effect of SET LOCAL will last only till the end of the
@@ -78,36 +154,108 @@ func (cmd *SetCmd) Session() *SetCmd {
 	return cmd
 }
 
+// Global sets the configuration parameter globally, for all new sessions
+// (SET GLOBAL). This is only supported by MySQL.
+func (cmd *SetCmd) Global() *SetCmd {
+	cmd.level = "GLOBAL"
+	return cmd
+}
+
 // ToSQL generates the SET command SQL and returns a list of
 // bindings. It is used internally by Exec, but is exported if you
 // wish to use it directly.
 func (cmd *SetCmd) ToSQL(rebind bool) (string, []interface{}) {
 	clauses := []string{"SET"}
-	if cmd.level != "" {
+	if cmd.level != "" && cmd.bindings == nil {
 		clauses = append(clauses, cmd.level)
 	}
 
-	clauses = append(clauses, cmd.configParam, "TO", cmd.value)
+	switch {
+	case cmd.bindings != nil:
+		clauses = append(clauses, cmd.configParam, "=", "?")
+	case dialectOf(cmd.execer) == DialectMySQL:
+		clauses = append(clauses, cmd.configParam, "=", cmd.value)
+	default:
+		clauses = append(clauses, cmd.configParam, "TO", cmd.value)
+	}
 
 	asSQL := strings.Join(clauses, " ")
 
 	if rebind {
-		if db, ok := cmd.execer.(*sqlx.DB); ok {
-			asSQL = db.Rebind(asSQL)
-		} else if tx, ok := cmd.execer.(*sqlx.Tx); ok {
-			asSQL = tx.Rebind(asSQL)
-		}
+		asSQL = rebindFor(cmd.execer, asSQL)
 	}
 
-	return asSQL, []interface{}{}
+	bindings := make([]interface{}, len(cmd.bindings))
+	copy(bindings, cmd.bindings)
+
+	return asSQL, bindings
 }
 
 // Exec executes the SET command, returning the standard
-// sql.Result struct and an error if the query failed.
+// sql.Result struct and an error if the query failed. The command is
+// checked against the guards of the DB or Tx it was created from first
+// (see Defaults.Guards).
 func (cmd *SetCmd) Exec() (res sql.Result, err error) {
-	asSQL, bindings := cmd.ToSQL(true)
-	res, err = cmd.execer.Exec(asSQL, bindings...)
-	cmd.Statement.HandleError(err)
+	return execDDL(context.Background(), cmd, cmd.execer, cmd.guards, cmd.Statement)
+}
+
+// SetConstraintsCmd represents a SET CONSTRAINTS command, which sets
+// whether deferrable constraints are checked at the end of the current
+// transaction or after every statement
+type SetConstraintsCmd struct {
+	*Statement
+	Constraints []string
+	IsDeferred  bool
+	execer      Ext
+	guards      []Guard
+}
+
+// SetConstraints creates a new SetConstraintsCmd object for the provided
+// constraints, or for all deferrable constraints if none are provided. By
+// default, the constraints are set to be checked immediately; use Deferred
+// to defer them to the end of the transaction instead.
+func (tx *Tx) SetConstraints(names ...string) *SetConstraintsCmd {
+	return &SetConstraintsCmd{
+		Constraints: names,
+		execer:      tx.ext(),
+		guards:      tx.defaults().Guards,
+		Statement:   &Statement{tx.ErrHandlers},
+	}
+}
+
+// Deferred defers checking the constraints to the end of the transaction
+func (cmd *SetConstraintsCmd) Deferred() *SetConstraintsCmd {
+	cmd.IsDeferred = true
+	return cmd
+}
+
+// Immediate checks the constraints after every statement. If they were
+// previously deferred, they are checked when the command is executed.
+func (cmd *SetConstraintsCmd) Immediate() *SetConstraintsCmd {
+	cmd.IsDeferred = false
+	return cmd
+}
+
+// ToSQL generates the SET CONSTRAINTS command SQL. The command has no
+// bindings.
+func (cmd *SetConstraintsCmd) ToSQL(_ bool) (string, []interface{}) {
+	constraints := "ALL"
+	if len(cmd.Constraints) > 0 {
+		constraints = strings.Join(cmd.Constraints, ", ")
+	}
+
+	mode := "IMMEDIATE"
+	if cmd.IsDeferred {
+		mode = "DEFERRED"
+	}
+
+	return "SET CONSTRAINTS " + constraints + " " + mode, []interface{}{}
+}
 
-	return res, err
+// Exec executes the SET CONSTRAINTS command, returning the standard
+// sql.Result struct and an error if the query failed. The command is
+// checked against the guards of the Tx it was created from first (see
+// Defaults.Guards).
+func (cmd *SetConstraintsCmd) Exec() (res sql.Result, err error) {
+	return execDDL(context.Background(), cmd, cmd.execer, cmd.guards, cmd.Statement)
 }
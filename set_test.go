@@ -1,6 +1,11 @@
 package sqlz
 
-import "testing"
+import (
+	"testing"
+	"time"
+
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
 
 func TestSet(t *testing.T) {
 	runTests(t, func(dbz *DB) []test {
@@ -22,4 +27,86 @@ func TestSet(t *testing.T) {
 			},
 		}
 	})
+
+	runTestsWithDriver(t, "mysql", func(dbz *DB) []test {
+		return []test{
+			{
+				name:        "session set",
+				stmt:        dbz.Set("sql_mode", "'TRADITIONAL'").Session(),
+				expectedSQL: "SET SESSION sql_mode = 'TRADITIONAL'",
+			},
+			{
+				name:        "global set",
+				stmt:        dbz.Set("max_connections", "200").Global(),
+				expectedSQL: "SET GLOBAL max_connections = 200",
+			},
+			{
+				name:             "user variable",
+				stmt:             dbz.SetVar("tenant", 42),
+				expectedSQL:      "SET @tenant = ?",
+				expectedBindings: []interface{}{42},
+			},
+		}
+	})
+}
+
+func TestSetConstraints(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	dbz := New(db, "postgres")
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`SET CONSTRAINTS ALL DEFERRED`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`SET CONSTRAINTS parent_fk, order_fk IMMEDIATE`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	err = dbz.Transactional(func(tx *Tx) error {
+		if _, err := tx.SetConstraints().Deferred().Exec(); err != nil {
+			return err
+		}
+
+		_, err := tx.SetConstraints("parent_fk", "order_fk").Immediate().Exec()
+
+		return err
+	})
+	if err != nil {
+		t.Errorf("SetConstraints failed: %s", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %s", err)
+	}
+}
+
+func TestWithLockTimeout(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	dbz := New(db, "postgres")
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SHOW lock_timeout`).WillReturnRows(sqlmock.NewRows([]string{"lock_timeout"}).AddRow("10s"))
+	mock.ExpectExec(`SET LOCAL lock_timeout TO "1500ms"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`UPDATE accounts SET balance = \$1 WHERE id = \$2`).WithArgs(0, 1).WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec(`SET LOCAL lock_timeout TO '10s'`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	err = dbz.Transactional(func(tx *Tx) error {
+		return tx.WithLockTimeout(1500*time.Millisecond, func(tx *Tx) error {
+			_, err := tx.Update("accounts").Set("balance", 0).Where(Eq("id", 1)).Exec()
+			return err
+		})
+	})
+	if err != nil {
+		t.Errorf("WithLockTimeout failed: %s", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %s", err)
+	}
 }
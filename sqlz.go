@@ -3,10 +3,13 @@ package sqlz
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
+	"reflect"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/jmoiron/sqlx"
 )
@@ -30,12 +33,27 @@ type Queryer interface {
 type DB struct {
 	*sqlx.DB
 	ErrHandlers []func(err error)
+	// Defaults are applied to statements created from the DB, and are
+	// inherited by transactions started from it. Once the DB is used
+	// concurrently, they must only be modified through Configure.
+	Defaults Defaults
+
+	mu sync.RWMutex
 }
 
 // Tx is a wrapper around sqlx.Tx (which is a wrapper around sql.Tx)
 type Tx struct {
 	*sqlx.Tx
 	ErrHandlers []func(err error)
+	// Defaults are applied to statements created from the Tx. Once the
+	// Tx is used concurrently, they must only be modified through
+	// Configure.
+	Defaults Defaults
+
+	mu sync.RWMutex
+
+	trace    *TxTrace
+	onCommit []func()
 }
 
 // SQLStmt is an interface representing a general SQL statement. All
@@ -45,6 +63,32 @@ type SQLStmt interface {
 	ToSQL(bool) (string, []interface{})
 }
 
+// dialectAware is implemented by SQL fragments whose generated SQL depends
+// on the dialect of the database in use
+type dialectAware interface {
+	forDialect(dialect Dialect) SQLStmt
+}
+
+// driverName returns the name of the database driver used by the provided
+// queryer or execer, or an empty string if it cannot be determined
+func driverName(q interface{}) string {
+	if named, ok := q.(interface{ DriverName() string }); ok {
+		return named.DriverName()
+	}
+
+	return ""
+}
+
+// rebindFor rebinds the placeholders in the provided SQL to those used by the
+// database driver of the provided queryer or execer
+func rebindFor(q interface{}, asSQL string) string {
+	if binder, ok := q.(interface{ Rebind(string) string }); ok {
+		return binder.Rebind(asSQL)
+	}
+
+	return asSQL
+}
+
 // New creates a new DB instance from an underlying sql.DB object.
 // It requires the name of the SQL driver in order to use the correct
 // placeholders when generating SQL
@@ -66,7 +110,8 @@ func Newx(db *sqlx.DB) *DB {
 // Transactional runs the provided function inside a transaction. The
 // function must receive an sqlz Tx object, and return an error. If the
 // function returns an error, the transaction is automatically rolled
-// back. Otherwise, the transaction is committed.
+// back. Otherwise, the transaction is committed. To run a function inside
+// a transaction that was already started, see Tx.Transactional.
 func (db *DB) Transactional(f func(tx *Tx) error, opts ...*sql.TxOptions) error {
 	var lastOpts *sql.TxOptions
 	if len(opts) > 0 {
@@ -90,13 +135,15 @@ func (db *DB) TransactionalContext(
 		return fmt.Errorf("failed starting transaction: %w", err)
 	}
 
-	err = f(&Tx{Tx: tx, ErrHandlers: db.ErrHandlers})
+	txz := &Tx{Tx: tx, ErrHandlers: db.ErrHandlers, Defaults: db.defaults()}
+
+	err = f(txz)
 	if err != nil {
-		tx.Rollback() //nolint: errcheck
+		txz.Rollback() //nolint: errcheck
 		return err
 	}
 
-	err = tx.Commit()
+	err = txz.Commit()
 	if err != nil {
 		return fmt.Errorf("failed committing transaction: %w", err)
 	}
@@ -136,11 +183,22 @@ type PreCondition struct {
 	Condition WhereCondition
 }
 
+// GroupCondition wraps a condition in parentheses, which are always
+// retained in the generated SQL
+type GroupCondition struct {
+	Condition WhereCondition
+}
+
 // SubqueryCondition is a WHERE condition on the results
-// of a sub-query.
+// of a sub-query. The sub-query can be any SQL statement,
+// e.g. a SelectStmt, a WithStmt, or raw SQL created with
+// Indirect. Left is an optional left-value (usually a
+// column) for operators that compare a value with the
+// results of the sub-query (e.g. "IN").
 type SubqueryCondition struct {
-	Stmt     *SelectStmt
+	Stmt     SQLStmt
 	Operator string
+	Left     string
 }
 
 // SQLCondition represents a condition written directly in
@@ -193,71 +251,92 @@ func Not(cond WhereCondition) PreCondition {
 	return PreCondition{"NOT", cond}
 }
 
+// Group encloses a condition in parentheses that are always retained, even
+// when it is the only condition of a WHERE or HAVING clause
+func Group(cond WhereCondition) GroupCondition {
+	return GroupCondition{cond}
+}
+
 // Eq represents a simple equality condition ("=" operator)
 func Eq(col string, value interface{}) SimpleCondition {
-	return SimpleCondition{col, value, "="}
+	return SimpleCondition{col, value, string(OpEq)}
 }
 
 // Ne represents a simple non-equality condition ("<>" operator)
 func Ne(col string, value interface{}) SimpleCondition {
-	return SimpleCondition{col, value, "<>"}
+	return SimpleCondition{col, value, string(OpNe)}
 }
 
 // Gt represents a simple greater-than condition (">" operator)
 func Gt(col string, value interface{}) SimpleCondition {
-	return SimpleCondition{col, value, ">"}
+	return SimpleCondition{col, value, string(OpGt)}
 }
 
 // Gte represents a simple greater-than-or-equals condition (">=" operator)
 func Gte(col string, value interface{}) SimpleCondition {
-	return SimpleCondition{col, value, ">="}
+	return SimpleCondition{col, value, string(OpGte)}
 }
 
 // Lt represents a simple less-than condition ("<" operator)
 func Lt(col string, value interface{}) SimpleCondition {
-	return SimpleCondition{col, value, "<"}
+	return SimpleCondition{col, value, string(OpLt)}
 }
 
 // Lte represents a simple less-than-or-equals condition ("<=" operator)
 func Lte(col string, value interface{}) SimpleCondition {
-	return SimpleCondition{col, value, "<="}
+	return SimpleCondition{col, value, string(OpLte)}
 }
 
 // Like represents a wildcard equality condition ("LIKE" operator)
 func Like(col string, value interface{}) SimpleCondition {
-	return SimpleCondition{col, value, "LIKE"}
+	return SimpleCondition{col, value, string(OpLike)}
 }
 
 // NotLike represents a wildcard non-equality condition ("NOT LIKE" operator)
 func NotLike(col string, value interface{}) SimpleCondition {
-	return SimpleCondition{col, value, "NOT LIKE"}
+	return SimpleCondition{col, value, string(OpNotLike)}
 }
 
 // ILike represents a wildcard equality condition ("ILIKE" operator)
 func ILike(col string, value interface{}) SimpleCondition {
-	return SimpleCondition{col, value, "ILIKE"}
+	return SimpleCondition{col, value, string(OpILike)}
 }
 
 // IsNull represents a simple nullity condition ("IS NULL" operator)
 func IsNull(col string) SimpleCondition {
-	return SimpleCondition{col, nil, "IS NULL"}
+	return SimpleCondition{col, nil, string(OpIsNull)}
 }
 
 // IsNotNull represents a simple non-nullity condition ("IS NOT NULL" operator)
 func IsNotNull(col string) SimpleCondition {
-	return SimpleCondition{col, nil, "IS NOT NULL"}
+	return SimpleCondition{col, nil, string(OpIsNotNull)}
 }
 
 // Exists creates a sub-query condition checking the sub-query
-// returns results ("EXISTS" operator)
-func Exists(stmt *SelectStmt) SubqueryCondition {
-	return SubqueryCondition{stmt, "EXISTS"}
+// returns results ("EXISTS" operator). The sub-query may be
+// any SQL statement, including WITH statements and raw SQL
+// (e.g. Exists(Indirect("SELECT 1 FROM t WHERE id = ?", 1))).
+func Exists(stmt SQLStmt) SubqueryCondition {
+	return SubqueryCondition{stmt, "EXISTS", ""}
 }
 
 // NotExists creates a sub-query condition checking the sub-query
 // does not return results ("NOT EXISTS" operator)
-func NotExists(stmt *SelectStmt) SubqueryCondition {
-	return SubqueryCondition{stmt, "NOT EXISTS"}
+func NotExists(stmt SQLStmt) SubqueryCondition {
+	return SubqueryCondition{stmt, "NOT EXISTS", ""}
+}
+
+// InSubquery creates a sub-query condition checking that the value
+// of a column is one of the results of the sub-query ("IN" operator)
+func InSubquery(col string, stmt SQLStmt) SubqueryCondition {
+	return SubqueryCondition{stmt, "IN", col}
+}
+
+// NotInSubquery creates a sub-query condition checking that the value
+// of a column is not one of the results of the sub-query ("NOT IN"
+// operator)
+func NotInSubquery(col string, stmt SQLStmt) SubqueryCondition {
+	return SubqueryCondition{stmt, "NOT IN", col}
 }
 
 // JSONBOp creates simple conditions with JSONB operators for
@@ -287,15 +366,85 @@ type InCondition struct {
 }
 
 // In creates an IN condition for matching the value of a column
-// against an array of possible values
+// against an array of possible values. The values may also be provided
+// as a single slice of any type (e.g. In("id", ids) for an []int64),
+// which is expanded into its elements.
 func In(col string, values ...interface{}) InCondition {
-	return InCondition{false, col, values}
+	return InCondition{false, col, expandSlice(values)}
 }
 
 // NotIn creates a NOT IN condition for checking that the value
-// of a column is not one of the defined values
+// of a column is not one of the defined values. Like with In, the values
+// may be provided as a single slice.
 func NotIn(col string, values ...interface{}) InCondition {
-	return InCondition{true, col, values}
+	return InCondition{true, col, expandSlice(values)}
+}
+
+// expandSlice returns the elements of the provided values if they consist
+// of a single slice (or array), or the values themselves otherwise. Byte
+// slices and values implementing driver.Valuer (e.g. array types of
+// PostgreSQL drivers) are single values, and are not expanded.
+func expandSlice(values []interface{}) []interface{} {
+	if len(values) != 1 {
+		return values
+	}
+
+	switch v := values[0].(type) {
+	case []interface{}:
+		return v
+	case []byte, driver.Valuer:
+		return values
+	}
+
+	v := reflect.ValueOf(values[0])
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return values
+	}
+
+	expanded := make([]interface{}, v.Len())
+	for i := range expanded {
+		expanded[i] = v.Index(i).Interface()
+	}
+
+	return expanded
+}
+
+// EmptyInPolicy determines how IN and NOT IN conditions with no values,
+// which would generate invalid SQL such as "id IN ()", are handled. This
+// is common when IN conditions are built from dynamic slices.
+type EmptyInPolicy uint8
+
+const (
+	// EmptyInReject reports IN conditions with no values as invalid (see
+	// Validate), so executing statements with such conditions fails
+	// before they reach the database
+	EmptyInReject EmptyInPolicy = iota
+	// EmptyInConstant renders IN conditions with no values as a condition
+	// that is always false (FALSE, or 1 = 0 where boolean literals are not
+	// supported), as no value is in an empty list, and NOT IN conditions
+	// with no values as a condition that is always true
+	EmptyInConstant
+)
+
+// BetweenCondition is a struct representing BETWEEN and NOT BETWEEN
+// conditions
+type BetweenCondition struct {
+	NotBetween bool
+	Left       string
+	Low        interface{}
+	High       interface{}
+}
+
+// Between creates a BETWEEN condition checking that the value of a
+// column is within the provided range (inclusive)
+func Between(col string, low, high interface{}) BetweenCondition {
+	return BetweenCondition{false, col, low, high}
+}
+
+// NotBetween creates a NOT BETWEEN condition checking that the value of
+// a column is outside the provided range
+func NotBetween(col string, low, high interface{}) BetweenCondition {
+	return BetweenCondition{true, col, low, high}
 }
 
 // ArrayCondition represents an array comparison condition
@@ -472,6 +621,29 @@ func (in InCondition) Parse() (asSQL string, bindings []interface{}) {
 	return asSQL, bindings
 }
 
+// Parse implements the WhereCondition interface, generating SQL from
+// the condition
+func (between BetweenCondition) Parse() (asSQL string, bindings []interface{}) {
+	asSQL = between.Left
+	if between.NotBetween {
+		asSQL += " NOT"
+	}
+
+	bounds := make([]string, 2)
+
+	for i, val := range []interface{}{between.Low, between.High} {
+		if indirect, isIndirect := val.(IndirectValue); isIndirect {
+			bounds[i] = indirect.Reference
+			bindings = append(bindings, indirect.Bindings...)
+		} else {
+			bounds[i] = "?"
+			bindings = append(bindings, val)
+		}
+	}
+
+	return asSQL + " BETWEEN " + bounds[0] + " AND " + bounds[1], bindings
+}
+
 // Parse implements the WhereCondition interface, generating SQL from
 // the condition
 func (andOr AndOrCondition) Parse() (asSQL string, bindings []interface{}) {
@@ -489,7 +661,20 @@ func (andOr AndOrCondition) Parse() (asSQL string, bindings []interface{}) {
 		op = " OR "
 	}
 
-	return fmt.Sprintf("(%s)", strings.Join(sqls, op)), bindings
+	return "(" + strings.Join(sqls, op) + ")", bindings
+}
+
+// Parse implements the WhereCondition interface, generating SQL from
+// the condition
+func (group GroupCondition) Parse() (asSQL string, bindings []interface{}) {
+	asSQL, bindings = group.Condition.Parse()
+
+	if _, isAndOr := group.Condition.(AndOrCondition); isAndOr {
+		// AND/OR groups are already enclosed in parentheses
+		return asSQL, bindings
+	}
+
+	return "(" + asSQL + ")", bindings
 }
 
 // Parse implements the WhereCondition interface, generating SQL from
@@ -498,24 +683,41 @@ func (pre PreCondition) Parse() (asSQL string, bindings []interface{}) {
 	innerSQL, innerBindings := pre.Condition.Parse()
 	bindings = append(bindings, innerBindings...)
 
-	return fmt.Sprintf("%s(%s)", pre.Pre, innerSQL), bindings
+	return pre.Pre + "(" + innerSQL + ")", bindings
 }
 
 // Parse implements the WhereCondition interface, generating SQL from
 // the condition
 func (subCond SubqueryCondition) Parse() (asSQL string, bindings []interface{}) {
+	// the sub-query must never be rebound by itself, as placeholders
+	// are rebound once for the entire outer statement
 	asSQL, bindings = subCond.Stmt.ToSQL(false)
-	return subCond.Operator + " (" + asSQL + ")", bindings
+	asSQL = subCond.Operator + " (" + asSQL + ")"
+
+	if subCond.Left != "" {
+		asSQL = subCond.Left + " " + asSQL
+	}
+
+	return asSQL, bindings
 }
 
+// parseConditions generates SQL from a list of conditions, joining them with
+// AND. The outer parentheses are only removed when they were generated by an
+// AndOrCondition, as only then is it certain that they enclose the entire
+// expression; conditions written directly in SQL (and conditions wrapped
+// with Group) are left untouched.
 func parseConditions(conds []WhereCondition) (asSQL string, bindings []interface{}) {
+	var generated bool
+
 	if len(conds) > 1 {
 		asSQL, bindings = (AndOrCondition{false, conds}).Parse()
+		generated = true
 	} else if len(conds) == 1 {
 		asSQL, bindings = conds[0].Parse()
+		_, generated = conds[0].(AndOrCondition)
 	}
 
-	if strings.HasPrefix(asSQL, "(") {
+	if generated {
 		asSQL = strings.TrimPrefix(strings.TrimSuffix(asSQL, ")"), "(")
 	}
 
@@ -1,6 +1,7 @@
 package sqlz
 
 import (
+	"reflect"
 	"testing"
 
 	"gopkg.in/DATA-DOG/go-sqlmock.v1"
@@ -14,12 +15,16 @@ type test struct {
 }
 
 func runTests(t *testing.T, source func(dbz *DB) []test) {
+	runTestsWithDriver(t, "sqlmock", source)
+}
+
+func runTestsWithDriver(t *testing.T, driver string, source func(dbz *DB) []test) {
 	db, _, err := sqlmock.New()
 	if err != nil {
 		t.Fatalf("Failed creating mock database: %s", err)
 	}
 
-	for _, tst := range source(New(db, "sqlmock")) {
+	for _, tst := range source(New(db, driver)) {
 		t.Run(tst.name, func(t *testing.T) {
 			resultingSQL, resultingBindings := tst.stmt.ToSQL(true)
 			if resultingSQL != tst.expectedSQL {
@@ -30,7 +35,7 @@ func runTests(t *testing.T, source func(dbz *DB) []test) {
 				t.Errorf("Failed %s: expected %d bindings, got %d", tst.name, len(tst.expectedBindings), len(resultingBindings))
 			} else {
 				for i := range tst.expectedBindings {
-					if tst.expectedBindings[i] != resultingBindings[i] {
+					if !reflect.DeepEqual(tst.expectedBindings[i], resultingBindings[i]) {
 						t.Errorf("Failed %s: expected binding %d to be %v, got %v", tst.name, i+1, tst.expectedBindings[i], resultingBindings[i])
 					}
 				}
@@ -38,3 +43,45 @@ func runTests(t *testing.T, source func(dbz *DB) []test) {
 		})
 	}
 }
+
+func TestExecerOverride(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	sqlmockDB := New(db, "sqlmock")
+	postgresDB := New(db, "postgres")
+
+	sel := sqlmockDB.Select("*").From("users").Where(Eq("id", 1))
+	upd := sqlmockDB.Update("users").Set("name", "Alice").Where(Eq("id", 1))
+
+	runTests(t, func(dbz *DB) []test {
+		return []test{
+			{
+				"select with overridden queryer",
+				sel.WithQueryer(postgresDB),
+				"SELECT * FROM users WHERE id = $1",
+				[]interface{}{1},
+			},
+		}
+	})
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE users SET name = \$1 WHERE id = \$2`).
+		WithArgs("Alice", 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err = postgresDB.Transactional(func(tx *Tx) error {
+		_, err := upd.WithExecer(tx).Exec()
+		return err
+	})
+	if err != nil {
+		t.Errorf("Failed executing with overridden execer: %s", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %s", err)
+	}
+}
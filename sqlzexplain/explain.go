@@ -0,0 +1,185 @@
+// Package sqlzexplain provides test helpers that assert properties of the
+// PostgreSQL query plans of statements built with sqlz, so that query plan
+// regressions of critical queries (e.g. a dropped index, or a query that
+// no longer uses it) are caught in CI rather than in production:
+//
+//	func TestRecentOrdersPlan(t *testing.T) {
+//	    db := sqlz.New(testDB, "postgres")
+//
+//	    sqlzexplain.Assert(t, db, recentOrdersQuery(db, 42),
+//	        sqlzexplain.UsesIndex("orders_customer_id_idx"),
+//	        sqlzexplain.NoSeqScan("orders"),
+//	    )
+//	}
+//
+// Statements are explained with EXPLAIN (FORMAT JSON), which plans them
+// without executing them, so tests must run against a real database whose
+// schema and statistics resemble production.
+package sqlzexplain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ido50/sqlz"
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrNoPlan is returned by Explain when the database returns no query plan
+var ErrNoPlan = errors.New("database returned no query plan")
+
+// Plan is a node of a PostgreSQL query plan, as returned by EXPLAIN
+// (FORMAT JSON). Only the commonly asserted properties are decoded.
+type Plan struct {
+	NodeType     string  `json:"Node Type"`
+	RelationName string  `json:"Relation Name"`
+	Alias        string  `json:"Alias"`
+	IndexName    string  `json:"Index Name"`
+	StartupCost  float64 `json:"Startup Cost"`
+	TotalCost    float64 `json:"Total Cost"`
+	Rows         float64 `json:"Plan Rows"`
+	Plans        []*Plan `json:"Plans"`
+}
+
+// Walk calls the provided function for the node and each of its
+// descendants, depth first
+func (plan *Plan) Walk(fn func(node *Plan)) {
+	fn(plan)
+
+	for _, child := range plan.Plans {
+		child.Walk(fn)
+	}
+}
+
+// String formats the plan as an indented tree, one node per line
+func (plan *Plan) String() string {
+	var b strings.Builder
+	plan.format(&b, 0)
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func (plan *Plan) format(b *strings.Builder, depth int) {
+	b.WriteString(strings.Repeat("  ", depth))
+	b.WriteString(plan.NodeType)
+
+	if plan.IndexName != "" {
+		fmt.Fprintf(b, " using %s", plan.IndexName)
+	}
+
+	if plan.RelationName != "" {
+		fmt.Fprintf(b, " on %s", plan.RelationName)
+	}
+
+	fmt.Fprintf(b, " (cost=%.2f..%.2f rows=%.0f)\n", plan.StartupCost, plan.TotalCost, plan.Rows)
+
+	for _, child := range plan.Plans {
+		child.format(b, depth+1)
+	}
+}
+
+// Explain returns the query plan of the provided statement, obtained by
+// running EXPLAIN (FORMAT JSON) on it with the provided DB or Tx. The
+// statement's SQL is generated with ToSQL, so defaults that are only
+// applied when statements are executed (e.g. scopes) are not reflected in
+// the plan.
+func Explain(ctx context.Context, q sqlx.QueryerContext, stmt sqlz.SQLStmt) (*Plan, error) {
+	asSQL, bindings := stmt.ToSQL(true)
+
+	var out []byte
+
+	err := q.QueryRowxContext(ctx, "EXPLAIN (FORMAT JSON) "+asSQL, bindings...).Scan(&out)
+	if err != nil {
+		return nil, fmt.Errorf("failed explaining statement: %w", err)
+	}
+
+	var explained []struct {
+		Plan *Plan `json:"Plan"`
+	}
+
+	err = json.Unmarshal(out, &explained)
+	if err != nil {
+		return nil, fmt.Errorf("failed parsing query plan: %w", err)
+	}
+
+	if len(explained) == 0 || explained[0].Plan == nil {
+		return nil, ErrNoPlan
+	}
+
+	return explained[0].Plan, nil
+}
+
+// Check is an assertion on a query plan, returning an error describing
+// why the plan does not satisfy it
+type Check func(plan *Plan) error
+
+// UsesIndex checks that the plan scans the provided index
+func UsesIndex(index string) Check {
+	return func(plan *Plan) error {
+		found := false
+		plan.Walk(func(node *Plan) {
+			if node.IndexName == index {
+				found = true
+			}
+		})
+
+		if !found {
+			return fmt.Errorf("plan does not use index %s", index)
+		}
+
+		return nil
+	}
+}
+
+// NoSeqScan checks that the plan does not sequentially scan the provided
+// table
+func NoSeqScan(table string) Check {
+	return func(plan *Plan) error {
+		found := false
+		plan.Walk(func(node *Plan) {
+			if node.NodeType == "Seq Scan" && node.RelationName == table {
+				found = true
+			}
+		})
+
+		if found {
+			return fmt.Errorf("plan has a sequential scan on %s", table)
+		}
+
+		return nil
+	}
+}
+
+// MaxCost checks that the estimated total cost of the plan does not exceed
+// the provided cost
+func MaxCost(cost float64) Check {
+	return func(plan *Plan) error {
+		if plan.TotalCost > cost {
+			return fmt.Errorf("plan cost %.2f exceeds %.2f", plan.TotalCost, cost)
+		}
+
+		return nil
+	}
+}
+
+// Assert explains the provided statement and runs the provided checks on
+// its plan, failing the test with the plan if any of them fails. The test
+// is stopped if the statement cannot be explained.
+func Assert(t testing.TB, q sqlx.QueryerContext, stmt sqlz.SQLStmt, checks ...Check) {
+	t.Helper()
+
+	plan, err := Explain(context.Background(), q, stmt)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+
+	for _, check := range checks {
+		if err := check(plan); err != nil {
+			t.Errorf("%s\n%s", err, plan)
+		}
+	}
+}
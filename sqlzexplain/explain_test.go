@@ -0,0 +1,94 @@
+package sqlzexplain
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ido50/sqlz"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+const ordersPlan = `[{"Plan": {
+	"Node Type": "Nested Loop", "Startup Cost": 0.57, "Total Cost": 16.62, "Plan Rows": 1,
+	"Plans": [
+		{"Node Type": "Index Scan", "Relation Name": "orders", "Alias": "o",
+		 "Index Name": "orders_customer_id_idx", "Startup Cost": 0.29, "Total Cost": 8.30, "Plan Rows": 1},
+		{"Node Type": "Seq Scan", "Relation Name": "customers", "Alias": "c",
+		 "Startup Cost": 0.00, "Total Cost": 8.31, "Plan Rows": 1}
+	]
+}}]`
+
+func TestExplain(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	dbz := sqlz.New(db, "postgres")
+	stmt := dbz.Select("o.*").
+		From("orders o").
+		InnerJoin("customers c", sqlz.Eq("c.id", sqlz.Indirect("o.customer_id"))).
+		Where(sqlz.Eq("o.customer_id", 42))
+
+	mock.ExpectQuery(`EXPLAIN \(FORMAT JSON\) SELECT o\.\* FROM orders o ` +
+		`INNER JOIN customers c ON c\.id = o\.customer_id WHERE o\.customer_id = \$1`).
+		WithArgs(42).
+		WillReturnRows(sqlmock.NewRows([]string{"QUERY PLAN"}).AddRow(ordersPlan))
+
+	plan, err := Explain(context.Background(), dbz, stmt)
+	if err != nil {
+		t.Fatalf("Explain failed: %s", err)
+	}
+
+	tests := []struct {
+		name  string
+		check Check
+		fails bool
+	}{
+		{"uses index", UsesIndex("orders_customer_id_idx"), false},
+		{"does not use index", UsesIndex("orders_pkey"), true},
+		{"no seq scan", NoSeqScan("orders"), false},
+		{"seq scan", NoSeqScan("customers"), true},
+		{"below max cost", MaxCost(20), false},
+		{"above max cost", MaxCost(10), true},
+	}
+
+	for _, test := range tests {
+		if err := test.check(plan); (err != nil) != test.fails {
+			t.Errorf("%s: unexpected result %v", test.name, err)
+		}
+	}
+
+	expected := "Nested Loop (cost=0.57..16.62 rows=1)\n" +
+		"  Index Scan using orders_customer_id_idx on orders (cost=0.29..8.30 rows=1)\n" +
+		"  Seq Scan on customers (cost=0.00..8.31 rows=1)"
+	if plan.String() != expected {
+		t.Errorf("Unexpected plan string:\n%s", plan)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %s", err)
+	}
+}
+
+func TestAssert(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	dbz := sqlz.New(db, "postgres")
+
+	mock.ExpectQuery(`EXPLAIN \(FORMAT JSON\) SELECT \* FROM orders WHERE customer_id = \$1`).
+		WithArgs(42).
+		WillReturnRows(sqlmock.NewRows([]string{"QUERY PLAN"}).AddRow(ordersPlan))
+
+	Assert(t, dbz, dbz.Select("*").From("orders").Where(sqlz.Eq("customer_id", 42)),
+		UsesIndex("orders_customer_id_idx"),
+		NoSeqScan("orders"),
+	)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %s", err)
+	}
+}
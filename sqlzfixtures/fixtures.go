@@ -0,0 +1,295 @@
+// Package sqlzfixtures loads test fixtures into database tables using the
+// sqlz insert builder.
+//
+// Fixtures are sets of rows keyed by table name, and can be added directly
+// from Go code, or loaded from JSON files (or any other format for which a
+// decoder was registered with RegisterDecoder, e.g. YAML):
+//
+//	{
+//	    "users": [
+//	        { "id": 1, "name": "Alice" }
+//	    ],
+//	    "orders": [
+//	        { "id": 1, "user_id": 1, "total": 9.99 }
+//	    ]
+//	}
+//
+// Tables are loaded in the order they were added, unless dependencies
+// between them were declared with DependsOn, in which case referenced
+// tables are always loaded before the tables that reference them (and
+// truncated after them).
+package sqlzfixtures
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ido50/sqlz"
+)
+
+var (
+	// ErrCyclicDependencies is returned when the dependencies declared
+	// between tables form a cycle
+	ErrCyclicDependencies = errors.New("cyclic dependencies between fixture tables")
+	// ErrUnknownFormat is returned when loading a fixture file whose
+	// extension has no registered decoder
+	ErrUnknownFormat = errors.New("unknown fixture file format")
+)
+
+// Decoder is a function that decodes the contents of a fixture file into
+// the provided value, with the same semantics as json.Unmarshal
+type Decoder func(data []byte, v interface{}) error
+
+var decoders = map[string]Decoder{
+	".json": json.Unmarshal,
+}
+
+// RegisterDecoder registers a decoder for fixture files with the provided
+// extension (e.g. ".yaml"). The package only supports JSON out of the box;
+// YAML support can be added by registering a YAML library's Unmarshal
+// function:
+//
+//	sqlzfixtures.RegisterDecoder(".yaml", yaml.Unmarshal)
+//	sqlzfixtures.RegisterDecoder(".yml", yaml.Unmarshal)
+func RegisterDecoder(ext string, decoder Decoder) {
+	decoders[strings.ToLower(ext)] = decoder
+}
+
+// Fixture is a set of rows to insert into a table
+type Fixture struct {
+	Table string
+	Rows  []map[string]interface{}
+}
+
+// Loader loads fixtures into a database
+type Loader struct {
+	db       *sqlz.DB
+	fixtures []*Fixture
+	deps     map[string][]string
+	truncate bool
+}
+
+// New creates a new, empty Loader for the provided database
+func New(db *sqlz.DB) *Loader {
+	return &Loader{
+		db:   db,
+		deps: make(map[string][]string),
+	}
+}
+
+// Add adds rows to the fixture of the provided table
+func (loader *Loader) Add(table string, rows ...map[string]interface{}) *Loader {
+	for _, fixture := range loader.fixtures {
+		if fixture.Table == table {
+			fixture.Rows = append(fixture.Rows, rows...)
+			return loader
+		}
+	}
+
+	loader.fixtures = append(loader.fixtures, &Fixture{Table: table, Rows: rows})
+
+	return loader
+}
+
+// DependsOn declares that the provided table references the parent tables
+// (e.g. via foreign keys), so the parents must be loaded before it
+func (loader *Loader) DependsOn(table string, parents ...string) *Loader {
+	loader.deps[table] = append(loader.deps[table], parents...)
+	return loader
+}
+
+// Truncate makes the loader delete all existing rows from the fixture
+// tables before loading the fixtures
+func (loader *Loader) Truncate() *Loader {
+	loader.truncate = true
+	return loader
+}
+
+// AddJSON adds fixtures from a JSON object that maps table names to arrays
+// of rows. Tables are added in the order they appear in the object.
+func (loader *Loader) AddJSON(r io.Reader) error {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+
+	if _, err := dec.Token(); err != nil {
+		return fmt.Errorf("failed parsing fixtures: %w", err)
+	}
+
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return fmt.Errorf("failed parsing fixtures: %w", err)
+		}
+
+		var rows []map[string]interface{}
+
+		err = dec.Decode(&rows)
+		if err != nil {
+			return fmt.Errorf("failed parsing fixtures of %v: %w", key, err)
+		}
+
+		for _, row := range rows {
+			for col, val := range row {
+				row[col] = jsonValue(val)
+			}
+		}
+
+		loader.Add(fmt.Sprint(key), rows...)
+	}
+
+	return nil
+}
+
+// AddFile adds fixtures from a file, using the decoder registered for the
+// file's extension. JSON files retain the order of their tables; for other
+// formats, tables are added in alphabetical order.
+func (loader *Loader) AddFile(path string) error {
+	ext := strings.ToLower(filepath.Ext(path))
+
+	decoder, ok := decoders[ext]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownFormat, path)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed reading %s: %w", path, err)
+	}
+
+	if ext == ".json" {
+		return loader.AddJSON(strings.NewReader(string(data)))
+	}
+
+	var fixtures map[string][]map[string]interface{}
+
+	err = decoder(data, &fixtures)
+	if err != nil {
+		return fmt.Errorf("failed parsing %s: %w", path, err)
+	}
+
+	tables := make([]string, 0, len(fixtures))
+	for table := range fixtures {
+		tables = append(tables, table)
+	}
+
+	sort.Strings(tables)
+
+	for _, table := range tables {
+		loader.Add(table, fixtures[table]...)
+	}
+
+	return nil
+}
+
+// Load loads the fixtures into the database, inside a transaction
+func (loader *Loader) Load() error {
+	return loader.LoadContext(context.Background())
+}
+
+// LoadContext loads the fixtures into the database, inside a transaction
+func (loader *Loader) LoadContext(ctx context.Context) error {
+	ordered, err := loader.order()
+	if err != nil {
+		return err
+	}
+
+	return loader.db.TransactionalContext(ctx, nil, func(tx *sqlz.Tx) error {
+		if loader.truncate {
+			for i := len(ordered) - 1; i >= 0; i-- {
+				_, err := tx.DeleteFrom(ordered[i].Table).ExecContext(ctx)
+				if err != nil {
+					return fmt.Errorf("failed truncating %s: %w", ordered[i].Table, err)
+				}
+			}
+		}
+
+		for _, fixture := range ordered {
+			for _, row := range fixture.Rows {
+				_, err := tx.InsertInto(fixture.Table).ValueMap(row).ExecContext(ctx)
+				if err != nil {
+					return fmt.Errorf("failed loading fixture into %s: %w", fixture.Table, err)
+				}
+			}
+		}
+
+		return nil
+	})
+}
+
+// order returns the fixtures sorted so that every table comes after the
+// tables it depends on, otherwise retaining the order they were added in
+func (loader *Loader) order() ([]*Fixture, error) {
+	byTable := make(map[string]*Fixture, len(loader.fixtures))
+	for _, fixture := range loader.fixtures {
+		byTable[fixture.Table] = fixture
+	}
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+
+	state := make(map[string]int, len(loader.fixtures))
+	ordered := make([]*Fixture, 0, len(loader.fixtures))
+
+	var visit func(table string) error
+
+	visit = func(table string) error {
+		switch state[table] {
+		case visiting:
+			return fmt.Errorf("%w: %s", ErrCyclicDependencies, table)
+		case visited:
+			return nil
+		}
+
+		state[table] = visiting
+
+		for _, parent := range loader.deps[table] {
+			if err := visit(parent); err != nil {
+				return err
+			}
+		}
+
+		state[table] = visited
+
+		if fixture, ok := byTable[table]; ok {
+			ordered = append(ordered, fixture)
+		}
+
+		return nil
+	}
+
+	for _, fixture := range loader.fixtures {
+		if err := visit(fixture.Table); err != nil {
+			return nil, err
+		}
+	}
+
+	return ordered, nil
+}
+
+// jsonValue converts numbers decoded from JSON fixtures into int64 or
+// float64 values, as database drivers do not accept json.Number values
+func jsonValue(val interface{}) interface{} {
+	num, ok := val.(json.Number)
+	if !ok {
+		return val
+	}
+
+	if i, err := num.Int64(); err == nil {
+		return i
+	}
+
+	if f, err := num.Float64(); err == nil {
+		return f
+	}
+
+	return num.String()
+}
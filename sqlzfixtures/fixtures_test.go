@@ -0,0 +1,69 @@
+package sqlzfixtures
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ido50/sqlz"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestLoad(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	loader := New(sqlz.New(db, "sqlmock")).
+		Truncate().
+		DependsOn("orders", "users")
+
+	err = loader.AddJSON(strings.NewReader(`{
+		"orders": [{ "id": 1, "user_id": 1, "total": 9.99 }],
+		"users": [{ "id": 1, "name": "Alice" }, { "id": 2, "name": "Bob" }]
+	}`))
+	if err != nil {
+		t.Fatalf("Failed parsing fixtures: %s", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM orders`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`DELETE FROM users`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO users \(id, name\)`).
+		WithArgs(int64(1), "Alice").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO users \(id, name\)`).
+		WithArgs(int64(2), "Bob").
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectExec(`INSERT INTO orders \(id, total, user_id\)`).
+		WithArgs(int64(1), 9.99, int64(1)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err = loader.Load()
+	if err != nil {
+		t.Fatalf("Failed loading fixtures: %s", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %s", err)
+	}
+}
+
+func TestCyclicDependencies(t *testing.T) {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	err = New(sqlz.New(db, "sqlmock")).
+		Add("a", map[string]interface{}{"id": 1}).
+		Add("b", map[string]interface{}{"id": 1}).
+		DependsOn("a", "b").
+		DependsOn("b", "a").
+		Load()
+	if !errors.Is(err, ErrCyclicDependencies) {
+		t.Errorf("Expected ErrCyclicDependencies, got %v", err)
+	}
+}
@@ -0,0 +1,222 @@
+// Package sqlzgen generates Go code describing a database schema, so that
+// statements can reference tables and columns through typed identifiers
+// rather than bare strings, and typos are caught by the compiler:
+//
+//	schema, err := dbz.LoadSchema(ctx)
+//	if err != nil {
+//	    return err
+//	}
+//
+//	var buf bytes.Buffer
+//	err = sqlzgen.Generate(&buf, schema, sqlzgen.Options{Package: "models"})
+//
+// For every table, a variable is generated holding the names of the table
+// and its columns, which can be used with the sqlz builders:
+//
+//	dbz.Select(models.Users.ID, models.Users.Email).
+//	    From(models.Users.Table).
+//	    Where(sqlz.Eq(models.Users.Email, email))
+//
+// With Options.Conditions, columns are generated with a Column type whose
+// methods create conditions, e.g. models.Users.Email.Eq(email). Such
+// columns are converted with String where the builders expect strings.
+package sqlzgen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"io"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/ido50/sqlz"
+)
+
+// ErrNoPackage is returned by Generate when no package name is provided
+var ErrNoPackage = errors.New("no package name provided")
+
+// Options are options for code generation
+type Options struct {
+	// Package is the name of the package of the generated code
+	Package string
+	// Conditions generates columns with a Column type, whose methods
+	// create sqlz conditions and orderings on the column
+	Conditions bool
+}
+
+// initialisms are words written in upper case in Go identifiers
+var initialisms = map[string]bool{
+	"API": true, "CSS": true, "DB": true, "DNS": true, "HTML": true,
+	"HTTP": true, "ID": true, "IP": true, "JSON": true, "SQL": true,
+	"TLS": true, "UI": true, "URI": true, "URL": true, "UUID": true,
+	"XML": true,
+}
+
+type genTable struct {
+	Ident   string
+	Name    string
+	Columns []genColumn
+}
+
+type genColumn struct {
+	Ident string
+	Name  string
+}
+
+// Generate writes Go code describing the tables and columns of the
+// provided schema to w. Tables are generated in alphabetical order, and
+// columns in the order they appear in the schema. Tables keyed both by
+// name and by qualified name (as loaded by sqlz.DB.LoadSchema) are only
+// generated once, by name.
+func Generate(w io.Writer, schema sqlz.Schema, opts Options) error {
+	if opts.Package == "" {
+		return ErrNoPackage
+	}
+
+	names := make([]string, 0, len(schema))
+	for name := range schema {
+		if i := strings.LastIndexByte(name, '.'); i >= 0 {
+			if _, ok := schema[name[i+1:]]; ok {
+				continue
+			}
+		}
+
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	tables := make([]genTable, len(names))
+	for i, name := range names {
+		table := genTable{Ident: Ident(name), Name: name}
+
+		for _, col := range schema[name] {
+			ident := Ident(col)
+			if ident == "Table" {
+				ident += "_"
+			}
+
+			table.Columns = append(table.Columns, genColumn{ident, col})
+		}
+
+		tables[i] = table
+	}
+
+	var buf bytes.Buffer
+
+	err := codeTemplate.Execute(&buf, struct {
+		Options
+		Tables []genTable
+	}{opts, tables})
+	if err != nil {
+		return err
+	}
+
+	code, err := format.Source(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("failed formatting generated code: %w", err)
+	}
+
+	_, err = w.Write(code)
+
+	return err
+}
+
+// Ident converts the provided table or column name (which may be
+// qualified) into an exported Go identifier, e.g. "user_id" into "UserID"
+// and "audit.events" into "AuditEvents"
+func Ident(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var b strings.Builder
+
+	for _, word := range words {
+		if upper := strings.ToUpper(word); initialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+
+	ident := b.String()
+	if ident == "" || !unicode.IsLetter([]rune(ident)[0]) {
+		ident = "X" + ident
+	}
+
+	return ident
+}
+
+var codeTemplate = template.Must(template.New("code").Parse(`// Code generated by sqlzgen. DO NOT EDIT.
+
+package {{ .Package }}
+{{ if .Conditions }}
+import "github.com/ido50/sqlz"
+
+// Column is the name of a column, whose methods create conditions on it
+type Column string
+
+// String returns the name of the column
+func (col Column) String() string { return string(col) }
+
+// Eq creates an "=" condition on the column
+func (col Column) Eq(value interface{}) sqlz.SimpleCondition { return sqlz.Eq(string(col), value) }
+
+// Ne creates a "<>" condition on the column
+func (col Column) Ne(value interface{}) sqlz.SimpleCondition { return sqlz.Ne(string(col), value) }
+
+// Gt creates a ">" condition on the column
+func (col Column) Gt(value interface{}) sqlz.SimpleCondition { return sqlz.Gt(string(col), value) }
+
+// Gte creates a ">=" condition on the column
+func (col Column) Gte(value interface{}) sqlz.SimpleCondition { return sqlz.Gte(string(col), value) }
+
+// Lt creates a "<" condition on the column
+func (col Column) Lt(value interface{}) sqlz.SimpleCondition { return sqlz.Lt(string(col), value) }
+
+// Lte creates a "<=" condition on the column
+func (col Column) Lte(value interface{}) sqlz.SimpleCondition { return sqlz.Lte(string(col), value) }
+
+// Like creates a LIKE condition on the column
+func (col Column) Like(value interface{}) sqlz.SimpleCondition { return sqlz.Like(string(col), value) }
+
+// In creates an IN condition on the column
+func (col Column) In(values ...interface{}) sqlz.InCondition { return sqlz.In(string(col), values...) }
+
+// NotIn creates a NOT IN condition on the column
+func (col Column) NotIn(values ...interface{}) sqlz.InCondition { return sqlz.NotIn(string(col), values...) }
+
+// IsNull creates an IS NULL condition on the column
+func (col Column) IsNull() sqlz.SimpleCondition { return sqlz.IsNull(string(col)) }
+
+// IsNotNull creates an IS NOT NULL condition on the column
+func (col Column) IsNotNull() sqlz.SimpleCondition { return sqlz.IsNotNull(string(col)) }
+
+// Asc orders results by the column in ascending order
+func (col Column) Asc() sqlz.OrderColumn { return sqlz.Asc(string(col)) }
+
+// Desc orders results by the column in descending order
+func (col Column) Desc() sqlz.OrderColumn { return sqlz.Desc(string(col)) }
+{{ end }}
+{{- range .Tables }}
+// {{ .Ident }} holds the names of the {{ .Name }} table and its columns
+var {{ .Ident }} = struct {
+	Table string
+	{{- range .Columns }}
+	{{ .Ident }} {{ if $.Conditions }}Column{{ else }}string{{ end }}
+	{{- end }}
+}{
+	Table: {{ printf "%q" .Name }},
+	{{- range .Columns }}
+	{{ .Ident }}: {{ printf "%q" .Name }},
+	{{- end }}
+}
+{{ end }}`))
@@ -0,0 +1,105 @@
+package sqlzgen
+
+import (
+	"bytes"
+	"errors"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/ido50/sqlz"
+)
+
+func TestGenerate(t *testing.T) {
+	schema := sqlz.Schema{
+		"users":        {"id", "email", "table"},
+		"public.users": {"id", "email", "table"},
+		"audit.events": {"id", "user_id"},
+	}
+
+	var buf bytes.Buffer
+
+	if err := Generate(&buf, schema, Options{Package: "models"}); err != nil {
+		t.Fatalf("Generate failed: %s", err)
+	}
+
+	expected := `// Code generated by sqlzgen. DO NOT EDIT.
+
+package models
+
+// AuditEvents holds the names of the audit.events table and its columns
+var AuditEvents = struct {
+	Table  string
+	ID     string
+	UserID string
+}{
+	Table:  "audit.events",
+	ID:     "id",
+	UserID: "user_id",
+}
+
+// Users holds the names of the users table and its columns
+var Users = struct {
+	Table  string
+	ID     string
+	Email  string
+	Table_ string
+}{
+	Table:  "users",
+	ID:     "id",
+	Email:  "email",
+	Table_: "table",
+}
+`
+
+	if buf.String() != expected {
+		t.Errorf("Unexpected generated code:\n%s", buf.String())
+	}
+}
+
+func TestGenerateConditions(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := Generate(&buf, sqlz.Schema{"users": {"id"}}, Options{Package: "models", Conditions: true})
+	if err != nil {
+		t.Fatalf("Generate failed: %s", err)
+	}
+
+	code := buf.String()
+
+	if _, err := parser.ParseFile(token.NewFileSet(), "models.go", code, 0); err != nil {
+		t.Fatalf("Generated code does not parse: %s", err)
+	}
+
+	for _, snippet := range []string{
+		`import "github.com/ido50/sqlz"`,
+		"func (col Column) Eq(value interface{}) sqlz.SimpleCondition",
+		"ID    Column",
+	} {
+		if !strings.Contains(code, snippet) {
+			t.Errorf("Expected generated code to contain %q", snippet)
+		}
+	}
+
+	if err := Generate(&buf, nil, Options{}); !errors.Is(err, ErrNoPackage) {
+		t.Errorf("Expected ErrNoPackage, got %v", err)
+	}
+}
+
+func TestIdent(t *testing.T) {
+	tests := map[string]string{
+		"user_id":      "UserID",
+		"api_key":      "APIKey",
+		"createdAt":    "CreatedAt",
+		"audit.events": "AuditEvents",
+		"2fa_enabled":  "X2faEnabled",
+		"profile-url":  "ProfileURL",
+	}
+
+	for name, expected := range tests {
+		if ident := Ident(name); ident != expected {
+			t.Errorf("Expected %s to be converted to %s, got %s", name, expected, ident)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+// Package sqlzmigrate runs database migrations written as Go functions
+// that receive an sqlz transaction:
+//
+//	migrator := sqlzmigrate.New(db).
+//	    Add(1, "create users", func(tx *sqlz.Tx) error {
+//	        _, err := tx.CreateTable("users").
+//	            Column(
+//	                sqlz.Column("id", "bigint").AsIdentity(sqlz.IdentityAlways).PrimaryKey(),
+//	                sqlz.Column("email", "text").NotNull().Unique(),
+//	            ).
+//	            Exec()
+//	        return err
+//	    }).
+//	    Add(2, "add users.name", func(tx *sqlz.Tx) error {
+//	        _, err := tx.AlterTable("users").AddColumn(sqlz.Column("name", "text")).Exec()
+//	        return err
+//	    })
+//
+//	applied, err := migrator.Run()
+//
+// The versions of applied migrations are tracked in a table (by default
+// "schema_migrations"), which is created if it does not exist. Every
+// pending migration runs in its own transaction, in ascending version
+// order, and is recorded in the same transaction, so a failed migration
+// leaves no trace. On PostgreSQL, the transactions also take an advisory
+// lock, so that migrators running concurrently (e.g. when multiple
+// instances of an application start at the same time) never apply the same
+// migration twice.
+package sqlzmigrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+
+	"github.com/ido50/sqlz"
+)
+
+// DefaultTable is the default name of the table tracking applied migrations
+const DefaultTable = "schema_migrations"
+
+// ErrDuplicateVersion is returned when running migrations if more than one
+// migration was added with the same version
+var ErrDuplicateVersion = errors.New("duplicate migration version")
+
+// Migration is a change to the database schema (or data)
+type Migration struct {
+	Version int64
+	Name    string
+	Up      func(tx *sqlz.Tx) error
+}
+
+// Migrator applies migrations to a database
+type Migrator struct {
+	db         *sqlz.DB
+	table      string
+	migrations []Migration
+}
+
+// New creates a new Migrator for the provided database, with no migrations
+func New(db *sqlz.DB) *Migrator {
+	return &Migrator{
+		db:    db,
+		table: DefaultTable,
+	}
+}
+
+// Table sets the name of the table tracking applied migrations
+func (m *Migrator) Table(name string) *Migrator {
+	m.table = name
+	return m
+}
+
+// Add adds a migration with the provided version and name. Migrations may
+// be added in any order, as they are always applied in ascending version
+// order.
+func (m *Migrator) Add(version int64, name string, up func(tx *sqlz.Tx) error) *Migrator {
+	m.migrations = append(m.migrations, Migration{version, name, up})
+	return m
+}
+
+// Applied returns the versions of the migrations applied to the database,
+// in ascending order
+func (m *Migrator) Applied() ([]int64, error) {
+	return m.AppliedContext(context.Background())
+}
+
+// AppliedContext returns the versions of the migrations applied to the
+// database, in ascending order
+func (m *Migrator) AppliedContext(ctx context.Context) (versions []int64, err error) {
+	if err := m.createTable(ctx); err != nil {
+		return nil, err
+	}
+
+	err = m.db.Select("version").
+		From(m.table).
+		OrderBy(sqlz.Asc("version")).
+		Unbounded().
+		GetAllContext(ctx, &versions)
+
+	return versions, err
+}
+
+// Run applies all pending migrations, returning the migrations that were
+// applied. If a migration fails, the migrations applied before it are
+// returned along with the error.
+func (m *Migrator) Run() ([]Migration, error) {
+	return m.RunContext(context.Background())
+}
+
+// RunContext applies all pending migrations, returning the migrations that
+// were applied
+func (m *Migrator) RunContext(ctx context.Context) (applied []Migration, err error) {
+	migrations, err := m.sorted()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.createTable(ctx); err != nil {
+		return nil, err
+	}
+
+	for _, migration := range migrations {
+		var ran bool
+
+		err = m.db.TransactionalContext(ctx, nil, func(tx *sqlz.Tx) (err error) {
+			ran, err = m.apply(ctx, tx, migration)
+			return err
+		})
+		if err != nil {
+			return applied, fmt.Errorf("migration %d (%s) failed: %w", migration.Version, migration.Name, err)
+		}
+
+		if ran {
+			applied = append(applied, migration)
+		}
+	}
+
+	return applied, nil
+}
+
+// sorted returns the migrations in ascending version order
+func (m *Migrator) sorted() ([]Migration, error) {
+	migrations := append([]Migration(nil), m.migrations...)
+	sort.SliceStable(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version == migrations[i-1].Version {
+			return nil, fmt.Errorf("%w: %d", ErrDuplicateVersion, migrations[i].Version)
+		}
+	}
+
+	return migrations, nil
+}
+
+// createTable creates the table tracking applied migrations, if it does
+// not exist
+func (m *Migrator) createTable(ctx context.Context) error {
+	_, err := m.db.CreateTable(m.table).
+		IfNotExists().
+		Column(
+			sqlz.Column("version", "bigint").PrimaryKey(),
+			sqlz.Column("name", "varchar(255)").NotNull(),
+			sqlz.Column("applied_at", "timestamp").Default(sqlz.Indirect("CURRENT_TIMESTAMP")).NotNull(),
+		).
+		ExecContext(ctx)
+
+	return err
+}
+
+// apply applies a migration inside the provided transaction, unless it was
+// already applied, returning whether it was
+func (m *Migrator) apply(ctx context.Context, tx *sqlz.Tx, migration Migration) (bool, error) {
+	if tx.Dialect() == sqlz.DialectPostgres {
+		_, err := tx.ExecDirectContext(ctx, "SELECT pg_advisory_xact_lock(?)", m.lockKey())
+		if err != nil {
+			return false, err
+		}
+	}
+
+	count, err := tx.Select("version").
+		From(m.table).
+		Where(sqlz.Eq("version", migration.Version)).
+		GetCountContext(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	if count > 0 {
+		// applied by a concurrent migrator, or in a previous run
+		return false, nil
+	}
+
+	if err := migration.Up(tx); err != nil {
+		return false, err
+	}
+
+	_, err = tx.InsertInto(m.table).
+		Columns("version", "name").
+		Values(migration.Version, migration.Name).
+		ExecContext(ctx)
+
+	return err == nil, err
+}
+
+// lockKey returns the key of the advisory lock taken when applying
+// migrations, which is derived from the name of the migrations table so
+// that unrelated migrators do not block each other
+func (m *Migrator) lockKey() int64 {
+	h := fnv.New64a()
+	h.Write([]byte("sqlzmigrate:" + m.table)) //nolint: errcheck
+
+	return int64(h.Sum64())
+}
@@ -0,0 +1,119 @@
+package sqlzmigrate
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ido50/sqlz"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestRun(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	var ran []int64
+
+	migrator := New(sqlz.New(db, "postgres")).
+		Add(2, "add users.name", func(tx *sqlz.Tx) error {
+			ran = append(ran, 2)
+			_, err := tx.AlterTable("users").AddColumn(sqlz.Column("name", "text")).Exec()
+			return err
+		}).
+		Add(1, "create users", func(tx *sqlz.Tx) error {
+			ran = append(ran, 1)
+			return nil
+		})
+
+	mock.ExpectExec(`CREATE TABLE IF NOT EXISTS schema_migrations \(version bigint PRIMARY KEY, ` +
+		`name varchar\(255\) NOT NULL, applied_at timestamp DEFAULT CURRENT_TIMESTAMP NOT NULL\)`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	// migration 1 was already applied
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock\(\$1\)`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM schema_migrations WHERE version = \$1`).
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectCommit()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock\(\$1\)`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM schema_migrations WHERE version = \$1`).
+		WithArgs(int64(2)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectExec(`ALTER TABLE users ADD COLUMN name text`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO schema_migrations \(version, name\) VALUES \(\$1, \$2\)`).
+		WithArgs(int64(2), "add users.name").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	applied, err := migrator.Run()
+	if err != nil {
+		t.Fatalf("Run failed: %s", err)
+	}
+
+	if len(applied) != 1 || applied[0].Version != 2 {
+		t.Errorf("Expected only migration 2 to be applied, got %v", applied)
+	}
+
+	if len(ran) != 1 || ran[0] != 2 {
+		t.Errorf("Expected only migration 2 to run, got %v", ran)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %s", err)
+	}
+}
+
+func TestRunFailure(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	errFailed := errors.New("failed")
+
+	migrator := New(sqlz.New(db, "sqlmock")).
+		Table("migrations").
+		Add(1, "broken", func(tx *sqlz.Tx) error {
+			return errFailed
+		})
+
+	mock.ExpectExec(`CREATE TABLE IF NOT EXISTS migrations`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM migrations WHERE version = \?`).
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectRollback()
+
+	applied, err := migrator.Run()
+	if !errors.Is(err, errFailed) {
+		t.Errorf("Expected migration error, got %v", err)
+	}
+
+	if len(applied) != 0 {
+		t.Errorf("Expected no migrations to be applied, got %v", applied)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %s", err)
+	}
+}
+
+func TestDuplicateVersion(t *testing.T) {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	_, err = New(sqlz.New(db, "sqlmock")).
+		Add(1, "a", func(tx *sqlz.Tx) error { return nil }).
+		Add(1, "b", func(tx *sqlz.Tx) error { return nil }).
+		Run()
+	if !errors.Is(err, ErrDuplicateVersion) {
+		t.Errorf("Expected ErrDuplicateVersion, got %v", err)
+	}
+}
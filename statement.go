@@ -1,5 +1,13 @@
 package sqlz
 
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
 // Statement is a base struct for all statement types in the library.
 type Statement struct {
 	// ErrHandlers is a list of error handler functions
@@ -15,3 +23,139 @@ func (stmt *Statement) HandleError(err error) {
 		}
 	}
 }
+
+// contextWithTimeout derives a context with the provided timeout from the
+// provided context. If the timeout is not positive, the context is returned
+// as is.
+func contextWithTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeout(ctx, timeout)
+}
+
+// Rows is an sqlx.Rows object returned by the Rows methods of
+// statements. It must be closed with Close(), which also releases the
+// context created for the statement's timeout (see WithTimeout).
+type Rows struct {
+	*sqlx.Rows
+	cancel context.CancelFunc
+}
+
+// Close closes the rows and releases the statement's timeout
+func (rows *Rows) Close() error {
+	defer rows.cancel()
+	return rows.Rows.Close()
+}
+
+// queryRows executes the provided query with the provided timeout. The
+// context derived for the timeout remains active while the caller iterates
+// over the rows, until they are closed.
+func queryRows(
+	ctx context.Context,
+	timeout time.Duration,
+	queryer sqlx.QueryerContext,
+	query string,
+	args []interface{},
+) (*Rows, error) {
+	ctx, cancel := contextWithTimeout(ctx, timeout)
+
+	rows, err := queryer.QueryxContext(ctx, query, args...)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	return &Rows{Rows: rows, cancel: cancel}, nil
+}
+
+// prepare returns a copy of the provided statement in the form it is
+// executed in: restricted by its scope, then with its audit columns set,
+// then with its values encoded by its codecs. Every path executing
+// statements must prepare them first, so that none of these are bypassed.
+// Statements of other types are returned as is.
+func prepare(ctx context.Context, stmt SQLStmt) (SQLStmt, error) {
+	switch s := stmt.(type) {
+	case *SelectStmt:
+		return s.scoped(ctx), nil
+	case *InsertStmt:
+		return s.prepared(ctx)
+	case *UpdateStmt:
+		return s.prepared(ctx)
+	case *DeleteStmt:
+		return s.scoped(ctx), nil
+	case *WithStmt:
+		return s.pipeline(ctx)
+	default:
+		return stmt, nil
+	}
+}
+
+// prepared returns a copy of the statement in the form it is executed in
+// (see prepare)
+func (stmt *InsertStmt) prepared(ctx context.Context) (*InsertStmt, error) {
+	return stmt.scoped(ctx).audited(ctx).encoded()
+}
+
+// prepared returns a copy of the statement in the form it is executed in
+// (see prepare)
+func (stmt *UpdateStmt) prepared(ctx context.Context) (*UpdateStmt, error) {
+	return stmt.scoped(ctx).audited(ctx).encoded()
+}
+
+// execOn executes the provided statement with the provided execer. SQLz
+// statements are executed through their own ExecContext method, so that
+// they are guarded, prepared, timed out and reported to write listeners
+// (and their errors handled) just like when they are executed directly.
+// Other statements are checked against the provided guards, prepared and
+// executed, with errors handled by the provided handler.
+func execOn(ctx context.Context, execer Ext, guards []Guard, handler *Statement, stmt SQLStmt) (sql.Result, error) {
+	switch s := stmt.(type) {
+	case *InsertStmt:
+		onExecer := *s
+		onExecer.execer = execer
+
+		return onExecer.ExecContext(ctx)
+	case *UpdateStmt:
+		onExecer := *s
+		onExecer.execer = execer
+
+		return onExecer.ExecContext(ctx)
+	case *DeleteStmt:
+		onExecer := *s
+		onExecer.execer = execer
+
+		return onExecer.ExecContext(ctx)
+	case *WithStmt:
+		onExecer := *s
+		onExecer.execer = execer
+
+		return onExecer.ExecContext(ctx)
+	case *RawStmt:
+		onExecer := *s
+		onExecer.execer = execer
+
+		return onExecer.ExecContext(ctx)
+	}
+
+	if err := checkStmt(ctx, guards, stmt); err != nil {
+		handler.HandleError(err)
+		return nil, err
+	}
+
+	prepared, err := prepare(ctx, stmt)
+	if err != nil {
+		handler.HandleError(err)
+		return nil, err
+	}
+
+	asSQL, bindings := prepared.ToSQL(false)
+
+	res, err := execer.ExecContext(ctx, rebindFor(execer, asSQL), bindings...)
+	if err != nil {
+		handler.HandleError(err)
+	}
+
+	return res, err
+}
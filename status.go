@@ -0,0 +1,157 @@
+package sqlz
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrUnsupportedDialect is returned by helpers that have no implementation
+// for the dialect of the database in use
+var ErrUnsupportedDialect = errors.New("not supported by the database dialect")
+
+// Version is a parsed database server version
+type Version struct {
+	Major int
+	Minor int
+	Patch int
+	// Raw is the version string as reported by the server
+	Raw string
+}
+
+// String returns the version as reported by the server
+func (v Version) String() string {
+	return v.Raw
+}
+
+// AtLeast returns true if the version is equal to or newer than the
+// provided major and minor version, e.g. AtLeast(15, 0) to check for
+// features added in PostgreSQL 15
+func (v Version) AtLeast(major, minor int) bool {
+	return v.Major > major || (v.Major == major && v.Minor >= minor)
+}
+
+// ParseVersion parses a version string as reported by a database server,
+// e.g. "14.5 (Debian 14.5-1.pgdg110+1)" or "8.0.32-0ubuntu0.22.04.2".
+// Components missing from the string are zero.
+func ParseVersion(raw string) (Version, error) {
+	version := Version{Raw: raw}
+
+	numeric := strings.TrimSpace(raw)
+	if end := strings.IndexFunc(numeric, func(r rune) bool {
+		return r != '.' && (r < '0' || r > '9')
+	}); end >= 0 {
+		numeric = numeric[:end]
+	}
+
+	parts := strings.Split(numeric, ".")
+	dest := []*int{&version.Major, &version.Minor, &version.Patch}
+
+	for i := 0; i < len(parts) && i < len(dest); i++ {
+		n, err := strconv.Atoi(parts[i])
+		if err != nil {
+			return version, fmt.Errorf("invalid server version %q", raw)
+		}
+
+		*dest[i] = n
+	}
+
+	return version, nil
+}
+
+// ServerVersion returns the version of the database server, using the
+// appropriate query for its dialect
+func (db *DB) ServerVersion(ctx context.Context) (Version, error) {
+	return serverVersion(ctx, db.ext(), &Statement{db.ErrHandlers})
+}
+
+// ServerVersion returns the version of the database server (see
+// DB.ServerVersion)
+func (tx *Tx) ServerVersion(ctx context.Context) (Version, error) {
+	return serverVersion(ctx, tx.ext(), &Statement{tx.ErrHandlers})
+}
+
+func serverVersion(ctx context.Context, ext Ext, stmt *Statement) (version Version, err error) {
+	var query string
+
+	switch dialectOf(ext) {
+	case DialectPostgres:
+		query = "SHOW server_version"
+	case DialectSQLite:
+		query = "SELECT sqlite_version()"
+	case DialectMSSQL:
+		query = "SELECT CAST(SERVERPROPERTY('ProductVersion') AS VARCHAR(128))"
+	case DialectOracle:
+		query = "SELECT version FROM product_component_version WHERE product LIKE 'Oracle%' AND ROWNUM = 1"
+	default:
+		query = "SELECT VERSION()"
+	}
+
+	var raw string
+
+	err = ext.QueryRowxContext(ctx, query).Scan(&raw)
+	if err == nil {
+		version, err = ParseVersion(raw)
+	}
+
+	stmt.HandleError(err)
+
+	return version, err
+}
+
+// isolationLevels maps the names of isolation levels, as reported by
+// databases, to their sql.IsolationLevel values
+var isolationLevels = map[string]sql.IsolationLevel{
+	"READ UNCOMMITTED": sql.LevelReadUncommitted,
+	"READ COMMITTED":   sql.LevelReadCommitted,
+	"REPEATABLE READ":  sql.LevelRepeatableRead,
+	"SNAPSHOT":         sql.LevelSnapshot,
+	"SERIALIZABLE":     sql.LevelSerializable,
+}
+
+// CurrentIsolationLevel returns the isolation level of the transaction,
+// using the appropriate query for the database's dialect. It fails with
+// ErrUnsupportedDialect for Oracle, which has no way to query it, and for
+// unknown database drivers.
+func (tx *Tx) CurrentIsolationLevel(ctx context.Context) (level sql.IsolationLevel, err error) {
+	var query string
+
+	switch dialectOf(tx.ext()) {
+	case DialectPostgres:
+		query = "SHOW transaction_isolation"
+	case DialectMySQL:
+		query = "SELECT @@transaction_isolation"
+	case DialectSQLite:
+		query = "SELECT CASE WHEN read_uncommitted THEN 'READ UNCOMMITTED' ELSE 'SERIALIZABLE' END FROM pragma_read_uncommitted"
+	case DialectMSSQL:
+		query = "SELECT CASE transaction_isolation_level " +
+			"WHEN 1 THEN 'READ UNCOMMITTED' WHEN 2 THEN 'READ COMMITTED' " +
+			"WHEN 3 THEN 'REPEATABLE READ' WHEN 4 THEN 'SERIALIZABLE' " +
+			"WHEN 5 THEN 'SNAPSHOT' END FROM sys.dm_exec_sessions WHERE session_id = @@SPID"
+	default:
+		err = fmt.Errorf("CurrentIsolationLevel: %w", ErrUnsupportedDialect)
+		(&Statement{tx.ErrHandlers}).HandleError(err)
+
+		return level, err
+	}
+
+	var name string
+
+	err = tx.ext().QueryRowxContext(ctx, query).Scan(&name)
+	if err == nil {
+		var ok bool
+
+		// MySQL separates words with dashes, e.g. REPEATABLE-READ
+		level, ok = isolationLevels[strings.ToUpper(strings.ReplaceAll(name, "-", " "))]
+		if !ok {
+			err = fmt.Errorf("unknown isolation level %q", name)
+		}
+	}
+
+	(&Statement{tx.ErrHandlers}).HandleError(err)
+
+	return level, err
+}
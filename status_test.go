@@ -0,0 +1,123 @@
+package sqlz
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		raw      string
+		expected Version
+	}{
+		{"14.5 (Debian 14.5-1.pgdg110+1)", Version{14, 5, 0, "14.5 (Debian 14.5-1.pgdg110+1)"}},
+		{"8.0.32-0ubuntu0.22.04.2", Version{8, 0, 32, "8.0.32-0ubuntu0.22.04.2"}},
+		{"15.0.2000.5", Version{15, 0, 2000, "15.0.2000.5"}},
+		{"3.41.2", Version{3, 41, 2, "3.41.2"}},
+	}
+
+	for _, tst := range tests {
+		version, err := ParseVersion(tst.raw)
+		if err != nil {
+			t.Errorf("Failed parsing %s: %s", tst.raw, err)
+		} else if version != tst.expected {
+			t.Errorf("Expected %s to be parsed as %+v, got %+v", tst.raw, tst.expected, version)
+		}
+	}
+
+	if _, err := ParseVersion("unknown"); err == nil {
+		t.Error("Expected parsing an invalid version to fail")
+	}
+
+	if v := (Version{Major: 14, Minor: 5}); !v.AtLeast(14, 0) || !v.AtLeast(13, 9) || v.AtLeast(14, 6) || v.AtLeast(15, 0) {
+		t.Errorf("Unexpected AtLeast results for %+v", v)
+	}
+}
+
+func TestServerStatus(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	dbz := New(db, "postgres")
+
+	mock.ExpectQuery(`SHOW server_version`).
+		WillReturnRows(sqlmock.NewRows([]string{"server_version"}).AddRow("16.2"))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SHOW transaction_isolation`).
+		WillReturnRows(sqlmock.NewRows([]string{"transaction_isolation"}).AddRow("repeatable read"))
+	mock.ExpectCommit()
+
+	version, err := dbz.ServerVersion(context.Background())
+	if err != nil {
+		t.Fatalf("ServerVersion failed: %s", err)
+	}
+
+	if version.Major != 16 || version.Minor != 2 {
+		t.Errorf("Expected version 16.2, got %+v", version)
+	}
+
+	err = dbz.Transactional(func(tx *Tx) error {
+		level, err := tx.CurrentIsolationLevel(context.Background())
+		if err != nil {
+			return err
+		}
+
+		if level != sql.LevelRepeatableRead {
+			t.Errorf("Expected repeatable read, got %s", level)
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Errorf("CurrentIsolationLevel failed: %s", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %s", err)
+	}
+}
+
+func TestCurrentIsolationLevelMySQL(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	dbz := New(db, "mysql")
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT @@transaction_isolation`).
+		WillReturnRows(sqlmock.NewRows([]string{"isolation"}).AddRow("READ-COMMITTED"))
+	mock.ExpectRollback()
+
+	tx, err := dbz.BeginTxx(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("Failed starting transaction: %s", err)
+	}
+
+	dbzTx := &Tx{Tx: tx}
+
+	if level, err := dbzTx.CurrentIsolationLevel(context.Background()); err != nil || level != sql.LevelReadCommitted {
+		t.Errorf("Expected read committed, got %s (%v)", level, err)
+	}
+
+	dbzTx.Defaults.Dialect = DialectOracle
+
+	if _, err := dbzTx.CurrentIsolationLevel(context.Background()); !errors.Is(err, ErrUnsupportedDialect) {
+		t.Errorf("Expected ErrUnsupportedDialect for Oracle, got %v", err)
+	}
+
+	if err := tx.Rollback(); err != nil {
+		t.Errorf("Rollback failed: %s", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %s", err)
+	}
+}
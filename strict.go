@@ -0,0 +1,147 @@
+package sqlz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// ErrUnsafeIdentifier is returned (wrapped in a PolicyError) when executing
+// a statement with an identifier that is not safe, if the DB or Tx it was
+// created from uses strict identifiers (see DB.StrictIdentifiers)
+var ErrUnsafeIdentifier = errors.New("unsafe identifier")
+
+var (
+	// safeIdentifier matches plain, possibly qualified identifiers, e.g.
+	// name, u.name or public.users
+	safeIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$]*(\.[A-Za-z_][A-Za-z0-9_$]*)*$`)
+	// safeTable matches safe identifiers with an optional alias, e.g.
+	// users u or public.users AS u
+	safeTable = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$]*(\.[A-Za-z_][A-Za-z0-9_$]*)*( +((?i)AS +)?[A-Za-z_][A-Za-z0-9_$]*)?$`)
+)
+
+// StrictIdentifiers makes statements subsequently created from the DB (and
+// from transactions started from it) validate their identifiers before
+// they are executed: table names (optionally followed by an alias), the
+// columns of INSERT statements and of SET clauses, and GROUP BY and ORDER
+// BY columns must be plain identifiers, possibly qualified, or execution
+// fails with ErrUnsafeIdentifier. This is a safety net for applications
+// that build statements from user input, e.g. sort columns. Selected
+// columns and conditions are not validated, as they commonly contain
+// expressions; user input should never be used there.
+func (db *DB) StrictIdentifiers() {
+	db.Configure(func(defaults *Defaults) {
+		defaults.Guards = append(defaults.Guards, CheckIdentifiers())
+	})
+}
+
+// StrictIdentifiers makes statements subsequently created from the Tx
+// validate their identifiers before they are executed (see
+// DB.StrictIdentifiers)
+func (tx *Tx) StrictIdentifiers() {
+	tx.Configure(func(defaults *Defaults) {
+		defaults.Guards = append(defaults.Guards, CheckIdentifiers())
+	})
+}
+
+// CheckIdentifiers returns a guard rejecting statements with unsafe
+// identifiers (see DB.StrictIdentifiers)
+func CheckIdentifiers() Guard {
+	return func(_ context.Context, stmt SQLStmt) error {
+		return checkIdentifiers(stmt)
+	}
+}
+
+// checkIdentifiers returns an error for the first unsafe identifier in the
+// provided statement, including in its sub-queries
+func checkIdentifiers(stmt SQLStmt) error {
+	var (
+		idents, tables []string
+		subStmts       []SQLStmt
+	)
+
+	switch s := stmt.(type) {
+	case *SelectStmt:
+		tables = append(tables, s.Table)
+
+		for _, join := range s.Joins {
+			if join.ResultSet != nil {
+				subStmts = append(subStmts, join.ResultSet)
+			} else {
+				tables = append(tables, join.Table)
+			}
+
+			idents = append(idents, join.UsingColumns...)
+		}
+
+		idents = append(idents, s.Grouping...)
+
+		for _, order := range s.Ordering {
+			if col, ok := order.(OrderColumn); ok {
+				idents = append(idents, col.Column)
+			}
+		}
+
+		for _, union := range s.Unions {
+			subStmts = append(subStmts, union)
+		}
+	case *InsertStmt:
+		tables = append(tables, s.Table)
+		idents = append(idents, s.InsCols...)
+
+		if s.SelectStmt != nil {
+			subStmts = append(subStmts, s.SelectStmt)
+		}
+	case *UpdateStmt:
+		tables = append(tables, s.Table)
+		tables = append(tables, s.FromTables...)
+
+		for _, join := range s.Joins {
+			tables = append(tables, join.Table)
+		}
+
+		for col := range s.Updates {
+			idents = append(idents, col)
+		}
+
+		if s.SelectStmt != nil {
+			subStmts = append(subStmts, s.SelectStmt)
+		}
+	case *DeleteStmt:
+		tables = append(tables, s.Table)
+		tables = append(tables, s.UsingTables...)
+
+		for _, join := range s.Joins {
+			tables = append(tables, join.Table)
+		}
+	case *WithStmt:
+		for _, aux := range s.AuxStmts {
+			subStmts = append(subStmts, aux.Stmt)
+		}
+
+		if s.MainStmt != nil {
+			subStmts = append(subStmts, s.MainStmt)
+		}
+	}
+
+	for _, table := range tables {
+		if table != "" && !safeTable.MatchString(table) {
+			return fmt.Errorf("%w: table %q", ErrUnsafeIdentifier, table)
+		}
+	}
+
+	for _, ident := range idents {
+		if !safeIdentifier.MatchString(ident) {
+			return fmt.Errorf("%w: column %q", ErrUnsafeIdentifier, ident)
+		}
+	}
+
+	for _, sub := range subStmts {
+		if err := checkIdentifiers(sub); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
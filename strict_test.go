@@ -0,0 +1,79 @@
+package sqlz
+
+import (
+	"errors"
+	"testing"
+
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestStrictIdentifiers(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	dbz := New(db, "postgres")
+	dbz.StrictIdentifiers()
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\) AS total FROM public.users u INNER JOIN roles AS r USING \(role_id\) ORDER BY u.name DESC`).
+		WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(1))
+
+	var total int64
+
+	err = dbz.Select("COUNT(*) AS total").From("public.users u").
+		JoinUsing(InnerJoin, "roles AS r", "role_id").
+		OrderBy(Desc("u.name")).
+		GetRow(&total)
+	if err != nil {
+		t.Fatalf("Safe statement failed: %s", err)
+	}
+
+	tests := []struct {
+		name string
+		stmt func() error
+	}{
+		{
+			"unsafe ORDER BY column",
+			func() error {
+				return dbz.Select("*").From("users").OrderBy(Asc("name; DROP TABLE users")).GetAll(&[]struct{}{})
+			},
+		},
+		{
+			"unsafe table",
+			func() error {
+				_, err := dbz.DeleteFrom("users WHERE 1=1 --").Where(Eq("id", 1)).Exec()
+				return err
+			},
+		},
+		{
+			"unsafe SET column",
+			func() error {
+				_, err := dbz.Update("users").Set("name = 'x', admin", true).Where(Eq("id", 1)).Exec()
+				return err
+			},
+		},
+		{
+			"unsafe column in sub-query",
+			func() error {
+				_, err := dbz.InsertInto("archive").Columns("id").
+					FromSelect(dbz.Select("id").From("users").GroupBy("id)")).
+					Exec()
+				return err
+			},
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.name, func(t *testing.T) {
+			err := tst.stmt()
+			if !errors.Is(err, ErrUnsafeIdentifier) || !IsPolicyError(err) {
+				t.Errorf("Expected an unsafe identifier policy error, got %v", err)
+			}
+		})
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %s", err)
+	}
+}
@@ -0,0 +1,229 @@
+package sqlz
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ErrNotStruct is returned when a struct (or a slice of structs) was
+// expected, but a value of a different type was provided
+var ErrNotStruct = errors.New("value is not a struct or a slice of structs")
+
+// structField represents a field of a struct that maps to a database
+// column
+type structField struct {
+	Column string
+	Index  []int
+}
+
+// structFields returns the fields of a struct type that map to database
+// columns. Like sqlx, columns are named after the field's `db` tag, or
+// the lowercased field name if the tag is missing. Fields tagged with
+// `db:"-"` and unexported fields are skipped, and fields of embedded
+// structs are flattened into the list (unless the embedded field has a
+// tag, in which case it is considered a column).
+func structFields(t reflect.Type) []structField {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var fields []structField
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		tag := field.Tag.Get("db")
+		if comma := strings.Index(tag, ","); comma > -1 {
+			tag = tag[:comma]
+		}
+
+		if tag == "-" {
+			continue
+		}
+
+		if field.Anonymous && tag == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+
+			if ft.Kind() == reflect.Struct {
+				for _, inner := range structFields(ft) {
+					inner.Index = append([]int{i}, inner.Index...)
+					fields = append(fields, inner)
+				}
+
+				continue
+			}
+		}
+
+		if field.PkgPath != "" {
+			// unexported field
+			continue
+		}
+
+		if tag == "" {
+			tag = strings.ToLower(field.Name)
+		}
+
+		fields = append(fields, structField{Column: tag, Index: []int{i}})
+	}
+
+	return fields
+}
+
+// structValue returns the value of a struct field by its index path, or
+// nil if the path goes through a nil embedded pointer
+func structValue(v reflect.Value, index []int) interface{} {
+	for _, i := range index {
+		for v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return nil
+			}
+
+			v = v.Elem()
+		}
+
+		v = v.Field(i)
+	}
+
+	return v.Interface()
+}
+
+// structRows receives a struct, a pointer to a struct, or a slice of either,
+// and returns the names of the columns it maps to, and the values of every
+// struct, in the same order as the columns.
+func structRows(in interface{}) (cols []string, rows [][]interface{}, err error) {
+	v := reflect.ValueOf(in)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, nil, ErrNotStruct
+		}
+
+		v = v.Elem()
+	}
+
+	var items []reflect.Value
+
+	switch v.Kind() {
+	case reflect.Struct:
+		items = []reflect.Value{v}
+	case reflect.Slice, reflect.Array:
+		items = make([]reflect.Value, v.Len())
+		for i := range items {
+			items[i] = v.Index(i)
+		}
+	default:
+		return nil, nil, ErrNotStruct
+	}
+
+	elemType := v.Type()
+	if v.Kind() != reflect.Struct {
+		elemType = elemType.Elem()
+	}
+
+	fields := structFields(elemType)
+	if len(fields) == 0 {
+		return nil, nil, ErrNotStruct
+	}
+
+	cols = make([]string, len(fields))
+	for i, field := range fields {
+		cols[i] = field.Column
+	}
+
+	rows = make([][]interface{}, 0, len(items))
+
+	for _, item := range items {
+		for item.Kind() == reflect.Ptr {
+			if item.IsNil() {
+				return nil, nil, ErrNotStruct
+			}
+
+			item = item.Elem()
+		}
+
+		row := make([]interface{}, len(fields))
+		for i, field := range fields {
+			row[i] = structValue(item, field.Index)
+		}
+
+		rows = append(rows, row)
+	}
+
+	return cols, rows, nil
+}
+
+// ColumnsOf returns the names of the columns the provided struct maps to,
+// in the order of its fields, for use with Select, Returning or Columns
+// instead of manually maintained column lists. Columns are named like in
+// sqlx (see structFields). The value may be a struct, a pointer to one, or
+// a slice of either (it may be nil or empty, as only its type is used),
+// e.g. ColumnsOf((*User)(nil), "u"). If a prefix is provided, the columns
+// are qualified with it (e.g. u.id). If the value is not a struct, nil is
+// returned.
+func ColumnsOf(v interface{}, prefix string) []string {
+	t := reflect.TypeOf(v)
+	if t == nil {
+		return nil
+	}
+
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+
+	fields := structFields(t)
+	if len(fields) == 0 {
+		return nil
+	}
+
+	cols := make([]string, len(fields))
+	for i, field := range fields {
+		cols[i] = field.Column
+		if prefix != "" {
+			cols[i] = prefix + "." + field.Column
+		}
+	}
+
+	return cols
+}
+
+// returningStmt is implemented by statements that load the values returned
+// by their RETURNING clause
+type returningStmt interface {
+	RowStmt
+	AllStmt
+}
+
+// loadReturning executes a statement created with ReturningStruct, loading
+// the returned values into the provided struct, or slice of structs. The
+// returned result reports the number of rows loaded as affected.
+func loadReturning(ctx context.Context, stmt returningStmt, into interface{}) (sql.Result, error) {
+	t := reflect.TypeOf(into)
+	if t == nil || t.Kind() != reflect.Ptr || len(ColumnsOf(into, "")) == 0 {
+		return nil, fmt.Errorf("%w: ReturningStruct received %T", ErrNotStruct, into)
+	}
+
+	if t.Elem().Kind() != reflect.Slice {
+		if err := stmt.GetRowContext(ctx, into); err != nil {
+			return nil, err
+		}
+
+		return driver.RowsAffected(1), nil
+	}
+
+	if err := stmt.GetAllContext(ctx, into); err != nil {
+		return nil, err
+	}
+
+	return driver.RowsAffected(reflect.ValueOf(into).Elem().Len()), nil
+}
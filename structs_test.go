@@ -0,0 +1,133 @@
+package sqlz
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+type columnsOfBase struct {
+	ID        int64     `db:"id"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+type columnsOfUser struct {
+	columnsOfBase
+	Name    string `db:"name"`
+	Email   string `db:"email,omitempty"`
+	Age     int
+	Ignored string `db:"-"`
+	secret  string
+}
+
+func TestColumnsOf(t *testing.T) {
+	expected := []string{"id", "created_at", "name", "email", "age"}
+
+	tests := []struct {
+		name     string
+		value    interface{}
+		prefix   string
+		expected []string
+	}{
+		{"struct", columnsOfUser{secret: "x"}, "", expected},
+		{"nil pointer", (*columnsOfUser)(nil), "", expected},
+		{"slice of pointers", []*columnsOfUser{}, "", expected},
+		{"pointer to slice", &[]columnsOfUser{}, "", expected},
+		{"prefix", columnsOfUser{}, "u", []string{"u.id", "u.created_at", "u.name", "u.email", "u.age"}},
+		{"not a struct", 3, "", nil},
+		{"nil", nil, "", nil},
+	}
+
+	for _, tst := range tests {
+		if cols := ColumnsOf(tst.value, tst.prefix); !reflect.DeepEqual(cols, tst.expected) {
+			t.Errorf("%s: expected %v, got %v", tst.name, tst.expected, cols)
+		}
+	}
+
+	runTests(t, func(dbz *DB) []test {
+		return []test{
+			{
+				"select columns of a struct",
+				dbz.Select(ColumnsOf(columnsOfUser{}, "u")...).From("users u").Where(Eq("u.id", 1)),
+				"SELECT u.id, u.created_at, u.name, u.email, u.age FROM users u WHERE u.id = ?",
+				[]interface{}{1},
+			},
+			{
+				"returning columns of a struct",
+				dbz.DeleteFrom("users").Where(Eq("id", 1)).Returning(ColumnsOf(columnsOfBase{}, "")...),
+				"DELETE FROM users WHERE id = ? RETURNING id, created_at",
+				[]interface{}{1},
+			},
+		}
+	})
+}
+
+func TestReturningStruct(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	type row struct {
+		ID   int64  `db:"id"`
+		Name string `db:"name"`
+	}
+
+	dbz := New(db, "postgres")
+	dbz.Defaults.Returning = []string{"*"}
+
+	mock.ExpectQuery(`INSERT INTO users \(name\) VALUES \(\$1\) RETURNING id, name`).
+		WithArgs("Alice").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "Alice"))
+	mock.ExpectQuery(`UPDATE users SET name = \$1 WHERE id > \$2 RETURNING id, name`).
+		WithArgs("Bob", 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "Bob").AddRow(2, "Bob"))
+	mock.ExpectQuery(`DELETE FROM users WHERE id = \$1 RETURNING id, name`).
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+
+	var inserted row
+
+	res, err := dbz.InsertInto("users").Columns("name").Values("Alice").ReturningStruct(&inserted).Exec()
+	if err != nil {
+		t.Fatalf("Insert failed: %s", err)
+	}
+
+	if affected, _ := res.RowsAffected(); affected != 1 || inserted != (row{1, "Alice"}) {
+		t.Errorf("Unexpected insert result: %d rows, %#v", affected, inserted)
+	}
+
+	var updated []row
+
+	res, err = dbz.Update("users").Set("name", "Bob").Where(Gt("id", 0)).ReturningStruct(&updated).Exec()
+	if err != nil {
+		t.Fatalf("Update failed: %s", err)
+	}
+
+	if affected, _ := res.RowsAffected(); affected != 2 || !reflect.DeepEqual(updated, []row{{1, "Bob"}, {2, "Bob"}}) {
+		t.Errorf("Unexpected update result: %d rows, %#v", affected, updated)
+	}
+
+	var deleted []row
+
+	res, err = dbz.DeleteFrom("users").Where(Eq("id", 3)).ReturningStruct(&deleted).Exec()
+	if err != nil {
+		t.Fatalf("Delete failed: %s", err)
+	}
+
+	if affected, _ := res.RowsAffected(); affected != 0 || len(deleted) != 0 {
+		t.Errorf("Unexpected delete result: %d rows, %#v", affected, deleted)
+	}
+
+	var notStruct int64
+	if _, err := dbz.DeleteFrom("users").Where(Eq("id", 3)).ReturningStruct(&notStruct).Exec(); !errors.Is(err, ErrNotStruct) {
+		t.Errorf("Expected ErrNotStruct, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %s", err)
+	}
+}
@@ -0,0 +1,315 @@
+package sqlz
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+)
+
+// CreateTableStmt represents a CREATE TABLE statement
+type CreateTableStmt struct {
+	*Statement
+	Table         string
+	Columns       []*ColumnDef
+	Constraints   []SQLStmt
+	IsIfNotExists bool
+	execer        Ext
+	guards        []Guard
+}
+
+// CreateTable creates a new CreateTableStmt object for the provided table
+func (db *DB) CreateTable(table string) *CreateTableStmt {
+	return &CreateTableStmt{
+		Table:     table,
+		execer:    db.ext(),
+		guards:    db.defaults().Guards,
+		Statement: &Statement{db.ErrHandlers},
+	}
+}
+
+// CreateTable creates a new CreateTableStmt object for the provided table
+func (tx *Tx) CreateTable(table string) *CreateTableStmt {
+	return &CreateTableStmt{
+		Table:     table,
+		execer:    tx.ext(),
+		guards:    tx.defaults().Guards,
+		Statement: &Statement{tx.ErrHandlers},
+	}
+}
+
+// IfNotExists makes the statement do nothing if the table already exists
+func (stmt *CreateTableStmt) IfNotExists() *CreateTableStmt {
+	stmt.IsIfNotExists = true
+	return stmt
+}
+
+// Column adds column definitions to the table, e.g.
+// Column("id", "bigint").AsIdentity(IdentityAlways).PrimaryKey()
+func (stmt *CreateTableStmt) Column(cols ...*ColumnDef) *CreateTableStmt {
+	stmt.Columns = append(stmt.Columns, cols...)
+	return stmt
+}
+
+// Constraint adds table constraints, e.g. PrimaryKey, Unique, Check,
+// ForeignKey or Exclude constraints
+func (stmt *CreateTableStmt) Constraint(constraints ...SQLStmt) *CreateTableStmt {
+	stmt.Constraints = append(stmt.Constraints, constraints...)
+	return stmt
+}
+
+// ToSQL generates the CREATE TABLE statement's SQL. DDL statements have no
+// bindings.
+func (stmt *CreateTableStmt) ToSQL(_ bool) (asSQL string, bindings []interface{}) {
+	asSQL = "CREATE TABLE "
+	if stmt.IsIfNotExists {
+		asSQL += "IF NOT EXISTS "
+	}
+
+	defs := make([]string, 0, len(stmt.Columns)+len(stmt.Constraints))
+
+	for _, col := range stmt.Columns {
+		colSQL, _ := col.ToSQL(false)
+		defs = append(defs, colSQL)
+	}
+
+	for _, constraint := range stmt.Constraints {
+		constraintSQL, _ := constraint.ToSQL(false)
+		defs = append(defs, constraintSQL)
+	}
+
+	return asSQL + stmt.Table + " (" + strings.Join(defs, ", ") + ")", nil
+}
+
+// Exec executes the statement, returning the standard sql.Result struct
+// and an error if the query failed
+func (stmt *CreateTableStmt) Exec() (res sql.Result, err error) {
+	return stmt.ExecContext(context.Background())
+}
+
+// ExecContext executes the statement, returning the standard sql.Result
+// struct and an error if the query failed
+func (stmt *CreateTableStmt) ExecContext(ctx context.Context) (res sql.Result, err error) {
+	return execDDL(ctx, stmt, stmt.execer, stmt.guards, stmt.Statement)
+}
+
+// AlterAction is an action of an ALTER TABLE statement, e.g. ADD COLUMN
+// followed by a column definition, or DROP CONSTRAINT followed by a name
+type AlterAction struct {
+	Clause string
+	// Def is the definition of the added column or constraint, if any
+	Def SQLStmt
+}
+
+// AlterTableStmt represents an ALTER TABLE statement
+type AlterTableStmt struct {
+	*Statement
+	Table   string
+	Actions []AlterAction
+	execer  Ext
+	guards  []Guard
+	err     error
+}
+
+// AlterTable creates a new AlterTableStmt object for the provided table
+func (db *DB) AlterTable(table string) *AlterTableStmt {
+	return &AlterTableStmt{
+		Table:     table,
+		execer:    db.ext(),
+		guards:    db.defaults().Guards,
+		Statement: &Statement{db.ErrHandlers},
+	}
+}
+
+// AlterTable creates a new AlterTableStmt object for the provided table
+func (tx *Tx) AlterTable(table string) *AlterTableStmt {
+	return &AlterTableStmt{
+		Table:     table,
+		execer:    tx.ext(),
+		guards:    tx.defaults().Guards,
+		Statement: &Statement{tx.ErrHandlers},
+	}
+}
+
+func (stmt *AlterTableStmt) action(clause string, def SQLStmt) *AlterTableStmt {
+	stmt.Actions = append(stmt.Actions, AlterAction{clause, def})
+	return stmt
+}
+
+// AddColumn adds a column to the table
+func (stmt *AlterTableStmt) AddColumn(col *ColumnDef) *AlterTableStmt {
+	return stmt.action("ADD COLUMN", col)
+}
+
+// DropColumn drops a column from the table
+func (stmt *AlterTableStmt) DropColumn(name string) *AlterTableStmt {
+	return stmt.action("DROP COLUMN "+name, nil)
+}
+
+// RenameColumn renames a column of the table
+func (stmt *AlterTableStmt) RenameColumn(from, to string) *AlterTableStmt {
+	return stmt.action("RENAME COLUMN "+from+" TO "+to, nil)
+}
+
+// SetColumnType changes the data type of a column (ALTER COLUMN ... TYPE)
+func (stmt *AlterTableStmt) SetColumnType(name, dataType string) *AlterTableStmt {
+	return stmt.action("ALTER COLUMN "+name+" TYPE "+dataType, nil)
+}
+
+// SetDefault sets the default value of a column, which is inlined as a
+// literal (see ColumnDef.Default). If the value cannot be converted to a
+// literal, the error is returned when the statement is executed.
+func (stmt *AlterTableStmt) SetDefault(name string, value interface{}) *AlterTableStmt {
+	literal, err := literalOrIndirect(value)
+	if err != nil && stmt.err == nil {
+		stmt.err = err
+	}
+
+	return stmt.action("ALTER COLUMN "+name+" SET DEFAULT "+literal, nil)
+}
+
+// DropDefault removes the default value of a column
+func (stmt *AlterTableStmt) DropDefault(name string) *AlterTableStmt {
+	return stmt.action("ALTER COLUMN "+name+" DROP DEFAULT", nil)
+}
+
+// SetNotNull adds a NOT NULL constraint to a column
+func (stmt *AlterTableStmt) SetNotNull(name string) *AlterTableStmt {
+	return stmt.action("ALTER COLUMN "+name+" SET NOT NULL", nil)
+}
+
+// DropNotNull removes the NOT NULL constraint of a column
+func (stmt *AlterTableStmt) DropNotNull(name string) *AlterTableStmt {
+	return stmt.action("ALTER COLUMN "+name+" DROP NOT NULL", nil)
+}
+
+// AddConstraint adds a table constraint, e.g. a Check or ForeignKey
+// constraint
+func (stmt *AlterTableStmt) AddConstraint(constraint SQLStmt) *AlterTableStmt {
+	return stmt.action("ADD", constraint)
+}
+
+// DropConstraint drops the table constraint with the provided name
+func (stmt *AlterTableStmt) DropConstraint(name string) *AlterTableStmt {
+	return stmt.action("DROP CONSTRAINT "+name, nil)
+}
+
+// RenameTo renames the table
+func (stmt *AlterTableStmt) RenameTo(name string) *AlterTableStmt {
+	return stmt.action("RENAME TO "+name, nil)
+}
+
+// ToSQL generates the ALTER TABLE statement's SQL. DDL statements have no
+// bindings.
+func (stmt *AlterTableStmt) ToSQL(_ bool) (asSQL string, bindings []interface{}) {
+	actions := make([]string, len(stmt.Actions))
+
+	for i, action := range stmt.Actions {
+		actions[i] = action.Clause
+		if action.Def != nil {
+			defSQL, _ := action.Def.ToSQL(false)
+			actions[i] += " " + defSQL
+		}
+	}
+
+	return "ALTER TABLE " + stmt.Table + " " + strings.Join(actions, ", "), nil
+}
+
+// Exec executes the statement, returning the standard sql.Result struct
+// and an error if the query failed
+func (stmt *AlterTableStmt) Exec() (res sql.Result, err error) {
+	return stmt.ExecContext(context.Background())
+}
+
+// ExecContext executes the statement, returning the standard sql.Result
+// struct and an error if the query failed
+func (stmt *AlterTableStmt) ExecContext(ctx context.Context) (res sql.Result, err error) {
+	return execDDL(ctx, stmt, stmt.execer, stmt.guards, stmt.Statement)
+}
+
+// DropTableStmt represents a DROP TABLE statement
+type DropTableStmt struct {
+	*Statement
+	TableNames []string
+	IsIfExists bool
+	IsCascade  bool
+	execer     Ext
+	guards     []Guard
+}
+
+// DropTable creates a new DropTableStmt object for the provided tables
+func (db *DB) DropTable(tables ...string) *DropTableStmt {
+	return &DropTableStmt{
+		TableNames: tables,
+		execer:     db.ext(),
+		guards:     db.defaults().Guards,
+		Statement:  &Statement{db.ErrHandlers},
+	}
+}
+
+// DropTable creates a new DropTableStmt object for the provided tables
+func (tx *Tx) DropTable(tables ...string) *DropTableStmt {
+	return &DropTableStmt{
+		TableNames: tables,
+		execer:     tx.ext(),
+		guards:     tx.defaults().Guards,
+		Statement:  &Statement{tx.ErrHandlers},
+	}
+}
+
+// IfExists makes the statement do nothing for tables that do not exist
+func (stmt *DropTableStmt) IfExists() *DropTableStmt {
+	stmt.IsIfExists = true
+	return stmt
+}
+
+// Cascade also drops objects that depend on the tables, e.g. views and
+// foreign key constraints of other tables
+func (stmt *DropTableStmt) Cascade() *DropTableStmt {
+	stmt.IsCascade = true
+	return stmt
+}
+
+// ToSQL generates the DROP TABLE statement's SQL. DDL statements have no
+// bindings.
+func (stmt *DropTableStmt) ToSQL(_ bool) (asSQL string, bindings []interface{}) {
+	asSQL = "DROP TABLE "
+	if stmt.IsIfExists {
+		asSQL += "IF EXISTS "
+	}
+
+	asSQL += strings.Join(stmt.TableNames, ", ")
+
+	if stmt.IsCascade {
+		asSQL += " CASCADE"
+	}
+
+	return asSQL, nil
+}
+
+// Exec executes the statement, returning the standard sql.Result struct
+// and an error if the query failed
+func (stmt *DropTableStmt) Exec() (res sql.Result, err error) {
+	return stmt.ExecContext(context.Background())
+}
+
+// ExecContext executes the statement, returning the standard sql.Result
+// struct and an error if the query failed
+func (stmt *DropTableStmt) ExecContext(ctx context.Context) (res sql.Result, err error) {
+	return execDDL(ctx, stmt, stmt.execer, stmt.guards, stmt.Statement)
+}
+
+// execDDL validates a DDL (or SET) statement, checks it against the
+// provided guards and executes it
+func execDDL(ctx context.Context, stmt SQLStmt, execer Ext, guards []Guard, handler *Statement) (sql.Result, error) {
+	if err := checkStmt(ctx, guards, stmt); err != nil {
+		handler.HandleError(err)
+		return nil, err
+	}
+
+	asSQL, bindings := stmt.ToSQL(true)
+
+	res, err := execer.ExecContext(ctx, asSQL, bindings...)
+	handler.HandleError(err)
+
+	return res, err
+}
@@ -0,0 +1,106 @@
+package sqlz
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestWithTimeout(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	dbz := New(db, "postgres")
+
+	mock.ExpectQuery(`SELECT id FROM users`).
+		WillDelayFor(time.Second).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectExec(`DELETE FROM sessions`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	var ids []int64
+
+	start := time.Now()
+
+	err = dbz.Select("id").From("users").WithTimeout(20 * time.Millisecond).GetAll(&ids)
+	if err == nil {
+		t.Error("Expected query to time out")
+	}
+
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Expected query to be cancelled early, took %s", elapsed)
+	}
+
+	_, err = dbz.DeleteFrom("sessions").WithTimeout(time.Second).Exec()
+	if err != nil {
+		t.Errorf("Exec failed: %s", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %s", err)
+	}
+}
+
+func TestWithTimeoutRows(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	var contexts []context.Context
+
+	dbz := New(db, "postgres")
+	dbz.OnQuery(func(ctx context.Context, _ QueryEvent) {
+		contexts = append(contexts, ctx)
+	})
+
+	mock.ExpectQuery(`SELECT id FROM users`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(`SELECT id FROM users`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(`INSERT INTO users \(name\) VALUES \(\$1\) RETURNING id`).
+		WithArgs("Alice").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(`UPDATE users SET name = \$1 RETURNING id`).
+		WithArgs("Bob").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(`DELETE FROM users RETURNING id`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	stmts := []interface {
+		Rows() (*Rows, error)
+	}{
+		dbz.Select("id").From("users").WithTimeout(time.Hour),
+		dbz.Raw("SELECT id FROM users").WithTimeout(time.Hour),
+		dbz.InsertInto("users").Columns("name").Values("Alice").Returning("id").WithTimeout(time.Hour),
+		dbz.Update("users").Set("name", "Bob").Returning("id").WithTimeout(time.Hour),
+		dbz.DeleteFrom("users").Returning("id").WithTimeout(time.Hour),
+	}
+
+	for i, stmt := range stmts {
+		rows, err := stmt.Rows()
+		if err != nil {
+			t.Fatalf("Rows failed: %s", err)
+		}
+
+		if contexts[i].Err() != nil {
+			t.Errorf("Expected the context to remain active while iterating, got %s", contexts[i].Err())
+		}
+
+		rows.Close()
+
+		// the timeout is released once the rows are closed, rather than
+		// when its deadline passes
+		if contexts[i].Err() != context.Canceled {
+			t.Errorf("Expected the context to be released on Close, got %v", contexts[i].Err())
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %s", err)
+	}
+}
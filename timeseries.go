@@ -0,0 +1,44 @@
+package sqlz
+
+import (
+	"strings"
+)
+
+// Truncation units accepted by DateTrunc
+const (
+	TruncMinute  = "minute"
+	TruncHour    = "hour"
+	TruncDay     = "day"
+	TruncWeek    = "week"
+	TruncMonth   = "month"
+	TruncQuarter = "quarter"
+	TruncYear    = "year"
+)
+
+// DateTrunc returns a PostgreSQL date_trunc expression truncating a
+// timestamp column to the provided unit (e.g. "day"). The expression is
+// returned as a string, so it can be used with Select, GroupBy, Asc and
+// Desc alike:
+//
+//	day := sqlz.DateTrunc(sqlz.TruncDay, "created_at")
+//	db.Select(day+" AS day", "COUNT(*)").From("events").GroupBy(day).OrderBy(sqlz.Asc(day))
+//
+// The unit is embedded as a quoted literal rather than bound as a
+// parameter, as PostgreSQL only matches GROUP BY expressions with the
+// selected expressions if they are identical.
+func DateTrunc(unit, col string) string {
+	return "date_trunc(" + quoteLiteral(unit) + ", " + col + ")"
+}
+
+// TimeBucket returns a TimescaleDB time_bucket expression grouping a
+// timestamp column into buckets of the provided interval (e.g. "15
+// minutes"). Like DateTrunc, the expression is returned as a string for
+// usage with Select, GroupBy, Asc and Desc.
+func TimeBucket(interval, col string) string {
+	return "time_bucket(" + quoteLiteral(interval) + "::interval, " + col + ")"
+}
+
+// quoteLiteral quotes a string as an SQL string literal
+func quoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
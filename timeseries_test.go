@@ -0,0 +1,39 @@
+package sqlz
+
+import (
+	"testing"
+)
+
+func TestTimeSeries(t *testing.T) {
+	runTests(t, func(dbz *DB) []test {
+		day := DateTrunc(TruncDay, "created_at")
+		bucket := TimeBucket("15 minutes", "ts")
+
+		return []test{
+			{
+				"daily rollup",
+				dbz.Select(day+" AS day", "COUNT(*)").
+					From("events").
+					Where(Eq("type", "click")).
+					GroupBy(day).
+					OrderBy(Asc(day)),
+				"SELECT date_trunc('day', created_at) AS day, COUNT(*) FROM events WHERE type = ? " +
+					"GROUP BY date_trunc('day', created_at) ORDER BY date_trunc('day', created_at) ASC",
+				[]interface{}{"click"},
+			},
+			{
+				"time bucket rollup",
+				dbz.Select(bucket+" AS bucket", "AVG(value)").From("metrics").GroupBy(bucket).OrderBy(Desc(bucket)),
+				"SELECT time_bucket('15 minutes'::interval, ts) AS bucket, AVG(value) FROM metrics " +
+					"GROUP BY time_bucket('15 minutes'::interval, ts) ORDER BY time_bucket('15 minutes'::interval, ts) DESC",
+				[]interface{}{},
+			},
+			{
+				"quotes are escaped",
+				dbz.Select(DateTrunc("day'); DROP TABLE x; --", "ts")).From("t"),
+				"SELECT date_trunc('day''); DROP TABLE x; --', ts) FROM t",
+				[]interface{}{},
+			},
+		}
+	})
+}
@@ -0,0 +1,166 @@
+package sqlz
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TxOutcome is the outcome of a traced transaction
+type TxOutcome string
+
+const (
+	// TxPending is the outcome of a transaction that was neither committed
+	// nor rolled back yet
+	TxPending TxOutcome = ""
+	// TxCommitted is the outcome of a committed transaction
+	TxCommitted TxOutcome = "COMMIT"
+	// TxRolledBack is the outcome of a rolled back transaction
+	TxRolledBack TxOutcome = "ROLLBACK"
+)
+
+// TxTrace is a record of the statements executed within a transaction,
+// created by Tx.Trace. It is safe for concurrent use.
+type TxTrace struct {
+	mu      sync.Mutex
+	start   time.Time
+	end     time.Time
+	events  []QueryEvent
+	outcome TxOutcome
+	err     error
+}
+
+// Trace starts recording every statement subsequently executed by
+// statements created from the transaction (its SQL, bindings, duration and
+// error, as provided to query hooks), along with the outcome of the
+// transaction once it is committed or rolled back, and returns the trace.
+// Calling Trace again returns the same trace. This is useful for debugging
+// long business transactions and for audit logs:
+//
+//	var trace *sqlz.TxTrace
+//	err := db.Transactional(func(tx *sqlz.Tx) error {
+//	    trace = tx.Trace()
+//	    ...
+//	})
+//	log.Print(trace)
+func (tx *Tx) Trace() *TxTrace {
+	tx.mu.Lock()
+	if tx.trace != nil {
+		tx.mu.Unlock()
+		return tx.trace
+	}
+
+	trace := &TxTrace{start: time.Now()}
+	tx.trace = trace
+	tx.mu.Unlock()
+
+	tx.OnQuery(trace.record)
+
+	return trace
+}
+
+// Commit commits the transaction, recording its outcome if it is traced,
+// and invalidating cached results (see InvalidateCache)
+func (tx *Tx) Commit() error {
+	err := tx.Tx.Commit()
+	tx.finishTrace(TxCommitted, err)
+	tx.finishCommit(err == nil)
+
+	return err
+}
+
+// Rollback rolls back the transaction, recording its outcome if it is
+// traced
+func (tx *Tx) Rollback() error {
+	err := tx.Tx.Rollback()
+	tx.finishTrace(TxRolledBack, err)
+	tx.finishCommit(false)
+
+	return err
+}
+
+func (tx *Tx) finishTrace(outcome TxOutcome, err error) {
+	tx.mu.RLock()
+	trace := tx.trace
+	tx.mu.RUnlock()
+
+	if trace == nil {
+		return
+	}
+
+	trace.mu.Lock()
+	defer trace.mu.Unlock()
+
+	if trace.outcome == TxPending {
+		trace.outcome, trace.err, trace.end = outcome, err, time.Now()
+	}
+}
+
+func (trace *TxTrace) record(_ context.Context, event QueryEvent) {
+	trace.mu.Lock()
+	defer trace.mu.Unlock()
+
+	trace.events = append(trace.events, event)
+}
+
+// Events returns the statements executed within the transaction, in the
+// order they were executed
+func (trace *TxTrace) Events() []QueryEvent {
+	trace.mu.Lock()
+	defer trace.mu.Unlock()
+
+	return append([]QueryEvent(nil), trace.events...)
+}
+
+// Outcome returns the outcome of the transaction, and the error returned
+// when committing or rolling it back, if any
+func (trace *TxTrace) Outcome() (TxOutcome, error) {
+	trace.mu.Lock()
+	defer trace.mu.Unlock()
+
+	return trace.outcome, trace.err
+}
+
+// Duration returns the time elapsed from the start of the trace until the
+// transaction was committed or rolled back (or until now, if it is still
+// pending)
+func (trace *TxTrace) Duration() time.Duration {
+	trace.mu.Lock()
+	defer trace.mu.Unlock()
+
+	if trace.outcome == TxPending {
+		return time.Since(trace.start)
+	}
+
+	return trace.end.Sub(trace.start)
+}
+
+// String formats the trace for logging, with one line per statement
+func (trace *TxTrace) String() string {
+	outcome, err := trace.Outcome()
+	if outcome == TxPending {
+		outcome = "PENDING"
+	}
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "transaction %s after %s", outcome, trace.Duration())
+	if err != nil {
+		fmt.Fprintf(&b, " (%s)", err)
+	}
+
+	for i, event := range trace.Events() {
+		fmt.Fprintf(&b, "\n%d. [%s] %s", i+1, event.Duration, event.SQL)
+		if len(event.Bindings) > 0 {
+			fmt.Fprintf(&b, " %v", event.Bindings)
+		}
+
+		if event.Err != nil {
+			fmt.Fprintf(&b, ": %s", event.Err)
+		}
+	}
+
+	return b.String()
+}
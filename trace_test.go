@@ -0,0 +1,78 @@
+package sqlz
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestTxTrace(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	dbz := New(db, "postgres")
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE accounts SET balance = \$1 WHERE id = \$2`).
+		WithArgs(10, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE accounts SET balance = \$1 WHERE id = \$2`).
+		WithArgs(20, 2).
+		WillReturnError(errors.New("deadlock detected"))
+	mock.ExpectRollback()
+
+	var trace *TxTrace
+
+	err = dbz.Transactional(func(tx *Tx) error {
+		trace = tx.Trace()
+		if tx.Trace() != trace {
+			t.Error("Expected Trace to return the same trace when called again")
+		}
+
+		if _, err := tx.Update("accounts").Set("balance", 10).Where(Eq("id", 1)).Exec(); err != nil {
+			return err
+		}
+
+		_, err := tx.Update("accounts").Set("balance", 20).Where(Eq("id", 2)).Exec()
+
+		return err
+	})
+	if err == nil {
+		t.Fatal("Expected transaction to fail")
+	}
+
+	events := trace.Events()
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 traced statements, got %d", len(events))
+	}
+
+	if events[0].SQL != "UPDATE accounts SET balance = $1 WHERE id = $2" ||
+		!reflect.DeepEqual(events[0].Bindings, []interface{}{10, 1}) ||
+		events[0].Err != nil {
+		t.Errorf("Unexpected first event: %+v", events[0])
+	}
+
+	if events[1].Err == nil {
+		t.Errorf("Expected second event to have an error")
+	}
+
+	if outcome, err := trace.Outcome(); outcome != TxRolledBack || err != nil {
+		t.Errorf("Expected rolled back outcome, got %q (%v)", outcome, err)
+	}
+
+	str := trace.String()
+	if !strings.HasPrefix(str, "transaction ROLLBACK after ") ||
+		!strings.Contains(str, "\n2. [") ||
+		!strings.HasSuffix(str, "UPDATE accounts SET balance = $1 WHERE id = $2 [20 2]: deadlock detected") {
+		t.Errorf("Unexpected trace string: %s", str)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %s", err)
+	}
+}
@@ -0,0 +1,72 @@
+package sqlz
+
+import (
+	"context"
+	"reflect"
+)
+
+// RowStmt is implemented by statements that can load a single result into
+// a variable, such as SelectStmt, or InsertStmt with a RETURNING clause
+type RowStmt interface {
+	SQLStmt
+	GetRowContext(ctx context.Context, into interface{}) error
+}
+
+// AllStmt is implemented by statements that can load multiple results into
+// a slice, such as SelectStmt, or UpdateStmt with a RETURNING clause
+type AllStmt interface {
+	SQLStmt
+	GetAllContext(ctx context.Context, into interface{}) error
+}
+
+// GetRow executes the provided statement and returns its result as a value
+// of type T, which may be a struct, a pointer to a struct, or a simple type
+// if only one column is returned, e.g.:
+//
+//	user, err := sqlz.GetRow[User](dbz.Select("*").From("users").Where(Eq("id", 1)))
+//
+// Like the statement's GetRow method, sql.ErrNoRows is returned if there
+// are no results.
+func GetRow[T any](stmt RowStmt) (T, error) {
+	return GetRowContext[T](context.Background(), stmt)
+}
+
+// GetRowContext executes the provided statement and returns its result as
+// a value of type T (see GetRow)
+func GetRowContext[T any](ctx context.Context, stmt RowStmt) (result T, err error) {
+	into := interface{}(&result)
+
+	// allocate the struct pointed to by pointer types, so that it is loaded
+	// into directly
+	if v := reflect.ValueOf(&result).Elem(); v.Kind() == reflect.Ptr {
+		v.Set(reflect.New(v.Type().Elem()))
+		into = result
+	}
+
+	if err := stmt.GetRowContext(ctx, into); err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return result, nil
+}
+
+// GetAll executes the provided statement and returns its results as a
+// slice of values of type T (see GetRow), e.g.:
+//
+//	ids, err := sqlz.GetAll[int64](dbz.Select("id").From("users"))
+func GetAll[T any](stmt AllStmt) ([]T, error) {
+	return GetAllContext[T](context.Background(), stmt)
+}
+
+// GetAllContext executes the provided statement and returns its results as
+// a slice of values of type T (see GetRow)
+func GetAllContext[T any](ctx context.Context, stmt AllStmt) ([]T, error) {
+	var results []T
+
+	if err := stmt.GetAllContext(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
@@ -0,0 +1,96 @@
+package sqlz
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestTypedResults(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	type user struct {
+		ID   int64  `db:"id"`
+		Name string `db:"name"`
+	}
+
+	dbz := New(db, "postgres")
+
+	mock.ExpectQuery(`SELECT \* FROM users WHERE id = \$1`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "Alice"))
+
+	u, err := GetRow[user](dbz.Select("*").From("users").Where(Eq("id", 1)))
+	if err != nil {
+		t.Fatalf("GetRow failed: %s", err)
+	}
+
+	if u != (user{1, "Alice"}) {
+		t.Errorf("Unexpected user %#v", u)
+	}
+
+	mock.ExpectQuery(`INSERT INTO users \(name\) VALUES \(\$1\) RETURNING \*`).
+		WithArgs("Bob").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(2, "Bob"))
+
+	inserted, err := GetRow[*user](dbz.InsertInto("users").Columns("name").Values("Bob").Returning("*"))
+	if err != nil {
+		t.Fatalf("GetRow failed for pointer type: %s", err)
+	}
+
+	if inserted == nil || *inserted != (user{2, "Bob"}) {
+		t.Errorf("Unexpected inserted user %#v", inserted)
+	}
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM users`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+	count, err := GetRow[int64](dbz.Select("COUNT(*)").From("users"))
+	if err != nil || count != 2 {
+		t.Errorf("Expected count of 2, got %d (%v)", count, err)
+	}
+
+	mock.ExpectQuery(`SELECT \* FROM users WHERE id = \$1`).
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+
+	missing, err := GetRow[*user](dbz.Select("*").From("users").Where(Eq("id", 3)))
+	if !errors.Is(err, sql.ErrNoRows) || missing != nil {
+		t.Errorf("Expected sql.ErrNoRows and a nil result, got %#v (%v)", missing, err)
+	}
+
+	mock.ExpectQuery(`UPDATE users SET name = \$1 RETURNING id`).
+		WithArgs("Carol").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+
+	ids, err := GetAll[int64](dbz.Update("users").Set("name", "Carol").Returning("id"))
+	if err != nil {
+		t.Fatalf("GetAll failed: %s", err)
+	}
+
+	if !reflect.DeepEqual(ids, []int64{1, 2}) {
+		t.Errorf("Unexpected ids %v", ids)
+	}
+
+	mock.ExpectQuery(`SELECT \* FROM users`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "Alice").AddRow(2, "Carol"))
+
+	users, err := GetAll[user](dbz.Select("*").From("users"))
+	if err != nil {
+		t.Fatalf("GetAll failed: %s", err)
+	}
+
+	if !reflect.DeepEqual(users, []user{{1, "Alice"}, {2, "Carol"}}) {
+		t.Errorf("Unexpected users %#v", users)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %s", err)
+	}
+}
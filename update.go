@@ -3,13 +3,22 @@ package sqlz
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"reflect"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 )
 
-// UpdateStmt represents an UPDATE statement
+// ErrNotMap is returned when executing an UPDATE statement whose SetCaseMap
+// method received a value that is not a map
+var ErrNotMap = errors.New("value is not a map")
+
+// UpdateStmt represents an UPDATE statement. Columns in Updates are always
+// rendered sorted by name, so the generated SQL and bindings are stable.
 type UpdateStmt struct {
 	*Statement
 	Table           string
@@ -19,7 +28,21 @@ type UpdateStmt struct {
 	execer          Ext
 	SelectStmt      *SelectStmt
 	SelectStmtAlias string
+	FromTables      []string
+	Joins           []JoinClause
 	MultipleValues  MultipleValues
+	CurrentOf       string
+	err             error
+	timeout         time.Duration
+	listeners       []WriteListener
+	guards          []Guard
+	scope           *Scope
+	filters         Filters
+	emptyIn         EmptyInPolicy
+	largeIn         LargeIn
+	audit           *Audit
+	codecs          Codecs
+	returnInto      interface{}
 }
 
 type MultipleValues struct {
@@ -32,10 +55,21 @@ type MultipleValues struct {
 // Update creates a new UpdateStmt object for
 // the specified table
 func (db *DB) Update(table string) *UpdateStmt {
+	defaults := db.defaults()
+
 	return &UpdateStmt{
 		Table:     table,
 		Updates:   make(map[string]interface{}),
-		execer:    db.DB,
+		Return:    defaults.returning(),
+		execer:    db.ext(),
+		listeners: defaults.WriteListeners,
+		guards:    defaults.Guards,
+		scope:     defaults.Scope,
+		filters:   defaults.Filters,
+		emptyIn:   defaults.EmptyIn,
+		largeIn:   defaults.LargeIn,
+		audit:     defaults.Audit,
+		codecs:    defaults.Codecs,
 		Statement: &Statement{db.ErrHandlers},
 	}
 }
@@ -43,14 +77,44 @@ func (db *DB) Update(table string) *UpdateStmt {
 // Update creates a new UpdateStmt object for
 // the specified table
 func (tx *Tx) Update(table string) *UpdateStmt {
+	defaults := tx.defaults()
+
 	return &UpdateStmt{
 		Table:     table,
 		Updates:   make(map[string]interface{}),
-		execer:    tx.Tx,
+		Return:    defaults.returning(),
+		execer:    tx.ext(),
+		listeners: defaults.WriteListeners,
+		guards:    defaults.Guards,
+		scope:     defaults.Scope,
+		filters:   defaults.Filters,
+		emptyIn:   defaults.EmptyIn,
+		largeIn:   defaults.LargeIn,
+		audit:     defaults.Audit,
+		codecs:    defaults.Codecs,
 		Statement: &Statement{tx.ErrHandlers},
 	}
 }
 
+// WithExecer sets the execer the statement is executed with, overriding the DB
+// or Tx it was created from. This allows a statement built once to be
+// executed against a different transaction or database (e.g. a replica).
+// Both *DB and *Tx objects can be provided.
+func (stmt *UpdateStmt) WithExecer(e Ext) *UpdateStmt {
+	stmt.execer = e
+	return stmt
+}
+
+// WithTimeout sets a timeout for executing the statement. Every execution
+// of the statement derives a context with this timeout (from the provided
+// context, if any), so the query is cancelled if it runs for too long. To
+// also enforce the timeout on the server inside a transaction, see
+// Tx.SetTimeout.
+func (stmt *UpdateStmt) WithTimeout(d time.Duration) *UpdateStmt {
+	stmt.timeout = d
+	return stmt
+}
+
 // Set receives the name of a column and a new value. Multiple calls to Set
 // can be chained together to modify multiple columns. Set can also be chained
 // with calls to SetMap
@@ -80,6 +144,133 @@ func (stmt *UpdateStmt) SetIf(col string, value interface{}, b bool) *UpdateStmt
 	return stmt
 }
 
+// SetCaseMap receives the name of a column, the name of a key column, and a
+// map (of any key and value types) from keys to new values, and sets the
+// column of every row to the value mapped from its key, i.e.
+// SET col = CASE keyCol WHEN ? THEN ? ... ELSE col END WHERE keyCol IN (...).
+// This allows batching heterogeneous updates in one statement. Keys are
+// rendered in sorted order, so the generated SQL is stable. If mapping is
+// not a map, ErrNotMap is returned when the statement is executed.
+func (stmt *UpdateStmt) SetCaseMap(col, keyCol string, mapping interface{}) *UpdateStmt {
+	val := reflect.ValueOf(mapping)
+	if val.Kind() != reflect.Map {
+		stmt.err = fmt.Errorf("%w: SetCaseMap received %T", ErrNotMap, mapping)
+		return stmt
+	}
+
+	keys := val.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return lessValue(keys[i], keys[j])
+	})
+
+	var (
+		caseSQL  strings.Builder
+		bindings = make([]interface{}, 0, 2*len(keys))
+		in       = make([]interface{}, len(keys))
+	)
+
+	caseSQL.WriteString("CASE " + keyCol)
+
+	for i, key := range keys {
+		caseSQL.WriteString(" WHEN ? THEN ?")
+		bindings = append(bindings, key.Interface(), val.MapIndex(key).Interface())
+		in[i] = key.Interface()
+	}
+
+	caseSQL.WriteString(" ELSE " + col + " END")
+
+	stmt.Updates[col] = Indirect(caseSQL.String(), bindings...)
+	stmt.Conditions = append(stmt.Conditions, In(keyCol, in...))
+
+	return stmt
+}
+
+// lessValue orders numeric and string values naturally, and other values
+// by their string representation
+func lessValue(a, b reflect.Value) bool {
+	if a.Kind() == reflect.Interface && b.Kind() == reflect.Interface {
+		a, b = a.Elem(), b.Elem()
+	}
+
+	switch {
+	case a.CanInt() && b.CanInt():
+		return a.Int() < b.Int()
+	case a.CanUint() && b.CanUint():
+		return a.Uint() < b.Uint()
+	case a.CanFloat() && b.CanFloat():
+		return a.Float() < b.Float()
+	case a.Kind() == reflect.String && b.Kind() == reflect.String:
+		return a.String() < b.String()
+	}
+
+	return fmt.Sprint(a.Interface()) < fmt.Sprint(b.Interface())
+}
+
+// ColumnFilter decides whether a column mapped from a struct field should
+// be included in a statement
+type ColumnFilter func(col string) bool
+
+// OnlyColumns creates a ColumnFilter including only the provided columns
+func OnlyColumns(cols ...string) ColumnFilter {
+	include := make(map[string]bool, len(cols))
+	for _, col := range cols {
+		include[col] = true
+	}
+
+	return func(col string) bool {
+		return include[col]
+	}
+}
+
+// ExceptColumns creates a ColumnFilter excluding the provided columns
+func ExceptColumns(cols ...string) ColumnFilter {
+	exclude := make(map[string]bool, len(cols))
+	for _, col := range cols {
+		exclude[col] = true
+	}
+
+	return func(col string) bool {
+		return !exclude[col]
+	}
+}
+
+// SetStruct receives a struct (or a pointer to one), and sets the columns
+// mapped from its fields (via `db` tags, like sqlx does) to their values.
+// If onlyNonZero is true, fields with zero values are skipped, which is
+// useful for partial updates. Column filters (e.g. OnlyColumns and
+// ExceptColumns) can be provided to restrict the columns that are set.
+// If the value is not a struct, the error is returned when the statement
+// is executed.
+func (stmt *UpdateStmt) SetStruct(v interface{}, onlyNonZero bool, filters ...ColumnFilter) *UpdateStmt {
+	val := reflect.ValueOf(v)
+	for val.Kind() == reflect.Ptr && !val.IsNil() {
+		val = val.Elem()
+	}
+
+	if val.Kind() != reflect.Struct {
+		stmt.err = fmt.Errorf("%w: SetStruct received %T", ErrNotStruct, v)
+		return stmt
+	}
+
+FIELDS:
+	for _, field := range structFields(val.Type()) {
+		for _, include := range filters {
+			if !include(field.Column) {
+				continue FIELDS
+			}
+		}
+
+		value := structValue(val, field.Index)
+		if onlyNonZero && (value == nil || reflect.ValueOf(value).IsZero()) {
+			continue
+		}
+
+		stmt.Updates[field.Column] = value
+	}
+
+	return stmt
+}
+
 // Where creates one or more WHERE conditions for the UPDATE statement.
 // If multiple conditions are passed, they are considered AND conditions.
 func (stmt *UpdateStmt) Where(conditions ...WhereCondition) *UpdateStmt {
@@ -87,6 +278,15 @@ func (stmt *UpdateStmt) Where(conditions ...WhereCondition) *UpdateStmt {
 	return stmt
 }
 
+// WhereCurrentOf makes the statement update the row the provided cursor
+// is currently positioned on (i.e. WHERE CURRENT OF cursor), for positioned
+// updates while scanning a cursor declared with DECLARE ... FOR UPDATE.
+// It cannot be combined with other WHERE conditions.
+func (stmt *UpdateStmt) WhereCurrentOf(cursor string) *UpdateStmt {
+	stmt.CurrentOf = cursor
+	return stmt
+}
+
 // Returning sets a RETURNING clause to receive values back from the
 // database once executing the UPDATE statement. Note that GetRow or
 // GetAll must be used to execute the query rather than Exec to get
@@ -96,6 +296,17 @@ func (stmt *UpdateStmt) Returning(cols ...string) *UpdateStmt {
 	return stmt
 }
 
+// ReturningStruct sets a RETURNING clause with the columns the provided
+// struct maps to (see ColumnsOf), replacing any columns set previously,
+// and makes Exec load the returned values into it. A pointer to a slice
+// of structs can be provided if the UPDATE statement affects multiple rows.
+func (stmt *UpdateStmt) ReturningStruct(into interface{}) *UpdateStmt {
+	stmt.Return = ColumnsOf(into, "")
+	stmt.returnInto = into
+
+	return stmt
+}
+
 // FromSelect allows creating update statements that takes values from the
 // result of a select statement.
 func (stmt *UpdateStmt) FromSelect(selStmt *SelectStmt, alias string) *UpdateStmt {
@@ -105,11 +316,72 @@ func (stmt *UpdateStmt) FromSelect(selStmt *SelectStmt, alias string) *UpdateStm
 	return stmt
 }
 
+// From adds tables to a FROM clause, for PostgreSQL-style UPDATE
+// statements that take values from other tables, i.e.
+// UPDATE t SET ... FROM other WHERE ... It cannot be combined with
+// FromSelect or FromValues.
+func (stmt *UpdateStmt) From(tables ...string) *UpdateStmt {
+	stmt.FromTables = append(stmt.FromTables, tables...)
+	return stmt
+}
+
+// Join adds a JOIN clause of the supplied type on the supplied table, with
+// the provided conditions. If the statement has FROM tables, joins follow
+// them (PostgreSQL-style, UPDATE t SET ... FROM a JOIN b ON ...); otherwise
+// they follow the updated table, for MySQL-style multiple-table UPDATE
+// statements, i.e. UPDATE t JOIN other ON ... SET ...
+func (stmt *UpdateStmt) Join(joinType JoinType, table string, conds ...WhereCondition) *UpdateStmt {
+	stmt.Joins = append(stmt.Joins, JoinClause{
+		Type:       joinType,
+		Table:      table,
+		Conditions: append([]WhereCondition{}, conds...),
+	})
+
+	return stmt
+}
+
+// InnerJoin is a wrapper of Join for creating an INNER JOIN on a table
+// with the provided conditions
+func (stmt *UpdateStmt) InnerJoin(table string, conds ...WhereCondition) *UpdateStmt {
+	return stmt.Join(InnerJoin, table, conds...)
+}
+
+// LeftJoin is a wrapper of Join for creating a LEFT JOIN on a table with
+// the provided conditions
+func (stmt *UpdateStmt) LeftJoin(table string, conds ...WhereCondition) *UpdateStmt {
+	return stmt.Join(LeftJoin, table, conds...)
+}
+
+// conditionEnv returns the environment the statement's conditions are
+// rendered in
+func (stmt *UpdateStmt) conditionEnv() conditionEnv {
+	return conditionEnv{dialectOf(stmt.execer), stmt.filters, stmt.emptyIn, stmt.largeIn}
+}
+
+// joinsSQL generates the SQL of the statement's joins
+func (stmt *UpdateStmt) joinsSQL() (clauses []string, bindings []interface{}) {
+	for _, join := range stmt.Joins {
+		joinSQL, joinBindings := join.toSQL(stmt.conditionEnv())
+		clauses = append(clauses, joinSQL)
+		bindings = append(bindings, joinBindings...)
+	}
+
+	return clauses, bindings
+}
+
 // ToSQL generates the UPDATE statement's SQL and returns a list of
 // bindings. It is used internally by Exec, GetRow and GetAll, but is
 // exported if you wish to use it directly.
 func (stmt *UpdateStmt) ToSQL(rebind bool) (asSQL string, bindings []interface{}) {
-	var clauses = []string{fmt.Sprintf("UPDATE %s", stmt.Table)}
+	var clauses = []string{"UPDATE " + stmt.Table}
+
+	if len(stmt.FromTables) == 0 {
+		// MySQL-style joins precede the SET clause, so their bindings
+		// come first
+		joinClauses, joinBindings := stmt.joinsSQL()
+		clauses = append(clauses, joinClauses...)
+		bindings = append(bindings, joinBindings...)
+	}
 
 	var updates []string
 
@@ -160,12 +432,20 @@ func (stmt *UpdateStmt) ToSQL(rebind bool) (asSQL string, bindings []interface{}
 		addClauses, addBindings := stmt.addUpdateFrom()
 		clauses = append(clauses, addClauses...)
 		bindings = append(bindings, addBindings...)
+	} else if len(stmt.FromTables) > 0 {
+		clauses = append(clauses, "FROM "+strings.Join(stmt.FromTables, ", "))
+
+		joinClauses, joinBindings := stmt.joinsSQL()
+		clauses = append(clauses, joinClauses...)
+		bindings = append(bindings, joinBindings...)
 	}
 
-	if len(stmt.Conditions) > 0 {
-		whereClause, whereBindings := parseConditions(stmt.Conditions)
+	if stmt.CurrentOf != "" {
+		clauses = append(clauses, "WHERE CURRENT OF "+stmt.CurrentOf)
+	} else if len(stmt.Conditions) > 0 {
+		whereClause, whereBindings := parseConditionsFor(stmt.conditionEnv(), stmt.Conditions)
 		bindings = append(bindings, whereBindings...)
-		clauses = append(clauses, fmt.Sprintf("WHERE %s", whereClause))
+		clauses = append(clauses, "WHERE "+whereClause)
 	}
 
 	if len(stmt.Return) > 0 {
@@ -175,11 +455,7 @@ func (stmt *UpdateStmt) ToSQL(rebind bool) (asSQL string, bindings []interface{}
 	asSQL = strings.Join(clauses, " ")
 
 	if rebind {
-		if db, ok := stmt.execer.(*sqlx.DB); ok {
-			asSQL = db.Rebind(asSQL)
-		} else if tx, ok := stmt.execer.(*sqlx.Tx); ok {
-			asSQL = tx.Rebind(asSQL)
-		}
+		asSQL = rebindFor(stmt.execer, asSQL)
 	}
 
 	return asSQL, bindings
@@ -206,9 +482,9 @@ func (stmt *UpdateStmt) addUpdateFrom() (
 	))
 
 	if len(stmt.MultipleValues.Where) > 0 {
-		whereClause, whereBindings := parseConditions(stmt.MultipleValues.Where)
+		whereClause, whereBindings := parseConditionsFor(stmt.conditionEnv(), stmt.MultipleValues.Where)
 		bindings = append(bindings, whereBindings...)
-		clauses = append(clauses, fmt.Sprintf("WHERE %s", whereClause))
+		clauses = append(clauses, "WHERE "+whereClause)
 	}
 
 	return clauses, bindings
@@ -217,21 +493,39 @@ func (stmt *UpdateStmt) addUpdateFrom() (
 // Exec executes the UPDATE statement, returning the standard
 // sql.Result struct and an error if the query failed.
 func (stmt *UpdateStmt) Exec() (res sql.Result, err error) {
-	asSQL, bindings := stmt.ToSQL(true)
-
-	res, err = stmt.execer.Exec(asSQL, bindings...)
-	stmt.HandleError(err)
-
-	return res, err
+	return stmt.ExecContext(context.Background())
 }
 
 // ExecContext executes the UPDATE statement, returning the standard
 // sql.Result struct and an error if the query failed.
 func (stmt *UpdateStmt) ExecContext(ctx context.Context) (res sql.Result, err error) {
-	asSQL, bindings := stmt.ToSQL(true)
+	if stmt.returnInto != nil {
+		return loadReturning(ctx, stmt, stmt.returnInto)
+	}
+
+	ctx, cancel := contextWithTimeout(ctx, stmt.timeout)
+	defer cancel()
+
+	if stmt.err != nil {
+		stmt.HandleError(stmt.err)
+		return nil, stmt.err
+	}
+
+	if err := stmt.guard(ctx); err != nil {
+		return nil, err
+	}
+
+	prepared, err := stmt.prepared(ctx)
+	if err != nil {
+		stmt.HandleError(err)
+		return nil, err
+	}
+
+	asSQL, bindings := prepared.ToSQL(true)
 
 	res, err = stmt.execer.ExecContext(ctx, asSQL, bindings...)
 	stmt.HandleError(err)
+	notifyWrite(ctx, stmt.listeners, stmt, stmt.writtenTables(), err)
 
 	return res, err
 }
@@ -242,12 +536,7 @@ func (stmt *UpdateStmt) ExecContext(ctx context.Context) (res sql.Result, err er
 // only one column is returned, or a struct if multiple columns
 // are returned)
 func (stmt *UpdateStmt) GetRow(into interface{}) error {
-	asSQL, bindings := stmt.ToSQL(true)
-
-	err := sqlx.Get(stmt.execer, into, asSQL, bindings...)
-	stmt.HandleError(err)
-
-	return err
+	return stmt.GetRowContext(context.Background(), into)
 }
 
 // GetRowContext executes an UPDATE statement with a RETURNING clause
@@ -256,10 +545,29 @@ func (stmt *UpdateStmt) GetRow(into interface{}) error {
 // only one column is returned, or a struct if multiple columns
 // are returned)
 func (stmt *UpdateStmt) GetRowContext(ctx context.Context, into interface{}) error {
-	asSQL, bindings := stmt.ToSQL(true)
+	ctx, cancel := contextWithTimeout(ctx, stmt.timeout)
+	defer cancel()
+
+	if stmt.err != nil {
+		stmt.HandleError(stmt.err)
+		return stmt.err
+	}
+
+	if err := stmt.guard(ctx); err != nil {
+		return err
+	}
 
-	err := sqlx.GetContext(ctx, stmt.execer, into, asSQL, bindings...)
+	prepared, err := stmt.prepared(ctx)
+	if err != nil {
+		stmt.HandleError(err)
+		return err
+	}
+
+	asSQL, bindings := prepared.ToSQL(true)
+
+	err = sqlx.GetContext(ctx, stmt.execer, into, asSQL, bindings...)
 	stmt.HandleError(err)
+	notifyWrite(ctx, stmt.listeners, stmt, stmt.writtenTables(), err)
 
 	return err
 }
@@ -268,26 +576,94 @@ func (stmt *UpdateStmt) GetRowContext(ctx context.Context, into interface{}) err
 // expected to return multiple rows, and loads the result into
 // the provided slice variable
 func (stmt *UpdateStmt) GetAll(into interface{}) error {
-	asSQL, bindings := stmt.ToSQL(true)
-
-	err := sqlx.Select(stmt.execer, into, asSQL, bindings...)
-	stmt.HandleError(err)
-
-	return err
+	return stmt.GetAllContext(context.Background(), into)
 }
 
 // GetAllContext executes an UPDATE statement with a RETURNING clause
 // expected to return multiple rows, and loads the result into
 // the provided slice variable
 func (stmt *UpdateStmt) GetAllContext(ctx context.Context, into interface{}) error {
-	asSQL, bindings := stmt.ToSQL(true)
+	ctx, cancel := contextWithTimeout(ctx, stmt.timeout)
+	defer cancel()
+
+	if stmt.err != nil {
+		stmt.HandleError(stmt.err)
+		return stmt.err
+	}
 
-	err := sqlx.SelectContext(ctx, stmt.execer, into, asSQL, bindings...)
+	if err := stmt.guard(ctx); err != nil {
+		return err
+	}
+
+	prepared, err := stmt.prepared(ctx)
+	if err != nil {
+		stmt.HandleError(err)
+		return err
+	}
+
+	asSQL, bindings := prepared.ToSQL(true)
+
+	err = sqlx.SelectContext(ctx, stmt.execer, into, asSQL, bindings...)
 	stmt.HandleError(err)
+	notifyWrite(ctx, stmt.listeners, stmt, stmt.writtenTables(), err)
 
 	return err
 }
 
+// Rows executes an UPDATE statement with a RETURNING clause and returns a
+// Rows object to use for iteration, so that large sets of returned rows
+// can be streamed rather than loaded into a slice. It is the caller's
+// responsibility to close the cursor with Close(), which also releases the
+// statement's timeout (see WithTimeout).
+func (stmt *UpdateStmt) Rows() (rows *Rows, err error) {
+	return stmt.RowsContext(context.Background())
+}
+
+// RowsContext is like Rows, but uses the provided context
+func (stmt *UpdateStmt) RowsContext(ctx context.Context) (rows *Rows, err error) {
+	if stmt.err != nil {
+		stmt.HandleError(stmt.err)
+		return nil, stmt.err
+	}
+
+	if err := stmt.guard(ctx); err != nil {
+		return nil, err
+	}
+
+	prepared, err := stmt.prepared(ctx)
+	if err != nil {
+		stmt.HandleError(err)
+		return nil, err
+	}
+
+	asSQL, bindings := prepared.ToSQL(true)
+
+	rows, err = queryRows(ctx, stmt.timeout, stmt.execer, asSQL, bindings)
+	stmt.HandleError(err)
+	notifyWrite(ctx, stmt.listeners, stmt, stmt.writtenTables(), err)
+
+	return rows, err
+}
+
+// GetAllAsRows executes an UPDATE statement with a RETURNING clause and
+// returns an sqlx.Rows object to use for iteration. It is the caller's
+// responsibility to close the cursor with Close(). The context derived for
+// the statement's timeout (see WithTimeout) is only released once the
+// timeout expires; use Rows to release it when the cursor is closed.
+func (stmt *UpdateStmt) GetAllAsRows() (rows *sqlx.Rows, err error) {
+	return stmt.GetAllAsRowsContext(context.Background())
+}
+
+// GetAllAsRowsContext is like GetAllAsRows, but uses the provided context
+func (stmt *UpdateStmt) GetAllAsRowsContext(ctx context.Context) (rows *sqlx.Rows, err error) {
+	cursor, err := stmt.RowsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return cursor.Rows, nil
+}
+
 // FromValues receives an array of interfaces in order to insert multiple records using the same insert statement
 func (stmt *UpdateStmt) FromValues(mv MultipleValues) *UpdateStmt {
 	stmt.MultipleValues.Values = append(stmt.MultipleValues.Values, mv.Values...)
@@ -1,12 +1,51 @@
 package sqlz
 
 import (
+	"reflect"
 	"testing"
 )
 
+type updateProfile struct {
+	ID       int64  `db:"id"`
+	Name     string `db:"name"`
+	Email    string `db:"email"`
+	Age      int    `db:"age"`
+	Password string `db:"-"`
+}
+
 func TestUpdate(t *testing.T) {
 	runTests(t, func(dbz *DB) []test {
+		profile := updateProfile{ID: 1, Name: "Alice", Email: "", Age: 30, Password: "secret"}
+
 		return []test{
+			{
+				"positioned update",
+				dbz.Update("queue").Set("status", "done").WhereCurrentOf("queue_cursor"),
+				"UPDATE queue SET status = ? WHERE CURRENT OF queue_cursor",
+				[]interface{}{"done"},
+			},
+
+			{
+				"update from struct",
+				dbz.Update("users").SetStruct(profile, false, ExceptColumns("id")).Where(Eq("id", profile.ID)),
+				"UPDATE users SET age = ?, email = ?, name = ? WHERE id = ?",
+				[]interface{}{30, "", "Alice", int64(1)},
+			},
+
+			{
+				"update from struct with only non-zero fields",
+				dbz.Update("users").SetStruct(&profile, true, ExceptColumns("id")).Where(Eq("id", profile.ID)),
+				"UPDATE users SET age = ?, name = ? WHERE id = ?",
+				[]interface{}{30, "Alice", int64(1)},
+			},
+
+			{
+				"update from struct with a whitelist",
+				dbz.Update("users").SetStruct(profile, false, OnlyColumns("email")).Where(Eq("id", profile.ID)),
+				"UPDATE users SET email = ? WHERE id = ?",
+				[]interface{}{"", int64(1)},
+			},
+
 			{
 				"simple update",
 				dbz.Update("table").Set("something", 3).Set("something-else", true),
@@ -73,6 +112,74 @@ func TestUpdate(t *testing.T) {
 				"UPDATE table SET table.name = values.name, table.age = values.age FROM (VALUES (?, ?), (?, ?)) AS values(name, age) WHERE values.name = table.name",
 				[]interface{}{"Tom", 20, "John", 3},
 			},
+			{
+				"update from tables",
+				dbz.Update("accounts").
+					Set("balance", Indirect("accounts.balance + t.amount")).
+					From("transfers t").
+					Where(Eq("t.account_id", Indirect("accounts.id")), Eq("t.status", "pending")),
+				"UPDATE accounts SET balance = accounts.balance + t.amount FROM transfers t WHERE t.account_id = accounts.id AND t.status = ?",
+				[]interface{}{"pending"},
+			},
+			{
+				"update from tables with joins",
+				dbz.Update("orders o").
+					Set("status", "flagged").
+					From("customers c").
+					InnerJoin("regions r", Eq("r.id", Indirect("c.region_id")), Eq("r.code", "EU")).
+					Where(Eq("c.id", Indirect("o.customer_id"))),
+				"UPDATE orders o SET status = ? FROM customers c INNER JOIN regions r ON r.id = c.region_id AND r.code = ? WHERE c.id = o.customer_id",
+				[]interface{}{"flagged", "EU"},
+			},
+			{
+				"mysql-style update with joins",
+				dbz.Update("orders o").
+					InnerJoin("customers c", Eq("c.id", Indirect("o.customer_id")), Eq("c.tier", "gold")).
+					Set("o.discount", 10).
+					Where(Gt("o.total", 100)),
+				"UPDATE orders o INNER JOIN customers c ON c.id = o.customer_id AND c.tier = ? SET o.discount = ? WHERE o.total > ?",
+				[]interface{}{"gold", 10, 100},
+			},
+			{
+				"update with case map",
+				dbz.Update("products").
+					SetCaseMap("price", "id", map[int]float64{10: 4.5, 2: 3, 7: 12}).
+					Set("updated_at", Indirect("NOW()")),
+				"UPDATE products SET price = CASE id WHEN ? THEN ? WHEN ? THEN ? WHEN ? THEN ? ELSE price END, updated_at = NOW() WHERE id IN (?, ?, ?)",
+				[]interface{}{2, 3.0, 7, 12.0, 10, 4.5, 2, 7, 10},
+			},
+			{
+				"update with case map of strings",
+				dbz.Update("users").
+					SetCaseMap("role", "email", map[string]string{"b@x.io": "admin", "a@x.io": "user"}).
+					Where(Eq("active", true)),
+				"UPDATE users SET role = CASE email WHEN ? THEN ? WHEN ? THEN ? ELSE role END WHERE email IN (?, ?) AND active = ?",
+				[]interface{}{"a@x.io", "user", "b@x.io", "admin", "a@x.io", "b@x.io", true},
+			},
 		}
 	})
 }
+
+// TestUpdateDeterministic verifies that UPDATE statements render columns
+// and bindings in the same order every time, regardless of map iteration
+// order
+func TestUpdateDeterministic(t *testing.T) {
+	dbz := New(nil, "postgres")
+
+	updates := map[string]interface{}{
+		"a": 1, "b": 2, "c": 3, "d": 4, "e": 5, "f": 6, "g": 7, "h": 8,
+	}
+
+	expectedSQL, expectedBindings := dbz.Update("table").SetMap(updates).ToSQL(false)
+
+	for i := 0; i < 50; i++ {
+		asSQL, bindings := dbz.Update("table").SetMap(updates).ToSQL(false)
+		if asSQL != expectedSQL {
+			t.Fatalf("SQL changed between runs: %q != %q", asSQL, expectedSQL)
+		}
+
+		if !reflect.DeepEqual(bindings, expectedBindings) {
+			t.Fatalf("Bindings changed between runs: %v != %v", bindings, expectedBindings)
+		}
+	}
+}
@@ -0,0 +1,300 @@
+package sqlz
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// maxBindings is the maximum number of bindings used in a single statement
+// generated by the library's batching helpers, which is the limit imposed
+// by PostgreSQL
+const maxBindings = 65535
+
+// DefaultUpsertBatchSize is the maximum number of rows inserted by every
+// statement generated by UpsertAll. The actual number of rows may be lower
+// if the rows have too many columns to fit the PostgreSQL limit on the
+// number of bindings in a statement.
+var DefaultUpsertBatchSize = 1000
+
+// UpsertAll inserts a slice of structs (or pointers to structs) into the
+// provided table, updating existing rows when they conflict on the provided
+// key columns (i.e. INSERT ... ON CONFLICT (keyCols) DO UPDATE SET ...).
+// Columns are mapped from struct fields using `db` tags, like sqlx does.
+// If no update columns are provided, all non-key columns are updated with
+// the values of the conflicting rows (col = EXCLUDED.col).
+//
+// Rows are inserted in batches of DefaultUpsertBatchSize rows, all inside
+// one transaction, and the total number of affected rows is returned.
+func (db *DB) UpsertAll(table string, rows interface{}, keyCols, updateCols []string) (affected int64, err error) {
+	err = db.Transactional(func(tx *Tx) error {
+		affected, err = tx.UpsertAll(table, rows, keyCols, updateCols)
+		return err
+	})
+
+	return affected, err
+}
+
+// UpsertAll inserts a slice of structs into the provided table, updating
+// existing rows on conflict. See DB.UpsertAll for more information.
+func (tx *Tx) UpsertAll(table string, rows interface{}, keyCols, updateCols []string) (affected int64, err error) {
+	stmts, err := upsertStmts(tx.InsertInto, table, rows, keyCols, updateCols)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, stmt := range stmts {
+		res, err := stmt.Exec()
+		if err != nil {
+			return affected, err
+		}
+
+		n, err := res.RowsAffected()
+		if err != nil {
+			return affected, err
+		}
+
+		affected += n
+	}
+
+	return affected, nil
+}
+
+// upsertStmts generates the batched INSERT statements for UpsertAll
+func upsertStmts(
+	insertInto func(table string) *InsertStmt,
+	table string,
+	rows interface{},
+	keyCols, updateCols []string,
+) (stmts []*InsertStmt, err error) {
+	cols, values, err := structRows(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(keyCols) == 0 {
+		return nil, fmt.Errorf("%w: upsert into %s requires key columns", ErrInvalidStatement, table)
+	}
+
+	if len(updateCols) == 0 {
+		isKey := make(map[string]bool, len(keyCols))
+		for _, col := range keyCols {
+			isKey[col] = true
+		}
+
+		for _, col := range cols {
+			if !isKey[col] {
+				updateCols = append(updateCols, col)
+			}
+		}
+	}
+
+	batchSize := batchSizeFor(DefaultUpsertBatchSize, len(cols))
+
+	for start := 0; start < len(values); start += batchSize {
+		end := start + batchSize
+		if end > len(values) {
+			end = len(values)
+		}
+
+		conflict := OnConflict(keyCols...)
+		if len(updateCols) > 0 {
+			conflict.DoUpdate().SetExcluded(updateCols...)
+		} else {
+			conflict.DoNothing()
+		}
+
+		stmts = append(stmts, insertInto(table).
+			Columns(cols...).
+			ValueMultiple(values[start:end]).
+			OnConflict(conflict))
+	}
+
+	return stmts, nil
+}
+
+// ExecOrUpdate executes the INSERT statement, and if it fails due to a
+// unique violation (see IsUniqueViolation), executes the provided UPDATE
+// statement instead. This emulates an upsert on databases (or versions)
+// that have no native support for one. Both statements are executed in the
+// same transaction: if the INSERT statement was created from (or set to
+// execute with) a DB, a transaction is started for them; if it was created
+// from a Tx, the INSERT is executed inside a savepoint, so the failed
+// statement does not abort the transaction. Write listeners are notified of
+// the statement that was actually executed.
+func (stmt *InsertStmt) ExecOrUpdate(update *UpdateStmt) (res sql.Result, err error) {
+	return stmt.ExecOrUpdateContext(context.Background(), update)
+}
+
+// ExecOrUpdateContext is like ExecOrUpdate, but uses the provided context
+func (stmt *InsertStmt) ExecOrUpdateContext(ctx context.Context, update *UpdateStmt) (res sql.Result, err error) {
+	if update.err != nil {
+		return nil, update.err
+	}
+
+	if err := stmt.guard(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := update.guard(ctx); err != nil {
+		return nil, err
+	}
+
+	insert, err := stmt.prepared(ctx)
+	if err != nil {
+		stmt.HandleError(err)
+		return nil, err
+	}
+
+	preparedUpdate, err := update.prepared(ctx)
+	if err != nil {
+		stmt.HandleError(err)
+		return nil, err
+	}
+
+	// whether the UPDATE statement was executed instead of the INSERT, so
+	// that write listeners are notified of the right one
+	var updated bool
+
+	run := func(tx *Tx) error {
+		updated = false
+
+		err := tx.withSavepoint(ctx, func() error {
+			asSQL, bindings := insert.ToSQL(false)
+
+			execer := tx.ext()
+			res, err = execer.ExecContext(ctx, rebindFor(execer, asSQL), bindings...)
+
+			return err
+		})
+		if err == nil || !IsUniqueViolation(err) {
+			return err
+		}
+
+		asSQL, bindings := preparedUpdate.ToSQL(false)
+
+		execer := tx.ext()
+		res, err = execer.ExecContext(ctx, rebindFor(execer, asSQL), bindings...)
+		updated = err == nil
+
+		return err
+	}
+
+	err = inTransaction(ctx, stmt.execer, run)
+	if errors.Is(err, errNoTransaction) {
+		return nil, fmt.Errorf("ExecOrUpdate %w", err)
+	}
+
+	stmt.HandleError(err)
+
+	if updated {
+		notifyWrite(ctx, update.listeners, update, update.writtenTables(), err)
+	} else {
+		notifyWrite(ctx, stmt.listeners, stmt, stmt.writtenTables(), err)
+	}
+
+	return res, err
+}
+
+// errNoTransaction is returned by inTransaction for execers that are
+// neither a DB nor a Tx
+var errNoTransaction = errors.New("requires a DB or Tx execer")
+
+// inTransaction runs the provided function in a transaction on the
+// provided execer: if it is a DB, a transaction is started (and committed
+// unless the function fails); if it is a Tx, the function runs inside it.
+// For execers of statements created from a DB or Tx, that DB or Tx is
+// used, with all of its defaults and error handlers. For raw sqlx execers,
+// query hooks and the dialect of the execer are preserved.
+func inTransaction(ctx context.Context, execer Ext, run func(tx *Tx) error) error {
+	orig, hooks := execer, []QueryHook(nil)
+	if hooked, ok := execer.(*hookedExt); ok {
+		execer, hooks = hooked.Ext, hooked.hooks
+	}
+
+	dialect := DialectGeneric
+	if dialected, ok := execer.(*dialectExt); ok {
+		execer, dialect = dialected.Ext, dialected.dialect
+	}
+
+	switch e := execer.(type) {
+	case *dbExt:
+		return e.db.TransactionalContext(ctx, nil, run)
+	case *txExt:
+		return run(e.tx)
+	case *sqlx.DB:
+		return (&DB{DB: e, Defaults: Defaults{QueryHooks: hooks, Dialect: dialect}}).TransactionalContext(ctx, nil, run)
+	case *DB:
+		return e.TransactionalContext(ctx, nil, run)
+	case *sqlx.Tx:
+		return run(&Tx{Tx: e, Defaults: Defaults{QueryHooks: hooks, Dialect: dialect}})
+	case *Tx:
+		return run(e)
+	default:
+		return fmt.Errorf("%w, got %T", errNoTransaction, orig)
+	}
+}
+
+// InsertOrGet creates a statement inserting a row into the provided table
+// unless a row with the same values for the provided key columns already
+// exists, returning the provided columns (by default "id") of the new or
+// existing row:
+//
+//	WITH ins AS (
+//		INSERT INTO t (...) VALUES (...) ON CONFLICT (keyCols) DO NOTHING RETURNING id
+//	) SELECT id FROM ins UNION SELECT id FROM t WHERE key = ?
+//
+// The key columns must have a unique constraint, and their values must be
+// included in values. Execute the statement with GetRow, e.g.:
+//
+//	var id int64
+//	err := db.InsertOrGet("tags", []string{"name"}, map[string]interface{}{
+//		"name": "go",
+//	}).GetRow(&id)
+//
+// Note that if a conflicting row is inserted by a concurrent transaction
+// that has not yet committed, no row is returned (sql.ErrNoRows), and the
+// statement may need to be retried. This is only supported by PostgreSQL.
+func (db *DB) InsertOrGet(table string, keyCols []string, values map[string]interface{}, returnCols ...string) *WithStmt {
+	ins, sel := insertOrGetStmts(db.InsertInto(table), db.Select, table, keyCols, values, returnCols)
+	return db.With(ins, "ins").Then(sel)
+}
+
+// InsertOrGet creates a statement inserting a row into the provided table
+// unless it already exists, returning the new or existing row's columns.
+// See DB.InsertOrGet for more information.
+func (tx *Tx) InsertOrGet(table string, keyCols []string, values map[string]interface{}, returnCols ...string) *WithStmt {
+	ins, sel := insertOrGetStmts(tx.InsertInto(table), tx.Select, table, keyCols, values, returnCols)
+	return tx.With(ins, "ins").Then(sel)
+}
+
+// insertOrGetStmts generates the auxiliary INSERT statement and the main
+// SELECT statement of InsertOrGet
+func insertOrGetStmts(
+	ins *InsertStmt,
+	selectFn func(cols ...string) *SelectStmt,
+	table string,
+	keyCols []string,
+	values map[string]interface{},
+	returnCols []string,
+) (*InsertStmt, *SelectStmt) {
+	if len(returnCols) == 0 {
+		returnCols = []string{"id"}
+	}
+
+	ins = ins.ValueMap(values).OnConflict(OnConflict(keyCols...).DoNothing())
+	// replace default RETURNING columns, if any
+	ins.Return = append([]string(nil), returnCols...)
+
+	keyConds := make([]WhereCondition, len(keyCols))
+	for i, col := range keyCols {
+		keyConds[i] = Eq(col, values[col])
+	}
+
+	existing := selectFn(returnCols...).From(table).Where(keyConds...)
+
+	return ins, selectFn(returnCols...).From("ins").Union(existing)
+}
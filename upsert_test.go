@@ -0,0 +1,305 @@
+package sqlz
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+type upsertBase struct {
+	ID int64 `db:"id"`
+}
+
+type upsertRow struct {
+	upsertBase
+	Name    string `db:"name"`
+	Email   string
+	Ignored string `db:"-"`
+	secret  string //nolint: unused
+}
+
+func TestUpsertStmts(t *testing.T) {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	dbz := New(db, "sqlmock")
+
+	rows := []upsertRow{
+		{upsertBase{1}, "Alice", "alice@example.com", "", ""},
+		{upsertBase{2}, "Bob", "bob@example.com", "", ""},
+		{upsertBase{3}, "Carol", "carol@example.com", "", ""},
+	}
+
+	defer func(size int) { DefaultUpsertBatchSize = size }(DefaultUpsertBatchSize)
+	DefaultUpsertBatchSize = 2
+
+	stmts, err := upsertStmts(dbz.InsertInto, "users", rows, []string{"id"}, nil)
+	if err != nil {
+		t.Fatalf("Failed generating statements: %s", err)
+	}
+
+	if len(stmts) != 2 {
+		t.Fatalf("Expected 2 statements, got %d", len(stmts))
+	}
+
+	runTests(t, func(dbz *DB) []test {
+		return []test{
+			{
+				"first upsert batch",
+				stmts[0],
+				"INSERT INTO users (id, name, email) VALUES (?, ?, ?), (?, ?, ?) ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name, email = EXCLUDED.email",
+				[]interface{}{int64(1), "Alice", "alice@example.com", int64(2), "Bob", "bob@example.com"},
+			},
+			{
+				"second upsert batch",
+				stmts[1],
+				"INSERT INTO users (id, name, email) VALUES (?, ?, ?) ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name, email = EXCLUDED.email",
+				[]interface{}{int64(3), "Carol", "carol@example.com"},
+			},
+		}
+	})
+
+	stmts, err = upsertStmts(dbz.InsertInto, "users", &rows[0], []string{"id"}, []string{"name"})
+	if err != nil {
+		t.Fatalf("Failed generating statements: %s", err)
+	}
+
+	runTests(t, func(dbz *DB) []test {
+		return []test{
+			{
+				"upsert with specific update columns",
+				stmts[0],
+				"INSERT INTO users (id, name, email) VALUES (?, ?, ?) ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name",
+				[]interface{}{int64(1), "Alice", "alice@example.com"},
+			},
+		}
+	})
+
+	if _, err = upsertStmts(dbz.InsertInto, "users", []int{1, 2}, []string{"id"}, nil); err == nil {
+		t.Errorf("Expected an error when upserting non-structs")
+	}
+}
+
+func TestUpsertAll(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO users").WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	affected, err := New(db, "sqlmock").UpsertAll("users", []upsertRow{
+		{upsertBase{1}, "Alice", "alice@example.com", "", ""},
+		{upsertBase{2}, "Bob", "bob@example.com", "", ""},
+	}, []string{"id"}, nil)
+	if err != nil {
+		t.Fatalf("Failed upserting: %s", err)
+	}
+
+	if affected != 2 {
+		t.Errorf("Expected 2 affected rows, got %d", affected)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %s", err)
+	}
+}
+
+func TestExecOrUpdate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	var events []WriteEvent
+
+	dbz := New(db, "postgres")
+	dbz.Defaults.WriteListeners = []WriteListener{
+		func(_ context.Context, event WriteEvent) {
+			events = append(events, event)
+		},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`SAVEPOINT sqlz_savepoint_\d+`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO users \(id, name\) VALUES \(\$1, \$2\)`).
+		WithArgs(1, "Alice").
+		WillReturnError(&pgxError{"23505"})
+	mock.ExpectExec(`ROLLBACK TO SAVEPOINT sqlz_savepoint_\d+`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`UPDATE users SET name = \$1 WHERE id = \$2`).
+		WithArgs("Alice", 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	res, err := dbz.InsertInto("users").
+		Columns("id", "name").
+		Values(1, "Alice").
+		ExecOrUpdate(dbz.Update("users").Set("name", "Alice").Where(Eq("id", 1)))
+	if err != nil {
+		t.Fatalf("ExecOrUpdate failed: %s", err)
+	}
+
+	if affected, _ := res.RowsAffected(); affected != 1 {
+		t.Errorf("Expected 1 affected row, got %d", affected)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`SAVEPOINT sqlz_savepoint_\d+`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO users \(id, name\) VALUES \(\$1, \$2\)`).
+		WithArgs(2, "Bob").
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectExec(`RELEASE SAVEPOINT sqlz_savepoint_\d+`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	_, err = dbz.InsertInto("users").
+		Columns("id", "name").
+		Values(2, "Bob").
+		ExecOrUpdate(dbz.Update("users").Set("name", "Bob").Where(Eq("id", 2)))
+	if err != nil {
+		t.Fatalf("ExecOrUpdate failed: %s", err)
+	}
+
+	// listeners are notified of the statement that was actually executed
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %d", len(events))
+	}
+
+	if events[0].Kind != KindUpdate || !reflect.DeepEqual(events[0].Tables, []string{"users"}) {
+		t.Errorf("Unexpected update event: %+v", events[0])
+	}
+
+	if events[1].Kind != KindInsert || !reflect.DeepEqual(events[1].Tables, []string{"users"}) {
+		t.Errorf("Unexpected insert event: %+v", events[1])
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %s", err)
+	}
+}
+
+func TestExecOrUpdateTransaction(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	var savepoints []string
+
+	dbz := New(db, "postgres")
+	dbz.OnQuery(func(_ context.Context, event QueryEvent) {
+		if strings.HasPrefix(event.SQL, "SAVEPOINT ") {
+			savepoints = append(savepoints, event.SQL)
+		}
+	})
+
+	insert := func(execer interface {
+		InsertInto(string) *InsertStmt
+		Update(string) *UpdateStmt
+	}) error {
+		_, err := execer.InsertInto("users").
+			Columns("id", "name").
+			Values(1, "Alice").
+			ExecOrUpdate(execer.Update("users").Set("name", "Alice").Where(Eq("id", 1)))
+		return err
+	}
+
+	// transactions started for statements created from a DB get the DB's
+	// defaults, including its query hooks
+	mock.ExpectBegin()
+	mock.ExpectExec(`SAVEPOINT sqlz_savepoint_\d+`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO users \(id, name\) VALUES \(\$1, \$2\)`).
+		WithArgs(1, "Alice").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`RELEASE SAVEPOINT sqlz_savepoint_\d+`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	if err := insert(dbz); err != nil {
+		t.Fatalf("ExecOrUpdate failed: %s", err)
+	}
+
+	if len(savepoints) != 1 {
+		t.Errorf("Expected query hooks to be called in the transaction, got %v", savepoints)
+	}
+
+	// savepoints created for statements inside a savepoint of the same
+	// transaction must not reuse its name
+	mock.ExpectBegin()
+	mock.ExpectExec(`SAVEPOINT sqlz_savepoint_\d+`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`SAVEPOINT sqlz_savepoint_\d+`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO users \(id, name\) VALUES \(\$1, \$2\)`).
+		WithArgs(1, "Alice").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`RELEASE SAVEPOINT sqlz_savepoint_\d+`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`RELEASE SAVEPOINT sqlz_savepoint_\d+`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	savepoints = nil
+	err = dbz.Transactional(func(tx *Tx) error {
+		return tx.Transactional(func(tx *Tx) error {
+			return insert(tx)
+		})
+	})
+	if err != nil {
+		t.Fatalf("Transaction failed: %s", err)
+	}
+
+	if len(savepoints) != 2 || savepoints[0] == savepoints[1] {
+		t.Errorf("Expected two distinct savepoints, got %v", savepoints)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %s", err)
+	}
+}
+
+func TestInsertOrGet(t *testing.T) {
+	runTests(t, func(dbz *DB) []test {
+		return []test{
+			{
+				"insert or get id",
+				dbz.InsertOrGet("tags", []string{"name"}, map[string]interface{}{"name": "go", "color": "blue"}),
+				"WITH ins AS (INSERT INTO tags (color, name) VALUES (?, ?) ON CONFLICT (name) DO NOTHING RETURNING id) " +
+					"SELECT id FROM ins UNION SELECT id FROM tags WHERE name = ?",
+				[]interface{}{"blue", "go", "go"},
+			},
+			{
+				"insert or get with composite key",
+				dbz.InsertOrGet("memberships", []string{"org_id", "user_id"}, map[string]interface{}{"org_id": 1, "user_id": 2}, "id", "role"),
+				"WITH ins AS (INSERT INTO memberships (org_id, user_id) VALUES (?, ?) ON CONFLICT (org_id, user_id) DO NOTHING RETURNING id, role) " +
+					"SELECT id, role FROM ins UNION SELECT id, role FROM memberships WHERE org_id = ? AND user_id = ?",
+				[]interface{}{1, 2, 1, 2},
+			},
+		}
+	})
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	dbz := New(db, "postgres")
+
+	mock.ExpectQuery(`WITH ins AS \(INSERT INTO tags \(name\) VALUES \(\$1\) ON CONFLICT \(name\) DO NOTHING RETURNING id\) SELECT id FROM ins UNION SELECT id FROM tags WHERE name = \$2`).
+		WithArgs("go", "go").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+
+	var id int64
+
+	err = dbz.InsertOrGet("tags", []string{"name"}, map[string]interface{}{"name": "go"}).GetRow(&id)
+	if err != nil {
+		t.Errorf("InsertOrGet failed: %s", err)
+	} else if id != 7 {
+		t.Errorf("Expected id 7, got %d", id)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %s", err)
+	}
+}
@@ -0,0 +1,693 @@
+package sqlz
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidStatement is the error wrapped by all errors returned by
+// Validate, so that callers can check for it using errors.Is.
+var ErrInvalidStatement = errors.New("invalid statement")
+
+// ValidationError is the error returned by Validate. It lists all the
+// structural problems found in a statement.
+type ValidationError struct {
+	Problems []string
+}
+
+// Error returns a description of all problems found in the statement
+func (e *ValidationError) Error() string {
+	return ErrInvalidStatement.Error() + ": " + strings.Join(e.Problems, "; ")
+}
+
+// Unwrap returns ErrInvalidStatement
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalidStatement
+}
+
+// Validate walks a statement tree (including sub-queries, auxiliary
+// statements and conditions) and reports structural problems that would
+// result in invalid SQL, such as IN conditions with no values, statements
+// without a table, INSERT statements with both values and a SELECT
+// statement, ON CONFLICT DO UPDATE clauses without columns to update, or
+// correlated sub-queries referencing aliases no outer query defines (see
+// SelectStmt.CorrelatedWith).
+// If problems are found, a *ValidationError is returned, otherwise nil is
+// returned. Validate does not access the database.
+//
+// Statements are validated automatically by their Exec and Get methods
+// (and the other methods executing them), so that mistakes surface as
+// descriptive errors rather than database syntax errors.
+func Validate(stmt SQLStmt) error {
+	v := &validator{}
+	v.stmt("", stmt)
+
+	if len(v.problems) > 0 {
+		return &ValidationError{Problems: v.problems}
+	}
+
+	return nil
+}
+
+// ToSQLE is like the ToSQL method of the provided statement, but validates
+// the statement first (see Validate), returning an error instead of
+// malformed SQL if it is invalid
+func ToSQLE(stmt SQLStmt, rebind bool) (asSQL string, bindings []interface{}, err error) {
+	if err := Validate(stmt); err != nil {
+		return "", nil, err
+	}
+
+	asSQL, bindings = stmt.ToSQL(rebind)
+
+	return asSQL, bindings, nil
+}
+
+type validator struct {
+	problems []string
+	// env is the condition environment of the statement being validated
+	env conditionEnv
+	// outer are the aliases defined by the queries enclosing the statement
+	// being validated, or nil if it is not nested in a query
+	outer []string
+}
+
+// useEnv sets the condition environment of the statement being validated,
+// and returns a function restoring the previous one
+func (v *validator) useEnv(env conditionEnv) func() {
+	previous := v.env
+	v.env = env
+
+	return func() { v.env = previous }
+}
+
+// useOuter adds the aliases of the provided tables to the aliases defined
+// by outer queries, for validating the statements nested in a query, and
+// returns a function restoring the previous ones
+func (v *validator) useOuter(tables ...string) func() {
+	previous := v.outer
+	v.outer = append([]string{}, previous...)
+
+	for _, table := range tables {
+		if alias := tableAlias(table); alias != "" {
+			v.outer = append(v.outer, alias)
+		}
+	}
+
+	return func() { v.outer = previous }
+}
+
+// correlated checks that the aliases referenced by a correlated sub-query
+// are defined by an outer query. Statements that are not nested in a query
+// are not checked, as they may be embedded in raw SQL.
+func (v *validator) correlated(path string, aliases []string) {
+	if v.outer == nil {
+		return
+	}
+
+	for _, alias := range aliases {
+		found := false
+
+		for _, outer := range v.outer {
+			if outer == alias {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			v.addf(path, "correlated sub-query references alias %s, which no outer query defines", alias)
+		}
+	}
+}
+
+// tableAlias returns the name a table is referenced by: its alias if it
+// has one (e.g. "users u", "users AS u" or "unnest(?) AS t(id)"), and
+// otherwise its name without a schema
+func tableAlias(table string) string {
+	fields := strings.Fields(table)
+	if len(fields) == 0 {
+		return ""
+	}
+
+	if len(fields) == 1 {
+		return fields[0][strings.LastIndex(fields[0], ".")+1:]
+	}
+
+	alias := fields[len(fields)-1]
+	if i := strings.Index(alias, "("); i > 0 {
+		alias = alias[:i]
+	}
+
+	return alias
+}
+
+func (v *validator) addf(path, format string, args ...interface{}) {
+	problem := fmt.Sprintf(format, args...)
+	if path != "" {
+		problem = path + ": " + problem
+	}
+
+	v.problems = append(v.problems, problem)
+}
+
+func (v *validator) stmt(path string, stmt SQLStmt) {
+	switch s := stmt.(type) {
+	case nil:
+		v.addf(path, "statement is missing")
+	case *SelectStmt:
+		if s == nil {
+			v.addf(path, "statement is missing")
+			return
+		}
+
+		v.selectStmt(path, s)
+	case *InsertStmt:
+		if s == nil {
+			v.addf(path, "statement is missing")
+			return
+		}
+
+		v.insertStmt(path, s)
+	case *UpdateStmt:
+		if s == nil {
+			v.addf(path, "statement is missing")
+			return
+		}
+
+		v.updateStmt(path, s)
+	case *DeleteStmt:
+		if s == nil {
+			v.addf(path, "statement is missing")
+			return
+		}
+
+		v.deleteStmt(path, s)
+	case *RawStmt:
+		if s != nil && s.err != nil {
+			v.addf(path, "%s", s.err)
+		}
+	case *WithStmt:
+		if s == nil {
+			v.addf(path, "statement is missing")
+			return
+		}
+
+		v.withStmt(path, s)
+	case *ValuesStmt:
+		if s == nil {
+			v.addf(path, "statement is missing")
+			return
+		}
+
+		v.valuesStmt(path, s)
+	case *ColumnDef:
+		if s == nil {
+			v.addf(path, "column definition is missing")
+			return
+		}
+
+		v.columnDef(path, s)
+	case *CheckConstraint:
+		if s == nil || len(s.Conditions) == 0 {
+			v.addf(path, "CHECK constraint has no conditions")
+			return
+		}
+
+		v.conditions(path, s.Conditions)
+		v.inlined(path, s.Conditions)
+	case *ExcludeConstraint:
+		if s == nil || len(s.Elements) == 0 {
+			v.addf(path, "EXCLUDE constraint has no elements")
+			return
+		}
+
+		v.conditions(subPath(path, "WHERE"), s.Conditions)
+		v.inlined(subPath(path, "WHERE"), s.Conditions)
+	case *ForeignKeyConstraint:
+		if s == nil {
+			v.addf(path, "FOREIGN KEY constraint is missing")
+			return
+		}
+
+		v.foreignKey(path, s)
+	case *KeyConstraint:
+		if s == nil || len(s.Columns) == 0 {
+			v.addf(path, "key constraint has no columns")
+		}
+	case *CreateTableStmt:
+		if s == nil {
+			v.addf(path, "statement is missing")
+			return
+		}
+
+		v.createTableStmt(path, s)
+	case *AlterTableStmt:
+		if s == nil {
+			v.addf(path, "statement is missing")
+			return
+		}
+
+		v.alterTableStmt(path, s)
+	case *DropTableStmt:
+		if s == nil || len(s.TableNames) == 0 {
+			v.addf(path, "DROP TABLE statement has no tables")
+		}
+	}
+}
+
+func (v *validator) selectStmt(path string, stmt *SelectStmt) {
+	defer v.useEnv(stmt.conditionEnv())()
+
+	if stmt.Table == "" && len(stmt.Joins) > 0 {
+		v.addf(path, "SELECT statement has joins but no table")
+	}
+
+	v.correlated(path, stmt.OuterAliases)
+
+	tables := []string{stmt.Table}
+	for _, join := range stmt.Joins {
+		tables = append(tables, join.Table)
+	}
+
+	restoreOuter := v.useOuter(tables...)
+
+	for i, join := range stmt.Joins {
+		joinPath := joinPath(path, i)
+
+		if join.Table == "" {
+			v.addf(joinPath, "join has no table or alias")
+		}
+
+		if join.ResultSet != nil {
+			v.stmt(joinPath, join.ResultSet)
+		}
+
+		if !join.Type.hasOnClause() && len(join.Conditions) > 0 {
+			v.addf(joinPath, "%s cannot have ON conditions", join.Type)
+		}
+
+		if len(join.UsingColumns) > 0 && len(join.Conditions) > 0 {
+			v.addf(joinPath, "join cannot have both USING columns and ON conditions")
+		}
+
+		v.conditions(joinPath+" ON", join.Conditions)
+	}
+
+	v.conditions(subPath(path, "WHERE"), stmt.Conditions)
+	v.conditions(subPath(path, "HAVING"), stmt.GroupConditions)
+
+	if len(stmt.GroupConditions) > 0 && len(stmt.Grouping) == 0 {
+		v.addf(path, "SELECT statement has HAVING conditions but no GROUP BY clause")
+	}
+
+	// unions are not nested in the statement
+	restoreOuter()
+
+	for i, union := range stmt.Unions {
+		v.stmt(subPath(path, fmt.Sprintf("UNION #%d", i+1)), union)
+	}
+}
+
+func (v *validator) insertStmt(path string, stmt *InsertStmt) {
+	defer v.useEnv(stmt.conditionEnv())()
+
+	if stmt.err != nil {
+		v.addf(path, "%s", stmt.err)
+	}
+
+	if stmt.Table == "" {
+		v.addf(path, "INSERT statement has no table")
+	}
+
+	sources := 0
+
+	if len(stmt.InsVals) > 0 {
+		sources++
+
+		if len(stmt.InsCols) > 0 && len(stmt.InsVals) != len(stmt.InsCols) {
+			v.addf(path, "INSERT statement has %d columns but %d values", len(stmt.InsCols), len(stmt.InsVals))
+		}
+	}
+
+	if len(stmt.InsMultipleVals) > 0 {
+		sources++
+
+		for i, row := range stmt.InsMultipleVals {
+			if len(stmt.InsCols) > 0 && len(row) != len(stmt.InsCols) {
+				v.addf(path, "INSERT statement has %d columns but row #%d has %d values", len(stmt.InsCols), i+1, len(row))
+			}
+		}
+	}
+
+	if stmt.SelectStmt != nil {
+		sources++
+
+		v.stmt(subPath(path, "SELECT"), stmt.SelectStmt)
+	}
+
+	if sources > 1 {
+		v.addf(path, "INSERT statement has conflicting sources of values (only one of Values, ValueMultiple and FromSelect can be used)")
+	}
+
+	for _, conflict := range stmt.Conflicts {
+		if conflict.Constraint != "" && len(conflict.Targets) > 0 {
+			v.addf(path, "ON CONFLICT clause cannot have both a constraint and target columns")
+		}
+
+		switch conflict.Action {
+		case DoNothing:
+		case DoUpdate:
+			if len(conflict.SetCols) == 0 {
+				v.addf(path, "ON CONFLICT DO UPDATE clause has no columns to update")
+			}
+
+			if conflict.Constraint == "" && len(conflict.Targets) == 0 {
+				v.addf(path, "ON CONFLICT DO UPDATE clause has no conflict target")
+			}
+
+			v.conditions(subPath(path, "ON CONFLICT WHERE"), conflict.Conditions)
+		default:
+			v.addf(path, "ON CONFLICT clause has no action")
+		}
+	}
+}
+
+func (v *validator) updateStmt(path string, stmt *UpdateStmt) {
+	defer v.useEnv(stmt.conditionEnv())()
+
+	if stmt.err != nil {
+		v.addf(path, "%s", stmt.err)
+	}
+
+	if stmt.Table == "" {
+		v.addf(path, "UPDATE statement has no table")
+	}
+
+	if len(stmt.Updates) == 0 && len(stmt.MultipleValues.Columns) == 0 {
+		v.addf(path, "UPDATE statement has no columns to set")
+	}
+
+	if stmt.SelectStmt != nil {
+		if stmt.SelectStmtAlias == "" {
+			v.addf(path, "UPDATE statement selects from a sub-query without an alias")
+		}
+
+		v.stmt(subPath(path, "FROM"), stmt.SelectStmt)
+	}
+
+	if stmt.CurrentOf != "" && len(stmt.Conditions) > 0 {
+		v.addf(path, "UPDATE statement has both WHERE CURRENT OF and other conditions")
+	}
+
+	if len(stmt.FromTables) > 0 && (stmt.SelectStmt != nil || len(stmt.MultipleValues.Values) > 0) {
+		v.addf(path, "UPDATE statement cannot combine FROM tables with FromSelect or FromValues")
+	}
+
+	tables := append([]string{stmt.Table, stmt.SelectStmtAlias}, stmt.FromTables...)
+	for _, join := range stmt.Joins {
+		tables = append(tables, join.Table)
+	}
+
+	defer v.useOuter(tables...)()
+
+	for i, join := range stmt.Joins {
+		joinPath := joinPath(path, i)
+
+		if join.Table == "" {
+			v.addf(joinPath, "join has no table")
+		}
+
+		v.conditions(joinPath+" ON", join.Conditions)
+	}
+
+	v.conditions(subPath(path, "WHERE"), stmt.Conditions)
+}
+
+func (v *validator) deleteStmt(path string, stmt *DeleteStmt) {
+	defer v.useEnv(stmt.conditionEnv())()
+
+	if stmt.Table == "" {
+		v.addf(path, "DELETE statement has no table")
+	}
+
+	if stmt.CurrentOf != "" && len(stmt.Conditions) > 0 {
+		v.addf(path, "DELETE statement has both WHERE CURRENT OF and other conditions")
+	}
+
+	if len(stmt.UsingTables) > 0 && len(stmt.Joins) > 0 {
+		v.addf(path, "DELETE statement cannot have both USING tables and joins")
+	}
+
+	tables := append([]string{stmt.Table}, stmt.UsingTables...)
+	for _, join := range stmt.Joins {
+		tables = append(tables, join.Table)
+	}
+
+	defer v.useOuter(tables...)()
+
+	for i, join := range stmt.Joins {
+		joinPath := joinPath(path, i)
+
+		if join.Table == "" {
+			v.addf(joinPath, "join has no table")
+		}
+
+		v.conditions(joinPath+" ON", join.Conditions)
+	}
+
+	v.conditions(subPath(path, "WHERE"), stmt.Conditions)
+}
+
+func (v *validator) withStmt(path string, stmt *WithStmt) {
+	if len(stmt.AuxStmts) == 0 {
+		v.addf(path, "WITH statement has no auxiliary statements")
+	}
+
+	for i, aux := range stmt.AuxStmts {
+		if aux.As == "" {
+			v.addf(path, "WITH statement has an auxiliary statement with no name")
+		}
+
+		v.stmt(subPath(path, "WITH "+aux.As), aux.Stmt)
+
+		if returning, isDML := returningOf(aux.Stmt); isDML && len(returning) == 0 &&
+			aux.As != "" && stmt.references(aux.As, i+1) {
+			v.addf(path, "auxiliary statement %s is referenced but has no RETURNING clause", aux.As)
+		}
+	}
+
+	if stmt.MainStmt == nil {
+		v.addf(path, "WITH statement has no main statement")
+	} else {
+		v.stmt(path, stmt.MainStmt)
+	}
+}
+
+func (v *validator) valuesStmt(path string, stmt *ValuesStmt) {
+	if len(stmt.Rows) == 0 {
+		v.addf(path, "VALUES list has no rows")
+		return
+	}
+
+	for i, row := range stmt.Rows {
+		if len(row) != len(stmt.Rows[0]) {
+			v.addf(path, "VALUES row %d has %d values, expected %d", i+1, len(row), len(stmt.Rows[0]))
+		}
+	}
+}
+
+// inlined checks that the bindings of conditions whose bindings are
+// inlined as literals can be converted
+func (v *validator) inlined(path string, conds []WhereCondition) {
+	if _, err := inlineConditions(conds); err != nil {
+		v.addf(path, "condition has an invalid value: %s", err)
+	}
+}
+
+func (v *validator) columnDef(path string, col *ColumnDef) {
+	if col.Name == "" || col.Type == "" {
+		v.addf(path, "column definition is missing a name or type")
+	}
+
+	if col.GeneratedExpr != "" && col.Identity != NoIdentity {
+		v.addf(path, "column %s cannot be both a generated and an identity column", col.Name)
+	}
+
+	if col.HasDefault && (col.GeneratedExpr != "" || col.Identity != NoIdentity) {
+		v.addf(path, "column %s cannot have a default value, as its values are generated", col.Name)
+	}
+
+	if col.HasDefault {
+		if _, err := literalOrIndirect(col.DefaultValue); err != nil {
+			v.addf(path, "column %s has an invalid default value: %s", col.Name, err)
+		}
+	}
+}
+
+func (v *validator) createTableStmt(path string, stmt *CreateTableStmt) {
+	if stmt.Table == "" {
+		v.addf(path, "CREATE TABLE statement has no table")
+	}
+
+	if len(stmt.Columns) == 0 {
+		v.addf(path, "CREATE TABLE statement has no columns")
+	}
+
+	primaryKeys := 0
+
+	for _, col := range stmt.Columns {
+		v.stmt(subPath(path, "COLUMN "+col.Name), col)
+
+		if col != nil && col.IsPrimaryKey {
+			primaryKeys++
+		}
+	}
+
+	for i, constraint := range stmt.Constraints {
+		v.stmt(subPath(path, fmt.Sprintf("CONSTRAINT #%d", i+1)), constraint)
+
+		if key, ok := constraint.(*KeyConstraint); ok && key != nil && key.Primary {
+			primaryKeys++
+		}
+	}
+
+	if primaryKeys > 1 {
+		v.addf(path, "CREATE TABLE statement has multiple primary keys")
+	}
+}
+
+func (v *validator) alterTableStmt(path string, stmt *AlterTableStmt) {
+	if stmt.err != nil {
+		v.addf(path, "%s", stmt.err)
+	}
+
+	if stmt.Table == "" {
+		v.addf(path, "ALTER TABLE statement has no table")
+	}
+
+	if len(stmt.Actions) == 0 {
+		v.addf(path, "ALTER TABLE statement has no actions")
+	}
+
+	for _, action := range stmt.Actions {
+		if action.Def != nil {
+			v.stmt(subPath(path, action.Clause), action.Def)
+		}
+	}
+}
+
+func (v *validator) foreignKey(path string, fk *ForeignKeyConstraint) {
+	if len(fk.Columns) == 0 {
+		v.addf(path, "FOREIGN KEY constraint has no columns")
+	}
+
+	if fk.RefTable == "" {
+		v.addf(path, "FOREIGN KEY constraint references no table")
+	}
+
+	if len(fk.RefColumns) > 0 && len(fk.RefColumns) != len(fk.Columns) {
+		v.addf(path, "FOREIGN KEY constraint has %d columns but references %d", len(fk.Columns), len(fk.RefColumns))
+	}
+}
+
+// references returns true if the WITH statement's auxiliary statements
+// starting at the provided index, or its main statement, reference the
+// provided name
+func (stmt *WithStmt) references(name string, from int) bool {
+	stmts := make([]SQLStmt, 0, len(stmt.AuxStmts)-from+1)
+	for _, aux := range stmt.AuxStmts[from:] {
+		stmts = append(stmts, aux.Stmt)
+	}
+
+	if stmt.MainStmt != nil {
+		stmts = append(stmts, stmt.MainStmt)
+	}
+
+	for _, s := range stmts {
+		for _, table := range collectTables(s) {
+			if table == name {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// returningOf returns the RETURNING columns of the provided statement, and
+// whether it is an INSERT, UPDATE or DELETE statement at all
+func returningOf(stmt SQLStmt) (cols []string, isDML bool) {
+	switch s := stmt.(type) {
+	case *InsertStmt:
+		return s.Return, true
+	case *UpdateStmt:
+		return s.Return, true
+	case *DeleteStmt:
+		return s.Return, true
+	}
+
+	return nil, false
+}
+
+func (v *validator) conditions(path string, conds []WhereCondition) {
+	for _, cond := range conds {
+		v.condition(path, cond)
+	}
+}
+
+func (v *validator) condition(path string, cond WhereCondition) {
+	switch c := cond.(type) {
+	case nil:
+		v.addf(path, "condition is missing")
+	case SimpleCondition:
+		if c.Left == "" || c.Operator == "" {
+			v.addf(path, "condition is missing a column or operator")
+		} else if !IsRegisteredOperator(Operator(c.Operator)) {
+			v.addf(path, "condition on %s uses unregistered operator %s", c.Left, c.Operator)
+		}
+	case InCondition:
+		if len(c.Right) == 0 && v.env.emptyIn == EmptyInReject {
+			op := "IN"
+			if c.NotIn {
+				op = "NOT IN"
+			}
+
+			v.addf(path, "%s condition on %s has no values", op, c.Left)
+		}
+	case AndOrCondition:
+		if len(c.Conditions) == 0 {
+			v.addf(path, "AND/OR condition has no inner conditions")
+		}
+
+		v.conditions(path, c.Conditions)
+	case PreCondition:
+		v.condition(path, c.Condition)
+	case GroupCondition:
+		v.condition(path, c.Condition)
+	case SubqueryCondition:
+		v.stmt(path, c.Stmt)
+	case FilterCondition:
+		if resolved, ok := v.env.filters.resolve(c); ok {
+			v.condition(path, resolved)
+		} else {
+			v.addf(path, "filter %s is not defined", c.Name)
+		}
+	case *KeysetClause:
+		if len(c.Values) != len(c.Columns) {
+			v.addf(path, "keyset has %d columns but %d values", len(c.Columns), len(c.Values))
+		}
+	}
+}
+
+func subPath(path, sub string) string {
+	if path == "" {
+		return sub
+	}
+
+	return path + " > " + sub
+}
+
+func joinPath(path string, i int) string {
+	return subPath(path, fmt.Sprintf("JOIN #%d", i+1))
+}
@@ -0,0 +1,288 @@
+package sqlz
+
+import (
+	"errors"
+	"testing"
+
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestValidate(t *testing.T) {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	dbz := New(db, "sqlmock")
+
+	RegisterOperator("&&")
+
+	tests := []struct {
+		name     string
+		stmt     SQLStmt
+		problems []string
+	}{
+		{
+			"valid select",
+			dbz.Select("*").From("table").Where(In("id", 1, 2)),
+			nil,
+		},
+		{
+			"select with empty IN",
+			dbz.Select("*").From("table").Where(Eq("a", 1), Or(In("id"), NotIn("other"))),
+			[]string{"WHERE: IN condition on id has no values", "WHERE: NOT IN condition on other has no values"},
+		},
+		{
+			"update from a non-struct",
+			dbz.Update("users").SetStruct(3, false),
+			[]string{"value is not a struct or a slice of structs: SetStruct received int", "UPDATE statement has no columns to set"},
+		},
+		{
+			"insert from a non-slice",
+			dbz.InsertInto("users").ValueStructs(3),
+			[]string{"value is not a struct or a slice of structs: ValueStructs received int"},
+		},
+		{
+			"update with case map from a non-map",
+			dbz.Update("users").SetCaseMap("role", "id", []string{"admin"}),
+			[]string{"value is not a map: SetCaseMap received []string", "UPDATE statement has no columns to set"},
+		},
+		{
+			"select with an unregistered operator",
+			dbz.Select("*").From("table").Where(Op("embedding", "<~>", "[1,2,3]")),
+			[]string{"WHERE: condition on embedding uses unregistered operator <~>"},
+		},
+		{
+			"select with a registered custom operator",
+			dbz.Select("*").From("table").Where(Op("area", "&&", "box(0,0,1,1)")),
+			nil,
+		},
+		{
+			"correlated sub-query with a defined alias",
+			dbz.Select("*").From("orders o").Where(Exists(
+				dbz.Select("1").From("orders o2").CorrelatedWith("o").Where(Eq("o2.customer_id", Indirect("o.customer_id"))),
+			)),
+			nil,
+		},
+		{
+			"correlated sub-query with an undefined alias",
+			dbz.Select("*").From("orders o").Where(Exists(
+				dbz.Select("1").From("orders o2").CorrelatedWith("c").Where(Eq("o2.customer_id", Indirect("c.id"))),
+			)),
+			[]string{"WHERE: correlated sub-query references alias c, which no outer query defines"},
+		},
+		{
+			"correlated sub-query in a delete",
+			dbz.DeleteFrom("public.sessions").Where(NotExists(
+				dbz.Select("1").From("users u").CorrelatedWith("sessions").Where(Eq("u.id", Indirect("sessions.user_id"))),
+			)),
+			nil,
+		},
+		{
+			"empty IN in a sub-query",
+			dbz.Select("*").From("table").Where(Exists(dbz.Select("1").From("other").Where(In("id")))),
+			[]string{"WHERE > WHERE: IN condition on id has no values"},
+		},
+		{
+			"select with joins and no table",
+			dbz.Select("*").LeftJoin("other", Eq("a", Indirect("b"))),
+			[]string{"SELECT statement has joins but no table"},
+		},
+		{
+			"cross join with conditions",
+			dbz.Select("*").From("a").CrossJoin("b").On(Eq("a.id", Indirect("b.a_id"))),
+			[]string{"JOIN #1: CROSS JOIN cannot have ON conditions"},
+		},
+		{
+			"join with both using and on",
+			dbz.Select("*").From("a").InnerJoinUsing("b", "id").On(Eq("a.x", Indirect("b.x"))),
+			[]string{"JOIN #1: join cannot have both USING columns and ON conditions"},
+		},
+		{
+			"insert with conflicting sources",
+			dbz.InsertInto("table").Columns("a").Values(1).FromSelect(dbz.Select("a").From("other")),
+			[]string{"INSERT statement has conflicting sources of values (only one of Values, ValueMultiple and FromSelect can be used)"},
+		},
+		{
+			"insert with do update and no columns",
+			dbz.InsertInto("table").Columns("a").Values(1).OnConflict(OnConflict("a").DoUpdate()),
+			[]string{"ON CONFLICT DO UPDATE clause has no columns to update"},
+		},
+		{
+			"insert with do update and no conflict target",
+			dbz.InsertInto("table").Columns("a").Values(1).OnConflict(OnConflict().DoUpdate().SetExcluded("a")),
+			[]string{"ON CONFLICT DO UPDATE clause has no conflict target"},
+		},
+		{
+			"insert without a table",
+			dbz.InsertInto("").Columns("a", "b").Values(1),
+			[]string{"INSERT statement has no table", "INSERT statement has 2 columns but 1 values"},
+		},
+		{
+			"update without set",
+			dbz.Update("table").Where(Eq("id", 1)),
+			[]string{"UPDATE statement has no columns to set"},
+		},
+		{
+			"update combining from tables and values",
+			dbz.Update("a").From("b").FromValues(MultipleValues{Values: [][]interface{}{{1}}, As: "v", Columns: []string{"x"}}),
+			[]string{"UPDATE statement cannot combine FROM tables with FromSelect or FromValues"},
+		},
+		{
+			"delete with both using tables and joins",
+			dbz.DeleteFrom("a").Using("b").InnerJoin("c", Eq("c.id", Indirect("a.id"))),
+			[]string{"DELETE statement cannot have both USING tables and joins"},
+		},
+		{
+			"with statement with invalid auxiliary statement",
+			dbz.With(dbz.DeleteFrom("").Where(In("id")), "deleted").Then(dbz.Select("*").From("deleted")),
+			[]string{
+				"WITH deleted: DELETE statement has no table",
+				"WITH deleted > WHERE: IN condition on id has no values",
+				"auxiliary statement deleted is referenced but has no RETURNING clause",
+			},
+		},
+		{
+			"with statement referencing a data-modifying statement's output",
+			dbz.With(dbz.DeleteFrom("queue").Where(Eq("id", 1)).Returning("*"), "due").
+				And(dbz.InsertInto("jobs").FromSelect(dbz.Select("*").From("due")), "moved").
+				Then(dbz.Select("*").From("due")),
+			nil,
+		},
+		{
+			"generated identity column",
+			Column("id", "bigint").AsIdentity(IdentityAlways).GeneratedAs("1"),
+			[]string{"column id cannot be both a generated and an identity column"},
+		},
+		{
+			"check constraint without conditions",
+			Check(),
+			[]string{"CHECK constraint has no conditions"},
+		},
+		{
+			"check constraint with empty IN",
+			Check(In("id")),
+			[]string{"IN condition on id has no values"},
+		},
+		{
+			"exclude constraint without elements",
+			Exclude("gist"),
+			[]string{"EXCLUDE constraint has no elements"},
+		},
+		{
+			"foreign key with mismatched columns",
+			ForeignKey("a", "b").References("other", "id"),
+			[]string{"FOREIGN KEY constraint has 2 columns but references 1"},
+		},
+		{
+			"foreign key without table",
+			ForeignKey(),
+			[]string{"FOREIGN KEY constraint has no columns", "FOREIGN KEY constraint references no table"},
+		},
+		{
+			"create table without columns",
+			dbz.CreateTable("users").Constraint(PrimaryKey("id"), Unique()),
+			[]string{"CREATE TABLE statement has no columns", "CONSTRAINT #2: key constraint has no columns"},
+		},
+		{
+			"create table with multiple primary keys",
+			dbz.CreateTable("users").
+				Column(Column("id", "bigint").PrimaryKey(), Column("seq", "int").AsIdentity(IdentityAlways).Default(1)).
+				Constraint(PrimaryKey("id", "seq")),
+			[]string{
+				"COLUMN seq: column seq cannot have a default value, as its values are generated",
+				"CREATE TABLE statement has multiple primary keys",
+			},
+		},
+		{
+			"alter table without actions",
+			dbz.AlterTable(""),
+			[]string{"ALTER TABLE statement has no table", "ALTER TABLE statement has no actions"},
+		},
+		{
+			"drop table without tables",
+			dbz.DropTable(),
+			[]string{"DROP TABLE statement has no tables"},
+		},
+		{
+			"keyset with missing values",
+			dbz.Select("*").From("posts").Keyset(Keyset(Asc("a"), Asc("id")).After(1)),
+			[]string{"WHERE: keyset has 2 columns but 1 values"},
+		},
+		{
+			"with statement with invalid VALUES list",
+			dbz.With(Values([]interface{}{1, "a"}, []interface{}{2}), "v", "id", "name").
+				Then(dbz.Select("*").From("v")),
+			[]string{"WITH v: VALUES row 2 has 1 values, expected 2"},
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.name, func(t *testing.T) {
+			err := Validate(tst.stmt)
+			if len(tst.problems) == 0 {
+				if err != nil {
+					t.Errorf("Expected no error, got %s", err)
+				}
+
+				return
+			}
+
+			var verr *ValidationError
+			if !errors.As(err, &verr) || !errors.Is(err, ErrInvalidStatement) {
+				t.Fatalf("Expected a validation error, got %v", err)
+			}
+
+			if len(verr.Problems) != len(tst.problems) {
+				t.Fatalf("Expected %d problems, got %d: %s", len(tst.problems), len(verr.Problems), err)
+			}
+
+			for i := range tst.problems {
+				if verr.Problems[i] != tst.problems[i] {
+					t.Errorf("Expected problem %d to be %q, got %q", i+1, tst.problems[i], verr.Problems[i])
+				}
+			}
+		})
+	}
+}
+
+func TestValidateOnExecution(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	var handled []error
+
+	dbz := New(db, "postgres", func(err error) {
+		if err != nil {
+			handled = append(handled, err)
+		}
+	})
+
+	if _, err := dbz.Update("users").Where(Eq("id", 1)).Exec(); !errors.Is(err, ErrInvalidStatement) {
+		t.Errorf("Expected UPDATE without SET to fail validation, got %v", err)
+	}
+
+	var ids []int64
+	if err := dbz.Select("id").From("users").Where(In("id")).GetAll(&ids); !errors.Is(err, ErrInvalidStatement) {
+		t.Errorf("Expected empty IN to fail validation, got %v", err)
+	}
+
+	if len(handled) != 2 {
+		t.Errorf("Expected 2 errors to be handled, got %d", len(handled))
+	}
+
+	if _, _, err := ToSQLE(dbz.InsertInto("users").Columns("id", "name").Values(1), true); !errors.Is(err, ErrInvalidStatement) {
+		t.Errorf("Expected ToSQLE to fail validation, got %v", err)
+	}
+
+	asSQL, bindings, err := ToSQLE(dbz.Select("id").From("users").Where(Eq("id", 1)), true)
+	if err != nil || asSQL != "SELECT id FROM users WHERE id = $1" || len(bindings) != 1 {
+		t.Errorf("Unexpected ToSQLE result: %s %v %v", asSQL, bindings, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %s", err)
+	}
+}
@@ -0,0 +1,165 @@
+package sqlz
+
+import (
+	"strings"
+)
+
+// WindowSpec represents a window definition, i.e. the contents of an OVER
+// clause or of a named window in a WINDOW clause
+type WindowSpec struct {
+	// BaseWindow is the name of an existing window the definition is
+	// based on
+	BaseWindow string
+	Partitions []string
+	Ordering   []SQLStmt
+	// FrameClause is the window's frame, e.g. "ROWS BETWEEN UNBOUNDED
+	// PRECEDING AND CURRENT ROW"
+	FrameClause string
+}
+
+// NamedWindow represents a window defined in the WINDOW clause of a
+// SELECT statement
+type NamedWindow struct {
+	Name string
+	Spec *WindowSpec
+}
+
+// Window creates a new window definition, for usage with
+// SelectStmt.Window
+func Window() *WindowSpec {
+	return &WindowSpec{}
+}
+
+// PartitionBy adds columns (or expressions) to the PARTITION BY clause of
+// the window
+func (spec *WindowSpec) PartitionBy(cols ...string) *WindowSpec {
+	spec.Partitions = append(spec.Partitions, cols...)
+	return spec
+}
+
+// OrderBy adds an ORDER BY clause to the window. Usage is the same as
+// SelectStmt.OrderBy.
+func (spec *WindowSpec) OrderBy(cols ...SQLStmt) *WindowSpec {
+	spec.Ordering = append(spec.Ordering, cols...)
+	return spec
+}
+
+// Frame sets the window's frame clause, e.g. "ROWS BETWEEN 6 PRECEDING AND
+// CURRENT ROW"
+func (spec *WindowSpec) Frame(frame string) *WindowSpec {
+	spec.FrameClause = frame
+	return spec
+}
+
+// From bases the window on an existing named window, whose definition it
+// extends
+func (spec *WindowSpec) From(window string) *WindowSpec {
+	spec.BaseWindow = window
+	return spec
+}
+
+// ToSQL generates SQL for the window definition, without the surrounding
+// parentheses
+func (spec *WindowSpec) ToSQL(_ bool) (asSQL string, bindings []interface{}) {
+	var clauses []string
+
+	if spec.BaseWindow != "" {
+		clauses = append(clauses, spec.BaseWindow)
+	}
+
+	if len(spec.Partitions) > 0 {
+		clauses = append(clauses, "PARTITION BY "+strings.Join(spec.Partitions, ", "))
+	}
+
+	if len(spec.Ordering) > 0 {
+		ordering := make([]string, len(spec.Ordering))
+
+		for i, order := range spec.Ordering {
+			o, orderBindings := order.ToSQL(false)
+			ordering[i] = o
+			bindings = append(bindings, orderBindings...)
+		}
+
+		clauses = append(clauses, "ORDER BY "+strings.Join(ordering, ", "))
+	}
+
+	if spec.FrameClause != "" {
+		clauses = append(clauses, spec.FrameClause)
+	}
+
+	return strings.Join(clauses, " "), bindings
+}
+
+// WindowFunction represents a call to a window (or aggregate) function over
+// a window, e.g. ROW_NUMBER() OVER (PARTITION BY user_id ORDER BY created_at
+// DESC). Since it implements SQLStmt, it can be selected with
+// SelectStmt.SelectExpr.
+type WindowFunction struct {
+	// Function is the function call, e.g. "ROW_NUMBER()" or "SUM(amount)"
+	Function string
+	// Bindings are the values bound to placeholders in Function
+	Bindings []interface{}
+	Spec     WindowSpec
+}
+
+// WindowFunc creates a new window function from the provided function call,
+// which may include placeholders for the provided bindings, e.g.
+// WindowFunc("LAG(price, ?)", 1)
+func WindowFunc(fn string, bindings ...interface{}) *WindowFunction {
+	return &WindowFunction{
+		Function: fn,
+		Bindings: bindings,
+	}
+}
+
+// PartitionBy adds columns to the PARTITION BY clause of the function's
+// window
+func (fn *WindowFunction) PartitionBy(cols ...string) *WindowFunction {
+	fn.Spec.PartitionBy(cols...)
+	return fn
+}
+
+// OrderBy adds an ORDER BY clause to the function's window
+func (fn *WindowFunction) OrderBy(cols ...SQLStmt) *WindowFunction {
+	fn.Spec.OrderBy(cols...)
+	return fn
+}
+
+// Frame sets the frame clause of the function's window
+func (fn *WindowFunction) Frame(frame string) *WindowFunction {
+	fn.Spec.Frame(frame)
+	return fn
+}
+
+// OverWindow makes the function use a named window defined with
+// SelectStmt.Window. If the function's window has no other definitions,
+// the function is rendered as "fn OVER name", otherwise the named window
+// is used as a base, i.e. "fn OVER (name ...)".
+func (fn *WindowFunction) OverWindow(name string) *WindowFunction {
+	fn.Spec.From(name)
+	return fn
+}
+
+// ToSQL generates SQL for the window function
+func (fn *WindowFunction) ToSQL(_ bool) (asSQL string, bindings []interface{}) {
+	bindings = append(bindings, fn.Bindings...)
+
+	if fn.Spec.BaseWindow != "" &&
+		len(fn.Spec.Partitions) == 0 &&
+		len(fn.Spec.Ordering) == 0 &&
+		fn.Spec.FrameClause == "" {
+		return fn.Function + " OVER " + fn.Spec.BaseWindow, bindings
+	}
+
+	specSQL, specBindings := fn.Spec.ToSQL(false)
+	bindings = append(bindings, specBindings...)
+
+	return fn.Function + " OVER (" + specSQL + ")", bindings
+}
+
+// Window adds a named window to the statement's WINDOW clause, which can
+// be referenced by window functions with WindowFunction.OverWindow
+func (stmt *SelectStmt) Window(name string, spec *WindowSpec) *SelectStmt {
+	stmt.Windows = append(stmt.Windows, NamedWindow{name, spec})
+	return stmt
+}
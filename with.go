@@ -4,51 +4,153 @@ import (
 	"context"
 	"database/sql"
 	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 )
 
 // AuxStmt represents an auxiliary statement that is part
-// of a WITH query. It includes the statement itself, and
-// the name used for referencing it in other queries
+// of a WITH query. It includes the statement itself, the
+// name used for referencing it in other queries, and an
+// optional list of column names
 type AuxStmt struct {
-	Stmt SQLStmt
-	As   string
+	Stmt    SQLStmt
+	As      string
+	Columns []string
+}
+
+// ValuesStmt represents a standalone VALUES list, usually used as an
+// auxiliary statement of a WITH query to provide a constant table, e.g.
+//
+//	With(Values([]interface{}{1, "a"}, []interface{}{2, "b"}), "v", "id", "name")
+//
+// generates WITH v (id, name) AS (VALUES (?, ?), (?, ?))
+type ValuesStmt struct {
+	Rows [][]interface{}
+}
+
+// Values creates a new ValuesStmt object with the provided rows. Values
+// may be IndirectValue or JSONBBuilder objects, just like in INSERT
+// statements.
+func Values(rows ...[]interface{}) *ValuesStmt {
+	return &ValuesStmt{Rows: rows}
+}
+
+// ToSQL generates the VALUES list's SQL and returns a list of bindings
+func (stmt *ValuesStmt) ToSQL(_ bool) (asSQL string, bindings []interface{}) {
+	rows := make([]string, len(stmt.Rows))
+
+	for i, row := range stmt.Rows {
+		placeholders, rowBindings := parseInsertValues(row)
+		bindings = append(bindings, rowBindings...)
+		rows[i] = "(" + strings.Join(placeholders, ", ") + ")"
+	}
+
+	return "VALUES " + strings.Join(rows, ", "), bindings
 }
 
 // WithStmt represents a WITH statement
 type WithStmt struct {
+	*Statement
 	// AuxStmts is the list of auxiliary statements that are
 	// part of the WITH query
 	AuxStmts []AuxStmt
 	// MainStmt is the query's main statement in which the
 	// auxiliary statements can be referenced
 	MainStmt SQLStmt
+	// IsRecursive marks the query as WITH RECURSIVE
+	IsRecursive bool
 
-	execer Ext
+	execer  Ext
+	timeout time.Duration
+	guards  []Guard
 }
 
 // With creates a new WithStmt object including
-// the provided auxiliary statements
-func (db *DB) With(stmt SQLStmt, as string) *WithStmt {
+// the provided auxiliary statement, optionally
+// naming its columns
+func (db *DB) With(stmt SQLStmt, as string, cols ...string) *WithStmt {
 	return &WithStmt{
-		AuxStmts: []AuxStmt{{stmt, as}},
-		execer:   db.DB,
+		AuxStmts:  []AuxStmt{{stmt, as, cols}},
+		execer:    db.ext(),
+		guards:    db.defaults().Guards,
+		Statement: &Statement{db.ErrHandlers},
 	}
 }
 
 // With creates a new WithStmt object including
-// the provided auxiliary statements
-func (tx *Tx) With(stmt SQLStmt, as string) *WithStmt {
+// the provided auxiliary statement, optionally
+// naming its columns
+func (tx *Tx) With(stmt SQLStmt, as string, cols ...string) *WithStmt {
 	return &WithStmt{
-		AuxStmts: []AuxStmt{{stmt, as}},
-		execer:   tx.Tx,
+		AuxStmts:  []AuxStmt{{stmt, as, cols}},
+		execer:    tx.ext(),
+		guards:    tx.defaults().Guards,
+		Statement: &Statement{tx.ErrHandlers},
 	}
 }
 
-// And adds another auxiliary statement to the query
-func (stmt *WithStmt) And(auxStmt SQLStmt, as string) *WithStmt {
-	stmt.AuxStmts = append(stmt.AuxStmts, AuxStmt{auxStmt, as})
+// WithRecursive creates a new WITH RECURSIVE statement including the
+// provided auxiliary statement, which may reference itself. The statement
+// is usually a UNION ALL of a non-recursive and a recursive part, e.g. for
+// walking a tree:
+//
+//	WithRecursive(
+//		Select("id", "parent_id").From("nodes").Where(Eq("id", 1)).
+//			UnionAll(Select("n.id", "n.parent_id").From("nodes n").
+//				InnerJoin("tree t", Eq("n.parent_id", Indirect("t.id")))),
+//		"tree", "id", "parent_id",
+//	).Then(Select("*").From("tree"))
+func (db *DB) WithRecursive(stmt SQLStmt, as string, cols ...string) *WithStmt {
+	return db.With(stmt, as, cols...).Recursive()
+}
+
+// WithRecursive creates a new WITH RECURSIVE statement including the
+// provided auxiliary statement. See DB.WithRecursive for more information.
+func (tx *Tx) WithRecursive(stmt SQLStmt, as string, cols ...string) *WithStmt {
+	return tx.With(stmt, as, cols...).Recursive()
+}
+
+// WithExecer sets the execer the statement is executed with, overriding the DB
+// or Tx it was created from. This allows a statement built once to be
+// executed against a different transaction or database (e.g. a replica).
+// Both *DB and *Tx objects can be provided.
+func (stmt *WithStmt) WithExecer(e Ext) *WithStmt {
+	stmt.execer = e
+	return stmt
+}
+
+// WithTimeout sets a timeout for executing the statement. Every execution
+// of the statement derives a context with this timeout (from the provided
+// context, if any), so the query is cancelled if it runs for too long. To
+// also enforce the timeout on the server inside a transaction, see
+// Tx.SetTimeout.
+func (stmt *WithStmt) WithTimeout(d time.Duration) *WithStmt {
+	stmt.timeout = d
+	return stmt
+}
+
+// And adds another auxiliary statement to the query,
+// optionally naming its columns. Auxiliary statements may
+// reference the statements added before them, including the
+// RETURNING output of data-modifying statements, e.g. to move
+// rows between tables:
+//
+//	With(DeleteFrom("queue").Where(Lt("run_at", now)).Returning("*"), "due").
+//		And(InsertInto("jobs").FromSelect(Select("*").From("due")).Returning("id"), "moved").
+//		Then(Select("COUNT(*)").From("moved"))
+//
+// Bindings are ordered by the position of the auxiliary
+// statements, followed by those of the main statement.
+func (stmt *WithStmt) And(auxStmt SQLStmt, as string, cols ...string) *WithStmt {
+	stmt.AuxStmts = append(stmt.AuxStmts, AuxStmt{auxStmt, as, cols})
+	return stmt
+}
+
+// Recursive marks the query as a WITH RECURSIVE query, allowing
+// auxiliary statements to reference themselves
+func (stmt *WithStmt) Recursive() *WithStmt {
+	stmt.IsRecursive = true
 	return stmt
 }
 
@@ -63,45 +165,146 @@ func (stmt *WithStmt) Then(mainStmt SQLStmt) *WithStmt {
 // exported if you wish to use it directly.
 func (stmt *WithStmt) ToSQL(rebind bool) (asSQL string, bindings []interface{}) {
 	var clauses = []string{"WITH"}
+	if stmt.IsRecursive {
+		clauses[0] = "WITH RECURSIVE"
+	}
 
 	auxStmts := make([]string, len(stmt.AuxStmts))
 
 	for i, aux := range stmt.AuxStmts {
 		auxSQL, auxBindings := aux.Stmt.ToSQL(false)
 		bindings = append(bindings, auxBindings...)
-		auxStmts[i] = aux.As + " AS (" + auxSQL + ")"
+		name := aux.As
+		if len(aux.Columns) > 0 {
+			name += " (" + strings.Join(aux.Columns, ", ") + ")"
+		}
+
+		auxStmts[i] = name + " AS (" + auxSQL + ")"
 	}
 
 	clauses = append(clauses, strings.Join(auxStmts, ", "))
 
-	mainSQL, mainBindings := stmt.MainStmt.ToSQL(false)
+	var mainSQL string
+	var mainBindings []interface{}
+	if mainSelect, ok := stmt.MainStmt.(*SelectStmt); ok {
+		// the main statement is top-level, so it is capped by MaxLimit
+		mainSQL, mainBindings = mainSelect.toSQL(false, true)
+	} else {
+		mainSQL, mainBindings = stmt.MainStmt.ToSQL(false)
+	}
+
 	clauses = append(clauses, mainSQL)
 	bindings = append(bindings, mainBindings...)
 
 	asSQL = strings.Join(clauses, " ")
 	if rebind {
-		if db, ok := stmt.execer.(*sqlx.DB); ok {
-			asSQL = db.Rebind(asSQL)
-		} else if tx, ok := stmt.execer.(*sqlx.Tx); ok {
-			asSQL = tx.Rebind(asSQL)
-		}
+		asSQL = rebindFor(stmt.execer, asSQL)
 	}
 
 	return asSQL, bindings
 }
 
+// pipeline returns a copy of the statement in which the auxiliary and main
+// statements are prepared just like when they are executed on their own
+// (see prepare)
+func (stmt *WithStmt) pipeline(ctx context.Context) (*WithStmt, error) {
+	pipeline := *stmt
+	pipeline.AuxStmts = make([]AuxStmt, len(stmt.AuxStmts))
+
+	for i, aux := range stmt.AuxStmts {
+		prepared, err := prepare(ctx, aux.Stmt)
+		if err != nil {
+			return nil, err
+		}
+
+		aux.Stmt = prepared
+		pipeline.AuxStmts[i] = aux
+	}
+
+	mainStmt, err := prepare(ctx, stmt.MainStmt)
+	if err != nil {
+		return nil, err
+	}
+
+	pipeline.MainStmt = mainStmt
+
+	return &pipeline, nil
+}
+
+// render checks the statement and generates the SQL and bindings of its
+// pipeline, handling errors
+func (stmt *WithStmt) render(ctx context.Context) (asSQL string, bindings []interface{}, err error) {
+	if err := checkStmt(ctx, stmt.guards, stmt); err != nil {
+		stmt.HandleError(err)
+		return "", nil, err
+	}
+
+	pipeline, err := stmt.pipeline(ctx)
+	if err != nil {
+		stmt.HandleError(err)
+		return "", nil, err
+	}
+
+	asSQL, bindings = pipeline.ToSQL(true)
+
+	return asSQL, bindings, nil
+}
+
+// checkBounds returns an error if the main statement is a SELECT statement
+// rejected for having neither a LIMIT clause nor conditions (see
+// Defaults.RejectUnbounded)
+func (stmt *WithStmt) checkBounds() error {
+	if mainSelect, ok := stmt.MainStmt.(*SelectStmt); ok {
+		return mainSelect.checkBounds()
+	}
+
+	return nil
+}
+
+// notifyWrites calls the write listeners of every data-modifying statement
+// in the pipeline, unless the query failed
+func (stmt *WithStmt) notifyWrites(ctx context.Context, err error) {
+	stmts := make([]SQLStmt, 0, len(stmt.AuxStmts)+1)
+	for _, aux := range stmt.AuxStmts {
+		stmts = append(stmts, aux.Stmt)
+	}
+
+	stmts = append(stmts, stmt.MainStmt)
+
+	for _, s := range stmts {
+		switch s := s.(type) {
+		case *InsertStmt:
+			notifyWrite(ctx, s.listeners, s, s.writtenTables(), err)
+		case *UpdateStmt:
+			notifyWrite(ctx, s.listeners, s, s.writtenTables(), err)
+		case *DeleteStmt:
+			notifyWrite(ctx, s.listeners, s, s.writtenTables(), err)
+		}
+	}
+}
+
 // Exec executes the WITH statement, returning the standard
 // sql.Result struct and an error if the query failed.
 func (stmt *WithStmt) Exec() (res sql.Result, err error) {
-	asSQL, bindings := stmt.ToSQL(true)
-	return stmt.execer.Exec(asSQL, bindings...)
+	return stmt.ExecContext(context.Background())
 }
 
 // ExecContext executes the WITH statement, returning the standard
 // sql.Result struct and an error if the query failed.
 func (stmt *WithStmt) ExecContext(ctx context.Context) (res sql.Result, err error) {
-	asSQL, bindings := stmt.ToSQL(true)
-	return stmt.execer.ExecContext(ctx, asSQL, bindings...)
+	ctx, cancel := contextWithTimeout(ctx, stmt.timeout)
+	defer cancel()
+
+	asSQL, bindings, err := stmt.render(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err = stmt.execer.ExecContext(ctx, asSQL, bindings...)
+	stmt.HandleError(err)
+	stmt.notifyWrites(ctx, err)
+
+	return res, err
 }
 
 // GetRow executes a WITH statement whose main statement has
@@ -110,8 +313,7 @@ func (stmt *WithStmt) ExecContext(ctx context.Context) (res sql.Result, err erro
 // simple variable if only one column is returned, or a
 // struct if multiple columns are returned)
 func (stmt *WithStmt) GetRow(into interface{}) error {
-	asSQL, bindings := stmt.ToSQL(true)
-	return sqlx.Get(stmt.execer, into, asSQL, bindings...)
+	return stmt.GetRowContext(context.Background(), into)
 }
 
 // GetRowContext executes a WITH statement whose main statement has
@@ -120,30 +322,91 @@ func (stmt *WithStmt) GetRow(into interface{}) error {
 // simple variable if only one column is returned, or a
 // struct if multiple columns are returned)
 func (stmt *WithStmt) GetRowContext(ctx context.Context, into interface{}) error {
-	asSQL, bindings := stmt.ToSQL(true)
-	return sqlx.GetContext(ctx, stmt.execer, into, asSQL, bindings...)
+	ctx, cancel := contextWithTimeout(ctx, stmt.timeout)
+	defer cancel()
+
+	asSQL, bindings, err := stmt.render(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = sqlx.GetContext(ctx, stmt.execer, into, asSQL, bindings...)
+	stmt.HandleError(err)
+	stmt.notifyWrites(ctx, err)
+
+	return err
 }
 
 // GetAll executes a WITH statement whose main statement has
 // a RETURNING clause expected to return multiple rows, and
 // loads the result into the provided slice variable
 func (stmt *WithStmt) GetAll(into interface{}) error {
-	asSQL, bindings := stmt.ToSQL(true)
-	return sqlx.Select(stmt.execer, into, asSQL, bindings...)
+	return stmt.GetAllContext(context.Background(), into)
 }
 
 // GetAllContext executes a WITH statement whose main statement has
 // a RETURNING clause expected to return multiple rows, and
 // loads the result into the provided slice variable
 func (stmt *WithStmt) GetAllContext(ctx context.Context, into interface{}) error {
-	asSQL, bindings := stmt.ToSQL(true)
-	return sqlx.SelectContext(ctx, stmt.execer, into, asSQL, bindings...)
+	ctx, cancel := contextWithTimeout(ctx, stmt.timeout)
+	defer cancel()
+
+	if err := stmt.checkBounds(); err != nil {
+		return err
+	}
+
+	asSQL, bindings, err := stmt.render(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = sqlx.SelectContext(ctx, stmt.execer, into, asSQL, bindings...)
+	stmt.HandleError(err)
+	stmt.notifyWrites(ctx, err)
+
+	return err
+}
+
+// Rows executes the WITH statement and returns a Rows object to use for
+// iteration. It is the caller's responsibility to close the cursor with
+// Close(), which also releases the statement's timeout (see WithTimeout).
+func (stmt *WithStmt) Rows() (rows *Rows, err error) {
+	return stmt.RowsContext(context.Background())
+}
+
+// RowsContext is like Rows, but uses the provided context
+func (stmt *WithStmt) RowsContext(ctx context.Context) (rows *Rows, err error) {
+	if err := stmt.checkBounds(); err != nil {
+		return nil, err
+	}
+
+	asSQL, bindings, err := stmt.render(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = queryRows(ctx, stmt.timeout, stmt.execer, asSQL, bindings)
+	stmt.HandleError(err)
+	stmt.notifyWrites(ctx, err)
+
+	return rows, err
 }
 
 // GetAllAsRows executes the WITH statement and returns an sqlx.Rows object
-// to use for iteration. It is the caller's responsibility to close the cursor
-// with Close().
+// to use for iteration. It is the caller's responsibility to close the
+// cursor with Close(). The context derived for the statement's timeout
+// (see WithTimeout) is only released once the timeout expires; use Rows to
+// release it when the cursor is closed.
 func (stmt *WithStmt) GetAllAsRows() (rows *sqlx.Rows, err error) {
-	asSQL, bindings := stmt.ToSQL(true)
-	return stmt.execer.Queryx(asSQL, bindings...)
+	return stmt.GetAllAsRowsContext(context.Background())
+}
+
+// GetAllAsRowsContext is like GetAllAsRows, but uses the provided context
+func (stmt *WithStmt) GetAllAsRowsContext(ctx context.Context) (rows *sqlx.Rows, err error) {
+	cursor, err := stmt.RowsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return cursor.Rows, nil
 }
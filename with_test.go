@@ -1,7 +1,12 @@
 package sqlz
 
 import (
+	"context"
+	"reflect"
 	"testing"
+	"time"
+
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
 )
 
 func TestWith(t *testing.T) {
@@ -62,6 +67,126 @@ func TestWith(t *testing.T) {
 				"WITH somethings AS (SELECT id FROM table WHERE something = ?) INSERT INTO ref_table SELECT * FROM somethings",
 				[]interface{}{3},
 			},
+
+			{
+				"WITH RECURSIVE with column names",
+				dbz.WithRecursive(
+					dbz.Select("id", "parent_id", "1").From("comments").Where(Eq("id", 10)).
+						UnionAll(
+							dbz.Select("c.id", "c.parent_id", "t.depth + 1").
+								From("comments c").
+								InnerJoin("thread t", Eq("c.parent_id", Indirect("t.id"))).
+								Where(Lt("t.depth", 5)),
+						),
+					"thread", "id", "parent_id", "depth",
+				).Then(
+					dbz.Select("*").From("thread"),
+				),
+				"WITH RECURSIVE thread (id, parent_id, depth) AS (SELECT id, parent_id, 1 FROM comments WHERE id = ? UNION ALL SELECT c.id, c.parent_id, t.depth + 1 FROM comments c INNER JOIN thread t ON c.parent_id = t.id WHERE t.depth < ?) SELECT * FROM thread",
+				[]interface{}{10, 5},
+			},
+
+			{
+				"WITH moving rows between tables through RETURNING",
+				dbz.With(
+					dbz.DeleteFrom("queue").
+						Where(Eq("status", "due"), Lt("attempts", 3)).
+						Returning("*"),
+					"due",
+				).And(
+					dbz.InsertInto("jobs").
+						FromSelect(dbz.Select("*").From("due").Where(Ne("kind", "noop"))).
+						Returning("id"),
+					"moved",
+				).And(
+					dbz.Update("stats").
+						Set("moved", Indirect("moved + (SELECT COUNT(*) FROM moved)")).
+						Where(Eq("name", "jobs")).
+						Returning("moved"),
+					"counted",
+				).Then(
+					dbz.Select("moved.id").
+						From("moved").
+						Where(Gt("moved.id", 100)),
+				),
+				"WITH due AS (DELETE FROM queue WHERE status = ? AND attempts < ? RETURNING *), moved AS (INSERT INTO jobs SELECT * FROM due WHERE kind <> ? RETURNING id), counted AS (UPDATE stats SET moved = moved + (SELECT COUNT(*) FROM moved) WHERE name = ? RETURNING moved) SELECT moved.id FROM moved WHERE moved.id > ?",
+				[]interface{}{"due", 3, "noop", "jobs", 100},
+			},
+
+			{
+				"WITH VALUES auxiliary statement",
+				dbz.With(
+					Values(
+						[]interface{}{1, "a"},
+						[]interface{}{2, Indirect("upper(?)", "b")},
+					),
+					"v", "id", "name",
+				).Then(
+					dbz.Update("items").
+						Set("name", Indirect("v.name")).
+						From("v").
+						Where(Eq("items.id", Indirect("v.id")), Eq("items.owner", 7)),
+				),
+				"WITH v (id, name) AS (VALUES (?, ?), (?, upper(?))) UPDATE items SET name = v.name FROM v WHERE items.id = v.id AND items.owner = ?",
+				[]interface{}{1, "a", 2, "b", 7},
+			},
 		}
 	})
 }
+
+func TestWithPipeline(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed creating mock database: %s", err)
+	}
+
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	var modified []string
+
+	dbz := New(db, "postgres")
+	dbz.Defaults.Audit = &Audit{
+		Tables:    []string{"orders"},
+		CreatedAt: "created_at",
+		Now:       func() time.Time { return now },
+	}
+	dbz.Defaults.WriteListeners = []WriteListener{func(_ context.Context, event WriteEvent) {
+		modified = append(modified, event.Tables...)
+	}}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`WITH new_order AS \(INSERT INTO orders \(customer_id, created_at\) VALUES \(\$1, \$2\) RETURNING id\) `+
+		`INSERT INTO order_items \(order_id, sku\) VALUES \(\(SELECT id FROM new_order\), \$3\) RETURNING id`).
+		WithArgs(7, now, "ABC-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(12))
+	mock.ExpectCommit()
+
+	var ids []int64
+
+	err = dbz.TransactionalContext(context.Background(), nil, func(tx *Tx) error {
+		return tx.With(
+			tx.InsertInto("orders").Columns("customer_id").Values(7).Returning("id"),
+			"new_order",
+		).Then(
+			tx.InsertInto("order_items").
+				Columns("order_id", "sku").
+				Values(Indirect("(SELECT id FROM new_order)"), "ABC-1").
+				Returning("id"),
+		).GetAllContext(context.Background(), &ids)
+	})
+	if err != nil {
+		t.Fatalf("Pipeline failed: %s", err)
+	}
+
+	if !reflect.DeepEqual(ids, []int64{12}) {
+		t.Errorf("Expected ids [12], got %v", ids)
+	}
+
+	if !reflect.DeepEqual(modified, []string{"orders", "order_items"}) {
+		t.Errorf("Expected orders and order_items to be modified, got %v", modified)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unmet expectations: %s", err)
+	}
+}